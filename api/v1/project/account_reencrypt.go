@@ -0,0 +1,91 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/request"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AccountReencryptApi 账号详情密钥轮换
+type AccountReencryptApi struct {
+}
+
+// StartJob 按当前密钥环配置启动重新加密任务
+func (a *AccountReencryptApi) StartJob(c *gin.Context) {
+	job, err := accountReencryptService.StartJob(utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("启动重新加密任务失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(job, "任务已启动", c)
+}
+
+// PauseJob 暂停任务
+func (a *AccountReencryptApi) PauseJob(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := accountReencryptService.PauseJob(req.Uint()); err != nil {
+		global.GVA_LOG.Error("暂停重新加密任务失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("已暂停", c)
+}
+
+// ResumeJob 从上次进度继续任务
+func (a *AccountReencryptApi) ResumeJob(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := accountReencryptService.ResumeJob(req.Uint()); err != nil {
+		global.GVA_LOG.Error("继续重新加密任务失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("任务已继续", c)
+}
+
+// GetJobList 重新加密任务列表
+func (a *AccountReencryptApi) GetJobList(c *gin.Context) {
+	var req request.PageInfo
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := accountReencryptService.GetJobList(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取重新加密任务失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
+
+// GetJob 任务进度
+func (a *AccountReencryptApi) GetJob(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	job, err := accountReencryptService.GetJob(req.Uint())
+	if err != nil {
+		response.FailWithMessage("任务不存在", c)
+		return
+	}
+	response.OkWithDetailed(job, "获取成功", c)
+}
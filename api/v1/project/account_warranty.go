@@ -0,0 +1,65 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AccountWarrantyApi 账号售后
+type AccountWarrantyApi struct {
+}
+
+// GetClaimList 售后申请列表
+func (a *AccountWarrantyApi) GetClaimList(c *gin.Context) {
+	var req request.WarrantyClaimListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := accountWarrantyService.GetClaimList(req, 0)
+	if err != nil {
+		global.GVA_LOG.Error("获取售后申请失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
+
+// ApproveClaim 通过售后申请，更换账号或部分退款
+func (a *AccountWarrantyApi) ApproveClaim(c *gin.Context) {
+	var req request.WarrantyClaimApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := accountWarrantyService.ApproveClaim(req, utils.GetUserID(c), utils.GetUserName(c)); err != nil {
+		global.GVA_LOG.Error("处理售后申请失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("处理成功", c)
+}
+
+// RejectClaim 驳回售后申请
+func (a *AccountWarrantyApi) RejectClaim(c *gin.Context) {
+	var req request.WarrantyClaimRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := accountWarrantyService.RejectClaim(req, utils.GetUserID(c)); err != nil {
+		global.GVA_LOG.Error("驳回售后申请失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("已驳回", c)
+}
@@ -5,10 +5,15 @@ import (
 	"ApkAdmin/model/common/response"
 	"ApkAdmin/model/project/request"
 	"ApkAdmin/utils"
+	"ApkAdmin/utils/sheet"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"io"
+	"net/http"
 )
 
+const maxAccountImportFileSize = 10 << 20
+
 // AppAccountApi 应用账号APi
 type AppAccountApi struct {
 }
@@ -115,7 +120,7 @@ func (a AppAccountApi) BatchUpdateStatus(c *gin.Context) {
 		response.FailWithMessage("更新应用账号状态参数错误,"+err.Error(), c)
 		return
 	}
-	resp, err := AppAccountService.UpdateAccountStatus(req)
+	resp, err := AppAccountService.UpdateAccountStatus(req, utils.GetUserID(c))
 	if err != nil {
 		global.GVA_LOG.Error("更新应用账号状态失败!", zap.Error(err))
 		response.FailWithMessage("更新应用账号状态失败,"+err.Error(), c)
@@ -123,3 +128,96 @@ func (a AppAccountApi) BatchUpdateStatus(c *gin.Context) {
 	}
 	response.OkWithDetailed(resp, "success", c)
 }
+
+// ImportAppAccounts 批量导入账号（CSV/XLSX），dry_run=true 时只校验并返回预览
+func (a AppAccountApi) ImportAppAccounts(c *gin.Context) {
+	var req request.AccountImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.FailWithMessage("请选择要导入的文件", c)
+		return
+	}
+	if fileHeader.Size > maxAccountImportFileSize {
+		response.FailWithMessage("导入文件不能超过10MB", c)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.FailWithMessage("读取文件失败", c)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		response.FailWithMessage("读取文件失败", c)
+		return
+	}
+	rows, err := sheet.Read(fileHeader.Filename, data)
+	if err != nil {
+		response.FailWithMessage("解析文件失败："+err.Error(), c)
+		return
+	}
+	result, err := AppAccountService.ImportAccounts(fileHeader.Filename, rows, req.DryRun, utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("导入账号失败!", zap.Error(err))
+		if result != nil {
+			response.FailWithDetailed(result, err.Error(), c)
+			return
+		}
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	msg := "导入完成"
+	if req.DryRun {
+		msg = "校验完成"
+	}
+	response.OkWithDetailed(result, msg, c)
+}
+
+// DownloadImportTemplate 下载账号导入模板
+func (a AppAccountApi) DownloadImportTemplate(c *gin.Context) {
+	c.Header("Content-Disposition", "attachment; filename=app_account_import_template.csv")
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", AppAccountService.AccountImportTemplate())
+}
+
+// ListImportJobs 账号导入记录
+func (a AppAccountApi) ListImportJobs(c *gin.Context) {
+	var req request.AccountImportJobListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := AppAccountService.GetImportJobList(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取导入记录失败!", zap.Error(err))
+		response.FailWithMessage("获取导入记录失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
+
+// DownloadImportReport 下载导入问题报告
+func (a AppAccountApi) DownloadImportReport(c *gin.Context) {
+	var req request.AccountImportReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	data, name, err := AppAccountService.ImportReportCSV(req.ID)
+	if err != nil {
+		global.GVA_LOG.Error("下载导入报告失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename="+name)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
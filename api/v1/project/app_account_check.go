@@ -0,0 +1,119 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	commonReq "ApkAdmin/model/common/request"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AppAccountCheckApi 账号健康检查
+type AppAccountCheckApi struct {
+}
+
+// GetCheckerTypes 支持的检查器类型
+func (a *AppAccountCheckApi) GetCheckerTypes(c *gin.Context) {
+	response.OkWithDetailed(appAccountCheckService.CheckerTypes(), "获取成功", c)
+}
+
+// SaveChecker 新增或修改应用的检查器
+func (a *AppAccountCheckApi) SaveChecker(c *gin.Context) {
+	var req request.AccountCheckerSaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	checker, err := appAccountCheckService.SaveChecker(req, utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("保存账号检查器失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(checker, "保存成功", c)
+}
+
+// DeleteChecker 删除检查器
+func (a *AppAccountCheckApi) DeleteChecker(c *gin.Context) {
+	var req commonReq.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := appAccountCheckService.DeleteChecker(req.Uint()); err != nil {
+		global.GVA_LOG.Error("删除账号检查器失败!", zap.Error(err))
+		response.FailWithMessage("删除失败", c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// GetCheckerList 检查器列表
+func (a *AppAccountCheckApi) GetCheckerList(c *gin.Context) {
+	var req request.AccountCheckerListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := appAccountCheckService.GetCheckerList(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取账号检查器失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
+
+// TestAccount 试运行检查器，不修改账号状态
+func (a *AppAccountCheckApi) TestAccount(c *gin.Context) {
+	var req request.AccountCheckTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	result, err := appAccountCheckService.TestAccount(req.AccountID)
+	if err != nil {
+		global.GVA_LOG.Error("试运行账号检查失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(result, "检查完成", c)
+}
+
+// RunChecks 立即在后台运行一次到期账号的检查
+func (a *AppAccountCheckApi) RunChecks(c *gin.Context) {
+	go func() {
+		if err := appAccountCheckService.RunDueChecks(); err != nil {
+			global.GVA_LOG.Error("运行账号健康检查失败!", zap.Error(err))
+		}
+	}()
+	response.OkWithMessage("已开始检查", c)
+}
+
+// GetStatusLogs 账号状态变更记录
+func (a *AppAccountCheckApi) GetStatusLogs(c *gin.Context) {
+	var req request.AccountStatusLogRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := appAccountCheckService.GetStatusLogs(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取账号状态记录失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
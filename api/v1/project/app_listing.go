@@ -0,0 +1,204 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/request"
+	"ApkAdmin/model/common/response"
+	request2 "ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AppListingApi struct {
+}
+
+// ==================== 截图 ====================
+
+// UploadScreenshots 批量上传截图
+func (a *AppListingApi) UploadScreenshots(c *gin.Context) {
+	var req request2.ScreenshotUploadRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	form, err := c.MultipartForm()
+	if err != nil {
+		response.FailWithMessage("获取文件失败："+err.Error(), c)
+		return
+	}
+	list, err := appScreenshotService.UploadScreenshots(req, form.File["files"])
+	if err != nil {
+		global.GVA_LOG.Error("上传截图失败!", zap.Error(err))
+		response.FailWithMessage("上传截图失败："+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(list, "上传成功", c)
+}
+
+// UpdateScreenshot 修改截图属性
+func (a *AppListingApi) UpdateScreenshot(c *gin.Context) {
+	var req request2.ScreenshotUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := appScreenshotService.UpdateScreenshot(req); err != nil {
+		global.GVA_LOG.Error("更新截图失败!", zap.Error(err))
+		response.FailWithMessage("更新失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("更新成功", c)
+}
+
+// ReorderScreenshots 调整截图顺序
+func (a *AppListingApi) ReorderScreenshots(c *gin.Context) {
+	var req request2.ScreenshotReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := appScreenshotService.ReorderScreenshots(req.IDs); err != nil {
+		global.GVA_LOG.Error("截图排序失败!", zap.Error(err))
+		response.FailWithMessage("排序失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("排序成功", c)
+}
+
+// DeleteScreenshots 批量删除截图
+func (a *AppListingApi) DeleteScreenshots(c *gin.Context) {
+	var req request2.ScreenshotDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := appScreenshotService.DeleteScreenshots(req.IDs); err != nil {
+		global.GVA_LOG.Error("删除截图失败!", zap.Error(err))
+		response.FailWithMessage("删除失败", c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// GetScreenshotList 应用截图列表
+func (a *AppListingApi) GetScreenshotList(c *gin.Context) {
+	var req request2.ScreenshotListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, err := appScreenshotService.GetScreenshotList(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取截图列表失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
+
+// ==================== 本地化信息 ====================
+
+// SaveListing 新增或更新本地化信息
+func (a *AppListingApi) SaveListing(c *gin.Context) {
+	var req request2.AppListingSaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	listing, err := appListingService.SaveListing(req)
+	if err != nil {
+		global.GVA_LOG.Error("保存本地化信息失败!", zap.Error(err))
+		response.FailWithMessage("保存失败："+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(listing, "保存成功", c)
+}
+
+// DeleteListing 删除本地化信息
+func (a *AppListingApi) DeleteListing(c *gin.Context) {
+	var info request.GetById
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(info, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := appListingService.DeleteListing(info.Uint()); err != nil {
+		global.GVA_LOG.Error("删除本地化信息失败!", zap.Error(err))
+		response.FailWithMessage("删除失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// GetListings 应用的本地化信息
+func (a *AppListingApi) GetListings(c *gin.Context) {
+	var req request2.AppListingListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, err := appListingService.GetListings(req.AppID)
+	if err != nil {
+		global.GVA_LOG.Error("获取本地化信息失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
+
+// ==================== 更新说明 ====================
+
+// SaveChangelog 新增或更新安装包更新说明
+func (a *AppListingApi) SaveChangelog(c *gin.Context) {
+	var req request2.ChangelogSaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	changelog, err := appListingService.SaveChangelog(req)
+	if err != nil {
+		global.GVA_LOG.Error("保存更新说明失败!", zap.Error(err))
+		response.FailWithMessage("保存失败："+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(changelog, "保存成功", c)
+}
+
+// DeleteChangelog 删除更新说明
+func (a *AppListingApi) DeleteChangelog(c *gin.Context) {
+	var info request.GetById
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(info, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := appListingService.DeleteChangelog(info.Uint()); err != nil {
+		global.GVA_LOG.Error("删除更新说明失败!", zap.Error(err))
+		response.FailWithMessage("删除失败", c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// GetChangelogs 安装包的更新说明
+func (a *AppListingApi) GetChangelogs(c *gin.Context) {
+	var req request2.ChangelogListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, err := appListingService.GetChangelogs(req.PackageID)
+	if err != nil {
+		global.GVA_LOG.Error("获取更新说明失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
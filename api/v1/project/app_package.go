@@ -144,3 +144,22 @@ func (a *AppPackageApi) BatchUpdateAppPackageStatus(c *gin.Context) {
 
 	response.OkWithMessage("批量更新状态成功", c)
 }
+
+// RescanAppPackage 重新扫描安装包
+func (a *AppPackageApi) RescanAppPackage(c *gin.Context) {
+	var info request.GetById
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(info, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := packageIntegrityService.RescanPackage(uint64(info.ID)); err != nil {
+		global.GVA_LOG.Error("重新扫描失败!", zap.Error(err))
+		response.FailWithMessage("重新扫描失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("已加入扫描队列", c)
+}
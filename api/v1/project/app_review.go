@@ -0,0 +1,108 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	request2 "ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AppReviewApi struct {
+}
+
+// GetReviewList 评价列表
+func (a *AppReviewApi) GetReviewList(c *gin.Context) {
+	var pageInfo request2.AppReviewListRequest
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(pageInfo, utils.PageInfoVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := appReviewService.GetReviewList(pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取评价列表失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// UpdateReviewStatus 批量显示或隐藏评价
+func (a *AppReviewApi) UpdateReviewStatus(c *gin.Context) {
+	var req request2.ReviewStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := appReviewService.UpdateStatus(req.IDs, req.Status); err != nil {
+		global.GVA_LOG.Error("更新评价状态失败!", zap.Error(err))
+		response.FailWithMessage("更新失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("更新成功", c)
+}
+
+// FlagReviews 标记或取消标记评价
+func (a *AppReviewApi) FlagReviews(c *gin.Context) {
+	var req request2.ReviewFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := appReviewService.Flag(req); err != nil {
+		global.GVA_LOG.Error("标记评价失败!", zap.Error(err))
+		response.FailWithMessage("标记失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("操作成功", c)
+}
+
+// ReplyReview 官方回复评价
+func (a *AppReviewApi) ReplyReview(c *gin.Context) {
+	var req request2.ReviewReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := appReviewService.Reply(req, utils.GetUserID(c)); err != nil {
+		global.GVA_LOG.Error("回复评价失败!", zap.Error(err))
+		response.FailWithMessage("回复失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("回复成功", c)
+}
+
+// DeleteReviews 删除评价
+func (a *AppReviewApi) DeleteReviews(c *gin.Context) {
+	var req request2.ReviewIDsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := appReviewService.DeleteReviews(req.IDs); err != nil {
+		global.GVA_LOG.Error("删除评价失败!", zap.Error(err))
+		response.FailWithMessage("删除失败", c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// RecomputeRatings 按公开评价重新计算所有应用和安装包的评分
+func (a *AppReviewApi) RecomputeRatings(c *gin.Context) {
+	if err := appReviewService.RecomputeAll(); err != nil {
+		global.GVA_LOG.Error("重新计算评分失败!", zap.Error(err))
+		response.FailWithMessage("重新计算失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("重新计算完成", c)
+}
@@ -136,3 +136,59 @@ func (a *CategoryApi) DeleteCategory(c *gin.Context) {
 	}
 	response.OkWithMessage("删除成功", c)
 }
+
+// GetCategoryTree 完整分类树，包含每个分类的应用数量
+func (a *CategoryApi) GetCategoryTree(c *gin.Context) {
+	tree, err := CategoryService.GetCategoryTree()
+	if err != nil {
+		global.GVA_LOG.Error("获取分类树失败!", zap.Error(err))
+		response.FailWithMessage("获取分类树失败", c)
+		return
+	}
+	response.OkWithDetailed(tree, "获取成功", c)
+}
+
+// MoveCategories 批量移动分类到新的父分类
+func (a *CategoryApi) MoveCategories(c *gin.Context) {
+	var req request.CategoryMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	if err := CategoryService.MoveCategories(req.Moves); err != nil {
+		global.GVA_LOG.Error("移动分类失败!", zap.Error(err))
+		response.FailWithMessage("移动分类失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("移动成功", c)
+}
+
+// ReorderCategories 同级分类排序
+func (a *CategoryApi) ReorderCategories(c *gin.Context) {
+	var req request.CategoryReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	if err := CategoryService.ReorderCategories(req.ParentID, req.IDs); err != nil {
+		global.GVA_LOG.Error("分类排序失败!", zap.Error(err))
+		response.FailWithMessage("排序失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("排序成功", c)
+}
+
+// MergeCategories 合并分类
+func (a *CategoryApi) MergeCategories(c *gin.Context) {
+	var req request.CategoryMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	if err := CategoryService.MergeCategories(req.SourceID, req.TargetID); err != nil {
+		global.GVA_LOG.Error("合并分类失败!", zap.Error(err))
+		response.FailWithMessage("合并分类失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("合并成功", c)
+}
@@ -0,0 +1,54 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	projectService "ApkAdmin/service/project"
+	"ApkAdmin/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RevealAppAccount 查看账号明文详情，每次查看都会记录管理员、IP 和原因
+func (a AppAccountApi) RevealAppAccount(c *gin.Context) {
+	var req request.AdminRevealAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	data, err := credentialRevealService.RevealForAdmin(req, projectService.RevealViewer{
+		ID:        utils.GetUserID(c),
+		Name:      utils.GetUserName(c),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		global.GVA_LOG.Error("查看账号详情失败!", zap.Error(err), zap.Uint("accountID", req.ID))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(data, c)
+}
+
+// ListRevealLogs 账号明文查看记录
+func (a AppAccountApi) ListRevealLogs(c *gin.Context) {
+	var req request.CredentialRevealLogRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := credentialRevealService.GetRevealLogs(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取查看记录失败!", zap.Error(err))
+		response.FailWithMessage("获取查看记录失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
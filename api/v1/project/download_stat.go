@@ -0,0 +1,106 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	request2 "ApkAdmin/model/project/request"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type DownloadStatApi struct {
+}
+
+// bindDownloadStatRequest 绑定并校验统计查询参数
+func bindDownloadStatRequest(c *gin.Context) (request2.DownloadStatRequest, bool) {
+	var req request2.DownloadStatRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return req, false
+	}
+	if err := req.Validate(); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return req, false
+	}
+	return req, true
+}
+
+// GetDownloadTrend 下载趋势
+func (a *DownloadStatApi) GetDownloadTrend(c *gin.Context) {
+	req, ok := bindDownloadStatRequest(c)
+	if !ok {
+		return
+	}
+	points, err := downloadStatService.GetTrend(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取下载趋势失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(points, "获取成功", c)
+}
+
+// GetTopDownloadApps 下载排行
+func (a *DownloadStatApi) GetTopDownloadApps(c *gin.Context) {
+	req, ok := bindDownloadStatRequest(c)
+	if !ok {
+		return
+	}
+	stats, err := downloadStatService.GetTopApps(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取下载排行失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(stats, "获取成功", c)
+}
+
+// GetDownloadFailReasons 下载失败原因分布
+func (a *DownloadStatApi) GetDownloadFailReasons(c *gin.Context) {
+	req, ok := bindDownloadStatRequest(c)
+	if !ok {
+		return
+	}
+	stats, err := downloadStatService.GetFailReasons(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取下载失败原因失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(stats, "获取成功", c)
+}
+
+// GetDownloaderSummary 下载次数与去重下载用户数
+func (a *DownloadStatApi) GetDownloaderSummary(c *gin.Context) {
+	req, ok := bindDownloadStatRequest(c)
+	if !ok {
+		return
+	}
+	summary, err := downloadStatService.GetDownloaderSummary(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取下载用户统计失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(summary, "获取成功", c)
+}
+
+// RebuildDownloadStat 从原始日志重建指定日期的汇总，用于上线前的历史数据回填
+func (a *DownloadStatApi) RebuildDownloadStat(c *gin.Context) {
+	var req request2.RebuildDownloadStatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	start, end, err := req.Range()
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err = downloadStatService.RebuildRange(start, end); err != nil {
+		global.GVA_LOG.Error("重建下载汇总失败!", zap.Error(err))
+		response.FailWithMessage("重建失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("重建成功", c)
+}
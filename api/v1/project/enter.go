@@ -17,6 +17,15 @@ type ApiGroup struct {
 	SystemAnnouncementApi
 	CommissionTierApi
 	UploadApi
+	PackageReleaseApi
+	PackagePatchApi
+	DownloadStatApi
+	SearchTermApi
+	AppReviewApi
+	AppListingApi
+	AccountReencryptApi
+	AppAccountCheckApi
+	AccountWarrantyApi
 }
 
 var (
@@ -36,4 +45,19 @@ var (
 	sysUserService               = service.ServiceGroupApp.SystemServiceGroup.UserService
 	commissionTierService        = service.ServiceGroupApp.ProjectServiceGroup.CommissionTierService
 	commissionDetailService      = service.ServiceGroupApp.ProjectServiceGroup.CommissionDetailService
+	uploadSessionService         = service.ServiceGroupApp.ProjectServiceGroup.UploadSessionService
+	packageReleaseService        = service.ServiceGroupApp.ProjectServiceGroup.PackageReleaseService
+	packagePatchService          = service.ServiceGroupApp.ProjectServiceGroup.PackagePatchService
+	packageIntegrityService      = service.ServiceGroupApp.ProjectServiceGroup.PackageIntegrityService
+	downloadStatService          = service.ServiceGroupApp.ProjectServiceGroup.DownloadStatService
+	searchQueryService           = service.ServiceGroupApp.ProjectServiceGroup.SearchQueryService
+	appReviewService             = service.ServiceGroupApp.ProjectServiceGroup.AppReviewService
+	appScreenshotService         = service.ServiceGroupApp.ProjectServiceGroup.AppScreenshotService
+	appListingService            = service.ServiceGroupApp.ProjectServiceGroup.AppListingService
+	accountReencryptService      = service.ServiceGroupApp.ProjectServiceGroup.AccountReencryptService
+	appAccountCheckService       = service.ServiceGroupApp.ProjectServiceGroup.AppAccountCheckService
+	accountWarrantyService       = service.ServiceGroupApp.ProjectServiceGroup.AccountWarrantyService
+	credentialRevealService      = service.ServiceGroupApp.ProjectServiceGroup.CredentialRevealService
+	userTwoFactorService         = service.ServiceGroupApp.ProjectServiceGroup.UserTwoFactorService
+	referralService              = service.ServiceGroupApp.ProjectServiceGroup.ReferralService
 )
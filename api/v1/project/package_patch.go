@@ -0,0 +1,54 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/request"
+	"ApkAdmin/model/common/response"
+	request2 "ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type PackagePatchApi struct {
+}
+
+// GetPackagePatchList 分页获取应用的差分补丁
+func (a *PackagePatchApi) GetPackagePatchList(c *gin.Context) {
+	var req request2.PackagePatchListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := packagePatchService.GetPatchList(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
+
+// RegeneratePackagePatch 重新生成差分补丁
+func (a *PackagePatchApi) RegeneratePackagePatch(c *gin.Context) {
+	var info request.GetById
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(info, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := packagePatchService.RegeneratePatch(uint64(info.ID)); err != nil {
+		global.GVA_LOG.Error("重新生成补丁失败!", zap.Error(err))
+		response.FailWithMessage("操作失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("已加入重新生成队列", c)
+}
@@ -0,0 +1,124 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/request"
+	"ApkAdmin/model/common/response"
+	request2 "ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type PackageReleaseApi struct {
+}
+
+// GetPackageReleaseList 获取应用的发布配置
+func (a *PackageReleaseApi) GetPackageReleaseList(c *gin.Context) {
+	var req request2.PackageReleaseListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, err := packageReleaseService.GetReleaseList(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
+
+// PublishPackage 发布安装包（全量或灰度）
+func (a *PackageReleaseApi) PublishPackage(c *gin.Context) {
+	var req request2.PackageReleasePublishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误，"+err.Error(), c)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	release, err := packageReleaseService.Publish(utils.GetUserID(c), req)
+	if err != nil {
+		global.GVA_LOG.Error("发布安装包失败!", zap.Error(err))
+		response.FailWithMessage("发布安装包失败："+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(release, "发布成功", c)
+}
+
+// UpdateRollout 调整灰度比例
+func (a *PackageReleaseApi) UpdateRollout(c *gin.Context) {
+	var req request2.PackageReleaseRolloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误，"+err.Error(), c)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := packageReleaseService.UpdateRollout(utils.GetUserID(c), req); err != nil {
+		global.GVA_LOG.Error("调整灰度失败!", zap.Error(err))
+		response.FailWithMessage("调整灰度失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("调整灰度成功", c)
+}
+
+// RollbackRelease 回滚版本
+func (a *PackageReleaseApi) RollbackRelease(c *gin.Context) {
+	var req request2.PackageReleaseRollbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误，"+err.Error(), c)
+		return
+	}
+	if err := packageReleaseService.Rollback(utils.GetUserID(c), req); err != nil {
+		global.GVA_LOG.Error("回滚失败!", zap.Error(err))
+		response.FailWithMessage("回滚失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("回滚成功", c)
+}
+
+// DeletePackageRelease 删除发布配置
+func (a *PackageReleaseApi) DeletePackageRelease(c *gin.Context) {
+	var info request.GetById
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(info, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := packageReleaseService.DeleteRelease(uint64(info.ID)); err != nil {
+		global.GVA_LOG.Error("删除失败!", zap.Error(err))
+		response.FailWithMessage("删除失败", c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// GetReleaseHistory 获取发布历史
+func (a *PackageReleaseApi) GetReleaseHistory(c *gin.Context) {
+	var req request2.PackageReleaseHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := packageReleaseService.GetReleaseHistory(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
@@ -0,0 +1,47 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RebindReferrer 修改用户的推荐人，必须填写原因，新旧推荐人的统计会重新计算
+func (u *UserApi) RebindReferrer(c *gin.Context) {
+	var req request.RebindReferrerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := referralService.RebindReferrer(req, utils.GetUserID(c), utils.GetUserName(c)); err != nil {
+		global.GVA_LOG.Error("修改推荐人失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("修改成功", c)
+}
+
+// GetReferralBindLogs 推荐关系变更记录
+func (u *UserApi) GetReferralBindLogs(c *gin.Context) {
+	var req request.ReferralBindLogRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := referralService.GetBindLogs(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取推荐关系记录失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
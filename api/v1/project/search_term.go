@@ -0,0 +1,113 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/request"
+	"ApkAdmin/model/common/response"
+	request2 "ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SearchTermApi struct {
+}
+
+// CreateSearchTermRule 新增热搜词置顶或屏蔽规则
+func (a *SearchTermApi) CreateSearchTermRule(c *gin.Context) {
+	var req request2.SearchTermRuleCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := searchQueryService.CreateTermRule(req); err != nil {
+		global.GVA_LOG.Error("创建失败!", zap.Error(err))
+		response.FailWithMessage("创建失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("创建成功", c)
+}
+
+// UpdateSearchTermRule 编辑热搜词规则
+func (a *SearchTermApi) UpdateSearchTermRule(c *gin.Context) {
+	var req request2.SearchTermRuleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := searchQueryService.UpdateTermRule(req); err != nil {
+		global.GVA_LOG.Error("更新失败!", zap.Error(err))
+		response.FailWithMessage("更新失败："+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("更新成功", c)
+}
+
+// DeleteSearchTermRule 删除热搜词规则
+func (a *SearchTermApi) DeleteSearchTermRule(c *gin.Context) {
+	var info request.GetById
+	if err := c.ShouldBindJSON(&info); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(info, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := searchQueryService.DeleteTermRule(info.Uint()); err != nil {
+		global.GVA_LOG.Error("删除失败!", zap.Error(err))
+		response.FailWithMessage("删除失败", c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// GetSearchTermRuleList 热搜词规则列表
+func (a *SearchTermApi) GetSearchTermRuleList(c *gin.Context) {
+	var pageInfo request2.SearchTermRuleListRequest
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(pageInfo, utils.PageInfoVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := searchQueryService.GetTermRuleList(pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// GetZeroResultQueries 无结果搜索词，供运营补充应用
+func (a *SearchTermApi) GetZeroResultQueries(c *gin.Context) {
+	var pageInfo request2.ZeroResultQueryRequest
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(pageInfo, utils.PageInfoVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := searchQueryService.GetZeroResultQueries(pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取无结果搜索词失败!", zap.Error(err))
+		response.FailWithMessage("获取失败："+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
@@ -0,0 +1,113 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	projectReq "ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"io"
+)
+
+// InitUploadSession 初始化分片上传会话
+func (u *UploadApi) InitUploadSession(c *gin.Context) {
+	var req projectReq.UploadSessionInitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	data, err := uploadSessionService.InitSession(utils.GetUserID(c), req)
+	if err != nil {
+		global.GVA_LOG.Error("初始化上传会话失败!", zap.Error(err))
+		response.FailWithMessage("初始化上传会话失败："+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(data, "初始化成功", c)
+}
+
+// UploadSessionChunk 上传分片
+func (u *UploadApi) UploadSessionChunk(c *gin.Context) {
+	var req projectReq.UploadSessionChunkRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.FailWithMessage("接收分片失败", c)
+		return
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		global.GVA_LOG.Error("分片读取失败!", zap.Error(err))
+		response.FailWithMessage("分片读取失败", c)
+		return
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		global.GVA_LOG.Error("分片读取失败!", zap.Error(err))
+		response.FailWithMessage("分片读取失败", c)
+		return
+	}
+	if err = uploadSessionService.UploadChunk(req, content); err != nil {
+		global.GVA_LOG.Error("上传分片失败!", zap.Error(err), zap.String("sessionId", req.SessionID))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("分片上传成功", c)
+}
+
+// GetUploadSession 查询上传进度和缺失分片
+func (u *UploadApi) GetUploadSession(c *gin.Context) {
+	var req projectReq.UploadSessionQueryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	data, err := uploadSessionService.GetSession(req.SessionID)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(data, "获取成功", c)
+}
+
+// CompleteUploadSession 完成上传
+func (u *UploadApi) CompleteUploadSession(c *gin.Context) {
+	var req projectReq.UploadSessionCompleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	data, err := uploadSessionService.CompleteSession(utils.GetUserID(c), req)
+	if err != nil {
+		global.GVA_LOG.Error("完成上传失败!", zap.Error(err), zap.String("sessionId", req.SessionID))
+		response.FailWithMessage("完成上传失败："+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(data, "上传完成", c)
+}
+
+// AbortUploadSession 取消上传
+func (u *UploadApi) AbortUploadSession(c *gin.Context) {
+	var req projectReq.UploadSessionQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	if err := uploadSessionService.AbortSession(req.SessionID); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("已取消上传", c)
+}
@@ -0,0 +1,48 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ResetUserTwoFactor 重置用户的双因子认证，用户丢失设备且没有恢复码时使用，必须填写原因
+func (u *UserApi) ResetUserTwoFactor(c *gin.Context) {
+	var req request.AdminResetTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	err := userTwoFactorService.AdminReset(req, utils.GetUserID(c), utils.GetUserName(c), c.ClientIP())
+	if err != nil {
+		global.GVA_LOG.Error("重置双因子认证失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("重置成功", c)
+}
+
+// GetTwoFactorLogs 用户双因子认证记录
+func (u *UserApi) GetTwoFactorLogs(c *gin.Context) {
+	var req request.TwoFactorLogRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := userTwoFactorService.GetLogs(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取双因子认证记录失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
@@ -0,0 +1,75 @@
+package web
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	projectService "ApkAdmin/service/project"
+	"ApkAdmin/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SubmitWarrantyClaim 对已购买的账号申请售后，凭证图片通过 evidence 字段上传
+func (o OrderApi) SubmitWarrantyClaim(c *gin.Context) {
+	var req request.WarrantyClaimSubmitRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	form, err := c.MultipartForm()
+	if err != nil {
+		response.FailWithMessage("获取文件失败："+err.Error(), c)
+		return
+	}
+	claim, err := accountWarrantyService.SubmitClaim(req, utils.GetUserID(c), form.File["evidence"])
+	if err != nil {
+		global.GVA_LOG.Error("提交售后申请失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(claim, "提交成功", c)
+}
+
+// GetWarrantyClaimList 我的售后申请
+func (o OrderApi) GetWarrantyClaimList(c *gin.Context) {
+	var req request.WarrantyClaimListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	list, total, err := accountWarrantyService.GetClaimList(req, utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("获取售后申请失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
+
+// RevealAccountCredentials 查看已购买账号的明文详情，每次查看都会记录并限制每小时次数
+func (o OrderApi) RevealAccountCredentials(c *gin.Context) {
+	var req request.BuyerRevealAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	data, err := credentialRevealService.RevealForBuyer(req, projectService.RevealViewer{
+		ID:        utils.GetUserID(c),
+		Name:      utils.GetUserName(c),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		global.GVA_LOG.Error("查看账号详情失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(data, c)
+}
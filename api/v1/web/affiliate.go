@@ -0,0 +1,78 @@
+package web
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AffiliateApi 推广员数据
+type AffiliateApi struct{}
+
+// GetDownlines 直属下级列表
+func (a *AffiliateApi) GetDownlines(c *gin.Context) {
+	var req request.AffiliateDownlineRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := affiliateService.GetDownlines(utils.GetUserID(c), req)
+	if err != nil {
+		global.GVA_LOG.Error("获取直属下级失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
+
+// GetTrend 推广趋势：每天新增下级、下级订单和佣金
+func (a *AffiliateApi) GetTrend(c *gin.Context) {
+	var req request.AffiliateStatRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	points, err := affiliateService.GetTrend(utils.GetUserID(c), req)
+	if err != nil {
+		global.GVA_LOG.Error("获取推广趋势失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(points, "获取成功", c)
+}
+
+// GetTierProgress 当前等级和升级进度
+func (a *AffiliateApi) GetTierProgress(c *gin.Context) {
+	progress, err := affiliateService.GetTierProgress(utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("获取等级进度失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(progress, "获取成功", c)
+}
+
+// GetLinkStats 各推广渠道的效果
+func (a *AffiliateApi) GetLinkStats(c *gin.Context) {
+	var req request.AffiliateStatRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, err := affiliateService.GetLinkStats(utils.GetUserID(c), req)
+	if err != nil {
+		global.GVA_LOG.Error("获取推广链接数据失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
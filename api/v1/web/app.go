@@ -4,15 +4,19 @@ import (
 	"ApkAdmin/global"
 	"ApkAdmin/model/common/response"
 	"ApkAdmin/model/project/request"
+	projectService "ApkAdmin/service/project"
+	"ApkAdmin/utils"
+	"ApkAdmin/utils/geoip"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"time"
 )
 
 type AppApi struct {
 }
 
 func (a AppApi) ListHotOrRecommendApp(c *gin.Context) {
-	appList, err := AppService.GetHotOrRecommendApp()
+	appList, err := AppService.GetHotOrRecommendApp(geoip.CountryFromRequest(c))
 	if err != nil {
 		global.GVA_LOG.Error("获取热门或推荐应用失败!", zap.Error(err))
 		response.FailWithMessage("获取热门或推荐应用失败，"+err.Error(), c)
@@ -34,7 +38,7 @@ func (a AppApi) GetFilterApps(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
-	list, total, err := AppService.FilterAppsByCategory(req)
+	list, total, err := AppService.FilterAppsByCategory(req, geoip.CountryFromRequest(c))
 	if err != nil {
 		global.GVA_LOG.Error("获取分类列表应用失败!", zap.Error(err))
 		response.FailWithMessage("获取分类列表应用失败", c)
@@ -84,14 +88,72 @@ func (a AppApi) SearchApps(c *gin.Context) {
 		response.FailWithMessage("搜索应用失败", c)
 		return
 	}
-	applicationList, total, err := AppService.SearchApps(req)
+	countryCode := geoip.CountryFromRequest(c)
+	result, err := AppService.SearchApps(req, countryCode)
 	if err != nil {
 		global.GVA_LOG.Error("搜索应用失败!", zap.Error(err))
 		response.FailWithMessage("搜索应用失败"+err.Error(), c)
 		return
 	}
-	response.OkWithDetailed(map[string]interface{}{
-		"list":  applicationList,
-		"total": total,
-	}, "获取成功", c)
+	// 翻页和筛选不重复计入搜索词统计
+	if req.Page <= 1 && req.CategoryId == 0 && req.Platform == "" {
+		record := projectService.SearchRecord{
+			UserID:      utils.GetUserID(c),
+			IP:          c.ClientIP(),
+			CountryCode: countryCode,
+			Query:       req.Keyword,
+			ResultCount: result.Total,
+			At:          time.Now(),
+		}
+		searchQueryService.Enqueue(record)
+	}
+	response.OkWithDetailed(result, "获取成功", c)
+}
+
+// SearchSuggest 搜索输入联想
+func (a AppApi) SearchSuggest(c *gin.Context) {
+	var req request.SearchSuggestRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	suggestion, err := appSearchService.Suggest(req, geoip.CountryFromRequest(c))
+	if err != nil {
+		global.GVA_LOG.Error("获取搜索联想失败!", zap.Error(err))
+		response.FailWithMessage("获取搜索联想失败", c)
+		return
+	}
+	response.OkWithDetailed(suggestion, "获取成功", c)
+}
+
+// TrendingSearches 热搜词
+func (a AppApi) TrendingSearches(c *gin.Context) {
+	var req request.TrendingSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	list, err := searchQueryService.Trending(req.Limit)
+	if err != nil {
+		global.GVA_LOG.Error("获取热搜词失败!", zap.Error(err))
+		response.FailWithMessage("获取热搜词失败", c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
+
+// GetStoreDetail 应用详情，名称、描述、截图和更新说明按 lang 参数或 Accept-Language 选择语言
+func (a AppApi) GetStoreDetail(c *gin.Context) {
+	var req request.AppStoreDetailRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	detail, err := appListingService.StoreDetail(req, c.GetHeader("Accept-Language"), geoip.CountryFromRequest(c))
+	if err != nil {
+		global.GVA_LOG.Error("获取应用详情失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(detail, "获取成功", c)
 }
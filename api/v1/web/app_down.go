@@ -7,14 +7,15 @@ import (
 	projectModel "ApkAdmin/model/project"
 	"ApkAdmin/model/project/request"
 	projectRes "ApkAdmin/model/project/response"
+	projectService "ApkAdmin/service/project"
 	"ApkAdmin/utils"
+	"ApkAdmin/utils/geoip"
 	"ApkAdmin/utils/upload"
 	"errors"
 	"fmt"
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"gorm.io/gorm/clause"
 	"strings"
 	"time"
 )
@@ -42,14 +43,25 @@ func (a AppApi) DownloadApp(c *gin.Context) {
 	}
 
 	// 3. 处理下载逻辑
-	resp, err := a.handleDownloadLogic(c, req.AppId, platform)
+	// 访问者所在国家，未指定安装包国家时按访问者国家选择
+	event := a.newDownloadEvent(c, req.AppId, platform)
+	if req.CountryCode == "" {
+		req.CountryCode = event.CountryCode
+	}
+	resp, err := a.handleDownloadLogic(c, req, platform, &event)
 	if err != nil {
+		event.FailReason = err.Error()
+		downloadLogService.Enqueue(event)
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
 
-	// 4. ✅ 记录下载日志（异步）
-	go a.recordDownloadLog(c, req.AppId, platform, resp.CanDownload)
+	// 4. ✅ 记录下载日志（异步批量写入）
+	event.Success = resp.CanDownload
+	if !resp.CanDownload {
+		event.FailReason = resp.DownloadReason
+	}
+	downloadLogService.Enqueue(event)
 
 	response.OkWithData(resp, c)
 }
@@ -66,32 +78,44 @@ func (a AppApi) detectPlatform(c *gin.Context) constants.Platform {
 }
 
 // handleDownloadLogic 处理下载逻辑（优化版）
-func (a AppApi) handleDownloadLogic(c *gin.Context, appID uint, platform constants.Platform) (*projectRes.DownloadResp, error) {
+func (a AppApi) handleDownloadLogic(c *gin.Context, req request.DownloadAppRequest, platform constants.Platform, event *projectService.DownloadEvent) (*projectRes.DownloadResp, error) {
 	// 1. 获取应用信息
-	appInfo, err := AppService.GetAppDetail(appID, platform)
+	appInfo, err := AppService.GetApplication(req.AppId)
 	if err != nil {
-		global.GVA_LOG.Error("获取应用安装包失败",
+		global.GVA_LOG.Error("获取应用信息失败",
 			zap.Error(err),
-			zap.String("platform", platform.String()))
+			zap.Uint("appId", req.AppId))
 		return nil, fmt.Errorf("获取应用%s安装包失败", platform.String())
 	}
+	if err = a.checkCountryAllowed(&appInfo, event.CountryCode); err != nil {
+		return nil, err
+	}
 
-	// 2. 检查是否有安装包
-	if platform == constants.PlatformAndroid && len(appInfo.Packages) == 0 {
-		return nil, fmt.Errorf("%s设备下暂无支持的安装包", platform.String())
+	// 2. 按发布渠道、国家固定版本和灰度规则选择安装包
+	var appPackage *projectModel.AppPackage
+	if platform == constants.PlatformAndroid {
+		appPackage, err = packageReleaseService.ResolvePackage(&appInfo, platform, utils.GetUserID(c), strings.ToUpper(req.CountryCode), req.Channel)
+		if err != nil {
+			global.GVA_LOG.Warn("选择安装包失败",
+				zap.Error(err),
+				zap.String("appId", appInfo.AppID),
+				zap.String("platform", platform.String()))
+			return nil, fmt.Errorf("%s设备下暂无支持的安装包", platform.String())
+		}
+		event.PackageID = uint(appPackage.ID)
 	}
 
 	// 3. 免费应用处理
 	if appInfo.IsFree != nil && *appInfo.IsFree {
-		return a.handleFreeAppDownload(platform, &appInfo)
+		return a.handleFreeAppDownload(platform, appPackage)
 	}
 
 	// 4. 收费应用处理
-	return a.handlePaidAppDownload(c, platform, &appInfo)
+	return a.handlePaidAppDownload(c, platform, appPackage, event)
 }
 
 // ✅ handleFreeAppDownload 处理免费应用下载
-func (a AppApi) handleFreeAppDownload(platform constants.Platform, appInfo *projectModel.Application) (*projectRes.DownloadResp, error) {
+func (a AppApi) handleFreeAppDownload(platform constants.Platform, appPackage *projectModel.AppPackage) (*projectRes.DownloadResp, error) {
 	switch platform {
 	case constants.PlatformIOS:
 		account := a.getFreeIOSAccount()
@@ -105,7 +129,7 @@ func (a AppApi) handleFreeAppDownload(platform constants.Platform, appInfo *proj
 		}, nil
 
 	case constants.PlatformAndroid:
-		return a.handleAndroidDownload(&appInfo.Packages[0])
+		return a.handleAndroidDownload(appPackage)
 
 	default:
 		return nil, errors.New("不支持的平台")
@@ -113,37 +137,24 @@ func (a AppApi) handleFreeAppDownload(platform constants.Platform, appInfo *proj
 }
 
 // ✅ handlePaidAppDownload 处理收费应用下载（优化版）
-func (a AppApi) handlePaidAppDownload(c *gin.Context, platform constants.Platform, appInfo *projectModel.Application) (*projectRes.DownloadResp, error) {
+func (a AppApi) handlePaidAppDownload(c *gin.Context, platform constants.Platform, appPackage *projectModel.AppPackage, event *projectService.DownloadEvent) (*projectRes.DownloadResp, error) {
 	userID := utils.GetUserID(c)
 
-	// ✅ 使用轻量级查询，只获取会员信息
-	membership, err := a.getUserValidMembership(userID, platform)
-	if err != nil {
-		// 区分不同的错误类型
-		if err.Error() == "no_membership" {
-			return &projectRes.DownloadResp{
-				CanDownload:    false,
-				DownloadReason: "普通用户无法下载，请升级VIP后下载",
-			}, nil
-		}
-		if err.Error() == "platform_not_supported" {
-			return &projectRes.DownloadResp{
-				CanDownload:    false,
-				DownloadReason: "当前会员套餐不支持该平台，请升级套餐",
-			}, nil
-		}
-		if err.Error() == "download_limit_exceeded" {
-			return &projectRes.DownloadResp{
-				CanDownload:    false,
-				DownloadReason: "今日下载次数已用完，请明天再试",
-			}, nil
-		}
-		return nil, err
-	}
-
-	// ✅ 根据平台返回下载信息
 	switch platform {
 	case constants.PlatformIOS:
+		// ✅ 使用轻量级查询，只获取会员信息
+		membership, err := a.getUserValidMembership(userID, platform)
+		if err != nil {
+			// 区分不同的错误类型
+			if err.Error() == "no_membership" {
+				return &projectRes.DownloadResp{
+					CanDownload:    false,
+					DownloadReason: "普通用户无法下载，请升级VIP后下载",
+				}, nil
+			}
+			return nil, err
+		}
+		event.MembershipID = membership.ID
 		return &projectRes.DownloadResp{
 			CanDownload:    true,
 			DownloadReason: "success",
@@ -151,18 +162,44 @@ func (a AppApi) handlePaidAppDownload(c *gin.Context, platform constants.Platfor
 		}, nil
 
 	case constants.PlatformAndroid:
-		appPackage := &appInfo.Packages[0]
-
-		// ✅ 增加下载计数（异步）
-		go a.incrementDownloadCount(membership.ID)
-
-		return a.handleAndroidDownload(appPackage)
+		// ✅ 下发地址前原子预占下载额度，地址生成失败时归还
+		reservation, err := downloadQuotaService.ReserveForUser(userID, platform)
+		if err != nil {
+			if reason, ok := quotaDeniedReason(err); ok {
+				return &projectRes.DownloadResp{
+					CanDownload:    false,
+					DownloadReason: reason,
+				}, nil
+			}
+			global.GVA_LOG.Error("预占下载额度失败", zap.Error(err))
+			return nil, errors.New("查询会员信息失败")
+		}
+		event.MembershipID = reservation.Membership.ID
+		resp, err := a.handleAndroidDownload(appPackage)
+		if err != nil || !resp.CanDownload {
+			downloadQuotaService.Release(reservation)
+			return resp, err
+		}
+		downloadQuotaService.Commit(reservation)
+		return resp, nil
 
 	default:
 		return nil, errors.New("不支持的平台")
 	}
 }
 
+// quotaDeniedReason 额度不足等业务拒绝返回给用户的提示，其他错误返回 false
+func quotaDeniedReason(err error) (string, bool) {
+	switch {
+	case errors.Is(err, projectService.ErrNoMembership),
+		errors.Is(err, projectService.ErrPlatformNotSupported),
+		errors.Is(err, projectService.ErrDailyQuotaExceeded),
+		errors.Is(err, projectService.ErrMonthlyQuotaExceeded):
+		return err.Error(), true
+	}
+	return "", false
+}
+
 // ✅ getUserValidMembership 获取用户有效会员（优化版）
 func (a AppApi) getUserValidMembership(userID uint, platform constants.Platform) (*projectModel.UserMembership, error) {
 	var memberships []projectModel.UserMembership
@@ -215,44 +252,28 @@ func (a AppApi) getUserValidMembership(userID uint, platform constants.Platform)
 	return nil, errors.New("下载次数已经用完")
 }
 
-// ✅ incrementDownloadCount 增加下载计数（异步）
-func (a AppApi) incrementDownloadCount(membershipID uint) {
-	var membership projectModel.UserMembership
-
-	// 加锁查询
-	err := global.GVA_DB.
-		Clauses(clause.Locking{Strength: "UPDATE"}).
-		Where("id = ?", membershipID).
-		First(&membership).Error
-
-	if err != nil {
-		global.GVA_LOG.Error("查询会员失败", zap.Error(err))
-		return
-	}
-
-	// 增加计数
-	membership.IncrementDownloadCount()
-
-	// 更新数据库
-	if err := global.GVA_DB.Save(&membership).Error; err != nil {
-		global.GVA_LOG.Error("更新下载计数失败", zap.Error(err))
+// checkCountryAllowed 访问者所在国家不支持时禁止下载
+func (a AppApi) checkCountryAllowed(app *projectModel.Application, countryCode string) error {
+	err := countryService.CheckDownloadAllowed(app, countryCode)
+	if err == nil || errors.Is(err, projectService.ErrCountryNotSupported) || errors.Is(err, projectService.ErrAppNotInCountry) {
+		return err
 	}
+	global.GVA_LOG.Error("检查下载国家失败", zap.Error(err), zap.String("countryCode", countryCode))
+	return errors.New("检查下载地区失败")
 }
 
-// ✅ recordDownloadLog 记录下载日志（异步）
-func (a AppApi) recordDownloadLog(c *gin.Context, appID uint, platform constants.Platform, success bool) {
-	UserAgent := c.Request.UserAgent()
-	log := projectModel.DownloadLog{
-		UserID:    utils.GetUserID(c),
-		AppID:     appID,
-		Platform:  platform,
-		Success:   success,
-		IP:        c.ClientIP(),
-		UserAgent: &UserAgent,
-		CreatedAt: time.Now(),
-	}
-	if err := global.GVA_DB.Create(&log).Error; err != nil {
-		global.GVA_LOG.Error("记录下载日志失败", zap.Error(err))
+// newDownloadEvent 在请求处理过程中提取下载日志需要的请求信息，入队后不再依赖 gin.Context
+func (a AppApi) newDownloadEvent(c *gin.Context, appID uint, platform constants.Platform) projectService.DownloadEvent {
+	userAgent := c.Request.UserAgent()
+	return projectService.DownloadEvent{
+		UserID:      utils.GetUserID(c),
+		AppID:       appID,
+		Platform:    platform,
+		IP:          c.ClientIP(),
+		UserAgent:   userAgent,
+		DeviceType:  utils.ParseDeviceType(userAgent),
+		CountryCode: geoip.CountryFromIP(c),
+		CreatedAt:   time.Now(),
 	}
 }
 
@@ -271,6 +292,8 @@ func (a *AppApi) handleAndroidDownload(appPackage *projectModel.AppPackage) (*pr
 		CanDownload:    true,
 		DownloadReason: "success",
 		PackageUrl:     url,
+		PackageSha256:  utils.StringValue(appPackage.FileSha256),
+		PackageMd5:     utils.StringValue(appPackage.FileMd5),
 	}, nil
 }
 
@@ -279,35 +302,37 @@ func (a *AppApi) getPackageUrl(appPackage *projectModel.AppPackage) (string, err
 	if appPackage == nil {
 		return "", errors.New("安装包信息为空")
 	}
+	return a.getObjectUrl(appPackage.ObjectName, appPackage.FileURL, appPackage.FileName, "package.apk")
+}
 
+// getObjectUrl 根据存储类型获取文件下载URL
+func (a *AppApi) getObjectUrl(objectName, fileURL, fileName *string, defaultFileName string) (string, error) {
 	switch global.GVA_CONFIG.System.OssType {
 	case "aliyun-oss":
-		return a.getAliyunOssUrl(appPackage)
+		return a.getAliyunOssUrl(objectName, fileName, defaultFileName)
 	default:
-		return a.getFileUrl(appPackage)
+		return a.getFileUrl(fileURL)
 	}
 }
 
 // getAliyunOssUrl 获取阿里云OSS URL
-func (a *AppApi) getAliyunOssUrl(appPackage *projectModel.AppPackage) (string, error) {
-	if appPackage.ObjectName == nil || *appPackage.ObjectName == "" {
+func (a *AppApi) getAliyunOssUrl(objectName, fileName *string, defaultFileName string) (string, error) {
+	if objectName == nil || *objectName == "" {
 		return "", errors.New("OSS对象名称为空")
 	}
 
-	objectName := *appPackage.ObjectName
-
 	// 公开文件：直接返回公开URL
-	if strings.HasPrefix(objectName, "public/") {
-		return utils.BuildPublicUrl(objectName), nil
+	if strings.HasPrefix(*objectName, "public/") {
+		return utils.BuildPublicUrl(*objectName), nil
 	}
 
 	// 私有文件：生成签名URL
-	fileName := "package.apk"
-	if appPackage.FileName != nil {
-		fileName = *appPackage.FileName
+	name := defaultFileName
+	if fileName != nil {
+		name = *fileName
 	}
 
-	signedUrl, err := a.GenerateApkDownloadUrl(objectName, fileName, 300)
+	signedUrl, err := a.GenerateApkDownloadUrl(*objectName, name, 300)
 	if err != nil {
 		return "", fmt.Errorf("生成签名URL失败: %w", err)
 	}
@@ -316,11 +341,11 @@ func (a *AppApi) getAliyunOssUrl(appPackage *projectModel.AppPackage) (string, e
 }
 
 // getFileUrl 获取文件URL
-func (a *AppApi) getFileUrl(appPackage *projectModel.AppPackage) (string, error) {
-	if appPackage.FileURL == nil || *appPackage.FileURL == "" {
+func (a *AppApi) getFileUrl(fileURL *string) (string, error) {
+	if fileURL == nil || *fileURL == "" {
 		return "", errors.New("文件URL为空")
 	}
-	return *appPackage.FileURL, nil
+	return *fileURL, nil
 }
 
 // GenerateApkDownloadUrl 生成APK下载的签名URL
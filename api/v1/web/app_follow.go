@@ -0,0 +1,78 @@
+package web
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// FollowApp 关注应用，新版本发布时收到站内通知
+func (a AppApi) FollowApp(c *gin.Context) {
+	var req request.AppFollowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	if err := appFollowService.Follow(utils.GetUserID(c), req.AppID); err != nil {
+		global.GVA_LOG.Error("关注应用失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("关注成功", c)
+}
+
+// UnfollowApp 取消关注应用
+func (a AppApi) UnfollowApp(c *gin.Context) {
+	var req request.AppFollowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	if err := appFollowService.Unfollow(utils.GetUserID(c), req.AppID); err != nil {
+		global.GVA_LOG.Error("取消关注失败!", zap.Error(err))
+		response.FailWithMessage("取消关注失败", c)
+		return
+	}
+	response.OkWithMessage("已取消关注", c)
+}
+
+// GetFollowStatus 是否已关注应用
+func (a AppApi) GetFollowStatus(c *gin.Context) {
+	var req request.AppFollowRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	following, err := appFollowService.IsFollowing(utils.GetUserID(c), req.AppID)
+	if err != nil {
+		global.GVA_LOG.Error("查询关注状态失败!", zap.Error(err))
+		response.FailWithMessage("查询关注状态失败", c)
+		return
+	}
+	response.OkWithDetailed(gin.H{"following": following}, "获取成功", c)
+}
+
+// GetFollowList 我关注的应用
+func (a AppApi) GetFollowList(c *gin.Context) {
+	var req request.FollowListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	list, total, err := appFollowService.GetFollowList(utils.GetUserID(c), req)
+	if err != nil {
+		global.GVA_LOG.Error("获取关注列表失败!", zap.Error(err))
+		response.FailWithMessage("获取关注列表失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
@@ -0,0 +1,43 @@
+package web
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"ApkAdmin/utils/geoip"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AlsoDownloaded 下载了该应用的用户还下载了
+func (a AppApi) AlsoDownloaded(c *gin.Context) {
+	var req request.AlsoDownloadedRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	list, err := recommendService.AlsoDownloaded(req.AppID, geoip.CountryFromRequest(c), req.Limit)
+	if err != nil {
+		global.GVA_LOG.Error("获取相关推荐失败!", zap.Error(err))
+		response.FailWithMessage("获取相关推荐失败", c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
+
+// ForYou 个性化推荐，未登录时返回人工推荐的应用
+func (a AppApi) ForYou(c *gin.Context) {
+	var req request.ForYouRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	list, err := recommendService.ForYou(utils.GetUserID(c), geoip.CountryFromRequest(c), req.Limit)
+	if err != nil {
+		global.GVA_LOG.Error("获取个性化推荐失败!", zap.Error(err))
+		response.FailWithMessage("获取推荐失败", c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
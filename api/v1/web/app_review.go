@@ -0,0 +1,99 @@
+package web
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	projectService "ApkAdmin/service/project"
+	"ApkAdmin/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SubmitReview 提交或修改应用评价
+func (a AppApi) SubmitReview(c *gin.Context) {
+	var req request.SubmitReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	review, err := appReviewService.Submit(projectService.ReviewSubmit{
+		UserID:              utils.GetUserID(c),
+		IP:                  c.ClientIP(),
+		SubmitReviewRequest: req,
+	})
+	if err != nil {
+		global.GVA_LOG.Error("提交评价失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(review, "评价成功", c)
+}
+
+// DeleteReview 删除自己的评价
+func (a AppApi) DeleteReview(c *gin.Context) {
+	var req request.AppReviewIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	if err := appReviewService.Delete(utils.GetUserID(c), req.AppID); err != nil {
+		global.GVA_LOG.Error("删除评价失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// GetMyReview 当前用户对应用的评价
+func (a AppApi) GetMyReview(c *gin.Context) {
+	var req request.AppReviewIDRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	review, err := appReviewService.GetMine(utils.GetUserID(c), req.AppID)
+	if err != nil {
+		global.GVA_LOG.Error("获取评价失败!", zap.Error(err))
+		response.FailWithMessage("获取评价失败", c)
+		return
+	}
+	response.OkWithDetailed(review, "获取成功", c)
+}
+
+// ListReviews 应用评价列表
+func (a AppApi) ListReviews(c *gin.Context) {
+	var req request.AppReviewQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	list, total, err := appReviewService.ListVisible(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取评价列表失败!", zap.Error(err))
+		response.FailWithMessage("获取评价列表失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
+
+// ReviewSummary 应用评分汇总
+func (a AppApi) ReviewSummary(c *gin.Context) {
+	var req request.AppReviewIDRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	summary, err := appReviewService.Summary(req.AppID)
+	if err != nil {
+		global.GVA_LOG.Error("获取评分汇总失败!", zap.Error(err))
+		response.FailWithMessage("获取评分汇总失败", c)
+		return
+	}
+	response.OkWithDetailed(summary, "获取成功", c)
+}
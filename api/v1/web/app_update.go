@@ -0,0 +1,132 @@
+package web
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	projectRes "ApkAdmin/model/project/response"
+	projectService "ApkAdmin/service/project"
+	"ApkAdmin/utils"
+	"ApkAdmin/utils/geoip"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CheckUpdate 检查应用更新，已安装包的哈希与补丁匹配时下发差分补丁，否则下发全量包
+func (a AppApi) CheckUpdate(c *gin.Context) {
+	var req request.CheckUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("检查更新失败，参数错误："+err.Error(), c)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.FailWithMessage("检查更新失败，参数错误："+err.Error(), c)
+		return
+	}
+
+	platform := a.detectPlatform(c)
+	if platform != constants.PlatformAndroid {
+		response.FailWithMessage("仅安卓设备支持检查更新", c)
+		return
+	}
+
+	appInfo, err := AppService.GetApplication(req.AppId)
+	if err != nil {
+		global.GVA_LOG.Error("获取应用信息失败", zap.Error(err), zap.Uint("appId", req.AppId))
+		response.FailWithMessage("获取应用信息失败", c)
+		return
+	}
+	// 未指定安装包国家时按访问者国家选择；国家限制只按IP识别，不能被请求头覆盖
+	country := geoip.CountryFromIP(c)
+	if req.CountryCode == "" {
+		req.CountryCode = country
+	}
+	if err = a.checkCountryAllowed(&appInfo, country); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	userID := utils.GetUserID(c)
+	target, err := packageReleaseService.ResolvePackage(&appInfo, platform, userID, req.CountryCode, req.Channel)
+	if err != nil {
+		response.FailWithMessage("暂无可用的安装包", c)
+		return
+	}
+
+	resp := &projectRes.CheckUpdateResp{}
+	if target.VersionCode == nil || *target.VersionCode <= req.VersionCode {
+		response.OkWithData(resp, c)
+		return
+	}
+	resp.HasUpdate = true
+	resp.VersionName = target.VersionName
+	resp.VersionCode = *target.VersionCode
+	resp.PackageSize = target.PackageSize
+	resp.PackageSha256 = utils.StringValue(target.FileSha256)
+	resp.PackageMd5 = utils.StringValue(target.FileMd5)
+
+	event := a.newDownloadEvent(c, req.AppId, platform)
+	event.PackageID = uint(target.ID)
+
+	// 收费应用与下载接口一致，需要有效会员并预占下载额度
+	var reservation *projectService.QuotaReservation
+	if appInfo.IsFree == nil || !*appInfo.IsFree {
+		reservation, err = downloadQuotaService.ReserveForUser(userID, platform)
+		if err != nil {
+			reason, ok := quotaDeniedReason(err)
+			if !ok {
+				global.GVA_LOG.Error("预占下载额度失败", zap.Error(err))
+				event.FailReason = err.Error()
+				downloadLogService.Enqueue(event)
+				response.FailWithMessage("查询会员信息失败", c)
+				return
+			}
+			resp.DownloadReason = reason
+			event.FailReason = reason
+			downloadLogService.Enqueue(event)
+			response.OkWithData(resp, c)
+			return
+		}
+		event.MembershipID = reservation.Membership.ID
+	}
+
+	packageUrl, err := a.getPackageUrl(target)
+	if err != nil {
+		downloadQuotaService.Release(reservation)
+		global.GVA_LOG.Error("生成安卓下载地址失败", zap.Error(err))
+		resp.DownloadReason = "下载地址获取失败"
+		event.FailReason = err.Error()
+		downloadLogService.Enqueue(event)
+		response.OkWithData(resp, c)
+		return
+	}
+	downloadQuotaService.Commit(reservation)
+	resp.CanDownload = true
+	resp.DownloadReason = "success"
+	resp.UpdateType = "full"
+	resp.PackageUrl = packageUrl
+
+	patch, err := packagePatchService.FindPatch(target, req.VersionCode, req.ApkSha256)
+	if err != nil {
+		global.GVA_LOG.Error("查询差分补丁失败", zap.Error(err))
+	}
+	if patch != nil {
+		fileName := "update.patch"
+		patchUrl, err := a.getObjectUrl(patch.ObjectName, patch.FileURL, &fileName, fileName)
+		if err != nil {
+			global.GVA_LOG.Error("生成补丁下载地址失败", zap.Error(err))
+		} else {
+			resp.UpdateType = "patch"
+			resp.PatchUrl = patchUrl
+			resp.PatchSize = patch.PatchSize
+			resp.PatchSha256 = patch.PatchSha256
+			resp.PatchFormat = projectService.PatchFormat
+			resp.OutputSha256 = patch.ToSha256
+		}
+	}
+
+	event.Success = true
+	downloadLogService.Enqueue(event)
+
+	response.OkWithData(resp, c)
+}
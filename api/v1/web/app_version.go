@@ -0,0 +1,119 @@
+package web
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils/feed"
+	"ApkAdmin/utils/geoip"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetVersionHistory 应用版本历史，更新说明按 lang 参数或 Accept-Language 选择语言
+func (a AppApi) GetVersionHistory(c *gin.Context) {
+	var req request.AppVersionHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	list, total, err := appVersionService.VersionHistory(req, c.GetHeader("Accept-Language"), geoip.CountryFromRequest(c))
+	if err != nil {
+		global.GVA_LOG.Error("获取版本历史失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
+
+// ReleasesRSS 最新版本 RSS 订阅源
+func (a AppApi) ReleasesRSS(c *gin.Context) {
+	a.writeReleaseFeed(c, "application/rss+xml; charset=utf-8", feed.Feed.RSS)
+}
+
+// ReleasesAtom 最新版本 Atom 订阅源
+func (a AppApi) ReleasesAtom(c *gin.Context) {
+	a.writeReleaseFeed(c, "application/atom+xml; charset=utf-8", feed.Feed.Atom)
+}
+
+// ReleasesJSON 最新版本 JSON Feed
+func (a AppApi) ReleasesJSON(c *gin.Context) {
+	a.writeReleaseFeed(c, "application/feed+json; charset=utf-8", feed.Feed.JSON)
+}
+
+func (a AppApi) writeReleaseFeed(c *gin.Context, contentType string, encode func(feed.Feed) ([]byte, error)) {
+	var req request.ReleaseFeedRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.String(http.StatusBadRequest, "参数错误："+err.Error())
+		return
+	}
+	cfg := global.GVA_CONFIG.Feed
+	releases, err := appVersionService.RecentReleases(req, c.GetHeader("Accept-Language"), geoip.CountryFromRequest(c), cfg.Limit)
+	if err != nil {
+		global.GVA_LOG.Error("获取最新版本失败!", zap.Error(err))
+		c.String(http.StatusInternalServerError, "获取最新版本失败")
+		return
+	}
+
+	site, ok := siteURL()
+	if !ok {
+		global.GVA_LOG.Error("生成订阅源失败，未配置 feed.site-url")
+		c.String(http.StatusServiceUnavailable, "订阅源未配置")
+		return
+	}
+	appPath := cfg.AppPath
+	if appPath == "" {
+		appPath = "/app/%d"
+	}
+	f := feed.Feed{
+		Title:       cfg.Title,
+		Link:        site,
+		FeedURL:     site + c.Request.URL.RequestURI(),
+		Description: "最近发布的应用新版本",
+		Language:    req.Lang,
+		Updated:     time.Now(),
+	}
+	if f.Title == "" {
+		f.Title = "最新版本"
+	}
+	if len(releases) > 0 && releases[0].PublishedAt != nil {
+		f.Updated = *releases[0].PublishedAt
+	}
+	for _, r := range releases {
+		link := site + fmt.Sprintf(appPath, r.ApplicationID)
+		item := feed.Item{
+			ID:       fmt.Sprintf("%s#package-%d", link, r.ID),
+			Title:    fmt.Sprintf("%s %s", r.AppName, r.VersionName),
+			Link:     link,
+			Content:  r.Changelog,
+			Category: r.Platform,
+		}
+		if r.PublishedAt != nil {
+			item.Published = *r.PublishedAt
+		}
+		f.Items = append(f.Items, item)
+	}
+	data, err := encode(f)
+	if err != nil {
+		global.GVA_LOG.Error("生成订阅源失败!", zap.Error(err))
+		c.String(http.StatusInternalServerError, "生成订阅源失败")
+		return
+	}
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// siteURL 前台站点地址，只使用配置的地址，不能用客户端可控的 Host 和 X-Forwarded-Proto 请求头拼接
+func siteURL() (string, bool) {
+	site := strings.TrimRight(global.GVA_CONFIG.Feed.SiteURL, "/")
+	return site, site != ""
+}
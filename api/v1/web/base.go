@@ -138,7 +138,15 @@ func (a BaseApi) Register(c *gin.Context) {
 		response.FailWithMessage("该邮箱已被注册", c)
 		return
 	}
-	err = UserService.RegisterUser(req, clientIP)
+	// 填写了邀请码时必须有效
+	if req.InviteCode != "" {
+		if inviteExists, _ := checkInviteCodeExists(req.InviteCode); !inviteExists {
+			response.FailWithMessage("邀请码无效", c)
+			return
+		}
+	}
+	clickID, _ := c.Cookie(referralCookieName)
+	err = UserService.RegisterUser(req, clientIP, clickID)
 	if err != nil {
 		incrementIPAttempt("register", clientIP)
 		global.GVA_LOG.Error("注册失败，", zap.Error(err))
@@ -147,6 +155,12 @@ func (a BaseApi) Register(c *gin.Context) {
 	}
 	// 注册成功，清除限制记录
 	clearIPAttempt("register", clientIP)
+	// 发送邮箱验证邮件，失败时用户可以登录后重新发送
+	if user, err := UserService.GetSimpleUser(project.WithEmail(req.Email)); err == nil {
+		if err = userEmailService.SendVerifyEmail(user.ID, clientIP, accountEmailSite()); err != nil {
+			global.GVA_LOG.Error("发送验证邮件失败!", zap.Error(err))
+		}
+	}
 	response.OkWithMessage("注册成功", c)
 
 }
@@ -203,6 +217,22 @@ func (a BaseApi) Login(c *gin.Context) {
 		response.FailWithMessage("用户被禁止登录", c)
 		return
 	}
+	// 开启双因子认证时先返回登录挑战，验证通过后再签发jwt
+	if user.TwoFactorEnabled {
+		challenge, expiresAt, err := userTwoFactorService.IssueLoginChallenge(user.ID)
+		if err != nil {
+			global.GVA_LOG.Error("生成登录挑战失败!", zap.Error(err))
+			response.FailWithMessage("登录失败，请稍后再试", c)
+			return
+		}
+		clearIPAttempt("login", clientIP)
+		response.OkWithDetailed(projectRes.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+			ExpiresAt:         expiresAt.Unix() * 1000,
+		}, "请输入双因子验证码", c)
+		return
+	}
 	//登录以后签发jwt
 	a.TokenNext(c, *user, clientIP)
 }
@@ -215,6 +245,11 @@ func (a *BaseApi) TokenNext(c *gin.Context, user projectModel.User, clientIP str
 		response.FailWithMessage("获取token失败", c)
 		return
 	}
+	if err = UserService.RecordSession(user.ID, token, claims.ExpiresAt.Time, clientIP, c.Request.UserAgent()); err != nil {
+		global.GVA_LOG.Error("记录登录令牌失败!", zap.Error(err))
+		response.FailWithMessage("获取token失败", c)
+		return
+	}
 	clearIPAttempt("login", clientIP)
 	response.OkWithDetailed(projectRes.LoginResponse{
 		User:      user,
@@ -288,6 +323,12 @@ func checkPhoneExists(phone string) (bool, error) {
 	return count > 0, err
 }
 
+// 检查邀请码是否存在
+func checkInviteCodeExists(code string) (bool, error) {
+	count, err := UserService.CountUserBy(project.WithInviteCode(code))
+	return count > 0, err
+}
+
 // 检查邮箱是否已存在
 func checkEmailExists(email string) (bool, error) {
 	count, err := UserService.CountUserBy(project.WithEmail(email))
@@ -15,6 +15,8 @@ type ApiGroup struct {
 	CommissionTierApi
 	WithdrawApi
 	CommissionDetailApi
+	UserNotificationApi
+	AffiliateApi
 }
 
 var (
@@ -30,4 +32,23 @@ var (
 	commissionTierService     = service.ServiceGroupApp.ProjectServiceGroup.CommissionTierService
 	systemConfigService       = service.ServiceGroupApp.ProjectServiceGroup.SystemConfigService
 	commissionDetailService   = service.ServiceGroupApp.ProjectServiceGroup.CommissionDetailService
+	packageReleaseService     = service.ServiceGroupApp.ProjectServiceGroup.PackageReleaseService
+	packagePatchService       = service.ServiceGroupApp.ProjectServiceGroup.PackagePatchService
+	downloadQuotaService      = service.ServiceGroupApp.ProjectServiceGroup.DownloadQuotaService
+	downloadLogService        = service.ServiceGroupApp.ProjectServiceGroup.DownloadLogService
+	countryService            = service.ServiceGroupApp.ProjectServiceGroup.CountryService
+	appSearchService          = service.ServiceGroupApp.ProjectServiceGroup.AppSearchService
+	searchQueryService        = service.ServiceGroupApp.ProjectServiceGroup.SearchQueryService
+	appReviewService          = service.ServiceGroupApp.ProjectServiceGroup.AppReviewService
+	recommendService          = service.ServiceGroupApp.ProjectServiceGroup.RecommendService
+	appListingService         = service.ServiceGroupApp.ProjectServiceGroup.AppListingService
+	appVersionService         = service.ServiceGroupApp.ProjectServiceGroup.AppVersionService
+	appFollowService          = service.ServiceGroupApp.ProjectServiceGroup.AppFollowService
+	userNotificationService   = service.ServiceGroupApp.ProjectServiceGroup.UserNotificationService
+	accountWarrantyService    = service.ServiceGroupApp.ProjectServiceGroup.AccountWarrantyService
+	credentialRevealService   = service.ServiceGroupApp.ProjectServiceGroup.CredentialRevealService
+	userEmailService          = service.ServiceGroupApp.ProjectServiceGroup.UserEmailService
+	userTwoFactorService      = service.ServiceGroupApp.ProjectServiceGroup.UserTwoFactorService
+	referralService           = service.ServiceGroupApp.ProjectServiceGroup.ReferralService
+	affiliateService          = service.ServiceGroupApp.ProjectServiceGroup.AffiliateService
 )
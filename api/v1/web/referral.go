@@ -0,0 +1,40 @@
+package web
+
+import (
+	"ApkAdmin/global"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// referralCookieName 推广链接点击ID的 cookie，注册时用来归属推荐人
+const referralCookieName = "ref_click"
+
+// ReferralLink 推广链接：记录点击并写入归属 cookie，然后跳转到注册页。邀请码无效时直接跳转，不写 cookie
+// 可选参数 ch 标记投放渠道，用于推广员查看各链接的效果
+func (a BaseApi) ReferralLink(c *gin.Context) {
+	code := strings.TrimSpace(c.Param("code"))
+	path := global.GVA_CONFIG.Referral.RegisterPath
+	if path == "" {
+		path = "/register?inviteCode=%s"
+	}
+	// 未配置站点地址时跳转到当前域名下的相对路径
+	target := accountEmailSite() + fmt.Sprintf(path, url.QueryEscape(code))
+	click, err := referralService.RecordClick(code, c.Query("ch"), c.ClientIP(), c.Request.UserAgent(), c.Request.Referer())
+	if err != nil {
+		global.GVA_LOG.Warn("推广链接无效", zap.String("code", code), zap.Error(err))
+		c.Redirect(http.StatusFound, accountEmailSite()+"/register")
+		return
+	}
+	days := global.GVA_CONFIG.Referral.CookieDays
+	if days <= 0 {
+		days = 30
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(referralCookieName, click.ClickID, days*24*3600, "/", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, target)
+}
@@ -38,7 +38,7 @@ func (u *UserApi) ChangePassword(c *gin.Context) {
 		return
 	}
 	userId := utils.GetUserID(c)
-	err = UserService.ChangeUserPassword(userId, req)
+	err = UserService.ChangeUserPassword(userId, req, c.ClientIP())
 	if err != nil {
 		global.GVA_LOG.Error("用户密码修改失败!", zap.Error(err))
 		response.FailWithMessage(err.Error(), c)
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SendVerifyEmail 给当前用户的邮箱发送验证链接
+func (u *UserApi) SendVerifyEmail(c *gin.Context) {
+	if err := userEmailService.SendVerifyEmail(utils.GetUserID(c), c.ClientIP(), accountEmailSite()); err != nil {
+		global.GVA_LOG.Error("发送验证邮件失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("验证邮件已发送，请查收", c)
+}
+
+// VerifyEmail 打开邮件中的验证链接后由前端提交令牌
+func (a BaseApi) VerifyEmail(c *gin.Context) {
+	var req request.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	if err := userEmailService.VerifyEmail(req.Token); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("邮箱验证成功", c)
+}
+
+// ForgotPassword 找回密码，无论邮箱是否注册都返回相同提示
+func (a BaseApi) ForgotPassword(c *gin.Context) {
+	var req request.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	clientIP := c.ClientIP()
+	if isBlocked, until := checkIPBlocked(clientIP); isBlocked {
+		response.FailWithMessage("该IP已被临时封禁至 "+until.Format("15:04"), c)
+		return
+	}
+	if !store.Verify(req.CaptchaKey, req.Captcha, true) {
+		incrementIPAttempt("forgot", clientIP)
+		response.FailWithMessage("图形验证码错误", c)
+		return
+	}
+	if err := userEmailService.SendPasswordReset(req.Email, clientIP, accountEmailSite()); err != nil {
+		global.GVA_LOG.Error("发送重置密码邮件失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("如果该邮箱已注册，重置链接已发送，请查收", c)
+}
+
+// ResetPassword 通过重置链接设置新密码，成功后所有设备需要重新登录
+func (a BaseApi) ResetPassword(c *gin.Context) {
+	var req request.ResetPasswordByTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	if err := userEmailService.ResetPassword(req.Token, req.Password); err != nil {
+		global.GVA_LOG.Error("重置密码失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("密码已重置，请重新登录", c)
+}
+
+// accountEmailSite 邮件链接的站点地址，未单独配置时与订阅源相同；都未配置时返回空，不使用请求头中的域名
+func accountEmailSite() string {
+	if site := global.GVA_CONFIG.AccountEmail.SiteURL; site != "" {
+		return strings.TrimRight(site, "/")
+	}
+	site, _ := siteURL()
+	return site
+}
@@ -0,0 +1,60 @@
+package web
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type UserNotificationApi struct{}
+
+// GetNotificationList 站内通知列表
+func (n UserNotificationApi) GetNotificationList(c *gin.Context) {
+	var req request.NotificationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	list, total, err := userNotificationService.GetNotificationList(utils.GetUserID(c), req)
+	if err != nil {
+		global.GVA_LOG.Error("获取通知列表失败!", zap.Error(err))
+		response.FailWithMessage("获取通知列表失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
+
+// GetUnreadCount 未读通知数量
+func (n UserNotificationApi) GetUnreadCount(c *gin.Context) {
+	count, err := userNotificationService.UnreadCount(utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("获取未读通知数量失败!", zap.Error(err))
+		response.FailWithMessage("获取未读通知数量失败", c)
+		return
+	}
+	response.OkWithDetailed(gin.H{"count": count}, "获取成功", c)
+}
+
+// MarkRead 标记通知已读，不传 ids 时全部标记为已读
+func (n UserNotificationApi) MarkRead(c *gin.Context) {
+	var req request.NotificationReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误："+err.Error(), c)
+		return
+	}
+	if err := userNotificationService.MarkRead(utils.GetUserID(c), req.IDs); err != nil {
+		global.GVA_LOG.Error("标记通知已读失败!", zap.Error(err))
+		response.FailWithMessage("标记已读失败", c)
+		return
+	}
+	response.OkWithMessage("操作成功", c)
+}
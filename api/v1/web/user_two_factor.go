@@ -0,0 +1,112 @@
+package web
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/response"
+	"ApkAdmin/model/project/request"
+	projectRes "ApkAdmin/model/project/response"
+	"ApkAdmin/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SetupTwoFactor 获取双因子认证的密钥和二维码，提交验证码确认后才生效
+func (u *UserApi) SetupTwoFactor(c *gin.Context) {
+	data, err := userTwoFactorService.Setup(utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("获取双因子认证密钥失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(data, c)
+}
+
+// EnableTwoFactor 确认验证码并开启双因子认证，返回的恢复码只显示一次
+func (u *UserApi) EnableTwoFactor(c *gin.Context) {
+	var req request.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	codes, err := userTwoFactorService.Enable(utils.GetUserID(c), req.Code, c.ClientIP())
+	if err != nil {
+		global.GVA_LOG.Error("开启双因子认证失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(projectRes.RecoveryCodesResponse{RecoveryCodes: codes}, "双因子认证已开启，请妥善保存恢复码", c)
+}
+
+// DisableTwoFactor 关闭双因子认证
+func (u *UserApi) DisableTwoFactor(c *gin.Context) {
+	var req request.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	if err := userTwoFactorService.Disable(utils.GetUserID(c), req.Code, c.ClientIP()); err != nil {
+		global.GVA_LOG.Error("关闭双因子认证失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("双因子认证已关闭", c)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码失效
+func (u *UserApi) RegenerateRecoveryCodes(c *gin.Context) {
+	var req request.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	codes, err := userTwoFactorService.RegenerateRecoveryCodes(utils.GetUserID(c), req.Code, c.ClientIP())
+	if err != nil {
+		global.GVA_LOG.Error("生成恢复码失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(projectRes.RecoveryCodesResponse{RecoveryCodes: codes}, "恢复码已重新生成", c)
+}
+
+// ChangeEmail 修改邮箱并向新邮箱发送验证邮件
+func (u *UserApi) ChangeEmail(c *gin.Context) {
+	var req request.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	userID := utils.GetUserID(c)
+	if err := UserService.ChangeEmail(userID, req, c.ClientIP()); err != nil {
+		global.GVA_LOG.Error("修改邮箱失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := userEmailService.SendVerifyEmail(userID, c.ClientIP(), accountEmailSite()); err != nil {
+		global.GVA_LOG.Error("发送验证邮件失败!", zap.Error(err))
+		response.OkWithMessage("邮箱已修改，验证邮件发送失败，请稍后重新发送", c)
+		return
+	}
+	response.OkWithMessage("邮箱已修改，请查收验证邮件", c)
+}
+
+// Login2FA 登录第二步，提交验证器中的验证码或恢复码
+func (a BaseApi) Login2FA(c *gin.Context) {
+	var req request.Login2FARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	clientIP := c.ClientIP()
+	if isBlocked, until := checkIPBlocked(clientIP); isBlocked {
+		response.FailWithMessage("该IP已被临时封禁至 "+until.Format("15:04"), c)
+		return
+	}
+	user, err := userTwoFactorService.CompleteLogin(req.ChallengeToken, req.Code, clientIP, c.Request.UserAgent())
+	if err != nil {
+		incrementIPAttempt("login", clientIP)
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	a.TokenNext(c, *user, clientIP)
+}
@@ -32,7 +32,7 @@ func (u *WithdrawApi) Withdraw(c *gin.Context) {
 		return
 	}
 	// 3. 调用服务层
-	if err := UserService.ApplyWithdraw(userID, req); err != nil {
+	if err := UserService.ApplyWithdraw(userID, req, c.ClientIP()); err != nil {
 		global.GVA_LOG.Error("申请提现失败",
 			zap.Uint("userID", userID),
 			zap.Float64("amount", req.Amount),
@@ -0,0 +1,13 @@
+package config
+
+// AccountEmail 前台用户的邮箱验证和找回密码邮件
+type AccountEmail struct {
+	SiteURL          string `mapstructure:"site-url" json:"site-url" yaml:"site-url"`                               // 前台站点地址，为空时使用 feed.site-url，都未配置时不发送邮件
+	VerifyPath       string `mapstructure:"verify-path" json:"verify-path" yaml:"verify-path"`                      // 邮箱验证页路径，%s 替换为令牌，默认 /verify-email?token=%s
+	ResetPath        string `mapstructure:"reset-path" json:"reset-path" yaml:"reset-path"`                         // 重置密码页路径，%s 替换为令牌，默认 /reset-password?token=%s
+	VerifyTTL        int    `mapstructure:"verify-ttl" json:"verify-ttl" yaml:"verify-ttl"`                         // 验证链接有效期（分钟），默认 1440
+	ResetTTL         int    `mapstructure:"reset-ttl" json:"reset-ttl" yaml:"reset-ttl"`                            // 重置链接有效期（分钟），默认 30
+	ResendInterval   int    `mapstructure:"resend-interval" json:"resend-interval" yaml:"resend-interval"`          // 同一邮箱两次发送的最小间隔（秒），默认 60
+	EmailHourlyLimit int    `mapstructure:"email-hourly-limit" json:"email-hourly-limit" yaml:"email-hourly-limit"` // 同一邮箱每小时最多发送次数，默认 5
+	IPHourlyLimit    int    `mapstructure:"ip-hourly-limit" json:"ip-hourly-limit" yaml:"ip-hourly-limit"`          // 同一IP每小时最多发送次数，默认 20
+}
@@ -28,6 +28,30 @@ type Server struct {
 
 	Excel Excel `mapstructure:"excel" json:"excel" yaml:"excel"`
 
+	// 安装包安全扫描
+	Scanner Scanner `mapstructure:"scanner" json:"scanner" yaml:"scanner"`
+
+	// 访问者国家识别
+	GeoIP GeoIP `mapstructure:"geoip" json:"geoip" yaml:"geoip"`
+
+	// 下载日志异步写入
+	DownloadLog DownloadLog `mapstructure:"download-log" json:"download-log" yaml:"download-log"`
+
+	// 应用搜索
+	Search Search `mapstructure:"search" json:"search" yaml:"search"`
+	// 应用推荐
+	Recommend Recommend `mapstructure:"recommend" json:"recommend" yaml:"recommend"`
+	// 新版本订阅源
+	Feed Feed `mapstructure:"feed" json:"feed" yaml:"feed"`
+	// 账号详情加密密钥环
+	Encryption Encryption `mapstructure:"encryption" json:"encryption" yaml:"encryption"`
+	// 查看账号明文详情的限制
+	CredentialReveal CredentialReveal `mapstructure:"credential-reveal" json:"credential-reveal" yaml:"credential-reveal"`
+	// 邮箱验证和找回密码邮件
+	AccountEmail AccountEmail `mapstructure:"account-email" json:"account-email" yaml:"account-email"`
+	// 推广链接
+	Referral Referral `mapstructure:"referral" json:"referral" yaml:"referral"`
+
 	DiskList []DiskList `mapstructure:"disk-list" json:"disk-list" yaml:"disk-list"`
 
 	// 跨域配置
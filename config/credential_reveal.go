@@ -0,0 +1,8 @@
+package config
+
+// CredentialReveal 查看账号明文详情的限制
+type CredentialReveal struct {
+	RequireTOTP      bool `mapstructure:"require-totp" json:"require-totp" yaml:"require-totp"`                   // 管理员查看时必须输入谷歌验证码
+	AdminHourlyLimit int  `mapstructure:"admin-hourly-limit" json:"admin-hourly-limit" yaml:"admin-hourly-limit"` // 每个管理员每小时最多查看次数，默认 30
+	BuyerHourlyLimit int  `mapstructure:"buyer-hourly-limit" json:"buyer-hourly-limit" yaml:"buyer-hourly-limit"` // 每个买家每小时最多查看次数，默认 20
+}
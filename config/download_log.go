@@ -0,0 +1,11 @@
+package config
+
+type DownloadLog struct {
+	QueueSize     int    `mapstructure:"queue-size" json:"queue-size" yaml:"queue-size"`             // 内存队列长度
+	Workers       int    `mapstructure:"workers" json:"workers" yaml:"workers"`                      // 批量写入的协程数
+	BatchSize     int    `mapstructure:"batch-size" json:"batch-size" yaml:"batch-size"`             // 单次批量写入条数
+	FlushInterval int    `mapstructure:"flush-interval" json:"flush-interval" yaml:"flush-interval"` // 未攒满一批时的最长等待时间，单位：ms(毫秒)
+	EnqueueWait   int    `mapstructure:"enqueue-wait" json:"enqueue-wait" yaml:"enqueue-wait"`       // 队列已满时最长等待时间，超时后写入溢出文件，单位：ms(毫秒)
+	SpillDir      string `mapstructure:"spill-dir" json:"spill-dir" yaml:"spill-dir"`                // 溢出文件目录
+	RetentionDays int    `mapstructure:"retention-days" json:"retention-days" yaml:"retention-days"` // 原始日志保留天数，0 表示不清理，汇总数据不受影响
+}
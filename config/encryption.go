@@ -0,0 +1,15 @@
+package config
+
+// Encryption 账号详情加密密钥环。Active 指定加密使用的密钥，其余密钥只用于解密旧数据；
+// 未配置密钥环时使用 system.encryption-key
+type Encryption struct {
+	Active   string          `mapstructure:"active" json:"active" yaml:"active"`       // 当前加密使用的密钥ID
+	Keys     []EncryptionKey `mapstructure:"keys" json:"keys" yaml:"keys"`             // 全部密钥，包括只解密的旧密钥
+	Envelope bool            `mapstructure:"envelope" json:"envelope" yaml:"envelope"` // 信封加密：每条记录使用独立的数据密钥，数据密钥由主密钥加密
+	HashKey  string          `mapstructure:"hash-key" json:"hash-key" yaml:"hash-key"` // 查重哈希密钥，轮换加密密钥时不需要修改，为空时使用 system.encryption-key
+}
+
+type EncryptionKey struct {
+	ID     string `mapstructure:"id" json:"id" yaml:"id"`             // 密钥ID，写入密文前缀，不能包含冒号
+	Secret string `mapstructure:"secret" json:"secret" yaml:"secret"` // 密钥内容
+}
@@ -0,0 +1,8 @@
+package config
+
+type Feed struct {
+	Title   string `mapstructure:"title" json:"title" yaml:"title"`          // 订阅源标题
+	SiteURL string `mapstructure:"site-url" json:"site-url" yaml:"site-url"` // 前台站点地址，订阅源中的链接使用，未配置时订阅源不可用
+	AppPath string `mapstructure:"app-path" json:"app-path" yaml:"app-path"` // 应用详情页路径，%d 替换为应用ID，默认 /app/%d
+	Limit   int    `mapstructure:"limit" json:"limit" yaml:"limit"`          // 订阅源条目数量，默认 50
+}
@@ -0,0 +1,8 @@
+package config
+
+type GeoIP struct {
+	DBPath         string `mapstructure:"db-path" json:"db-path" yaml:"db-path"`                         // mmdb 文件路径，留空表示不按IP识别国家
+	AllowOverride  bool   `mapstructure:"allow-override" json:"allow-override" yaml:"allow-override"`    // 是否允许通过请求头或参数指定展示用的国家，下载限制始终按IP识别
+	OverrideHeader string `mapstructure:"override-header" json:"override-header" yaml:"override-header"` // 指定国家的请求头，如 X-Country-Code、CF-IPCountry
+	OverrideQuery  string `mapstructure:"override-query" json:"override-query" yaml:"override-query"`    // 指定国家的查询参数
+}
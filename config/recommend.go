@@ -0,0 +1,9 @@
+package config
+
+type Recommend struct {
+	Cron         string `mapstructure:"cron" json:"cron" yaml:"cron"`                            // 离线计算相似度的定时表达式（6 位，含秒），默认每天 04:30
+	WindowDays   int    `mapstructure:"window-days" json:"window-days" yaml:"window-days"`       // 参与计算的下载和购买记录天数，默认 90
+	TopK         int    `mapstructure:"top-k" json:"top-k" yaml:"top-k"`                         // 每个应用保留的相似应用数量，默认 20
+	MinCoCount   int    `mapstructure:"min-co-count" json:"min-co-count" yaml:"min-co-count"`    // 至少被多少个用户同时下载才视为相似，默认 2
+	CacheMinutes int    `mapstructure:"cache-minutes" json:"cache-minutes" yaml:"cache-minutes"` // 个性化推荐缓存时间，单位：分钟，默认 30
+}
@@ -0,0 +1,7 @@
+package config
+
+// Referral 推广链接
+type Referral struct {
+	CookieDays   int    `mapstructure:"cookie-days" json:"cookie-days" yaml:"cookie-days"`       // 点击推广链接后多少天内注册归属推荐人，默认 30
+	RegisterPath string `mapstructure:"register-path" json:"register-path" yaml:"register-path"` // 推广链接跳转的注册页路径，%s 替换为邀请码，默认 /register?inviteCode=%s
+}
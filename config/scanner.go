@@ -0,0 +1,8 @@
+package config
+
+type Scanner struct {
+	Type    string `mapstructure:"type" json:"type" yaml:"type"`          // 扫描器类型：clamav，留空表示不扫描只校验哈希
+	Network string `mapstructure:"network" json:"network" yaml:"network"` // clamd 连接方式：unix 或 tcp
+	Address string `mapstructure:"address" json:"address" yaml:"address"` // clamd 地址，如 /var/run/clamav/clamd.ctl 或 127.0.0.1:3310
+	Timeout int    `mapstructure:"timeout" json:"timeout" yaml:"timeout"` // 单个文件扫描超时时间，单位：s(秒)
+}
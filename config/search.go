@@ -0,0 +1,11 @@
+package config
+
+type Search struct {
+	Type          string `mapstructure:"type" json:"type" yaml:"type"`                               // 搜索索引类型：memory 进程内倒排索引（默认），mysql 使用 FULLTEXT ngram 索引
+	TablePrefix   string `mapstructure:"table-prefix" json:"table-prefix" yaml:"table-prefix"`       // mysql 索引表名前缀，默认 app_search
+	ResyncMinutes int    `mapstructure:"resync-minutes" json:"resync-minutes" yaml:"resync-minutes"` // 全量重建索引的间隔，单位：分钟（1-59），用于同步下载量等统计字段，默认 10 分钟
+	TrendingHours int    `mapstructure:"trending-hours" json:"trending-hours" yaml:"trending-hours"` // 热搜统计的滑动窗口，单位：小时，默认 24
+	LogRetention  int    `mapstructure:"log-retention" json:"log-retention" yaml:"log-retention"`    // 搜索词记录保留天数，0 表示不清理
+	QueueSize     int    `mapstructure:"queue-size" json:"queue-size" yaml:"queue-size"`             // 搜索词记录队列长度，队列满时丢弃，默认 1000
+	Workers       int    `mapstructure:"workers" json:"workers" yaml:"workers"`                      // 写入搜索词记录的协程数，默认 2
+}
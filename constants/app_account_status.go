@@ -55,8 +55,8 @@ func (s AppAccountStatus) CanTransitionTo(target AppAccountStatus) bool {
 			AppAccountStatusBanned, // 风险 -> 封禁
 		},
 		AppAccountStatusSold: {
-			// 已卖出是终态，一般不允许转换
-			// 如果需要退款等场景，可以添加: AppAccountStatusNormal
+			// 已卖出一般不允许转换，售后更换或退款时回收为风险账号
+			AppAccountStatusRisk, // 已卖出 -> 风险（售后回收）
 		},
 	}
 
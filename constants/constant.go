@@ -41,4 +41,178 @@ const (
 	StatusReviewPending PackageStatus = "review_pending"
 	StatusPublished     PackageStatus = "published"
 	StatusRejected      PackageStatus = "rejected"
+	StatusQuarantined   PackageStatus = "quarantined" // 隔离中：等待扫描或扫描未通过，禁止发布
+)
+
+// UploadSessionStatus 分片上传会话状态
+type UploadSessionStatus string
+
+const (
+	UploadSessionUploading  UploadSessionStatus = "uploading"  // 上传中
+	UploadSessionCompleting UploadSessionStatus = "completing" // 合并中
+	UploadSessionCompleted  UploadSessionStatus = "completed"  // 已完成
+	UploadSessionFailed     UploadSessionStatus = "failed"     // 合并失败
+	UploadSessionAborted    UploadSessionStatus = "aborted"    // 已取消或已过期回收
+)
+
+// ReleaseChannel 发布渠道
+type ReleaseChannel string
+
+const (
+	ReleaseChannelStable ReleaseChannel = "stable" // 正式版
+	ReleaseChannelBeta   ReleaseChannel = "beta"   // 测试版
+)
+
+// IsValid 判断发布渠道是否有效
+func (c ReleaseChannel) IsValid() bool {
+	return c == ReleaseChannelStable || c == ReleaseChannelBeta
+}
+
+// ReleaseAction 发布操作类型
+type ReleaseAction string
+
+const (
+	ReleaseActionPublish  ReleaseAction = "publish"  // 全量发布
+	ReleaseActionRollout  ReleaseAction = "rollout"  // 灰度发布/调整灰度比例
+	ReleaseActionPromote  ReleaseAction = "promote"  // 灰度转全量
+	ReleaseActionHalt     ReleaseAction = "halt"     // 终止灰度
+	ReleaseActionRollback ReleaseAction = "rollback" // 回滚
+)
+
+// PackagePatchStatus 差分补丁状态
+type PackagePatchStatus string
+
+const (
+	PackagePatchReady   PackagePatchStatus = "ready"   // 可用
+	PackagePatchSkipped PackagePatchStatus = "skipped" // 补丁收益不足或文件过大，不提供差分
+	PackagePatchFailed  PackagePatchStatus = "failed"  // 生成失败
+)
+
+// PackageScanStatus 安装包安全扫描状态
+type PackageScanStatus string
+
+const (
+	ScanStatusPending      PackageScanStatus = "pending"       // 等待扫描
+	ScanStatusClean        PackageScanStatus = "clean"         // 扫描通过
+	ScanStatusInfected     PackageScanStatus = "infected"      // 发现威胁
+	ScanStatusError        PackageScanStatus = "error"         // 扫描失败，等待重试
+	ScanStatusHashMismatch PackageScanStatus = "hash_mismatch" // 存储中的文件与入库哈希不一致
+)
+
+// SearchTermAction 搜索词运营规则
+type SearchTermAction string
+
+const (
+	SearchTermPin   SearchTermAction = "pin"   // 置顶到热搜
+	SearchTermBlock SearchTermAction = "block" // 不出现在热搜中
+)
+
+// ReviewStatus 应用评价状态
+type ReviewStatus string
+
+const (
+	ReviewStatusVisible ReviewStatus = "visible" // 公开显示，计入评分
+	ReviewStatusHidden  ReviewStatus = "hidden"  // 已隐藏，不计入评分
+)
+
+// NotificationType 站内通知类型
+type NotificationType string
+
+const (
+	NotificationAppUpdate     NotificationType = "app_update"     // 关注的应用发布了新版本
+	NotificationAccountHealth NotificationType = "account_health" // 购买的账号检测到过期或异常
+	NotificationWarranty      NotificationType = "warranty"       // 账号售后申请处理结果
+)
+
+// AccountImportStatus 账号导入任务状态
+type AccountImportStatus string
+
+const (
+	AccountImportStatusPreview   AccountImportStatus = "preview"   // 预览，未写入
+	AccountImportStatusCompleted AccountImportStatus = "completed" // 已导入
+	AccountImportStatusFailed    AccountImportStatus = "failed"    // 写入失败，全部回滚
+)
+
+// ReencryptJobStatus 账号详情重新加密任务状态
+type ReencryptJobStatus string
+
+const (
+	ReencryptJobRunning   ReencryptJobStatus = "running"
+	ReencryptJobPaused    ReencryptJobStatus = "paused"
+	ReencryptJobCompleted ReencryptJobStatus = "completed"
+	ReencryptJobFailed    ReencryptJobStatus = "failed"
+)
+
+// AccountStatusSource 账号状态变更来源
+type AccountStatusSource string
+
+const (
+	AccountStatusSourceManual  AccountStatusSource = "manual"  // 管理员手动修改
+	AccountStatusSourceChecker AccountStatusSource = "checker" // 健康检查自动修改
+)
+
+// WarrantyClaimStatus 账号售后申请状态
+type WarrantyClaimStatus string
+
+const (
+	WarrantyClaimPending  WarrantyClaimStatus = "pending"  // 待审核
+	WarrantyClaimReplaced WarrantyClaimStatus = "replaced" // 已更换账号
+	WarrantyClaimRefunded WarrantyClaimStatus = "refunded" // 已部分退款
+	WarrantyClaimRejected WarrantyClaimStatus = "rejected" // 已驳回
+)
+
+// WarrantyAction 账号售后处理步骤
+type WarrantyAction string
+
+const (
+	WarrantyActionSubmit  WarrantyAction = "submit"  // 买家提交申请
+	WarrantyActionReplace WarrantyAction = "replace" // 更换为新账号
+	WarrantyActionRefund  WarrantyAction = "refund"  // 发起部分退款
+	WarrantyActionReject  WarrantyAction = "reject"  // 驳回申请
+)
+
+// CredentialViewer 查看账号明文详情的人员类型
+type CredentialViewer string
+
+const (
+	CredentialViewerAdmin CredentialViewer = "admin" // 后台管理员
+	CredentialViewerBuyer CredentialViewer = "buyer" // 购买账号的用户
+)
+
+// UserTokenPurpose 邮件一次性令牌的用途
+type UserTokenPurpose string
+
+const (
+	UserTokenEmailVerify   UserTokenPurpose = "email_verify"   // 邮箱验证
+	UserTokenPasswordReset UserTokenPurpose = "password_reset" // 找回密码
+	UserTokenLogin2FA      UserTokenPurpose = "login_2fa"      // 密码验证通过后等待双因子验证，不入库
+)
+
+// TwoFactorAction 前台用户双因子认证的变更记录类型
+type TwoFactorAction string
+
+const (
+	TwoFactorActionEnable       TwoFactorAction = "enable"        // 开启
+	TwoFactorActionDisable      TwoFactorAction = "disable"       // 用户关闭
+	TwoFactorActionRegenerate   TwoFactorAction = "regenerate"    // 重新生成恢复码
+	TwoFactorActionRecoveryUsed TwoFactorAction = "recovery_used" // 使用恢复码
+	TwoFactorActionAdminReset   TwoFactorAction = "admin_reset"   // 管理员重置
+)
+
+// ReferralBindSource 推荐关系的来源
+type ReferralBindSource string
+
+const (
+	ReferralBindInviteCode ReferralBindSource = "invite_code" // 注册时填写邀请码
+	ReferralBindLink       ReferralBindSource = "link"        // 推广链接的 cookie 归属
+	ReferralBindAdmin      ReferralBindSource = "admin"       // 管理员修改
+)
+
+// TierChangeSource 推广等级变化的触发来源
+type TierChangeSource string
+
+const (
+	TierChangeRegister  TierChangeSource = "register"  // 新下级注册
+	TierChangeRebind    TierChangeSource = "rebind"    // 管理员修改推荐人
+	TierChangeRecompute TierChangeSource = "recompute" // 每晚全量重算
 )
@@ -3,6 +3,7 @@ package core
 import (
 	"ApkAdmin/global"
 	"ApkAdmin/initialize"
+	"ApkAdmin/service"
 	"ApkAdmin/service/system"
 	"fmt"
 	"go.uber.org/zap"
@@ -28,6 +29,23 @@ func RunServer() {
 	if global.GVA_DB != nil {
 		system.LoadAll()
 	}
+	// 启动下载日志异步写入
+	service.ServiceGroupApp.ProjectServiceGroup.DownloadLogService.StartPipeline()
+	// 启动搜索词异步记录
+	service.ServiceGroupApp.ProjectServiceGroup.SearchQueryService.StartRecorder()
+	// 构建应用搜索索引，完成前搜索回退到数据库查询
+	if global.GVA_DB != nil {
+		go func() {
+			if err := service.ServiceGroupApp.ProjectServiceGroup.AppSearchService.Rebuild(); err != nil {
+				global.GVA_LOG.Error("构建应用搜索索引失败!", zap.Error(err))
+			}
+		}()
+	}
+
+	// 继续因重启中断的账号详情重新加密任务
+	if global.GVA_DB != nil {
+		service.ServiceGroupApp.ProjectServiceGroup.AccountReencryptService.ResumeInterruptedJobs()
+	}
 
 	Router := initialize.Routers()
 	address := fmt.Sprintf(":%d", global.GVA_CONFIG.System.Addr)
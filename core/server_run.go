@@ -1,6 +1,7 @@
 package core
 
 import (
+	"ApkAdmin/service"
 	"context"
 	"fmt"
 	"net/http"
@@ -52,9 +53,20 @@ func initServer(address string, router *gin.Engine, readTimeout, writeTimeout ti
 
 	defer cancel()
 
+	// 关闭超时也要继续写完下载日志，不能直接退出进程
 	if err := srv.Shutdown(ctx); err != nil {
-		zap.L().Fatal("WEB服务关闭异常", zap.Error(err))
+		zap.L().Error("WEB服务关闭异常", zap.Error(err))
+	} else {
+		zap.L().Info("WEB服务已关闭")
 	}
 
-	zap.L().Info("WEB服务已关闭")
+	// 写完队列中的下载日志
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer drainCancel()
+	if err := service.ServiceGroupApp.ProjectServiceGroup.DownloadLogService.StopPipeline(drainCtx); err != nil {
+		zap.L().Error("下载日志未全部写入，剩余日志已写入溢出文件", zap.Error(err))
+	}
+	if err := service.ServiceGroupApp.ProjectServiceGroup.SearchQueryService.StopRecorder(drainCtx); err != nil {
+		zap.L().Error("搜索词记录未全部写入", zap.Error(err))
+	}
 }
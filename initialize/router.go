@@ -104,6 +104,15 @@ func Routers() *gin.Engine {
 		projectRouter.InitSystemAnnouncementRouter(PrivateGroup)   // 公告路由
 		projectRouter.InitCommissionTierRouter(PrivateGroup)       // 分佣规则路由
 		projectRouter.InitUploadRoute(PrivateGroup)                // 上传路由
+		projectRouter.InitPackageReleaseRouter(PrivateGroup)       // 安装包发布路由
+		projectRouter.InitPackagePatchRouter(PrivateGroup)         // 差分补丁路由
+		projectRouter.InitDownloadStatRouter(PrivateGroup)         // 下载统计路由
+		projectRouter.InitSearchTermRouter(PrivateGroup)           // 搜索词运营路由
+		projectRouter.InitAppReviewRouter(PrivateGroup)            // 应用评价路由
+		projectRouter.InitAppListingRouter(PrivateGroup)           // 应用截图和本地化信息路由
+		projectRouter.InitAccountReencryptRouter(PrivateGroup)     // 账号详情密钥轮换路由
+		projectRouter.InitAppAccountCheckRouter(PrivateGroup)      // 账号健康检查路由
+		projectRouter.InitAccountWarrantyRouter(PrivateGroup)      // 账号售后路由
 
 	}
 
@@ -42,6 +42,8 @@ func initWebRouter(engine *gin.Engine) {
 		webRouter.InitCommissionTier(PrivateGroup)
 		webRouter.InitWithdrawRouter(PrivateGroup)
 		webRouter.InitCommissionDetailRouter(PrivateGroup)
+		webRouter.InitNotificationRouter(PrivateGroup)
+		webRouter.InitAffiliateRouter(PrivateGroup)
 	}
 
 }
@@ -1,6 +1,7 @@
 package initialize
 
 import (
+	"ApkAdmin/service"
 	"ApkAdmin/task"
 	"fmt"
 
@@ -24,6 +25,172 @@ func Timer() {
 			fmt.Println("add timer error:", err)
 		}
 
+		// 回收过期的分片上传会话
+		_, err = global.GVA_Timer.AddTaskByFunc("CleanUploadSessions", "0 */30 * * * *", func() {
+			err := service.ServiceGroupApp.ProjectServiceGroup.UploadSessionService.CleanExpiredSessions()
+			if err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时回收过期的分片上传会话", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 为相邻的已发布安卓版本生成差分补丁
+		_, err = global.GVA_Timer.AddTaskByFunc("GeneratePackagePatches", "0 10 * * * *", func() {
+			err := service.ServiceGroupApp.ProjectServiceGroup.PackagePatchService.GeneratePatches()
+			if err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时生成安装包差分补丁", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 扫描新上传或等待重试的安装包
+		_, err = global.GVA_Timer.AddTaskByFunc("ScanPackages", "0 */5 * * * *", func() {
+			err := service.ServiceGroupApp.ProjectServiceGroup.PackageIntegrityService.ScanPendingPackages()
+			if err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时扫描待检测的安装包", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 复核存储中安装包的哈希，发现被替换的文件
+		_, err = global.GVA_Timer.AddTaskByFunc("VerifyPackageHashes", "0 30 3 * * *", func() {
+			err := service.ServiceGroupApp.ProjectServiceGroup.PackageIntegrityService.VerifyPackageHashes()
+			if err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时复核安装包哈希", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 导入队列溢出或写库失败时落盘的下载日志
+		_, err = global.GVA_Timer.AddTaskByFunc("ReplayDownloadLogSpill", "0 */5 * * * *", func() {
+			err := service.ServiceGroupApp.ProjectServiceGroup.DownloadLogService.ReplaySpill()
+			if err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时导入溢出的下载日志", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 汇总最近的下载日志到按小时、按天的统计表
+		_, err = global.GVA_Timer.AddTaskByFunc("AggregateDownloadStats", "0 5 * * * *", func() {
+			err := service.ServiceGroupApp.ProjectServiceGroup.DownloadStatService.AggregateRecent()
+			if err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时汇总下载统计", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 汇总推广员的下级、订单、佣金和推广链接数据
+		_, err = global.GVA_Timer.AddTaskByFunc("AggregateAffiliateStats", "0 20 * * * *", func() {
+			err := service.ServiceGroupApp.ProjectServiceGroup.AffiliateService.AggregateRecent()
+			if err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时汇总推广数据", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 零点清空团队统计的今日新增
+		_, err = global.GVA_Timer.AddTaskByFunc("ResetTeamDailyCounters", "0 0 0 * * *", func() {
+			err := service.ServiceGroupApp.ProjectServiceGroup.TeamStatService.ResetDailyCounters()
+			if err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时清空团队今日新增", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 分批全量重算用户和团队统计，修正增量更新的偏差并重新评估推广等级
+		_, err = global.GVA_Timer.AddTaskByFunc("RecomputeTeamStats", "0 30 3 * * *", func() {
+			err := service.ServiceGroupApp.ProjectServiceGroup.TeamStatService.RecomputeAll()
+			if err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时重算推广统计", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 清理超过保留期的原始下载日志，清理前会重建对应日期的汇总
+		if days := global.GVA_CONFIG.DownloadLog.RetentionDays; days > 0 {
+			_, err = global.GVA_Timer.AddTaskByFunc("PruneDownloadLogs", "0 40 4 * * *", func() {
+				err := service.ServiceGroupApp.ProjectServiceGroup.DownloadLogService.DeleteOldLogs(days)
+				if err != nil {
+					fmt.Println("timer error:", err)
+				}
+			}, "定时清理原始下载日志", option...)
+			if err != nil {
+				fmt.Println("add timer error:", err)
+			}
+		}
+
+		// 全量重建应用搜索索引，同步下载量、评分等排序字段以及其它实例的修改
+		resync := global.GVA_CONFIG.Search.ResyncMinutes
+		if resync <= 0 || resync > 59 {
+			resync = 10
+		}
+		_, err = global.GVA_Timer.AddTaskByFunc("RebuildAppSearchIndex", fmt.Sprintf("0 */%d * * * *", resync), func() {
+			err := service.ServiceGroupApp.ProjectServiceGroup.AppSearchService.Rebuild()
+			if err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时重建应用搜索索引", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 清理超过保留期的搜索词记录
+		if days := global.GVA_CONFIG.Search.LogRetention; days > 0 {
+			_, err = global.GVA_Timer.AddTaskByFunc("PruneSearchQueryLogs", "0 50 4 * * *", func() {
+				err := service.ServiceGroupApp.ProjectServiceGroup.SearchQueryService.PruneLogs(days)
+				if err != nil {
+					fmt.Println("timer error:", err)
+				}
+			}, "定时清理搜索词记录", option...)
+			if err != nil {
+				fmt.Println("add timer error:", err)
+			}
+		}
+
+		// 离线计算应用相似度，用于"下载了该应用的用户还下载了"和个性化推荐
+		recommendCron := global.GVA_CONFIG.Recommend.Cron
+		if recommendCron == "" {
+			recommendCron = "0 30 4 * * *"
+		}
+		_, err = global.GVA_Timer.AddTaskByFunc("RebuildAppSimilarity", recommendCron, func() {
+			err := service.ServiceGroupApp.ProjectServiceGroup.RecommendService.RebuildSimilarity()
+			if err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时计算应用相似度", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 按各应用配置的检查器检查到期的账号，自动标记过期或风险
+		_, err = global.GVA_Timer.AddTaskByFunc("CheckAppAccounts", "0 */5 * * * *", func() {
+			err := service.ServiceGroupApp.ProjectServiceGroup.AppAccountCheckService.RunDueChecks()
+			if err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时检查应用账号健康状态", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
 		// 其他定时任务定在这里 参考上方使用方法
 
 		//_, err := global.GVA_Timer.AddTaskByFunc("定时任务标识", "corn表达式", func() {
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"ApkAdmin/global"
+	"ApkAdmin/model/project"
 	"ApkAdmin/utils"
 	"errors"
 	"github.com/golang-jwt/jwt/v5"
@@ -10,6 +11,7 @@ import (
 
 	"ApkAdmin/model/common/response"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 func JWTAuth() gin.HandlerFunc {
@@ -123,6 +125,21 @@ func ClientJWTAuth() gin.HandlerFunc {
 			newClaims, _ := j.ParseToken(newToken)
 			c.Header("new-token", newToken)
 			c.Header("new-expires-at", strconv.FormatInt(newClaims.ExpiresAt.Unix(), 10))
+			// 记录续期的令牌，重置密码时一并加入黑名单
+			userAgent := []rune(c.Request.UserAgent())
+			if len(userAgent) > 255 {
+				userAgent = userAgent[:255]
+			}
+			err = global.GVA_DB.Create(&project.UserSession{
+				UserID:    claims.BaseClaims.ID,
+				Token:     newToken,
+				IP:        c.ClientIP(),
+				UserAgent: string(userAgent),
+				ExpiresAt: newClaims.ExpiresAt.Time,
+			}).Error
+			if err != nil {
+				global.GVA_LOG.Error("记录续期令牌失败!", zap.Error(err))
+			}
 		}
 		c.Next()
 		if newToken, exists := c.Get("new-token"); exists {
@@ -51,12 +51,13 @@ func (j *JSONSlice) Scan(value interface{}) error {
 		return nil
 	}
 
-	bytes, ok := value.([]byte)
-	if !ok {
-		return fmt.Errorf("cannot scan %T into JSONSlice", value)
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, j)
+	case string:
+		return json.Unmarshal([]byte(v), j)
 	}
-
-	return json.Unmarshal(bytes, j)
+	return fmt.Errorf("cannot scan %T into JSONSlice", value)
 }
 
 func (j JSONSlice) Value() (driver.Value, error) {
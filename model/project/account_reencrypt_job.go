@@ -0,0 +1,28 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// AccountReencryptJob 账号详情重新加密任务，按主键顺序分批处理，LastID 记录进度用于中断后继续
+type AccountReencryptJob struct {
+	ID         uint                         `json:"id" gorm:"primarykey;comment:主键ID"`
+	Status     constants.ReencryptJobStatus `json:"status" gorm:"type:varchar(20);not null;index:idx_reencrypt_status;comment:任务状态"`
+	TargetKey  string                       `json:"target_key" gorm:"type:varchar(64);not null;comment:目标密钥ID"`
+	Envelope   bool                         `json:"envelope" gorm:"not null;default:0;comment:是否使用信封加密"`
+	LastID     uint                         `json:"last_id" gorm:"not null;default:0;comment:已处理到的账号ID"`
+	Total      int64                        `json:"total" gorm:"not null;default:0;comment:开始时的账号总数"`
+	Processed  int64                        `json:"processed" gorm:"not null;default:0;comment:已检查数量"`
+	Migrated   int64                        `json:"migrated" gorm:"not null;default:0;comment:已重新加密数量"`
+	Failed     int64                        `json:"failed" gorm:"not null;default:0;comment:解密失败数量"`
+	Message    string                       `json:"message" gorm:"type:varchar(500);not null;default:'';comment:失败原因"`
+	CreatedBy  uint                         `json:"created_by" gorm:"not null;comment:操作人ID"`
+	CreatedAt  time.Time                    `json:"created_at" gorm:"comment:创建时间"`
+	UpdatedAt  time.Time                    `json:"updated_at" gorm:"comment:最后一次进度更新时间"`
+	FinishedAt *time.Time                   `json:"finished_at" gorm:"comment:完成时间"`
+}
+
+func (AccountReencryptJob) TableName() string {
+	return "account_reencrypt_jobs"
+}
@@ -0,0 +1,53 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/model/common"
+	"time"
+)
+
+// AccountWarrantyClaim 已售出账号的售后申请，同一订单的同一账号同时只能有一个待审核申请
+type AccountWarrantyClaim struct {
+	ID                   uint                          `json:"id" gorm:"primarykey;comment:主键ID"`
+	OrderID              uint64                        `json:"order_id" gorm:"not null;index:idx_warranty_order;comment:订单ID"`
+	OrderNo              string                        `json:"order_no" gorm:"type:varchar(32);not null;comment:订单号"`
+	UserID               uint                          `json:"user_id" gorm:"not null;index:idx_warranty_user;comment:申请用户ID"`
+	AccountID            uint                          `json:"account_id" gorm:"not null;index:idx_warranty_account;comment:申请售后的账号ID"`
+	AppID                string                        `json:"app_id" gorm:"type:varchar(100);not null;comment:应用唯一标识符"`
+	Reason               string                        `json:"reason" gorm:"type:varchar(500);not null;comment:申请原因"`
+	Evidence             common.JSONSlice              `json:"evidence" gorm:"type:json;comment:凭证图片URL"`
+	EvidenceKeys         common.JSONSlice              `json:"-" gorm:"type:json;comment:凭证图片存储Key"`
+	Status               constants.WarrantyClaimStatus `json:"status" gorm:"type:varchar(20);not null;index:idx_warranty_status;comment:状态"`
+	ReplacementAccountID *uint                         `json:"replacement_account_id" gorm:"comment:更换后的账号ID"`
+	RefundID             *uint                         `json:"refund_id" gorm:"comment:退款记录ID"`
+	RefundAmount         float64                       `json:"refund_amount" gorm:"type:decimal(10,2);not null;default:0;comment:退款金额"`
+	ReviewNote           string                        `json:"review_note" gorm:"type:varchar(500);not null;default:'';comment:审核说明"`
+	ReviewerID           *uint                         `json:"reviewer_id" gorm:"comment:审核人ID"`
+	ReviewedAt           *time.Time                    `json:"reviewed_at" gorm:"comment:审核时间"`
+	CreatedAt            time.Time                     `json:"created_at" gorm:"comment:申请时间"`
+	UpdatedAt            time.Time                     `json:"updated_at" gorm:"comment:更新时间"`
+
+	Events []AccountWarrantyEvent `json:"events,omitempty" gorm:"foreignKey:ClaimID"`
+}
+
+func (AccountWarrantyClaim) TableName() string {
+	return "account_warranty_claims"
+}
+
+// AccountWarrantyEvent 售后处理的每一步，更换账号时记录新旧账号，作为订单账号的变更历史
+type AccountWarrantyEvent struct {
+	ID           uint64                   `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	ClaimID      uint                     `json:"claim_id" gorm:"not null;index:idx_warranty_event_claim;comment:售后申请ID"`
+	OrderID      uint64                   `json:"order_id" gorm:"not null;index:idx_warranty_event_order;comment:订单ID"`
+	Action       constants.WarrantyAction `json:"action" gorm:"type:varchar(20);not null;comment:处理步骤"`
+	OldAccountID uint                     `json:"old_account_id" gorm:"not null;default:0;comment:原账号ID"`
+	NewAccountID uint                     `json:"new_account_id" gorm:"not null;default:0;comment:新账号ID"`
+	Amount       float64                  `json:"amount" gorm:"type:decimal(10,2);not null;default:0;comment:退款金额"`
+	Note         string                   `json:"note" gorm:"type:varchar(500);not null;default:'';comment:说明"`
+	OperatorID   uint                     `json:"operator_id" gorm:"not null;default:0;comment:操作人ID，买家提交时为用户ID"`
+	CreatedAt    time.Time                `json:"created_at" gorm:"comment:时间"`
+}
+
+func (AccountWarrantyEvent) TableName() string {
+	return "account_warranty_events"
+}
@@ -0,0 +1,36 @@
+package project
+
+import "time"
+
+// PromoterStatDaily 推广员按天汇总：新增直属下级、下级已支付订单和获得的佣金，由定时任务聚合
+type PromoterStatDaily struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	UserID      uint      `gorm:"not null;uniqueIndex:uk_promoter_stat_daily,priority:1" json:"userId" comment:"推广员ID"`
+	StatDate    string    `gorm:"type:date;not null;uniqueIndex:uk_promoter_stat_daily,priority:2" json:"statDate" comment:"统计日期"`
+	NewMembers  int64     `gorm:"not null;default:0" json:"newMembers" comment:"新增直属下级"`
+	Orders      int64     `gorm:"not null;default:0" json:"orders" comment:"下级已支付订单数"`
+	OrderAmount float64   `gorm:"type:decimal(12,2);not null;default:0" json:"orderAmount" comment:"下级已支付订单金额"`
+	Commission  float64   `gorm:"type:decimal(12,2);not null;default:0" json:"commission" comment:"获得佣金（不含冻结）"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func (PromoterStatDaily) TableName() string {
+	return "promoter_stat_daily"
+}
+
+// ReferralLinkStatDaily 推广链接按渠道、按天汇总
+type ReferralLinkStatDaily struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	UserID        uint      `gorm:"not null;uniqueIndex:uk_referral_link_stat_daily,priority:1" json:"userId" comment:"推广员ID"`
+	Channel       string    `gorm:"type:varchar(32);not null;default:'';uniqueIndex:uk_referral_link_stat_daily,priority:2" json:"channel" comment:"推广渠道，空表示默认链接"`
+	StatDate      string    `gorm:"type:date;not null;uniqueIndex:uk_referral_link_stat_daily,priority:3" json:"statDate" comment:"统计日期"`
+	Clicks        int64     `gorm:"not null;default:0" json:"clicks" comment:"点击次数"`
+	Registrations int64     `gorm:"not null;default:0" json:"registrations" comment:"注册人数"`
+	Orders        int64     `gorm:"not null;default:0" json:"orders" comment:"通过该渠道注册的用户的已支付订单数"`
+	OrderAmount   float64   `gorm:"type:decimal(12,2);not null;default:0" json:"orderAmount" comment:"已支付订单金额"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+func (ReferralLinkStatDaily) TableName() string {
+	return "referral_link_stat_daily"
+}
@@ -5,6 +5,7 @@ import (
 	"ApkAdmin/global"
 	"ApkAdmin/model/system"
 	"ApkAdmin/utils/crypto"
+	"encoding/json"
 	"fmt"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -15,8 +16,9 @@ import (
 // AppAccount 应用账号表
 type AppAccount struct {
 	ID            uint                       `json:"id" gorm:"primarykey;comment:主键ID"`
-	AppID         string                     `json:"app_id" gorm:"type:varchar(100);not null;index:idx_app_id;comment:应用唯一标识符" binding:"required"`
+	AppID         string                     `json:"app_id" gorm:"type:varchar(100);not null;index:idx_app_id;uniqueIndex:uk_app_detail_hash,priority:1;comment:应用唯一标识符" binding:"required"`
 	AccountDetail string                     `json:"account_detail" gorm:"type:text;not null;comment:登录账号详情（加密存储）" binding:"required"`
+	DetailHash    string                     `json:"-" gorm:"type:char(64);default:null;uniqueIndex:uk_app_detail_hash,priority:2;comment:账号详情的带密钥哈希，同一应用下唯一；历史账号为 NULL，导入时补齐"`
 	CategoryID    uint                       `json:"category_id" gorm:"not null;index:idx_category_id;comment:分类ID" binding:"required"`
 	AccountNo     string                     `json:"account_no" gorm:"type:varchar(50);not null;uniqueIndex:uk_account_no;comment:账号编号（系统生成）"`
 	ExtraInfo     string                     `json:"extra_info" gorm:"type:text;comment:额外信息"`
@@ -26,6 +28,8 @@ type AppAccount struct {
 	DeletedAt     gorm.DeletedAt             `json:"deleted_at" gorm:"index:idx_deleted_at;comment:删除时间"`
 	CreatedBy     uint                       `json:"created_by" gorm:"not null;comment:创建人ID"`
 	UpdatedBy     *uint                      `json:"updated_by" gorm:"comment:更新人ID"`
+	LastCheckedAt *time.Time                 `json:"last_checked_at" gorm:"index:idx_last_checked_at;comment:最近一次健康检查时间"`
+	DecryptFailed bool                       `json:"decrypt_failed,omitempty" gorm:"-"` // 解密失败（密钥未配置或数据损坏），此时 AccountDetail 为空
 
 	// 关联字段（不存储到数据库）
 	Application *Application    `json:"application,omitempty" gorm:"foreignKey:AppID;references:AppID"`
@@ -42,9 +46,12 @@ func (AppAccount) TableName() string {
 func (a *AppAccount) BeforeCreate(tx *gorm.DB) error {
 	// 生成账号编号
 	if a.AccountNo == "" {
-		a.AccountNo = generateAccountNo()
+		a.AccountNo = GenerateAccountNo()
 	}
 	if a.AccountDetail != "" {
+		if a.DetailHash == "" {
+			a.DetailHash = crypto.AccountDetailHash(a.AccountDetail)
+		}
 		encrypted, err := crypto.EncryptAccountDetail(a.AccountDetail)
 		if err != nil {
 			return err
@@ -62,8 +69,8 @@ func (a *AppAccount) BeforeCreate(tx *gorm.DB) error {
 func (a *AppAccount) BeforeUpdate(tx *gorm.DB) error {
 	// 检查 AccountDetail 是否被修改
 	if tx.Statement.Changed("AccountDetail") && a.AccountDetail != "" {
-		// 判断是否已经加密（简单判断，可以更严格）
-		if _, err := crypto.DecryptAccountDetail(a.AccountDetail); err != nil {
+		// 判断是否已经加密；带版本前缀的密文即使当前无法解密也不能当作明文再次加密
+		if _, err := crypto.DecryptAccountDetail(a.AccountDetail); err != nil && !crypto.IsVersioned(a.AccountDetail) {
 			// 如果解密失败，说明是明文，需要加密
 			encrypted, err := crypto.EncryptAccountDetail(a.AccountDetail)
 			if err != nil {
@@ -80,8 +87,11 @@ func (a *AppAccount) AfterFind(tx *gorm.DB) error {
 	if a.AccountDetail != "" {
 		decrypted, err := crypto.DecryptAccountDetail(a.AccountDetail)
 		if err != nil {
-			// 解密失败，可能是旧数据或数据损坏
-			global.GVA_LOG.Error("解密数据失败", zap.Error(err))
+			// 解密失败，可能是密钥已从密钥环移除或数据损坏；不返回错误以免整个列表查询失败，也不把密文当作账号详情返回
+			keyID, _ := crypto.CiphertextKeyID(a.AccountDetail)
+			global.GVA_LOG.Error("解密账号详情失败", zap.Error(err), zap.Uint("accountID", a.ID), zap.String("keyID", keyID))
+			a.AccountDetail = ""
+			a.DecryptFailed = true
 			return nil
 		}
 		a.AccountDetail = decrypted
@@ -89,6 +99,25 @@ func (a *AppAccount) AfterFind(tx *gorm.DB) error {
 	return nil
 }
 
+// MarshalJSON 输出 JSON 时遮盖账号详情，明文只能通过查看接口获取并留下审计记录
+func (a AppAccount) MarshalJSON() ([]byte, error) {
+	type Alias AppAccount
+	alias := Alias(a)
+	if alias.AccountDetail != "" {
+		alias.AccountDetail = MaskAccountDetail(alias.AccountDetail)
+	}
+	return json.Marshal(alias)
+}
+
+// MaskAccountDetail 只显示账号详情的开头
+func MaskAccountDetail(detail string) string {
+	runes := []rune(detail)
+	if len(runes) <= 4 {
+		return "****"
+	}
+	return string(runes[:4]) + "****"
+}
+
 // GetEncryptedDetail 获取加密的详情（用于特殊场景）
 func (a *AppAccount) GetEncryptedDetail() (string, error) {
 	return crypto.EncryptAccountDetail(a.AccountDetail)
@@ -99,8 +128,8 @@ func (a *AppAccount) IsAvailable() bool {
 	return a.AccountStatus == constants.AppAccountStatusNormal && a.DeletedAt.Time.IsZero()
 }
 
-// 生成账号编号
-func generateAccountNo() string {
+// GenerateAccountNo 生成账号编号，批量创建时由调用方保证不重复
+func GenerateAccountNo() string {
 	// 格式：ACC + 年月日 + 6位随机数
 	now := time.Now()
 	dateStr := now.Format("20060102")
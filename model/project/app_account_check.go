@@ -0,0 +1,44 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"encoding/json"
+	"time"
+)
+
+// AppAccountChecker 应用的账号健康检查配置，每个应用一个检查器
+type AppAccountChecker struct {
+	ID              uint            `json:"id" gorm:"primarykey;comment:主键ID"`
+	AppID           string          `json:"app_id" gorm:"type:varchar(100);not null;uniqueIndex:uk_checker_app_id;comment:应用唯一标识符"`
+	CheckerType     string          `json:"checker_type" gorm:"type:varchar(30);not null;comment:检查器类型 extra_expiry/http_probe"`
+	Config          json.RawMessage `json:"config" gorm:"type:json;comment:检查器配置"`
+	Enabled         bool            `json:"enabled" gorm:"not null;comment:是否启用"`
+	IntervalMinutes int             `json:"interval_minutes" gorm:"not null;default:1440;comment:同一账号两次检查的间隔（分钟）"`
+	RatePerMinute   int             `json:"rate_per_minute" gorm:"not null;default:30;comment:每分钟最多检查的账号数"`
+	LastRunAt       *time.Time      `json:"last_run_at" gorm:"comment:最近一次运行时间"`
+	LastError       string          `json:"last_error" gorm:"type:varchar(500);not null;default:'';comment:最近一次运行的错误"`
+	CreatedBy       uint            `json:"created_by" gorm:"not null;comment:创建人ID"`
+	CreatedAt       time.Time       `json:"created_at" gorm:"comment:创建时间"`
+	UpdatedAt       time.Time       `json:"updated_at" gorm:"comment:更新时间"`
+}
+
+func (AppAccountChecker) TableName() string {
+	return "app_account_checkers"
+}
+
+// AppAccountStatusLog 账号状态变更记录。已售出账号检测到问题时不修改状态，Applied 为 false，只通知买家
+type AppAccountStatusLog struct {
+	ID         uint64                        `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	AccountID  uint                          `json:"account_id" gorm:"not null;index:idx_status_log_account;comment:账号ID"`
+	FromStatus constants.AppAccountStatus    `json:"from_status" gorm:"type:tinyint;not null;comment:变更前状态"`
+	ToStatus   constants.AppAccountStatus    `json:"to_status" gorm:"type:tinyint;not null;comment:变更后状态（未生效时为检测结论）"`
+	Applied    bool                          `json:"applied" gorm:"not null;comment:状态是否已修改"`
+	Source     constants.AccountStatusSource `json:"source" gorm:"type:varchar(20);not null;comment:来源 manual/checker"`
+	Reason     string                        `json:"reason" gorm:"type:varchar(500);not null;default:'';comment:原因"`
+	OperatorID uint                          `json:"operator_id" gorm:"not null;default:0;comment:操作人ID，自动检查为0"`
+	CreatedAt  time.Time                     `json:"created_at" gorm:"index:idx_status_log_created;comment:创建时间"`
+}
+
+func (AppAccountStatusLog) TableName() string {
+	return "app_account_status_logs"
+}
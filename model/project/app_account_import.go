@@ -0,0 +1,27 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// AppAccountImportJob 账号批量导入记录，报告只保存有问题的行，不保存账号详情
+type AppAccountImportJob struct {
+	ID            uint                          `json:"id" gorm:"primarykey;comment:主键ID"`
+	FileName      string                        `json:"file_name" gorm:"type:varchar(255);not null;comment:导入文件名"`
+	DryRun        bool                          `json:"dry_run" gorm:"not null;default:0;comment:是否仅预览"`
+	Status        constants.AccountImportStatus `json:"status" gorm:"type:varchar(20);not null;index:idx_import_status;comment:任务状态"`
+	TotalRows     int                           `json:"total_rows" gorm:"not null;default:0;comment:数据行数"`
+	ValidRows     int                           `json:"valid_rows" gorm:"not null;default:0;comment:校验通过行数"`
+	DuplicateRows int                           `json:"duplicate_rows" gorm:"not null;default:0;comment:重复行数"`
+	ErrorRows     int                           `json:"error_rows" gorm:"not null;default:0;comment:错误行数"`
+	ImportedRows  int                           `json:"imported_rows" gorm:"not null;default:0;comment:实际导入行数"`
+	Message       string                        `json:"message" gorm:"type:varchar(500);not null;default:'';comment:失败原因"`
+	Report        string                        `json:"-" gorm:"type:mediumtext;comment:逐行问题报告（JSON）"`
+	CreatedBy     uint                          `json:"created_by" gorm:"not null;comment:操作人ID"`
+	CreatedAt     time.Time                     `json:"created_at" gorm:"index:idx_import_created_at;comment:创建时间"`
+}
+
+func (AppAccountImportJob) TableName() string {
+	return "app_account_import_jobs"
+}
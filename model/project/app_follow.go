@@ -0,0 +1,15 @@
+package project
+
+import "time"
+
+// AppFollow 用户关注的应用，新版本发布时发送站内通知
+type AppFollow struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:uk_follow_user_app,priority:1;comment:用户ID"`
+	AppID     uint64    `json:"app_id" gorm:"not null;uniqueIndex:uk_follow_user_app,priority:2;index:idx_follow_app;comment:应用ID"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;comment:关注时间"`
+}
+
+func (AppFollow) TableName() string {
+	return "app_follows"
+}
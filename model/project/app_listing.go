@@ -0,0 +1,35 @@
+package project
+
+import "time"
+
+// AppListing 应用商店本地化信息，未配置的语言使用 Application 上的默认名称和描述
+type AppListing struct {
+	ID               uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	AppID            string    `json:"app_id" gorm:"size:100;not null;uniqueIndex:uk_listing_app_lang,priority:1;comment:应用ID"`
+	LanguageCode     string    `json:"language_code" gorm:"size:10;not null;uniqueIndex:uk_listing_app_lang,priority:2;comment:语言代码"`
+	AppName          string    `json:"app_name" gorm:"size:200;not null;comment:本地化应用名称"`
+	ShortDescription string    `json:"short_description" gorm:"size:255;not null;default:'';comment:一句话简介"`
+	Description      string    `json:"description" gorm:"type:text;comment:详细描述"`
+	CreatedAt        time.Time `json:"created_at" gorm:"comment:创建时间"`
+	UpdatedAt        time.Time `json:"updated_at" gorm:"comment:更新时间"`
+}
+
+// TableName 指定表名
+func (AppListing) TableName() string {
+	return "app_listings"
+}
+
+// AppPackageChangelog 安装包的本地化更新说明
+type AppPackageChangelog struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	PackageID    uint64    `json:"package_id" gorm:"not null;uniqueIndex:uk_changelog_package_lang,priority:1;comment:安装包ID"`
+	LanguageCode string    `json:"language_code" gorm:"size:10;not null;uniqueIndex:uk_changelog_package_lang,priority:2;comment:语言代码"`
+	Changelog    string    `json:"changelog" gorm:"type:text;comment:更新说明"`
+	CreatedAt    time.Time `json:"created_at" gorm:"comment:创建时间"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"comment:更新时间"`
+}
+
+// TableName 指定表名
+func (AppPackageChangelog) TableName() string {
+	return "app_package_changelogs"
+}
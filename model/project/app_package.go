@@ -18,7 +18,13 @@ type AppPackage struct {
 	ObjectName    *string            `json:"object_name" gorm:"type:varchar(500);comment:OSS路径"`
 	FileName      *string            `json:"file_name" gorm:"type:varchar(500);comment:文件名"`
 	PackageSize   int64              `json:"package_size" gorm:"type:int;comment:文件下载url"`
-	Status        string             `json:"status" gorm:"type:enum('review_pending','published','rejected');default:review_pending;index:idx_status;comment:包状态"`
+	Status        string             `json:"status" gorm:"type:enum('review_pending','published','rejected','quarantined');default:review_pending;index:idx_status;comment:包状态"`
+	FileSha256    *string            `json:"file_sha256" gorm:"type:char(64);comment:文件SHA-256"`
+	FileMd5       *string            `json:"file_md5" gorm:"type:char(32);comment:文件MD5"`
+	ScanStatus    string             `json:"scan_status" gorm:"type:varchar(20);not null;default:pending;index:idx_scan_status;comment:安全扫描状态"`
+	ScanResult    *string            `json:"scan_result" gorm:"type:varchar(255);comment:扫描结果（命中的特征或失败原因）"`
+	ScannedAt     *time.Time         `json:"scanned_at" gorm:"comment:扫描时间"`
+	VerifiedAt    *time.Time         `json:"verified_at" gorm:"comment:最近一次哈希复核时间"`
 	DownloadCount int                `json:"download_count" gorm:"default:0;comment:下载次数"`
 	RatingAverage *float64           `json:"rating_average" gorm:"type:decimal(3,2);comment:平均评分"`
 	RatingCount   int                `json:"rating_count" gorm:"not null;default:0;comment:评分次数"`
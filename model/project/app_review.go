@@ -0,0 +1,35 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// AppReview 用户评价，每个用户对每个应用只保留一条，可以修改
+type AppReview struct {
+	ID          uint64                 `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	AppID       uint64                 `json:"app_id" gorm:"not null;uniqueIndex:uk_review_user_app,priority:2;index:idx_review_app_status,priority:1;comment:应用ID"`
+	UserID      uint                   `json:"user_id" gorm:"not null;uniqueIndex:uk_review_user_app,priority:1;comment:用户ID"`
+	PackageID   uint64                 `json:"package_id" gorm:"not null;default:0;index:idx_review_package;comment:评价时使用的安装包ID"`
+	VersionName string                 `json:"version_name" gorm:"type:varchar(50);not null;default:'';comment:评价时使用的版本"`
+	Rating      int                    `json:"rating" gorm:"type:tinyint;not null;comment:评分1-5"`
+	Content     string                 `json:"content" gorm:"type:varchar(1000);not null;default:'';comment:评价内容"`
+	Status      constants.ReviewStatus `json:"status" gorm:"type:varchar(20);not null;default:visible;index:idx_review_app_status,priority:2;comment:状态"`
+	Flagged     bool                   `json:"flagged" gorm:"not null;default:0;index:idx_review_flagged;comment:是否被标记待处理"`
+	FlagReason  string                 `json:"flag_reason" gorm:"type:varchar(255);not null;default:'';comment:标记原因"`
+	Reply       string                 `json:"reply" gorm:"type:varchar(1000);not null;default:'';comment:官方回复"`
+	RepliedAt   *time.Time             `json:"replied_at" gorm:"comment:回复时间"`
+	RepliedBy   uint                   `json:"replied_by" gorm:"not null;default:0;comment:回复人ID"`
+	IP          string                 `json:"ip" gorm:"type:varchar(45);not null;default:'';comment:提交IP"`
+	EditCount   int                    `json:"edit_count" gorm:"not null;default:0;comment:修改次数"`
+	CreatedAt   time.Time              `json:"created_at" gorm:"autoCreateTime;comment:创建时间"`
+	UpdatedAt   time.Time              `json:"updated_at" gorm:"autoUpdateTime;comment:更新时间"`
+
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	// Application 的 AppID 字段与本表外键同名，GORM 会误判为 has one 关联，由服务层按 ID 填充
+	Application *Application `json:"application,omitempty" gorm:"-"`
+}
+
+func (AppReview) TableName() string {
+	return "app_reviews"
+}
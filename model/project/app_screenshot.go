@@ -5,10 +5,11 @@ import "time"
 // AppScreenshot 应用截图表
 type AppScreenshot struct {
 	ID             uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	AppID          string    `json:"app_id" gorm:"size:100;not null;comment:应用ID"`
+	AppID          string    `json:"app_id" gorm:"size:100;not null;index:idx_screenshot_app;comment:应用ID"`
 	LanguageCode   string    `json:"language_code" gorm:"size:10;comment:语言代码"`
 	Platform       string    `json:"platform" gorm:"type:enum('android','ios','harmony','windows');not null;comment:平台"`
 	ScreenshotURL  string    `json:"screenshot_url" gorm:"size:500;not null;comment:截图URL"`
+	ObjectKey      string    `json:"-" gorm:"size:500;comment:存储中的文件Key，删除截图时一并删除文件"`
 	ScreenshotType string    `json:"screenshot_type" gorm:"type:enum('phone','tablet','desktop','watch');default:'phone';comment:截图类型"`
 	DisplayOrder   int       `json:"display_order" gorm:"default:0;comment:显示顺序"`
 	CreatedAt      time.Time `json:"created_at" gorm:"comment:创建时间"`
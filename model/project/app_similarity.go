@@ -0,0 +1,16 @@
+package project
+
+import "time"
+
+// AppSimilarity 应用相似度，由定时任务根据共同下载和购买记录离线计算
+type AppSimilarity struct {
+	AppID        uint64    `json:"app_id" gorm:"primaryKey;autoIncrement:false;index:idx_similarity_score,priority:1;comment:应用ID"`
+	SimilarAppID uint64    `json:"similar_app_id" gorm:"primaryKey;autoIncrement:false;comment:相似应用ID"`
+	Score        float64   `json:"score" gorm:"not null;index:idx_similarity_score,priority:2,sort:desc;comment:余弦相似度"`
+	CoCount      int       `json:"co_count" gorm:"not null;comment:共同下载或购买的用户数"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime;comment:计算时间"`
+}
+
+func (AppSimilarity) TableName() string {
+	return "app_similarities"
+}
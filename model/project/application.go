@@ -20,13 +20,14 @@ type Application struct {
 	IsHot             *int                        `json:"is_hot" gorm:"default:0;comment:是否热门"`
 	IsRecommend       *int                        `json:"is_recommend" gorm:"default:0;comment:是否推荐"`
 	IsFree            *bool                       `json:"is_free" gorm:"default:0;comment:是否免费应用,0不是，1是"`
-	Rating            *float64                    `json:"rating" gorm:"default:4;comment:评分"`
+	Rating            *float64                    `json:"rating" gorm:"type:decimal(3,2);comment:评分（由用户评价汇总）"`
 	DownloadCount     uint                        `json:"download_count" gorm:"default:0;comment:下载次数"`
 	SalesCount        int64                       `json:"sales_count" gorm:"default:0;comment:总售卖次数"`
 	ApkSalesCount     int64                       `json:"apk_sales_count" gorm:"default:0;comment:apk套餐售卖次数"`
 	AccountSalesCount int64                       `json:"account_sales_count" gorm:"default:0;comment:账号售卖次数"`
 	SortOrder         int                         `json:"sort_order" gorm:"default:0;index:idx_sort_order;comment:排序权重"`
 	AccountPrice      decimal.Decimal             `json:"account_price" gorm:"default:4.00;comment:账号价格"`
+	WarrantyDays      int                         `json:"warranty_days" gorm:"not null;default:0;comment:账号售后保修天数，从支付时间起算，0表示不提供售后"`
 	Status            constants.ApplicationStatus `json:"status" gorm:"type:enum('active','suspended','deleted');default:active;comment:应用状态"`
 	CreatedAt         time.Time                   `json:"created_at" gorm:"autoCreateTime;comment:创建时间"`
 	UpdatedAt         time.Time                   `json:"updated_at" gorm:"autoUpdateTime;comment:更新时间"`
@@ -0,0 +1,26 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// CredentialRevealLog 查看账号明文详情的审计记录，被拒绝的尝试也会记录并计入次数限制
+type CredentialRevealLog struct {
+	ID         uint64                     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	ViewerType constants.CredentialViewer `json:"viewer_type" gorm:"type:varchar(10);not null;index:idx_reveal_viewer,priority:1;comment:查看人类型 admin/buyer"`
+	ViewerID   uint                       `json:"viewer_id" gorm:"not null;index:idx_reveal_viewer,priority:2;comment:管理员ID或用户ID"`
+	ViewerName string                     `json:"viewer_name" gorm:"type:varchar(100);not null;default:'';comment:查看人名称"`
+	AccountID  uint                       `json:"account_id" gorm:"not null;index:idx_reveal_account;comment:账号ID"`
+	OrderID    uint64                     `json:"order_id" gorm:"not null;default:0;comment:买家查看时的订单ID"`
+	IP         string                     `json:"ip" gorm:"type:varchar(64);not null;default:'';comment:请求IP"`
+	UserAgent  string                     `json:"user_agent" gorm:"type:varchar(255);not null;default:'';comment:User-Agent"`
+	Reason     string                     `json:"reason" gorm:"type:varchar(200);not null;default:'';comment:查看原因"`
+	Allowed    bool                       `json:"allowed" gorm:"not null;comment:是否已返回明文"`
+	Message    string                     `json:"message" gorm:"type:varchar(200);not null;default:'';comment:被拒绝的原因"`
+	CreatedAt  time.Time                  `json:"created_at" gorm:"index:idx_reveal_viewer,priority:3;comment:时间"`
+}
+
+func (CredentialRevealLog) TableName() string {
+	return "credential_reveal_logs"
+}
@@ -12,6 +12,7 @@ type DownloadLog struct {
 	PackageID    *uint              `gorm:"index" json:"packageId" comment:"安装包ID"`
 	MembershipID *uint              `gorm:"index" json:"membershipId" comment:"会员ID"`
 	Platform     constants.Platform `gorm:"type:varchar(20);not null" json:"platform" comment:"平台"`
+	CountryCode  string             `gorm:"type:varchar(10);not null;default:''" json:"countryCode" comment:"国家代码"`
 	Success      bool               `gorm:"not null;default:0" json:"success" comment:"是否成功"`
 	FailReason   *string            `gorm:"type:varchar(255)" json:"failReason" comment:"失败原因"`
 	IP           string             `gorm:"type:varchar(45);not null" json:"ip" comment:"IP地址"`
@@ -0,0 +1,70 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// DownloadStatHourly 下载日志按小时汇总，由定时任务从 download_logs 聚合
+type DownloadStatHourly struct {
+	ID          uint               `gorm:"primarykey" json:"id"`
+	StatHour    time.Time          `gorm:"not null;uniqueIndex:uk_stat_hourly,priority:1" json:"statHour" comment:"统计小时（整点）"`
+	AppID       uint               `gorm:"not null;uniqueIndex:uk_stat_hourly,priority:2;index:idx_app_hour,priority:1" json:"appId" comment:"应用ID"`
+	PackageID   uint               `gorm:"not null;default:0;uniqueIndex:uk_stat_hourly,priority:3" json:"packageId" comment:"安装包ID，0表示无安装包（如iOS）"`
+	Platform    constants.Platform `gorm:"type:varchar(20);not null;uniqueIndex:uk_stat_hourly,priority:4" json:"platform" comment:"平台"`
+	CountryCode string             `gorm:"type:varchar(10);not null;default:'';uniqueIndex:uk_stat_hourly,priority:5" json:"countryCode" comment:"国家代码"`
+	Success     bool               `gorm:"not null;uniqueIndex:uk_stat_hourly,priority:6" json:"success" comment:"是否成功"`
+	TotalCount  int64              `gorm:"not null;default:0" json:"totalCount" comment:"下载次数"`
+	UniqueUsers int64              `gorm:"not null;default:0" json:"uniqueUsers" comment:"下载用户数"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+}
+
+func (DownloadStatHourly) TableName() string {
+	return "download_stat_hourly"
+}
+
+// DownloadStatDaily 下载日志按天汇总
+type DownloadStatDaily struct {
+	ID          uint               `gorm:"primarykey" json:"id"`
+	StatDate    string             `gorm:"type:date;not null;uniqueIndex:uk_stat_daily,priority:1" json:"statDate" comment:"统计日期"`
+	AppID       uint               `gorm:"not null;uniqueIndex:uk_stat_daily,priority:2;index:idx_app_date,priority:1" json:"appId" comment:"应用ID"`
+	PackageID   uint               `gorm:"not null;default:0;uniqueIndex:uk_stat_daily,priority:3" json:"packageId" comment:"安装包ID，0表示无安装包（如iOS）"`
+	Platform    constants.Platform `gorm:"type:varchar(20);not null;uniqueIndex:uk_stat_daily,priority:4" json:"platform" comment:"平台"`
+	CountryCode string             `gorm:"type:varchar(10);not null;default:'';uniqueIndex:uk_stat_daily,priority:5" json:"countryCode" comment:"国家代码"`
+	Success     bool               `gorm:"not null;uniqueIndex:uk_stat_daily,priority:6" json:"success" comment:"是否成功"`
+	TotalCount  int64              `gorm:"not null;default:0" json:"totalCount" comment:"下载次数"`
+	UniqueUsers int64              `gorm:"not null;default:0" json:"uniqueUsers" comment:"下载用户数"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+}
+
+func (DownloadStatDaily) TableName() string {
+	return "download_stat_daily"
+}
+
+// DownloadFailReasonDaily 下载失败原因按天汇总
+type DownloadFailReasonDaily struct {
+	ID         uint               `gorm:"primarykey" json:"id"`
+	StatDate   string             `gorm:"type:date;not null;uniqueIndex:uk_fail_reason_daily,priority:1" json:"statDate" comment:"统计日期"`
+	AppID      uint               `gorm:"not null;uniqueIndex:uk_fail_reason_daily,priority:2" json:"appId" comment:"应用ID"`
+	Platform   constants.Platform `gorm:"type:varchar(20);not null;uniqueIndex:uk_fail_reason_daily,priority:3" json:"platform" comment:"平台"`
+	FailReason string             `gorm:"type:varchar(255);not null;default:'';uniqueIndex:uk_fail_reason_daily,priority:4" json:"failReason" comment:"失败原因"`
+	TotalCount int64              `gorm:"not null;default:0" json:"totalCount" comment:"失败次数"`
+	UpdatedAt  time.Time          `json:"updatedAt"`
+}
+
+func (DownloadFailReasonDaily) TableName() string {
+	return "download_fail_reason_daily"
+}
+
+// DownloadUserDaily 每天下载过各应用的用户，用于统计任意时间段的去重下载用户数
+type DownloadUserDaily struct {
+	StatDate   string             `gorm:"type:date;primaryKey" json:"statDate" comment:"统计日期"`
+	AppID      uint               `gorm:"primaryKey" json:"appId" comment:"应用ID"`
+	UserID     uint               `gorm:"primaryKey;index" json:"userId" comment:"用户ID"`
+	Platform   constants.Platform `gorm:"type:varchar(20);primaryKey" json:"platform" comment:"平台"`
+	TotalCount int64              `gorm:"not null;default:0" json:"totalCount" comment:"当天下载次数"`
+}
+
+func (DownloadUserDaily) TableName() string {
+	return "download_user_daily"
+}
@@ -85,12 +85,13 @@ func (a *AccountIDList) Scan(value interface{}) error {
 		return nil
 	}
 
-	bytes, ok := value.([]byte)
-	if !ok {
-		return nil
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
 	}
-
-	return json.Unmarshal(bytes, a)
+	return nil
 }
 
 // Value 实现 driver.Valuer 接口
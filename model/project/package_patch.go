@@ -0,0 +1,33 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// PackagePatch 安装包差分补丁表
+// 每对相邻的已发布版本一条记录，客户端凭旧包哈希下载补丁并在本地合成新包
+type PackagePatch struct {
+	ID              uint64                       `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	AppID           string                       `json:"app_id" gorm:"type:varchar(100);not null;index:idx_app_platform;comment:应用唯一标识符"`
+	Platform        constants.Platform           `json:"platform" gorm:"type:varchar(20);not null;index:idx_app_platform;comment:平台类型"`
+	FromPackageID   uint64                       `json:"from_package_id" gorm:"not null;uniqueIndex:uk_patch_pair;comment:旧版本安装包ID"`
+	ToPackageID     uint64                       `json:"to_package_id" gorm:"not null;uniqueIndex:uk_patch_pair;comment:新版本安装包ID"`
+	FromVersionCode int                          `json:"from_version_code" gorm:"not null;comment:旧版本号"`
+	ToVersionCode   int                          `json:"to_version_code" gorm:"not null;comment:新版本号"`
+	FromSha256      string                       `json:"from_sha256" gorm:"type:char(64);comment:旧版本安装包SHA-256"`
+	ToSha256        string                       `json:"to_sha256" gorm:"type:char(64);comment:合成后新安装包SHA-256"`
+	ObjectName      *string                      `json:"object_name" gorm:"type:varchar(500);comment:补丁OSS路径"`
+	FileURL         *string                      `json:"file_url" gorm:"type:varchar(500);comment:补丁访问url"`
+	PatchSize       int64                        `json:"patch_size" gorm:"not null;default:0;comment:补丁大小（字节）"`
+	PatchSha256     string                       `json:"patch_sha256" gorm:"type:char(64);comment:补丁文件SHA-256"`
+	Status          constants.PackagePatchStatus `json:"status" gorm:"type:varchar(20);not null;index:idx_status;comment:补丁状态"`
+	FailReason      *string                      `json:"fail_reason" gorm:"type:varchar(255);comment:失败或跳过原因"`
+	CreatedAt       time.Time                    `json:"created_at" gorm:"comment:创建时间"`
+	UpdatedAt       time.Time                    `json:"updated_at" gorm:"comment:更新时间"`
+}
+
+// TableName 指定表名
+func (PackagePatch) TableName() string {
+	return "package_patches"
+}
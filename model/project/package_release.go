@@ -0,0 +1,61 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// PackageRelease 安装包发布配置表
+// 每个 应用+平台+渠道+国家 一条记录，CountryCode 为空表示对所有国家生效
+type PackageRelease struct {
+	ID               uint64                   `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	AppID            string                   `json:"app_id" gorm:"type:varchar(100);not null;uniqueIndex:uk_release_target;comment:应用唯一标识符"`
+	Platform         constants.Platform       `json:"platform" gorm:"type:varchar(20);not null;uniqueIndex:uk_release_target;comment:平台类型"`
+	Channel          constants.ReleaseChannel `json:"channel" gorm:"type:varchar(20);not null;default:stable;uniqueIndex:uk_release_target;comment:发布渠道"`
+	CountryCode      string                   `json:"country_code" gorm:"type:varchar(3);not null;default:'';uniqueIndex:uk_release_target;comment:国家代码(空表示通用)"`
+	CurrentPackageID uint64                   `json:"current_package_id" gorm:"not null;comment:当前全量安装包ID"`
+	RolloutPackageID *uint64                  `json:"rollout_package_id" gorm:"comment:灰度中的安装包ID"`
+	RolloutPercent   int                      `json:"rollout_percent" gorm:"not null;default:0;comment:灰度比例(0-100)"`
+	CreatedAt        time.Time                `json:"created_at" gorm:"comment:创建时间"`
+	UpdatedAt        time.Time                `json:"updated_at" gorm:"comment:更新时间"`
+	UpdatedBy        uint                     `json:"updated_by" gorm:"comment:更新人ID"`
+
+	CurrentPackage *AppPackage `json:"current_package,omitempty" gorm:"foreignKey:CurrentPackageID"`
+	RolloutPackage *AppPackage `json:"rollout_package,omitempty" gorm:"foreignKey:RolloutPackageID"`
+}
+
+// TableName 指定表名
+func (PackageRelease) TableName() string {
+	return "package_releases"
+}
+
+// HasRollout 是否处于灰度中
+func (r *PackageRelease) HasRollout() bool {
+	return r.RolloutPackageID != nil && r.RolloutPercent > 0
+}
+
+// PickPackageID 根据用户的灰度分桶选择安装包
+func (r *PackageRelease) PickPackageID(bucket int) uint64 {
+	if r.HasRollout() && bucket < r.RolloutPercent {
+		return *r.RolloutPackageID
+	}
+	return r.CurrentPackageID
+}
+
+// PackageReleaseHistory 发布操作历史表，回滚时据此找到上一个版本
+type PackageReleaseHistory struct {
+	ID             uint64                  `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	ReleaseID      uint64                  `json:"release_id" gorm:"not null;index:idx_release_id;comment:发布配置ID"`
+	Action         constants.ReleaseAction `json:"action" gorm:"type:varchar(20);not null;comment:操作类型"`
+	FromPackageID  *uint64                 `json:"from_package_id" gorm:"comment:操作前全量安装包ID"`
+	ToPackageID    uint64                  `json:"to_package_id" gorm:"not null;comment:操作涉及的安装包ID"`
+	RolloutPercent int                     `json:"rollout_percent" gorm:"not null;default:0;comment:操作后的灰度比例"`
+	Remark         string                  `json:"remark" gorm:"type:varchar(255);comment:备注"`
+	OperatorID     uint                    `json:"operator_id" gorm:"not null;comment:操作人ID"`
+	CreatedAt      time.Time               `json:"created_at" gorm:"index:idx_created_at;comment:创建时间"`
+}
+
+// TableName 指定表名
+func (PackageReleaseHistory) TableName() string {
+	return "package_release_histories"
+}
@@ -0,0 +1,43 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// ReferralClick 推广链接的点击记录，ClickID 写入 cookie，注册时据此归属推荐人
+type ReferralClick struct {
+	ID               uint64     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	ClickID          string     `json:"click_id" gorm:"type:varchar(32);not null;uniqueIndex:uk_referral_click_id;comment:点击ID"`
+	ReferrerID       uint       `json:"referrer_id" gorm:"not null;index:idx_referral_click_referrer,priority:1;comment:推荐人ID"`
+	ReferralCode     string     `json:"referral_code" gorm:"type:varchar(20);not null;comment:邀请码"`
+	Channel          string     `json:"channel" gorm:"type:varchar(32);not null;default:'';comment:推广渠道"`
+	IP               string     `json:"ip" gorm:"type:varchar(45);not null;default:'';comment:点击IP"`
+	UserAgent        string     `json:"user_agent" gorm:"type:varchar(255);not null;default:'';comment:User-Agent"`
+	Referer          string     `json:"referer" gorm:"type:varchar(255);not null;default:'';comment:来源页面"`
+	RegisteredUserID *uint      `json:"registered_user_id" gorm:"comment:通过该点击注册的用户ID"`
+	RegisteredAt     *time.Time `json:"registered_at" gorm:"comment:注册时间"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"index:idx_referral_click_referrer,priority:2;comment:点击时间"`
+}
+
+func (ReferralClick) TableName() string {
+	return "referral_clicks"
+}
+
+// ReferralBindLog 推荐关系变更记录，包括注册时的绑定和管理员修改
+type ReferralBindLog struct {
+	ID            uint64                       `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	UserID        uint                         `json:"user_id" gorm:"not null;index:idx_referral_bind_user;comment:用户ID"`
+	OldReferrerID uint                         `json:"old_referrer_id" gorm:"not null;default:0;comment:原推荐人ID，0表示无"`
+	NewReferrerID uint                         `json:"new_referrer_id" gorm:"not null;default:0;comment:新推荐人ID，0表示解除"`
+	Source        constants.ReferralBindSource `json:"source" gorm:"type:varchar(20);not null;comment:来源"`
+	ClickID       string                       `json:"click_id" gorm:"type:varchar(32);not null;default:'';comment:推广链接点击ID"`
+	OperatorID    uint                         `json:"operator_id" gorm:"not null;default:0;comment:管理员ID"`
+	Operator      string                       `json:"operator" gorm:"type:varchar(100);not null;default:'';comment:管理员名称"`
+	Reason        string                       `json:"reason" gorm:"type:varchar(200);not null;default:'';comment:修改原因"`
+	CreatedAt     time.Time                    `json:"created_at" gorm:"comment:时间"`
+}
+
+func (ReferralBindLog) TableName() string {
+	return "referral_bind_logs"
+}
@@ -0,0 +1,35 @@
+package request
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/model/common/request"
+)
+
+// WarrantyClaimSubmitRequest 买家提交售后申请，凭证图片通过 multipart 的 evidence 字段上传
+type WarrantyClaimSubmitRequest struct {
+	OrderNo   string `form:"order_no" binding:"required"`
+	AccountID uint   `form:"account_id" binding:"required"`
+	Reason    string `form:"reason" binding:"required,max=500"`
+}
+
+// WarrantyClaimListRequest 售后申请列表，前台只返回当前用户的申请
+type WarrantyClaimListRequest struct {
+	request.PageInfo
+	Status    constants.WarrantyClaimStatus `json:"status" form:"status"`
+	OrderNo   string                        `json:"order_no" form:"order_no"`
+	AccountID uint                          `json:"account_id" form:"account_id"`
+}
+
+// WarrantyClaimApproveRequest 通过售后申请，resolution 为 replace 时更换账号，为 refund 时按 refund_amount 部分退款
+type WarrantyClaimApproveRequest struct {
+	ID           uint    `json:"id" binding:"required"`
+	Resolution   string  `json:"resolution" binding:"required,oneof=replace refund"`
+	RefundAmount float64 `json:"refund_amount"`
+	Note         string  `json:"note" binding:"max=500"`
+}
+
+// WarrantyClaimRejectRequest 驳回售后申请
+type WarrantyClaimRejectRequest struct {
+	ID   uint   `json:"id" binding:"required"`
+	Note string `json:"note" binding:"required,max=500"`
+}
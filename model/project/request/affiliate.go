@@ -0,0 +1,46 @@
+package request
+
+import (
+	"ApkAdmin/model/common/request"
+	"errors"
+	"time"
+)
+
+const maxAffiliateStatDays = 366
+
+// AffiliateDownlineRequest 推广员查看直属下级
+type AffiliateDownlineRequest struct {
+	request.PageInfo
+	Active string `json:"active" form:"active" binding:"omitempty,oneof=all active inactive"` // 活跃状态筛选：近30天有消费为活跃
+}
+
+// AffiliateStatRequest 推广数据查询，日期格式 2006-01-02，默认最近30天
+type AffiliateStatRequest struct {
+	StartDate string `json:"start_date" form:"start_date"`
+	EndDate   string `json:"end_date" form:"end_date"` // 包含当天
+}
+
+// Range 解析查询区间 [start, end)
+func (r *AffiliateStatRequest) Range() (start, end time.Time, err error) {
+	now := time.Now()
+	end = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+	if r.EndDate != "" {
+		if end, err = time.ParseInLocation("2006-01-02", r.EndDate, time.Local); err != nil {
+			return start, end, errors.New("结束日期格式不正确")
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+	start = end.AddDate(0, 0, -30)
+	if r.StartDate != "" {
+		if start, err = time.ParseInLocation("2006-01-02", r.StartDate, time.Local); err != nil {
+			return start, end, errors.New("开始日期格式不正确")
+		}
+	}
+	if !end.After(start) {
+		return start, end, errors.New("结束日期不能早于开始日期")
+	}
+	if end.Sub(start) > maxAffiliateStatDays*24*time.Hour {
+		return start, end, errors.New("查询范围过大")
+	}
+	return start, end, nil
+}
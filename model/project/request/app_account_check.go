@@ -0,0 +1,33 @@
+package request
+
+import (
+	"ApkAdmin/model/common/request"
+	"encoding/json"
+)
+
+// AccountCheckerSaveRequest 新增或修改应用的账号检查器，按 app_id 覆盖
+type AccountCheckerSaveRequest struct {
+	AppID           string          `json:"app_id" binding:"required"`
+	CheckerType     string          `json:"checker_type" binding:"required"`
+	Config          json.RawMessage `json:"config"`
+	Enabled         bool            `json:"enabled"`
+	IntervalMinutes int             `json:"interval_minutes" binding:"omitempty,min=10"` // 默认 1440
+	RatePerMinute   int             `json:"rate_per_minute" binding:"omitempty,min=1,max=600"`
+}
+
+// AccountCheckerListRequest 检查器列表
+type AccountCheckerListRequest struct {
+	request.PageInfo
+	AppID string `json:"app_id" form:"app_id"`
+}
+
+// AccountCheckTestRequest 用应用的检查器检查一个账号，只返回结果不修改状态
+type AccountCheckTestRequest struct {
+	AccountID uint `json:"account_id" binding:"required"`
+}
+
+// AccountStatusLogRequest 账号状态变更记录
+type AccountStatusLogRequest struct {
+	request.PageInfo
+	AccountID uint `json:"account_id" form:"account_id" binding:"required"`
+}
@@ -0,0 +1,18 @@
+package request
+
+import "ApkAdmin/model/common/request"
+
+// AccountImportRequest 批量导入账号，文件通过 multipart 的 file 字段上传
+type AccountImportRequest struct {
+	DryRun bool `form:"dry_run"` // 只校验并返回预览，不写入
+}
+
+// AccountImportJobListRequest 导入记录列表
+type AccountImportJobListRequest struct {
+	request.PageInfo
+}
+
+// AccountImportReportRequest 下载导入问题报告
+type AccountImportReportRequest struct {
+	ID uint `form:"id" binding:"required"`
+}
@@ -0,0 +1,68 @@
+package request
+
+// ScreenshotUploadRequest 批量上传截图，文件通过 multipart 的 files 字段提交
+type ScreenshotUploadRequest struct {
+	AppID          string `form:"app_id" binding:"required"`
+	LanguageCode   string `form:"language_code" binding:"max=10"` // 为空表示通用截图
+	Platform       string `form:"platform" binding:"required,oneof=android ios harmony windows"`
+	ScreenshotType string `form:"screenshot_type" binding:"omitempty,oneof=phone tablet desktop watch"`
+}
+
+// ScreenshotUpdateRequest 修改截图属性
+type ScreenshotUpdateRequest struct {
+	ID             uint   `json:"id" binding:"required"`
+	LanguageCode   string `json:"language_code" binding:"max=10"`
+	Platform       string `json:"platform" binding:"required,oneof=android ios harmony windows"`
+	ScreenshotType string `json:"screenshot_type" binding:"required,oneof=phone tablet desktop watch"`
+}
+
+// ScreenshotListRequest 截图列表
+type ScreenshotListRequest struct {
+	AppID          string  `form:"app_id" binding:"required"`
+	Platform       string  `form:"platform"`
+	LanguageCode   *string `form:"language_code"` // 传空字符串只查询通用截图
+	ScreenshotType string  `form:"screenshot_type"`
+}
+
+// ScreenshotReorderRequest 截图排序，按 ids 顺序设置显示顺序
+type ScreenshotReorderRequest struct {
+	IDs []uint `json:"ids" binding:"required,min=1"`
+}
+
+// ScreenshotDeleteRequest 批量删除截图
+type ScreenshotDeleteRequest struct {
+	IDs []uint `json:"ids" binding:"required,min=1"`
+}
+
+// AppListingSaveRequest 保存应用本地化信息，同一应用同一语言只有一条
+type AppListingSaveRequest struct {
+	AppID            string `json:"app_id" binding:"required"`
+	LanguageCode     string `json:"language_code" binding:"required,max=10"`
+	AppName          string `json:"app_name" binding:"required,max=200"`
+	ShortDescription string `json:"short_description" binding:"max=255"`
+	Description      string `json:"description"`
+}
+
+// AppListingListRequest 应用本地化信息列表
+type AppListingListRequest struct {
+	AppID string `form:"app_id" binding:"required"`
+}
+
+// ChangelogSaveRequest 保存安装包本地化更新说明
+type ChangelogSaveRequest struct {
+	PackageID    uint64 `json:"package_id" binding:"required"`
+	LanguageCode string `json:"language_code" binding:"required,max=10"`
+	Changelog    string `json:"changelog" binding:"required"`
+}
+
+// ChangelogListRequest 安装包更新说明列表
+type ChangelogListRequest struct {
+	PackageID uint64 `form:"package_id" binding:"required"`
+}
+
+// AppStoreDetailRequest 前台应用详情
+type AppStoreDetailRequest struct {
+	AppID    uint64 `form:"appId" binding:"required"`
+	Lang     string `form:"lang"`     // 指定语言，优先于 Accept-Language
+	Platform string `form:"platform"` // 只返回该平台的截图和安装包
+}
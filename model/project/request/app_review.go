@@ -0,0 +1,59 @@
+package request
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/model/common/request"
+)
+
+// SubmitReviewRequest 提交或修改评价
+type SubmitReviewRequest struct {
+	AppID   uint64 `json:"appId" binding:"required"`
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Content string `json:"content" binding:"max=1000"`
+}
+
+// AppReviewQuery 前台评价列表
+type AppReviewQuery struct {
+	PageInfo
+	AppID  uint64 `json:"appId" form:"appId" binding:"required"`
+	Rating int    `json:"rating" form:"rating" binding:"omitempty,min=1,max=5"` // 按星级筛选
+}
+
+// AppReviewIDRequest 按应用查询当前用户的评价
+type AppReviewIDRequest struct {
+	AppID uint64 `json:"appId" form:"appId" binding:"required"`
+}
+
+// AppReviewListRequest 后台评价列表
+type AppReviewListRequest struct {
+	request.PageInfo
+	AppID   uint64                 `json:"app_id" form:"app_id"`
+	UserID  uint                   `json:"user_id" form:"user_id"`
+	Rating  int                    `json:"rating" form:"rating"`
+	Status  constants.ReviewStatus `json:"status" form:"status"`
+	Flagged *bool                  `json:"flagged" form:"flagged"`
+}
+
+// ReviewStatusRequest 批量显示或隐藏评价
+type ReviewStatusRequest struct {
+	IDs    []uint64               `json:"ids" binding:"required,min=1"`
+	Status constants.ReviewStatus `json:"status" binding:"required,oneof=visible hidden"`
+}
+
+// ReviewFlagRequest 标记或取消标记评价
+type ReviewFlagRequest struct {
+	IDs     []uint64 `json:"ids" binding:"required,min=1"`
+	Flagged bool     `json:"flagged"`
+	Reason  string   `json:"reason" binding:"max=255"`
+}
+
+// ReviewReplyRequest 官方回复，内容为空表示删除回复
+type ReviewReplyRequest struct {
+	ID    uint64 `json:"id" binding:"required"`
+	Reply string `json:"reply" binding:"max=1000"`
+}
+
+// ReviewIDsRequest 批量操作评价
+type ReviewIDsRequest struct {
+	IDs []uint64 `json:"ids" binding:"required,min=1"`
+}
@@ -0,0 +1,36 @@
+package request
+
+// AppVersionHistoryRequest 应用版本历史
+type AppVersionHistoryRequest struct {
+	PageInfo
+	AppID    uint64 `json:"appId" form:"appId" binding:"required"`
+	Platform string `json:"platform" form:"platform"` // 为空时返回所有平台
+	Lang     string `json:"lang" form:"lang"`         // 更新说明语言，优先于 Accept-Language
+}
+
+// ReleaseFeedRequest 新版本订阅源
+type ReleaseFeedRequest struct {
+	Platform string `form:"platform"`
+	Lang     string `form:"lang"`
+}
+
+// AppFollowRequest 关注或取消关注应用
+type AppFollowRequest struct {
+	AppID uint64 `json:"appId" form:"appId" binding:"required"`
+}
+
+// FollowListRequest 我关注的应用
+type FollowListRequest struct {
+	PageInfo
+}
+
+// NotificationListRequest 站内通知列表
+type NotificationListRequest struct {
+	PageInfo
+	UnreadOnly bool `json:"unreadOnly" form:"unreadOnly"`
+}
+
+// NotificationReadRequest 标记通知已读，ids 为空时全部标记为已读
+type NotificationReadRequest struct {
+	IDs []uint64 `json:"ids"`
+}
@@ -18,8 +18,9 @@ type ApplicationCreateRequest struct {
 	IsHot         *int            `json:"is_hot" `
 	IsRecommend   *int            `json:"is_recommend" `
 	IsFree        bool            `json:"is_free" `
-	SortOrder     int             `json:"sort_order" binding:"min=0,max=9999"` // 排序权重
-	AccountPrice  decimal.Decimal `json:"account_price" binding:"required"`    //应用账号价格
+	SortOrder     int             `json:"sort_order" binding:"min=0,max=9999"`   // 排序权重
+	AccountPrice  decimal.Decimal `json:"account_price" binding:"required"`      //应用账号价格
+	WarrantyDays  int             `json:"warranty_days" binding:"min=0,max=365"` // 账号售后保修天数
 }
 
 func (r *ApplicationCreateRequest) Validate() error {
@@ -58,6 +59,7 @@ func (r *ApplicationCreateRequest) ToApplication() project.Application {
 		AppIcon:      r.AppIcon,
 		Description:  r.Description,
 		AccountPrice: r.AccountPrice,
+		WarrantyDays: r.WarrantyDays,
 		IsHot:        r.IsHot,
 		IsRecommend:  r.IsRecommend,
 		IsFree:       &r.IsFree,
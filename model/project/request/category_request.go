@@ -147,3 +147,26 @@ func (req *UpdateCategoryRequest) Validate() error {
 
 	return nil
 }
+
+// CategoryMove 将分类移动到新的父分类下，ParentID 为 0 表示移为顶级分类
+type CategoryMove struct {
+	ID       uint `json:"id" binding:"required"`
+	ParentID uint `json:"parent_id"`
+}
+
+// CategoryMoveRequest 批量移动分类，按顺序执行
+type CategoryMoveRequest struct {
+	Moves []CategoryMove `json:"moves" binding:"required,min=1,dive"`
+}
+
+// CategoryReorderRequest 同级分类排序，IDs 按显示顺序排列
+type CategoryReorderRequest struct {
+	ParentID uint   `json:"parent_id"`
+	IDs      []uint `json:"ids" binding:"required,min=1"`
+}
+
+// CategoryMergeRequest 合并分类，source 合并到 target 后删除
+type CategoryMergeRequest struct {
+	SourceID uint `json:"source_id" binding:"required"`
+	TargetID uint `json:"target_id" binding:"required"`
+}
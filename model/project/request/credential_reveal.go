@@ -0,0 +1,27 @@
+package request
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/model/common/request"
+)
+
+// AdminRevealAccountRequest 管理员查看账号明文详情，必须填写原因；开启 require-totp 时需要谷歌验证码
+type AdminRevealAccountRequest struct {
+	ID     uint   `json:"id" binding:"required"`
+	Reason string `json:"reason" binding:"required,max=200"`
+	Code   string `json:"code"`
+}
+
+// BuyerRevealAccountRequest 买家查看已购买账号的明文详情
+type BuyerRevealAccountRequest struct {
+	OrderNo   string `json:"order_no" binding:"required"`
+	AccountID uint   `json:"account_id" binding:"required"`
+}
+
+// CredentialRevealLogRequest 明文查看记录
+type CredentialRevealLogRequest struct {
+	request.PageInfo
+	ViewerType constants.CredentialViewer `json:"viewer_type" form:"viewer_type"`
+	ViewerID   uint                       `json:"viewer_id" form:"viewer_id"`
+	AccountID  uint                       `json:"account_id" form:"account_id"`
+}
@@ -1,14 +1,17 @@
 package request
 
 import (
+	"ApkAdmin/constants"
 	"ApkAdmin/utils"
 	"errors"
 	"strings"
 )
 
 type DownloadAppRequest struct {
-	AppId  uint   `json:"appId" binding:"required"`
-	OsType string `json:"osType" binding:"required"`
+	AppId       uint                     `json:"appId" binding:"required"`
+	OsType      string                   `json:"osType" binding:"required"`
+	Channel     constants.ReleaseChannel `json:"channel"`     // 发布渠道，默认正式版
+	CountryCode string                   `json:"countryCode"` // 国家代码，用于匹配国家固定版本
 }
 
 func (r DownloadAppRequest) Validate() error {
@@ -20,5 +23,8 @@ func (r DownloadAppRequest) Validate() error {
 	if !utils.Contains(validTypes, osType) {
 		return errors.New("系统类型不支持！")
 	}
+	if r.Channel != "" && !r.Channel.IsValid() {
+		return errors.New("发布渠道不正确")
+	}
 	return nil
 }
@@ -0,0 +1,126 @@
+package request
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	StatGranularityHour = "hour"
+	StatGranularityDay  = "day"
+
+	maxHourlyStatDays = 31
+	maxDailyStatDays  = 366
+)
+
+// DownloadStatRequest 下载统计查询
+type DownloadStatRequest struct {
+	StartTime   string `json:"start_time" form:"start_time"`     // 开始时间，格式 2006-01-02 或 2006-01-02 15:04:05，默认最近7天
+	EndTime     string `json:"end_time" form:"end_time"`         // 结束时间，只传日期时包含当天
+	AppID       uint   `json:"app_id" form:"app_id"`             // 应用ID
+	Platform    string `json:"platform" form:"platform"`         // 平台
+	CountryCode string `json:"country_code" form:"country_code"` // 国家代码
+	Granularity string `json:"granularity" form:"granularity"`   // 趋势粒度：hour 或 day，默认 day
+	Limit       int    `json:"limit" form:"limit"`               // 排行数量，默认10，最多100
+}
+
+// RebuildDownloadStatRequest 重建下载汇总
+type RebuildDownloadStatRequest struct {
+	StartDate string `json:"start_date" binding:"required"` // 开始日期 2006-01-02
+	EndDate   string `json:"end_date" binding:"required"`   // 结束日期 2006-01-02，包含当天
+}
+
+func (r *DownloadStatRequest) Validate() error {
+	r.CountryCode = strings.ToUpper(r.CountryCode)
+	if r.CountryCode != "" && !countryCodeRegexp.MatchString(r.CountryCode) {
+		return errors.New("国家代码格式不正确")
+	}
+	if r.Granularity == "" {
+		r.Granularity = StatGranularityDay
+	}
+	if r.Granularity != StatGranularityHour && r.Granularity != StatGranularityDay {
+		return errors.New("统计粒度只能是 hour 或 day")
+	}
+	_, _, err := r.Range()
+	return err
+}
+
+// Range 解析查询区间 [start, end)，按统计粒度对齐到整点或整天
+func (r *DownloadStatRequest) Range() (start, end time.Time, err error) {
+	now := time.Now()
+	end = now
+	if r.EndTime != "" {
+		var dateOnly bool
+		if end, dateOnly, err = parseStatTime(r.EndTime); err != nil {
+			return start, end, errors.New("结束时间格式不正确")
+		}
+		if dateOnly {
+			end = end.AddDate(0, 0, 1)
+		}
+	}
+	start = end.AddDate(0, 0, -7)
+	if r.StartTime != "" {
+		if start, _, err = parseStatTime(r.StartTime); err != nil {
+			return start, end, errors.New("开始时间格式不正确")
+		}
+	}
+
+	maxDays := maxDailyStatDays
+	if r.Granularity == StatGranularityHour {
+		start = start.Truncate(time.Hour)
+		if aligned := end.Truncate(time.Hour); aligned.Before(end) {
+			end = aligned.Add(time.Hour)
+		}
+		maxDays = maxHourlyStatDays
+	} else {
+		start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local)
+		if aligned := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.Local); aligned.Before(end) {
+			end = aligned.AddDate(0, 0, 1)
+		}
+	}
+	if !end.After(start) {
+		return start, end, errors.New("结束时间必须晚于开始时间")
+	}
+	if end.Sub(start) > time.Duration(maxDays)*24*time.Hour {
+		return start, end, errors.New("查询范围过大")
+	}
+	return start, end, nil
+}
+
+// TopLimit 排行数量
+func (r *DownloadStatRequest) TopLimit() int {
+	switch {
+	case r.Limit <= 0:
+		return 10
+	case r.Limit > 100:
+		return 100
+	default:
+		return r.Limit
+	}
+}
+
+// Range 解析重建区间 [start, end)
+func (r *RebuildDownloadStatRequest) Range() (start, end time.Time, err error) {
+	start, err = time.ParseInLocation("2006-01-02", r.StartDate, time.Local)
+	if err != nil {
+		return start, end, errors.New("开始日期格式不正确")
+	}
+	end, err = time.ParseInLocation("2006-01-02", r.EndDate, time.Local)
+	if err != nil {
+		return start, end, errors.New("结束日期格式不正确")
+	}
+	end = end.AddDate(0, 0, 1)
+	if !end.After(start) {
+		return start, end, errors.New("结束日期不能早于开始日期")
+	}
+	return start, end, nil
+}
+
+func parseStatTime(s string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.ParseInLocation("2006-01-02 15:04:05", s, time.Local); err == nil {
+		return t, false, nil
+	}
+	t, err = time.ParseInLocation("2006-01-02", s, time.Local)
+	return t, true, err
+}
@@ -0,0 +1,45 @@
+package request
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/model/common/request"
+	"errors"
+	"strings"
+)
+
+// PackagePatchListRequest 差分补丁列表
+type PackagePatchListRequest struct {
+	request.PageInfo
+	AppID  string                       `form:"app_id" json:"app_id" binding:"required"`
+	Status constants.PackagePatchStatus `form:"status" json:"status"`
+}
+
+// CheckUpdateRequest 客户端检查更新
+type CheckUpdateRequest struct {
+	AppId       uint                     `json:"appId" binding:"required"`
+	VersionCode int                      `json:"versionCode"` // 已安装版本号
+	ApkSha256   string                   `json:"apkSha256"`   // 已安装APK的SHA-256，用于匹配差分补丁
+	Channel     constants.ReleaseChannel `json:"channel"`
+	CountryCode string                   `json:"countryCode"`
+}
+
+func (r *CheckUpdateRequest) Validate() error {
+	if r.AppId <= 0 || r.AppId > 9999 {
+		return errors.New("APPID参数不正确")
+	}
+	if r.VersionCode < 0 {
+		return errors.New("版本号不正确")
+	}
+	if r.Channel != "" && !r.Channel.IsValid() {
+		return errors.New("发布渠道不正确")
+	}
+	r.ApkSha256 = strings.ToLower(strings.TrimSpace(r.ApkSha256))
+	if r.ApkSha256 != "" && !isSha256Hex(r.ApkSha256) {
+		return errors.New("安装包哈希格式不正确")
+	}
+	r.CountryCode = strings.ToUpper(strings.TrimSpace(r.CountryCode))
+	if r.CountryCode != "" && !countryCodeRegexp.MatchString(r.CountryCode) {
+		return errors.New("国家代码格式不正确")
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package request
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/model/common/request"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var countryCodeRegexp = regexp.MustCompile(`^[A-Z]{2,3}$`)
+
+// PackageReleaseListRequest 发布配置列表
+type PackageReleaseListRequest struct {
+	AppID    string `form:"app_id" json:"app_id" binding:"required"`
+	Platform string `form:"platform" json:"platform"`
+}
+
+// PackageReleasePublishRequest 发布安装包（全量或灰度）
+type PackageReleasePublishRequest struct {
+	AppID          string                   `json:"app_id" binding:"required"`
+	Platform       constants.Platform       `json:"platform" binding:"required"`
+	Channel        constants.ReleaseChannel `json:"channel"`
+	CountryCode    string                   `json:"country_code"` // 为空表示所有国家
+	PackageID      uint64                   `json:"package_id" binding:"required"`
+	RolloutPercent int                      `json:"rollout_percent"` // 1-100，100 表示全量
+	Remark         string                   `json:"remark"`
+}
+
+func (r *PackageReleasePublishRequest) Validate() error {
+	if strings.TrimSpace(r.AppID) == "" {
+		return errors.New("应用ID不能为空")
+	}
+	if !r.Platform.IsValid() || r.Platform == constants.PlatformUnknown {
+		return errors.New("无效的平台类型")
+	}
+	if r.Channel == "" {
+		r.Channel = constants.ReleaseChannelStable
+	}
+	if !r.Channel.IsValid() {
+		return errors.New("无效的发布渠道")
+	}
+	r.CountryCode = strings.ToUpper(strings.TrimSpace(r.CountryCode))
+	if r.CountryCode != "" && !countryCodeRegexp.MatchString(r.CountryCode) {
+		return errors.New("国家代码格式不正确")
+	}
+	if r.RolloutPercent == 0 {
+		r.RolloutPercent = 100
+	}
+	if r.RolloutPercent < 1 || r.RolloutPercent > 100 {
+		return errors.New("灰度比例必须在1-100之间")
+	}
+	if len(r.Remark) > 255 {
+		return errors.New("备注不能超过255个字符")
+	}
+	return nil
+}
+
+// PackageReleaseRolloutRequest 调整灰度比例，100 表示灰度转全量，0 表示终止灰度
+type PackageReleaseRolloutRequest struct {
+	ID             uint64 `json:"id" binding:"required"`
+	RolloutPercent *int   `json:"rollout_percent" binding:"required"`
+	Remark         string `json:"remark"`
+}
+
+func (r *PackageReleaseRolloutRequest) Validate() error {
+	if *r.RolloutPercent < 0 || *r.RolloutPercent > 100 {
+		return errors.New("灰度比例必须在0-100之间")
+	}
+	if len(r.Remark) > 255 {
+		return errors.New("备注不能超过255个字符")
+	}
+	return nil
+}
+
+// PackageReleaseRollbackRequest 回滚，PackageID 为空时回滚到上一个全量版本
+type PackageReleaseRollbackRequest struct {
+	ID        uint64  `json:"id" binding:"required"`
+	PackageID *uint64 `json:"package_id"`
+	Remark    string  `json:"remark"`
+}
+
+// PackageReleaseHistoryRequest 发布历史
+type PackageReleaseHistoryRequest struct {
+	request.PageInfo
+	ReleaseID uint64 `form:"release_id" json:"release_id" binding:"required"`
+}
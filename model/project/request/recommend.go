@@ -0,0 +1,12 @@
+package request
+
+// AlsoDownloadedRequest 下载了该应用的用户还下载了
+type AlsoDownloadedRequest struct {
+	AppID uint64 `json:"appId" form:"appId" binding:"required"`
+	Limit int    `json:"limit" form:"limit"` // 默认10，最多50
+}
+
+// ForYouRequest 个性化推荐
+type ForYouRequest struct {
+	Limit int `json:"limit" form:"limit"` // 默认10，最多50
+}
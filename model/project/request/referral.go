@@ -0,0 +1,20 @@
+package request
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/model/common/request"
+)
+
+// RebindReferrerRequest 管理员修改用户的推荐人，ReferrerID 为 0 表示解除推荐关系
+type RebindReferrerRequest struct {
+	UserID     uint   `json:"userId" binding:"required"`
+	ReferrerID uint   `json:"referrerId"`
+	Reason     string `json:"reason" binding:"required,max=200"`
+}
+
+// ReferralBindLogRequest 推荐关系变更记录，UserID 同时匹配被修改用户和新旧推荐人
+type ReferralBindLogRequest struct {
+	request.PageInfo
+	UserID uint                         `json:"user_id" form:"user_id"`
+	Source constants.ReferralBindSource `json:"source" form:"source"`
+}
@@ -0,0 +1,69 @@
+package request
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/model/common/request"
+	"errors"
+	"time"
+)
+
+// SearchSuggestRequest 搜索联想
+type SearchSuggestRequest struct {
+	Keyword string `json:"keyword" form:"keyword" binding:"required,max=100"`
+	Limit   int    `json:"limit" form:"limit"` // 每类返回数量，默认8，最多20
+}
+
+// TrendingSearchRequest 热搜词
+type TrendingSearchRequest struct {
+	Limit int `json:"limit" form:"limit"` // 默认10，最多50
+}
+
+// SearchTermRuleCreateRequest 新增热搜词规则
+type SearchTermRuleCreateRequest struct {
+	Term      string                     `json:"term" binding:"required,max=100"`
+	Action    constants.SearchTermAction `json:"action" binding:"required,oneof=pin block"`
+	SortOrder int                        `json:"sort_order"`
+	Remark    string                     `json:"remark" binding:"max=255"`
+}
+
+// SearchTermRuleUpdateRequest 编辑热搜词规则
+type SearchTermRuleUpdateRequest struct {
+	ID uint `json:"id" binding:"required"`
+	SearchTermRuleCreateRequest
+}
+
+// SearchTermRuleListRequest 热搜词规则列表
+type SearchTermRuleListRequest struct {
+	request.PageInfo
+	Action constants.SearchTermAction `json:"action" form:"action"`
+}
+
+// ZeroResultQueryRequest 无结果搜索词统计
+type ZeroResultQueryRequest struct {
+	request.PageInfo
+	StartDate string `json:"start_date" form:"start_date"` // 开始日期 2006-01-02，默认最近7天
+	EndDate   string `json:"end_date" form:"end_date"`     // 结束日期 2006-01-02，包含当天
+}
+
+// Range 解析查询区间 [start, end)
+func (r *ZeroResultQueryRequest) Range() (start, end time.Time, err error) {
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	end = today.AddDate(0, 0, 1)
+	if r.EndDate != "" {
+		if end, err = time.ParseInLocation("2006-01-02", r.EndDate, time.Local); err != nil {
+			return start, end, errors.New("结束日期格式不正确")
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+	start = end.AddDate(0, 0, -7)
+	if r.StartDate != "" {
+		if start, err = time.ParseInLocation("2006-01-02", r.StartDate, time.Local); err != nil {
+			return start, end, errors.New("开始日期格式不正确")
+		}
+	}
+	if !end.After(start) {
+		return start, end, errors.New("开始日期不能晚于结束日期")
+	}
+	return start, end, nil
+}
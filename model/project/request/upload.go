@@ -1,7 +1,91 @@
 package request
 
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
 type UploadSignatureRequest struct {
 	FileName string `json:"fileName" binding:"required"`
 	FileType string `json:"fileType"`
 	FileSize int64  `json:"fileSize"`
 }
+
+// UploadSessionInitRequest 初始化分片上传会话
+type UploadSessionInitRequest struct {
+	FileName   string  `json:"fileName" binding:"required"`
+	FileSize   int64   `json:"fileSize" binding:"required"`
+	FileSha256 string  `json:"fileSha256" binding:"required"`
+	ChunkSize  int64   `json:"chunkSize" binding:"required"`
+	PackageID  *uint64 `json:"packageId"` // 完成后挂载到的安装包，可在完成时再指定
+}
+
+func (r *UploadSessionInitRequest) Validate() error {
+	r.FileName = strings.TrimSpace(r.FileName)
+	if r.FileName == "" || len(r.FileName) > 255 {
+		return errors.New("文件名长度必须在1-255个字符之间")
+	}
+	if strings.Contains(r.FileName, "..") || strings.ContainsAny(r.FileName, `/\`) {
+		return errors.New("文件名不合法")
+	}
+	if r.FileSize <= 0 {
+		return errors.New("文件大小必须大于0")
+	}
+	maxSize := int64(2 * 1024 * 1024 * 1024) // 2GB
+	if r.FileSize > maxSize {
+		return errors.New("文件大小不能超过2GB")
+	}
+	if !isSha256Hex(r.FileSha256) {
+		return errors.New("文件SHA-256格式不正确")
+	}
+	r.FileSha256 = strings.ToLower(r.FileSha256)
+	if r.ChunkSize <= 0 {
+		return errors.New("分片大小必须大于0")
+	}
+	maxChunk := int64(100 * 1024 * 1024) // 100MB
+	if r.ChunkSize > maxChunk {
+		return errors.New("分片大小不能超过100MB")
+	}
+	if (r.FileSize+r.ChunkSize-1)/r.ChunkSize > 10000 {
+		return errors.New("分片数量不能超过10000")
+	}
+	return nil
+}
+
+// UploadSessionChunkRequest 上传分片（multipart/form-data，文件字段为 file）
+type UploadSessionChunkRequest struct {
+	SessionID   string `form:"sessionId" binding:"required"`
+	ChunkNumber *int   `form:"chunkNumber" binding:"required"` // 从0开始
+	ChunkSha256 string `form:"chunkSha256" binding:"required"`
+}
+
+func (r *UploadSessionChunkRequest) Validate() error {
+	if *r.ChunkNumber < 0 {
+		return errors.New("分片序号不能为负数")
+	}
+	if !isSha256Hex(r.ChunkSha256) {
+		return errors.New("分片SHA-256格式不正确")
+	}
+	r.ChunkSha256 = strings.ToLower(r.ChunkSha256)
+	return nil
+}
+
+// UploadSessionQueryRequest 查询会话
+type UploadSessionQueryRequest struct {
+	SessionID string `form:"sessionId" json:"sessionId" binding:"required"`
+}
+
+// UploadSessionCompleteRequest 完成上传
+type UploadSessionCompleteRequest struct {
+	SessionID string  `json:"sessionId" binding:"required"`
+	PackageID *uint64 `json:"packageId"`
+}
+
+func isSha256Hex(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -73,8 +73,9 @@ type GetUserOrdersRequest struct {
 }
 
 type ChangeUserPasswordRequest struct {
-	OldPassword string `json:"oldPassword" binding:"required"`
-	NewPassword string `json:"newPassword" binding:"required"`
+	OldPassword   string `json:"oldPassword" binding:"required"`
+	NewPassword   string `json:"newPassword" binding:"required"`
+	TwoFactorCode string `json:"twoFactorCode"` // 开启双因子认证时必填
 }
 
 func (c ChangeUserPasswordRequest) Validate() error {
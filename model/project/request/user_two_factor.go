@@ -0,0 +1,33 @@
+package request
+
+import "ApkAdmin/model/common/request"
+
+// TwoFactorCodeRequest 提交双因子验证码，可以是验证器中的6位数字或恢复码
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// Login2FARequest 登录第二步
+type Login2FARequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// ChangeEmailRequest 修改邮箱，需要登录密码，开启双因子认证时还需要验证码
+type ChangeEmailRequest struct {
+	Email         string `json:"email" binding:"required"`
+	Password      string `json:"password" binding:"required"`
+	TwoFactorCode string `json:"twoFactorCode"`
+}
+
+// AdminResetTwoFactorRequest 管理员重置用户的双因子认证
+type AdminResetTwoFactorRequest struct {
+	ID     uint   `json:"id" binding:"required"`
+	Reason string `json:"reason" binding:"required,max=200"`
+}
+
+// TwoFactorLogRequest 双因子认证记录
+type TwoFactorLogRequest struct {
+	request.PageInfo
+	UserID uint `json:"user_id" form:"user_id"`
+}
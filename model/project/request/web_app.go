@@ -1,6 +1,7 @@
 package request
 
 import (
+	"ApkAdmin/constants"
 	"ApkAdmin/utils"
 	"errors"
 )
@@ -42,6 +43,8 @@ func (r FilterAccountAppRequest) Validate() error {
 
 type SearchAppRequest struct {
 	PageInfo
-	Keyword  string `json:"keyword" form:"keyword" binding:"required"`
-	SortType string `json:"sort_type" form:"sortType" binding:"required,oneof=hot new rating downloads"`
+	Keyword    string             `json:"keyword" form:"keyword" binding:"required,max=100"`
+	SortType   string             `json:"sort_type" form:"sortType" binding:"omitempty,oneof=relevance hot new rating downloads"` // 为空或 relevance 按相关度排序
+	CategoryId uint               `json:"category_id" form:"categoryId"`                                                          // 按一级或二级分类过滤
+	Platform   constants.Platform `json:"platform" form:"platform"`                                                               // 按有已发布安装包的平台过滤
 }
@@ -49,3 +49,21 @@ func (r BaseRegisterRequest) Validate() error {
 
 	return nil
 }
+
+// VerifyEmailRequest 邮箱验证链接中的令牌
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ForgotPasswordRequest 找回密码，向注册邮箱发送重置链接
+type ForgotPasswordRequest struct {
+	Email      string `json:"email" binding:"required"`
+	Captcha    string `json:"captcha" binding:"required"`
+	CaptchaKey string `json:"captchaKey" binding:"required"`
+}
+
+// ResetPasswordByTokenRequest 通过重置链接设置新密码
+type ResetPasswordByTokenRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
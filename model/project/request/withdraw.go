@@ -19,6 +19,7 @@ type UserWithdrawRequest struct {
 	BankName      string  `json:"bankName"`      // 开户银行
 	BankAccount   string  `json:"bankAccount"`   // 银行卡号
 	BankHolder    string  `json:"bankHolder"`    // 持卡人姓名
+	TwoFactorCode string  `json:"twoFactorCode"` // 开启双因子认证时必填
 }
 
 // Validate 验证提现请求参数
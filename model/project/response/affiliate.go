@@ -0,0 +1,46 @@
+package response
+
+import (
+	"ApkAdmin/model/project"
+	"time"
+)
+
+// AffiliateDownline 直属下级，用户名和邮箱脱敏后返回
+type AffiliateDownline struct {
+	Username    string     `json:"username"`
+	Email       string     `json:"email"`
+	JoinedAt    time.Time  `json:"joinedAt"`
+	TotalSpent  float64    `json:"totalSpent"`
+	TotalOrders uint       `json:"totalOrders"`
+	LastOrderAt *time.Time `json:"lastOrderAt"`
+	Active      bool       `json:"active"` // 近30天有消费
+}
+
+// AffiliateTrendPoint 推广数据按天的趋势
+type AffiliateTrendPoint struct {
+	Date        string  `json:"date"`
+	NewMembers  int64   `json:"newMembers"`
+	Orders      int64   `json:"orders"`
+	OrderAmount float64 `json:"orderAmount"`
+	Commission  float64 `json:"commission"`
+}
+
+// AffiliateTierProgress 当前等级和升级进度
+type AffiliateTierProgress struct {
+	TotalMembers  int                     `json:"totalMembers"`
+	ActiveMembers int                     `json:"activeMembers"`
+	CurrentTier   *project.CommissionTier `json:"currentTier"`
+	NextTier      *project.CommissionTier `json:"nextTier"`      // 已是最高等级时为空
+	MembersNeeded int                     `json:"membersNeeded"` // 升到下一等级还需要的直属下级人数
+	Progress      float64                 `json:"progress"`      // 从当前等级到下一等级的进度，0-100
+}
+
+// AffiliateLinkStat 推广链接按渠道的效果
+type AffiliateLinkStat struct {
+	Channel        string  `json:"channel"`
+	Clicks         int64   `json:"clicks"`
+	Registrations  int64   `json:"registrations"`
+	Orders         int64   `json:"orders"`
+	OrderAmount    float64 `json:"orderAmount"`
+	ConversionRate float64 `json:"conversionRate"` // 注册人数 / 点击次数，百分比
+}
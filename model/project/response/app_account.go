@@ -36,3 +36,11 @@ type BatchUpdateStatusResponse struct {
 	FailCount    int          `json:"fail_count"`
 	FailDetails  []FailDetail `json:"fail_details"`
 }
+
+// RevealedAccountResp 明文账号详情，只由查看接口返回
+type RevealedAccountResp struct {
+	AccountID     uint   `json:"account_id"`
+	AccountNo     string `json:"account_no"`
+	AccountDetail string `json:"account_detail"`
+	ExtraInfo     string `json:"extra_info"`
+}
@@ -0,0 +1,8 @@
+package response
+
+// AccountCheckResult 单个账号的检查结果
+type AccountCheckResult struct {
+	AccountID uint   `json:"account_id"`
+	Verdict   string `json:"verdict"` // ok、expired、risk、unknown
+	Reason    string `json:"reason"`
+}
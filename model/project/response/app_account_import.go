@@ -0,0 +1,22 @@
+package response
+
+// AccountImportRow 导入文件中一行的校验结果
+type AccountImportRow struct {
+	Row     int    `json:"row"` // 文件中的行号，表头为第1行
+	AppID   string `json:"app_id"`
+	Preview string `json:"preview,omitempty"` // 脱敏后的账号详情
+	Result  string `json:"result"`            // ok、duplicate、error
+	Message string `json:"message,omitempty"`
+}
+
+// AccountImportResult 导入结果
+type AccountImportResult struct {
+	JobID         uint               `json:"job_id"`
+	DryRun        bool               `json:"dry_run"`
+	TotalRows     int                `json:"total_rows"`
+	ValidRows     int                `json:"valid_rows"`
+	DuplicateRows int                `json:"duplicate_rows"`
+	ErrorRows     int                `json:"error_rows"`
+	ImportedRows  int                `json:"imported_rows"`
+	Rows          []AccountImportRow `json:"rows"`
+}
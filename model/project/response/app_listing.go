@@ -0,0 +1,34 @@
+package response
+
+import (
+	"ApkAdmin/model/project"
+	"time"
+)
+
+// AppStoreDetail 前台应用详情，名称、描述、截图和更新说明按访问者语言选择
+type AppStoreDetail struct {
+	ID               uint64                  `json:"id"`
+	AppID            string                  `json:"appId"`
+	AppName          string                  `json:"appName"`
+	ShortDescription string                  `json:"shortDescription"`
+	Description      string                  `json:"description"`
+	AppIcon          *string                 `json:"appIcon"`
+	Rating           *float64                `json:"rating"`
+	DownloadCount    uint                    `json:"downloadCount"`
+	IsFree           *bool                   `json:"isFree"`
+	CategoryID       *uint                   `json:"categoryId"`
+	Language         string                  `json:"language"`  // 实际使用的语言，为空表示默认信息
+	Languages        []string                `json:"languages"` // 已配置的本地化语言
+	Screenshots      []project.AppScreenshot `json:"screenshots"`
+	Packages         []AppStorePackage       `json:"packages"`
+}
+
+// AppStorePackage 前台展示的已发布安装包
+type AppStorePackage struct {
+	ID          uint64     `json:"id"`
+	Platform    string     `json:"platform"`
+	VersionName string     `json:"versionName"`
+	PackageSize int64      `json:"packageSize"`
+	PublishedAt *time.Time `json:"publishedAt"`
+	Changelog   string     `json:"changelog"`
+}
@@ -0,0 +1,23 @@
+package response
+
+import "time"
+
+// AppReviewItem 前台展示的评价
+type AppReviewItem struct {
+	ID          uint64     `json:"id"`
+	UserName    string     `json:"userName"`
+	Rating      int        `json:"rating"`
+	Content     string     `json:"content"`
+	VersionName string     `json:"versionName"`
+	Reply       string     `json:"reply"`
+	RepliedAt   *time.Time `json:"repliedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// AppRatingSummary 应用评分汇总，Distribution 依次为1到5星的人数
+type AppRatingSummary struct {
+	Average      float64  `json:"average"`
+	Count        int64    `json:"count"`
+	Distribution [5]int64 `json:"distribution"`
+}
@@ -0,0 +1,21 @@
+package response
+
+import "time"
+
+// AppRelease 订阅源中的新版本
+type AppRelease struct {
+	AppStorePackage
+	ApplicationID uint64  `json:"applicationId"`
+	AppName       string  `json:"appName"`
+	AppIcon       *string `json:"appIcon"`
+}
+
+// AppFollowItem 我关注的应用
+type AppFollowItem struct {
+	AppID         uint64     `json:"appId"`
+	AppName       string     `json:"appName"`
+	AppIcon       *string    `json:"appIcon"`
+	LatestVersion string     `json:"latestVersion"`
+	LatestAt      *time.Time `json:"latestAt"`
+	FollowedAt    time.Time  `json:"followedAt"`
+}
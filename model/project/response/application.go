@@ -14,4 +14,20 @@ type AccountAppResp struct {
 	Rating       *float64        `json:"rating" `       //评分
 	Stock        int             `json:"stock"`         // 库存数量
 	SalesCount   int64           `json:"sales_count" gorm:"default:0;comment:总售卖次数"`
+	WarrantyDays int             `json:"warranty_days"` // 售后保修天数，0表示不提供售后
+}
+
+// SearchFacet 搜索结果按分类或平台统计的数量
+type SearchFacet struct {
+	Value string `json:"value"` // 分类ID或平台
+	Name  string `json:"name"`  // 分类名称或平台
+	Count int64  `json:"count"`
+}
+
+// AppSearchResp 应用搜索结果
+type AppSearchResp struct {
+	List       interface{}   `json:"list"`
+	Total      int64         `json:"total"`
+	Categories []SearchFacet `json:"categories"`
+	Platforms  []SearchFacet `json:"platforms"`
 }
@@ -9,3 +9,22 @@ type LoginResponse struct {
 	Token     string       `json:"token"`
 	ExpiresAt int64        `json:"expiresAt"`
 }
+
+// TwoFactorChallengeResponse 密码正确但需要双因子验证，用 ChallengeToken 调用登录第二步
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeToken    string `json:"challengeToken"`
+	ExpiresAt         int64  `json:"expiresAt"`
+}
+
+// TwoFactorSetupResponse 开启双因子认证的密钥，QRCode 为 PNG 图片的 data URI
+type TwoFactorSetupResponse struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"`
+	QRCode string `json:"qrcode"`
+}
+
+// RecoveryCodesResponse 恢复码只在生成时返回一次
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
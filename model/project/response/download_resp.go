@@ -1,8 +1,29 @@
 package response
 
 type DownloadResp struct {
-	CanDownload    bool   `json:"can_download"`    // 是否可以下载
-	PackageUrl     string `json:"package_url"`     // 安装包地址
-	PackageDetail  string `json:"package_detail"`  //安装包详情
-	DownloadReason string `json:"download_reason"` //是否可以下载原因
+	CanDownload    bool   `json:"can_download"`             // 是否可以下载
+	PackageUrl     string `json:"package_url"`              // 安装包地址
+	PackageDetail  string `json:"package_detail"`           //安装包详情
+	DownloadReason string `json:"download_reason"`          //是否可以下载原因
+	PackageSha256  string `json:"package_sha256,omitempty"` // 安装包SHA-256，供客户端校验
+	PackageMd5     string `json:"package_md5,omitempty"`    // 安装包MD5
+}
+
+// CheckUpdateResp 检查更新结果
+type CheckUpdateResp struct {
+	HasUpdate      bool   `json:"has_update"`               // 是否有新版本
+	VersionName    string `json:"version_name"`             // 新版本名称
+	VersionCode    int    `json:"version_code"`             // 新版本号
+	CanDownload    bool   `json:"can_download"`             // 是否可以下载
+	DownloadReason string `json:"download_reason"`          // 不可下载原因
+	UpdateType     string `json:"update_type"`              // patch 差分更新 / full 全量更新
+	PackageUrl     string `json:"package_url"`              // 全量安装包地址，差分合成失败时使用
+	PackageSize    int64  `json:"package_size"`             // 全量安装包大小
+	PackageSha256  string `json:"package_sha256,omitempty"` // 全量安装包SHA-256
+	PackageMd5     string `json:"package_md5,omitempty"`    // 全量安装包MD5
+	PatchUrl       string `json:"patch_url,omitempty"`
+	PatchSize      int64  `json:"patch_size,omitempty"`
+	PatchSha256    string `json:"patch_sha256,omitempty"`  // 补丁文件SHA-256
+	PatchFormat    string `json:"patch_format,omitempty"`  // 补丁格式
+	OutputSha256   string `json:"output_sha256,omitempty"` // 合成后安装包应有的SHA-256
 }
@@ -0,0 +1,23 @@
+package response
+
+// DownloadTrendPoint 下载趋势中的一个时间点
+type DownloadTrendPoint struct {
+	Time         string `json:"time"`
+	TotalCount   int64  `json:"totalCount"`
+	SuccessCount int64  `json:"successCount"`
+	FailCount    int64  `json:"failCount"`
+}
+
+// DownloadFailReasonStat 下载失败原因分布
+type DownloadFailReasonStat struct {
+	FailReason string `json:"failReason"`
+	TotalCount int64  `json:"totalCount"`
+}
+
+// DownloaderSummary 时间段内下载次数与去重下载用户数
+type DownloaderSummary struct {
+	TotalAttempts     int64   `json:"totalAttempts"`     // 下载请求次数（含失败）
+	TotalDownloads    int64   `json:"totalDownloads"`    // 成功下载次数
+	UniqueDownloaders int64   `json:"uniqueDownloaders"` // 成功下载的去重用户数
+	DownloadsPerUser  float64 `json:"downloadsPerUser"`  // 人均下载次数
+}
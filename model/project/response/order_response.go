@@ -1,5 +1,19 @@
 package response
 
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/model/project"
+	"time"
+)
+
 // AppAccountOrderDetailResp 应用账号订单详情的response
 type AppAccountOrderDetailResp struct {
+	AccountID     uint                           `json:"account_id"`
+	AccountNo     string                         `json:"account_no"`
+	AccountStatus constants.AppAccountStatus     `json:"account_status"`
+	Order         *project.Order                 `json:"order"`          // 账号所在订单，售后更换出去的账号为原订单
+	InOrder       bool                           `json:"in_order"`       // 账号当前是否仍在订单的账号列表中
+	WarrantyDays  int                            `json:"warranty_days"`  // 应用的售后保修天数
+	WarrantyUntil *time.Time                     `json:"warranty_until"` // 售后截止时间，不提供售后时为空
+	Claims        []project.AccountWarrantyClaim `json:"claims"`         // 该订单的售后申请及处理记录
 }
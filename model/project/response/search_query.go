@@ -0,0 +1,38 @@
+package response
+
+import "time"
+
+// SearchSuggestion 搜索联想结果
+type SearchSuggestion struct {
+	Apps       []SuggestApp      `json:"apps"`
+	Categories []SuggestCategory `json:"categories"`
+}
+
+// SuggestApp 联想的应用
+type SuggestApp struct {
+	ID      uint64  `json:"id"`
+	AppName string  `json:"app_name"`
+	AppIcon *string `json:"app_icon"`
+}
+
+// SuggestCategory 联想的分类
+type SuggestCategory struct {
+	ID           uint    `json:"id"`
+	CategoryName string  `json:"category_name"`
+	EmojiIcon    *string `json:"emoji_icon"`
+}
+
+// TrendingSearch 热搜词
+type TrendingSearch struct {
+	Term   string `json:"term"`
+	Score  int64  `json:"score"`  // 窗口内的搜索人次，置顶词为0
+	Pinned bool   `json:"pinned"` // 是否运营置顶
+}
+
+// ZeroResultQuery 无结果搜索词
+type ZeroResultQuery struct {
+	Query          string    `json:"query"`
+	SearchCount    int64     `json:"search_count"` // 搜索人次（同一访客每小时计一次）
+	Visitors       int64     `json:"visitors"`     // 去重访客数
+	LastSearchedAt time.Time `json:"last_searched_at"`
+}
@@ -0,0 +1,22 @@
+package response
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// UploadSessionResp 分片上传会话状态
+type UploadSessionResp struct {
+	SessionID      string                        `json:"sessionId"`
+	FileName       string                        `json:"fileName"`
+	FileSize       int64                         `json:"fileSize"`
+	ChunkSize      int64                         `json:"chunkSize"`
+	ChunkTotal     int                           `json:"chunkTotal"`
+	Status         constants.UploadSessionStatus `json:"status"`
+	UploadedChunks int                           `json:"uploadedChunks"`
+	MissingChunks  []int                         `json:"missingChunks"`
+	PackageID      *uint64                       `json:"packageId"`
+	ObjectName     *string                       `json:"objectName"`
+	FileURL        *string                       `json:"fileUrl"`
+	ExpiresAt      time.Time                     `json:"expiresAt"`
+}
@@ -0,0 +1,37 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// SearchQueryLog 搜索词记录，同一访客同一小时内相同的搜索词只记录一次
+type SearchQueryLog struct {
+	ID          uint64    `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	VisitorKey  string    `json:"visitor_key" gorm:"type:varchar(64);not null;uniqueIndex:uk_search_visitor_hour,priority:1;comment:访客标识(u:用户ID 或 ip:IP)"`
+	HourBucket  time.Time `json:"hour_bucket" gorm:"not null;uniqueIndex:uk_search_visitor_hour,priority:2;index:idx_search_hour;comment:所在小时"`
+	Query       string    `json:"query" gorm:"type:varchar(100);not null;uniqueIndex:uk_search_visitor_hour,priority:3;comment:归一化后的搜索词"`
+	UserID      uint      `json:"user_id" gorm:"not null;default:0;comment:用户ID(未登录为0)"`
+	CountryCode string    `json:"country_code" gorm:"type:varchar(10);not null;default:'';comment:国家代码"`
+	ResultCount int64     `json:"result_count" gorm:"not null;default:0;comment:结果数量"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime;comment:创建时间"`
+}
+
+func (SearchQueryLog) TableName() string {
+	return "search_query_logs"
+}
+
+// SearchTermRule 热搜词运营规则：置顶或屏蔽
+type SearchTermRule struct {
+	ID        uint                       `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	Term      string                     `json:"term" gorm:"type:varchar(100);not null;uniqueIndex:uk_search_term;comment:归一化后的搜索词"`
+	Action    constants.SearchTermAction `json:"action" gorm:"type:varchar(10);not null;comment:pin 置顶 block 屏蔽"`
+	SortOrder int                        `json:"sort_order" gorm:"default:0;comment:置顶排序，越大越靠前"`
+	Remark    string                     `json:"remark" gorm:"type:varchar(255);comment:备注"`
+	CreatedAt time.Time                  `json:"created_at" gorm:"autoCreateTime;comment:创建时间"`
+	UpdatedAt time.Time                  `json:"updated_at" gorm:"autoUpdateTime;comment:更新时间"`
+}
+
+func (SearchTermRule) TableName() string {
+	return "search_term_rules"
+}
@@ -0,0 +1,24 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// TeamTierLog 推广等级升降记录
+type TeamTierLog struct {
+	ID           uint64                     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	UserID       uint                       `json:"user_id" gorm:"not null;index:idx_team_tier_log_user;comment:推广员ID"`
+	FromTierID   *int                       `json:"from_tier_id" gorm:"comment:原等级ID"`
+	FromTierName string                     `json:"from_tier_name" gorm:"type:varchar(50);not null;default:'';comment:原等级名称"`
+	ToTierID     *int                       `json:"to_tier_id" gorm:"comment:新等级ID"`
+	ToTierName   string                     `json:"to_tier_name" gorm:"type:varchar(50);not null;default:'';comment:新等级名称"`
+	Upgrade      bool                       `json:"upgrade" gorm:"not null;comment:是否升级，否为降级"`
+	TotalMembers int                        `json:"total_members" gorm:"not null;default:0;comment:变化时的直属下级人数"`
+	Source       constants.TierChangeSource `json:"source" gorm:"type:varchar(20);not null;comment:触发来源"`
+	CreatedAt    time.Time                  `json:"created_at" gorm:"comment:创建时间"`
+}
+
+func (TeamTierLog) TableName() string {
+	return "team_tier_logs"
+}
@@ -0,0 +1,67 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// UploadSession 分片上传会话表
+type UploadSession struct {
+	ID          uint64                        `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	SessionID   string                        `json:"session_id" gorm:"type:varchar(36);not null;uniqueIndex:uk_session_id;comment:会话ID"`
+	FileName    string                        `json:"file_name" gorm:"type:varchar(255);not null;comment:文件名"`
+	FileSize    int64                         `json:"file_size" gorm:"not null;comment:文件大小（字节）"`
+	FileSha256  string                        `json:"file_sha256" gorm:"type:char(64);not null;comment:整个文件的SHA-256"`
+	ChunkSize   int64                         `json:"chunk_size" gorm:"not null;comment:分片大小（字节）"`
+	ChunkTotal  int                           `json:"chunk_total" gorm:"not null;comment:分片总数"`
+	Status      constants.UploadSessionStatus `json:"status" gorm:"type:varchar(20);not null;default:uploading;index:idx_status_expires;comment:会话状态"`
+	PackageID   *uint64                       `json:"package_id" gorm:"index:idx_package_id;comment:完成后关联的安装包ID"`
+	ObjectName  *string                       `json:"object_name" gorm:"type:varchar(500);comment:OSS路径"`
+	FileURL     *string                       `json:"file_url" gorm:"type:varchar(500);comment:文件访问url"`
+	UploadID    *string                       `json:"-" gorm:"type:varchar(255);comment:对象存储分片上传ID"`
+	FailReason  *string                       `json:"fail_reason" gorm:"type:varchar(255);comment:失败原因"`
+	ExpiresAt   time.Time                     `json:"expires_at" gorm:"not null;index:idx_status_expires;comment:过期时间"`
+	CompletedAt *time.Time                    `json:"completed_at" gorm:"comment:完成时间"`
+	CreatedBy   uint                          `json:"created_by" gorm:"not null;comment:创建人ID"`
+	CreatedAt   time.Time                     `json:"created_at" gorm:"comment:创建时间"`
+	UpdatedAt   time.Time                     `json:"updated_at" gorm:"comment:更新时间"`
+
+	Chunks []UploadSessionChunk `json:"chunks,omitempty" gorm:"foreignKey:SessionID;references:SessionID"`
+}
+
+// TableName 指定表名
+func (UploadSession) TableName() string {
+	return "upload_sessions"
+}
+
+// IsExpired 会话是否已过期
+func (u *UploadSession) IsExpired() bool {
+	return time.Now().After(u.ExpiresAt)
+}
+
+// ExpectedChunkSize 计算指定分片应有的大小（最后一片可能不足 ChunkSize）
+func (u *UploadSession) ExpectedChunkSize(chunkNumber int) int64 {
+	if chunkNumber < 0 || chunkNumber >= u.ChunkTotal {
+		return 0
+	}
+	if chunkNumber == u.ChunkTotal-1 {
+		return u.FileSize - int64(u.ChunkTotal-1)*u.ChunkSize
+	}
+	return u.ChunkSize
+}
+
+// UploadSessionChunk 分片上传记录表
+type UploadSessionChunk struct {
+	ID          uint64    `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	SessionID   string    `json:"session_id" gorm:"type:varchar(36);not null;uniqueIndex:uk_session_chunk;comment:会话ID"`
+	ChunkNumber int       `json:"chunk_number" gorm:"not null;uniqueIndex:uk_session_chunk;comment:分片序号（从0开始）"`
+	ChunkSize   int64     `json:"chunk_size" gorm:"not null;comment:分片大小"`
+	ChunkSha256 string    `json:"chunk_sha256" gorm:"type:char(64);not null;comment:分片SHA-256"`
+	CreatedAt   time.Time `json:"created_at" gorm:"comment:创建时间"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"comment:更新时间"`
+}
+
+// TableName 指定表名
+func (UploadSessionChunk) TableName() string {
+	return "upload_session_chunks"
+}
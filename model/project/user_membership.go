@@ -60,7 +60,8 @@ func (u *UserMembership) IsExpired() bool {
 	return time.Now().After(*u.EndDate)
 }
 
-// CanDownload 检查是否可以下载（考虑下载限制）
+// CanDownload 检查是否可以下载（考虑下载限制），限制为 NULL 表示不限次数
+// 这里只做预检查，实际扣减以 DownloadQuotaService 的原子预占为准
 func (u *UserMembership) CanDownload(checkDaily, checkMonthly bool) bool {
 	if !u.IsActive() {
 		return false
@@ -69,20 +70,36 @@ func (u *UserMembership) CanDownload(checkDaily, checkMonthly bool) bool {
 	if u.Plan == nil {
 		return false
 	}
+	now := time.Now()
 	// 检查日下载限制
 	if checkDaily && u.Plan.DownloadLimitDaily != nil {
-		if u.DownloadUsedDaily >= uint(*u.Plan.DownloadLimitDaily) {
+		if u.UsedDaily(now) >= uint(*u.Plan.DownloadLimitDaily) {
 			return false
 		}
 	}
 	// 检查月下载限制
 	if checkMonthly && u.Plan.DownloadLimitMonthly != nil {
-		if u.DownloadUsedMonthly >= uint(*u.Plan.DownloadLimitMonthly) {
+		if u.UsedMonthly(now) >= uint(*u.Plan.DownloadLimitMonthly) {
 			return false
 		}
-		return true
 	}
-	return false
+	return true
+}
+
+// UsedDaily 返回 now 所在自然日的已用下载次数，计数属于之前的日期时视为 0
+func (u *UserMembership) UsedDaily(now time.Time) uint {
+	if u.LastResetDaily == nil || u.LastResetDaily.Format("2006-01-02") < now.Format("2006-01-02") {
+		return 0
+	}
+	return u.DownloadUsedDaily
+}
+
+// UsedMonthly 返回 now 所在自然月的已用下载次数，计数属于之前的月份时视为 0
+func (u *UserMembership) UsedMonthly(now time.Time) uint {
+	if u.LastResetMonthly == nil || u.LastResetMonthly.Format("2006-01") < now.Format("2006-01") {
+		return 0
+	}
+	return u.DownloadUsedMonthly
 }
 
 // IncrementDownloadCount 增加下载计数
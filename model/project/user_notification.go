@@ -0,0 +1,24 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// UserNotification 站内通知，同一用户同一类型同一关联对象只通知一次
+type UserNotification struct {
+	ID        uint64                     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	UserID    uint                       `json:"user_id" gorm:"not null;uniqueIndex:uk_notification_ref,priority:1;index:idx_notification_user_read,priority:1;comment:用户ID"`
+	Type      constants.NotificationType `json:"type" gorm:"type:varchar(30);not null;uniqueIndex:uk_notification_ref,priority:2;comment:通知类型"`
+	RefID     uint64                     `json:"ref_id" gorm:"not null;default:0;uniqueIndex:uk_notification_ref,priority:3;comment:关联对象ID，如安装包ID"`
+	AppID     uint64                     `json:"app_id" gorm:"not null;default:0;comment:关联应用ID"`
+	Title     string                     `json:"title" gorm:"type:varchar(200);not null;comment:标题"`
+	Content   string                     `json:"content" gorm:"type:varchar(1000);not null;default:'';comment:内容"`
+	IsRead    bool                       `json:"is_read" gorm:"not null;default:0;index:idx_notification_user_read,priority:2;comment:是否已读"`
+	ReadAt    *time.Time                 `json:"read_at" gorm:"comment:阅读时间"`
+	CreatedAt time.Time                  `json:"created_at" gorm:"autoCreateTime;comment:创建时间"`
+}
+
+func (UserNotification) TableName() string {
+	return "user_notifications"
+}
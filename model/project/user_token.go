@@ -0,0 +1,38 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// UserEmailToken 邮件中发出的一次性令牌，只保存 Nonce 摘要，使用后写入 UsedAt
+type UserEmailToken struct {
+	ID        uint64                     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	UserID    uint                       `json:"user_id" gorm:"not null;index:idx_email_token_user,priority:1;comment:用户ID"`
+	Purpose   constants.UserTokenPurpose `json:"purpose" gorm:"type:varchar(20);not null;index:idx_email_token_user,priority:2;comment:用途 email_verify/password_reset"`
+	Email     string                     `json:"email" gorm:"type:varchar(100);not null;comment:发送时的邮箱"`
+	NonceHash string                     `json:"-" gorm:"type:char(64);not null;uniqueIndex:uk_email_token_nonce;comment:Nonce摘要"`
+	RequestIP string                     `json:"request_ip" gorm:"type:varchar(45);not null;default:'';comment:申请IP"`
+	ExpiresAt time.Time                  `json:"expires_at" gorm:"not null;comment:过期时间"`
+	UsedAt    *time.Time                 `json:"used_at" gorm:"comment:使用时间"`
+	CreatedAt time.Time                  `json:"created_at" gorm:"comment:创建时间"`
+}
+
+func (UserEmailToken) TableName() string {
+	return "user_email_tokens"
+}
+
+// UserSession 前台签发的登录令牌，重置密码时把未过期的令牌加入 JWT 黑名单
+type UserSession struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	UserID    uint      `json:"user_id" gorm:"not null;index:idx_user_session_user;comment:用户ID"`
+	Token     string    `json:"-" gorm:"type:text;not null;comment:JWT"`
+	IP        string    `json:"ip" gorm:"type:varchar(45);not null;default:'';comment:登录IP"`
+	UserAgent string    `json:"user_agent" gorm:"type:varchar(255);not null;default:'';comment:User-Agent"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index:idx_user_session_expires;comment:过期时间"`
+	CreatedAt time.Time `json:"created_at" gorm:"comment:创建时间"`
+}
+
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
@@ -0,0 +1,35 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"time"
+)
+
+// UserRecoveryCode 双因子认证恢复码，只保存摘要，每个只能使用一次
+type UserRecoveryCode struct {
+	ID        uint64     `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	UserID    uint       `json:"user_id" gorm:"not null;index:idx_recovery_code_user;comment:用户ID"`
+	CodeHash  string     `json:"-" gorm:"type:char(64);not null;comment:恢复码摘要"`
+	UsedAt    *time.Time `json:"used_at" gorm:"comment:使用时间"`
+	CreatedAt time.Time  `json:"created_at" gorm:"comment:创建时间"`
+}
+
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
+
+// UserTwoFactorLog 双因子认证的开启、关闭、恢复码使用和管理员重置记录
+type UserTwoFactorLog struct {
+	ID         uint64                    `json:"id" gorm:"primaryKey;autoIncrement;comment:主键ID"`
+	UserID     uint                      `json:"user_id" gorm:"not null;index:idx_two_factor_log_user;comment:用户ID"`
+	Action     constants.TwoFactorAction `json:"action" gorm:"type:varchar(20);not null;comment:操作"`
+	OperatorID uint                      `json:"operator_id" gorm:"not null;default:0;comment:管理员ID，用户本人操作为0"`
+	Operator   string                    `json:"operator" gorm:"type:varchar(100);not null;default:'';comment:管理员名称"`
+	IP         string                    `json:"ip" gorm:"type:varchar(45);not null;default:'';comment:请求IP"`
+	Note       string                    `json:"note" gorm:"type:varchar(200);not null;default:'';comment:说明"`
+	CreatedAt  time.Time                 `json:"created_at" gorm:"comment:时间"`
+}
+
+func (UserTwoFactorLog) TableName() string {
+	return "user_two_factor_logs"
+}
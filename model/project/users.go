@@ -30,7 +30,10 @@ type User struct {
 	EmailVerified    bool                    `json:"email_verified" gorm:"default:0;comment:邮箱是否已验证"`
 	PhoneVerified    bool                    `json:"phone_verified" gorm:"default:0;comment:手机是否已验证"`
 	TwoFactorEnabled bool                    `json:"two_factor_enabled" gorm:"default:0;comment:是否启用双因子认证"`
+	TwoFactorSecret  string                  `json:"-" gorm:"type:varchar(64);not null;default:'';comment:双因子认证密钥，开启前为待确认的密钥"`
+	TwoFactorAt      *time.Time              `json:"two_factor_at" gorm:"comment:开启双因子认证的时间"`
 	RegisterIP       *string                 `json:"register_ip" gorm:"type:varchar(45);comment:注册IP"`
+	Timezone         string                  `json:"timezone" gorm:"type:varchar(64);not null;default:'';comment:用户时区（IANA名称，为空使用服务器时区）"`
 	// 登录成功记录
 	LastLoginAt     *time.Time `json:"last_login_at" gorm:"index:idx_last_login;comment:最后登录时间"`
 	LastLoginIP     *string    `json:"last_login_ip" gorm:"type:varchar(45);comment:最后登录IP"`
@@ -0,0 +1,24 @@
+package project
+
+import (
+	"ApkAdmin/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type AccountReencryptRouter struct {
+}
+
+// InitAccountReencryptRouter 账号详情密钥轮换
+func (r *AccountReencryptRouter) InitAccountReencryptRouter(Router *gin.RouterGroup) {
+	router := Router.Group("accountReencrypt").Use(middleware.OperationRecord())
+	routerWithoutRecord := Router.Group("accountReencrypt")
+	{
+		router.POST("start", accountReencryptApi.StartJob)  // 启动重新加密任务
+		router.PUT("pause", accountReencryptApi.PauseJob)   // 暂停任务
+		router.PUT("resume", accountReencryptApi.ResumeJob) // 继续任务
+	}
+	{
+		routerWithoutRecord.GET("jobs", accountReencryptApi.GetJobList) // 任务列表
+		routerWithoutRecord.GET("job", accountReencryptApi.GetJob)      // 任务进度
+	}
+}
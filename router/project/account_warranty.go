@@ -0,0 +1,22 @@
+package project
+
+import (
+	"ApkAdmin/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type AccountWarrantyRouter struct {
+}
+
+// InitAccountWarrantyRouter 账号售后
+func (r *AccountWarrantyRouter) InitAccountWarrantyRouter(Router *gin.RouterGroup) {
+	router := Router.Group("accountWarranty").Use(middleware.OperationRecord())
+	routerWithoutRecord := Router.Group("accountWarranty")
+	{
+		router.PUT("approve", accountWarrantyApi.ApproveClaim) // 通过售后申请
+		router.PUT("reject", accountWarrantyApi.RejectClaim)   // 驳回售后申请
+	}
+	{
+		routerWithoutRecord.GET("claims", accountWarrantyApi.GetClaimList) // 售后申请列表
+	}
+}
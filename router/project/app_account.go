@@ -16,12 +16,19 @@ func (r *AppAccountRouter) InitAppAccountRouter(Router *gin.RouterGroup) {
 		router.PUT("", appAccountApi.UpdateAppAccount)                   // 更新应用账号
 		router.PUT("batchUpdateStatus", appAccountApi.BatchUpdateStatus) // 批量更新应用账号状态
 		router.DELETE("", appAccountApi.DeleteAppAccount)                // 删除应用账号
+		router.POST("import", appAccountApi.ImportAppAccounts)           // 批量导入账号（dry_run 预览）
 
 	}
 	{
-		routerWithoutRecord.GET("list", appAccountApi.ListAppAccount)        // 应用账号列表
-		routerWithoutRecord.GET("detail", appAccountApi.GetAppAccountDetail) // 应用账号详情
-		routerWithoutRecord.GET("order", appAccountApi.ViewAppAccountOrder)  // 查看售出账号关联订单
+		routerWithoutRecord.GET("list", appAccountApi.ListAppAccount)                   // 应用账号列表
+		routerWithoutRecord.GET("detail", appAccountApi.GetAppAccountDetail)            // 应用账号详情
+		routerWithoutRecord.GET("order", appAccountApi.ViewAppAccountOrder)             // 查看售出账号关联订单
+		routerWithoutRecord.GET("importTemplate", appAccountApi.DownloadImportTemplate) // 下载导入模板
+		routerWithoutRecord.GET("importJobs", appAccountApi.ListImportJobs)             // 导入记录
+		routerWithoutRecord.GET("importReport", appAccountApi.DownloadImportReport)     // 下载导入问题报告
+		routerWithoutRecord.GET("revealLogs", appAccountApi.ListRevealLogs)             // 账号明文查看记录
+		// 查看账号明文（单独的接口权限，按需分配给角色）；操作记录会保存请求体和响应，会泄露谷歌验证码和账号明文，审计只写查看记录
+		routerWithoutRecord.POST("reveal", appAccountApi.RevealAppAccount)
 
 	}
 }
@@ -0,0 +1,26 @@
+package project
+
+import (
+	"ApkAdmin/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type AppAccountCheckRouter struct {
+}
+
+// InitAppAccountCheckRouter 账号健康检查
+func (r *AppAccountCheckRouter) InitAppAccountCheckRouter(Router *gin.RouterGroup) {
+	router := Router.Group("appAccountCheck").Use(middleware.OperationRecord())
+	routerWithoutRecord := Router.Group("appAccountCheck")
+	{
+		router.POST("checker", appAccountCheckApi.SaveChecker)     // 新增或修改应用的检查器
+		router.DELETE("checker", appAccountCheckApi.DeleteChecker) // 删除检查器
+		router.POST("test", appAccountCheckApi.TestAccount)        // 试运行检查器
+		router.POST("run", appAccountCheckApi.RunChecks)           // 立即检查到期账号
+	}
+	{
+		routerWithoutRecord.GET("types", appAccountCheckApi.GetCheckerTypes)   // 检查器类型
+		routerWithoutRecord.GET("checkers", appAccountCheckApi.GetCheckerList) // 检查器列表
+		routerWithoutRecord.GET("logs", appAccountCheckApi.GetStatusLogs)      // 账号状态变更记录
+	}
+}
@@ -0,0 +1,30 @@
+package project
+
+import (
+	"ApkAdmin/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type AppListingRouter struct {
+}
+
+// InitAppListingRouter 应用截图、本地化信息和更新说明
+func (r *AppListingRouter) InitAppListingRouter(Router *gin.RouterGroup) {
+	router := Router.Group("appListing").Use(middleware.OperationRecord())
+	routerWithoutRecord := Router.Group("appListing")
+	{
+		router.POST("screenshot/upload", appListingApi.UploadScreenshots)   // 批量上传截图
+		router.PUT("screenshot/update", appListingApi.UpdateScreenshot)     // 修改截图
+		router.PUT("screenshot/reorder", appListingApi.ReorderScreenshots)  // 截图排序
+		router.DELETE("screenshot/delete", appListingApi.DeleteScreenshots) // 删除截图
+		router.POST("listing/save", appListingApi.SaveListing)              // 保存本地化信息
+		router.DELETE("listing/delete", appListingApi.DeleteListing)        // 删除本地化信息
+		router.POST("changelog/save", appListingApi.SaveChangelog)          // 保存更新说明
+		router.DELETE("changelog/delete", appListingApi.DeleteChangelog)    // 删除更新说明
+	}
+	{
+		routerWithoutRecord.GET("screenshot/list", appListingApi.GetScreenshotList) // 截图列表
+		routerWithoutRecord.GET("listing/list", appListingApi.GetListings)          // 本地化信息列表
+		routerWithoutRecord.GET("changelog/list", appListingApi.GetChangelogs)      // 更新说明列表
+	}
+}
@@ -16,6 +16,7 @@ func (r *AppPackageRouter) InitAppPackageRouter(Router *gin.RouterGroup) {
 		router.PUT("update", appPackageApi.UpdateAppPackage)                         // 编辑应用安装包
 		router.DELETE("delete", appPackageApi.DeleteAppPackage)                      // 删除应用安装包
 		router.PUT("batch-update-status", appPackageApi.BatchUpdateAppPackageStatus) // 批量更新应用安装包状态
+		router.POST("rescan", appPackageApi.RescanAppPackage)                        // 重新扫描安装包
 	}
 	{
 		routerWithoutRecord.GET("list", appPackageApi.GetAppPackageList) // 应用安装包列表
@@ -0,0 +1,24 @@
+package project
+
+import (
+	"ApkAdmin/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type AppReviewRouter struct {
+}
+
+func (r *AppReviewRouter) InitAppReviewRouter(Router *gin.RouterGroup) {
+	router := Router.Group("appReview").Use(middleware.OperationRecord())
+	routerWithoutRecord := Router.Group("appReview")
+	{
+		router.PUT("status", appReviewApi.UpdateReviewStatus)   // 显示或隐藏评价
+		router.PUT("flag", appReviewApi.FlagReviews)            // 标记评价
+		router.PUT("reply", appReviewApi.ReplyReview)           // 回复评价
+		router.DELETE("delete", appReviewApi.DeleteReviews)     // 删除评价
+		router.POST("recompute", appReviewApi.RecomputeRatings) // 重新计算评分
+	}
+	{
+		routerWithoutRecord.GET("list", appReviewApi.GetReviewList) // 评价列表
+	}
+}
@@ -16,10 +16,14 @@ func (r *CategoryRouter) InitCategoryRouter(Router *gin.RouterGroup) {
 		router.POST("category", categoryApi.AddCategory)      // 添加分类
 		router.PUT("category", categoryApi.UpdateCategory)    // 编辑分类
 		router.DELETE("category", categoryApi.DeleteCategory) // 删除分类
+		router.PUT("move", categoryApi.MoveCategories)        // 批量移动分类
+		router.PUT("reorder", categoryApi.ReorderCategories)  // 同级分类排序
+		router.POST("merge", categoryApi.MergeCategories)     // 合并分类
 	}
 	{
 		routerWithoutRecord.GET("selectCategory", categoryApi.GetSelectCategory) // 获取下拉列表分类
 		routerWithoutRecord.GET("categoryList", categoryApi.GetCategoryList)     // 分类列表
 		routerWithoutRecord.GET("category", categoryApi.FirstCategory)           // 获取单一分类信息
+		routerWithoutRecord.GET("tree", categoryApi.GetCategoryTree)             // 完整分类树
 	}
 }
@@ -0,0 +1,23 @@
+package project
+
+import (
+	"ApkAdmin/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type DownloadStatRouter struct {
+}
+
+func (r *DownloadStatRouter) InitDownloadStatRouter(Router *gin.RouterGroup) {
+	router := Router.Group("downloadStat").Use(middleware.OperationRecord())
+	routerWithoutRecord := Router.Group("downloadStat")
+	{
+		router.POST("rebuild", downloadStatApi.RebuildDownloadStat) // 重建下载汇总
+	}
+	{
+		routerWithoutRecord.GET("trend", downloadStatApi.GetDownloadTrend)             // 下载趋势
+		routerWithoutRecord.GET("topApps", downloadStatApi.GetTopDownloadApps)         // 下载排行
+		routerWithoutRecord.GET("failReasons", downloadStatApi.GetDownloadFailReasons) // 失败原因分布
+		routerWithoutRecord.GET("downloaders", downloadStatApi.GetDownloaderSummary)   // 下载用户统计
+	}
+}
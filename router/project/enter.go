@@ -17,6 +17,15 @@ type RouterGroup struct {
 	SystemAnnouncementRouter
 	CommissionTierRouter
 	UploadRoute
+	PackageReleaseRouter
+	PackagePatchRouter
+	DownloadStatRouter
+	SearchTermRouter
+	AppReviewRouter
+	AppListingRouter
+	AccountReencryptRouter
+	AppAccountCheckRouter
+	AccountWarrantyRouter
 }
 
 var (
@@ -33,4 +42,13 @@ var (
 	appAccountApi         = api.ApiGroupApp.ProjectApiGroup.AppAccountApi
 	systemAnnouncementApi = api.ApiGroupApp.ProjectApiGroup.SystemAnnouncementApi
 	commissionTierApi     = api.ApiGroupApp.ProjectApiGroup.CommissionTierApi
+	packageReleaseApi     = api.ApiGroupApp.ProjectApiGroup.PackageReleaseApi
+	packagePatchApi       = api.ApiGroupApp.ProjectApiGroup.PackagePatchApi
+	downloadStatApi       = api.ApiGroupApp.ProjectApiGroup.DownloadStatApi
+	searchTermApi         = api.ApiGroupApp.ProjectApiGroup.SearchTermApi
+	appReviewApi          = api.ApiGroupApp.ProjectApiGroup.AppReviewApi
+	appListingApi         = api.ApiGroupApp.ProjectApiGroup.AppListingApi
+	accountReencryptApi   = api.ApiGroupApp.ProjectApiGroup.AccountReencryptApi
+	appAccountCheckApi    = api.ApiGroupApp.ProjectApiGroup.AppAccountCheckApi
+	accountWarrantyApi    = api.ApiGroupApp.ProjectApiGroup.AccountWarrantyApi
 )
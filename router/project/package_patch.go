@@ -0,0 +1,20 @@
+package project
+
+import (
+	"ApkAdmin/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type PackagePatchRouter struct {
+}
+
+func (r *PackagePatchRouter) InitPackagePatchRouter(Router *gin.RouterGroup) {
+	router := Router.Group("packagePatch").Use(middleware.OperationRecord())
+	routerWithoutRecord := Router.Group("packagePatch")
+	{
+		router.POST("regenerate", packagePatchApi.RegeneratePackagePatch) // 重新生成差分补丁
+	}
+	{
+		routerWithoutRecord.GET("list", packagePatchApi.GetPackagePatchList) // 差分补丁列表
+	}
+}
@@ -0,0 +1,24 @@
+package project
+
+import (
+	"ApkAdmin/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type PackageReleaseRouter struct {
+}
+
+func (r *PackageReleaseRouter) InitPackageReleaseRouter(Router *gin.RouterGroup) {
+	router := Router.Group("packageRelease").Use(middleware.OperationRecord())
+	routerWithoutRecord := Router.Group("packageRelease")
+	{
+		router.POST("publish", packageReleaseApi.PublishPackage)        // 发布安装包（全量/灰度）
+		router.PUT("rollout", packageReleaseApi.UpdateRollout)          // 调整灰度比例
+		router.POST("rollback", packageReleaseApi.RollbackRelease)      // 一键回滚
+		router.DELETE("delete", packageReleaseApi.DeletePackageRelease) // 删除发布配置
+	}
+	{
+		routerWithoutRecord.GET("list", packageReleaseApi.GetPackageReleaseList) // 发布配置列表
+		routerWithoutRecord.GET("history", packageReleaseApi.GetReleaseHistory)  // 发布历史
+	}
+}
@@ -0,0 +1,23 @@
+package project
+
+import (
+	"ApkAdmin/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type SearchTermRouter struct {
+}
+
+func (r *SearchTermRouter) InitSearchTermRouter(Router *gin.RouterGroup) {
+	router := Router.Group("searchTerm").Use(middleware.OperationRecord())
+	routerWithoutRecord := Router.Group("searchTerm")
+	{
+		router.POST("rule", searchTermApi.CreateSearchTermRule)   // 新增热搜词规则
+		router.PUT("rule", searchTermApi.UpdateSearchTermRule)    // 编辑热搜词规则
+		router.DELETE("rule", searchTermApi.DeleteSearchTermRule) // 删除热搜词规则
+	}
+	{
+		routerWithoutRecord.GET("ruleList", searchTermApi.GetSearchTermRuleList)         // 热搜词规则列表
+		routerWithoutRecord.GET("zeroResultQueries", searchTermApi.GetZeroResultQueries) // 无结果搜索词
+	}
+}
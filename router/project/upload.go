@@ -14,7 +14,15 @@ func (u UploadRoute) InitUploadRoute(Router *gin.RouterGroup) {
 
 	// 需要认证的路由组
 	userRouter := Router.Group("upload").Use(middleware.OperationRecord())
+	routerWithoutRecord := Router.Group("upload")
 	{
 		userRouter.POST("getUploadSignature", uploadApi.GetUploadSignature)
+		userRouter.POST("session/init", uploadApi.InitUploadSession)         // 初始化分片上传会话
+		userRouter.POST("session/complete", uploadApi.CompleteUploadSession) // 完成分片上传
+		userRouter.POST("session/abort", uploadApi.AbortUploadSession)       // 取消分片上传
+	}
+	{
+		routerWithoutRecord.POST("session/chunk", uploadApi.UploadSessionChunk) // 上传分片（不记录操作日志，避免记录文件内容）
+		routerWithoutRecord.GET("session/detail", uploadApi.GetUploadSession)   // 查询上传进度
 	}
 }
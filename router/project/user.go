@@ -17,6 +17,8 @@ func (r *UserRouter) InitUserRouter(Router *gin.RouterGroup) {
 		userRouter.DELETE("removeUser/:id", userApi.DeleteUser)                 // 删除用户
 		userRouter.POST("batchUpdateUserStatus", userApi.BatchUpdateUserStatus) // 批量更新状态
 		userRouter.POST("resetUserPassword", userApi.ResetUserPassword)         // 重置密码
+		userRouter.POST("resetTwoFactor", userApi.ResetUserTwoFactor)           // 重置双因子认证
+		userRouter.POST("rebindReferrer", userApi.RebindReferrer)               // 修改推荐人
 	}
 	{
 		// 查询接口（需要认证但不记录操作日志）
@@ -24,6 +26,8 @@ func (r *UserRouter) InitUserRouter(Router *gin.RouterGroup) {
 		userRouterWithoutRecord.GET("getUser/:id", userApi.GetUser)                            // 获取用户详情
 		userRouterWithoutRecord.GET("getUserMemberships/:user_id", userApi.GetUserMemberships) // 获取用户会员记录
 		userRouterWithoutRecord.GET("getUserOrders/:user_id", userApi.GetUserOrders)           // 获取用户订单记录
+		userRouterWithoutRecord.GET("twoFactorLogs", userApi.GetTwoFactorLogs)                 // 双因子认证记录
+		userRouterWithoutRecord.GET("referralBindLogs", userApi.GetReferralBindLogs)           // 推荐关系变更记录
 	}
 
 }
@@ -0,0 +1,17 @@
+package web
+
+import "github.com/gin-gonic/gin"
+
+// AffiliateRouter 推广员数据路由
+type AffiliateRouter struct {
+}
+
+func (r *AffiliateRouter) InitAffiliateRouter(Router *gin.RouterGroup) {
+	affiliateRouter := Router.Group("affiliate")
+	{
+		affiliateRouter.GET("downlines", affiliateApi.GetDownlines)       // 直属下级列表
+		affiliateRouter.GET("trend", affiliateApi.GetTrend)               // 推广趋势
+		affiliateRouter.GET("tierProgress", affiliateApi.GetTierProgress) // 等级进度
+		affiliateRouter.GET("links", affiliateApi.GetLinkStats)           // 推广链接效果
+	}
+}
@@ -11,9 +11,28 @@ func (r *AppRoute) InitAppRouter(PublicRouter *gin.RouterGroup, PrivateRoute *gi
 		PublicRouter.GET("categories/apps", appApi.GetFilterApps)                       //条件查找分类列表下的应用
 		PublicRouter.GET("accounts/apps", appApi.GetAccountAppsListByCategory)          //根据分类获取应用账号
 		PublicRouter.GET("app/searchApp", appApi.SearchApps)                            //搜索应用
+		PublicRouter.GET("app/searchSuggest", appApi.SearchSuggest)                     //搜索联想
+		PublicRouter.GET("app/trendingSearches", appApi.TrendingSearches)               //热搜词
+		PublicRouter.GET("app/reviews", appApi.ListReviews)                             //应用评价列表
+		PublicRouter.GET("app/reviewSummary", appApi.ReviewSummary)                     //应用评分汇总
+		PublicRouter.GET("app/alsoDownloaded", appApi.AlsoDownloaded)                   //下载了该应用的用户还下载了
+		PublicRouter.GET("app/forYou", appApi.ForYou)                                   //个性化推荐
+		PublicRouter.GET("app/detail", appApi.GetStoreDetail)                           //应用详情（按访问者语言本地化）
+		PublicRouter.GET("app/versions", appApi.GetVersionHistory)                      //应用版本历史
+		PublicRouter.GET("feed/releases.rss", appApi.ReleasesRSS)                       //最新版本 RSS
+		PublicRouter.GET("feed/releases.atom", appApi.ReleasesAtom)                     //最新版本 Atom
+		PublicRouter.GET("feed/releases.json", appApi.ReleasesJSON)                     //最新版本 JSON Feed
 	}
 	{
-		PrivateRoute.POST("app/downloadApp", appApi.DownloadApp) //下载应用
+		PrivateRoute.POST("app/downloadApp", appApi.DownloadApp)     //下载应用
+		PrivateRoute.POST("app/checkUpdate", appApi.CheckUpdate)     //检查更新（差分/全量）
+		PrivateRoute.POST("app/review", appApi.SubmitReview)         //提交或修改评价
+		PrivateRoute.DELETE("app/review", appApi.DeleteReview)       //删除自己的评价
+		PrivateRoute.GET("app/myReview", appApi.GetMyReview)         //我的评价
+		PrivateRoute.POST("app/follow", appApi.FollowApp)            //关注应用
+		PrivateRoute.DELETE("app/follow", appApi.UnfollowApp)        //取消关注
+		PrivateRoute.GET("app/followStatus", appApi.GetFollowStatus) //是否已关注
+		PrivateRoute.GET("app/follows", appApi.GetFollowList)        //我关注的应用
 
 	}
 }
@@ -12,6 +12,11 @@ func (r BaseRouter) InitBaseRouter(Router *gin.RouterGroup) {
 	Router.GET("customer-service/config", baseApi.CustomerServiceConfig) // 获取站点客服配置
 	Router.GET("captcha", middleware.CaptchaLimit(), baseApi.Captcha)    // 获取验证码
 	//Router.POST("base/register", middleware.RegisterLimit(), baseApi.Register) // 用户注册
-	Router.POST("base/register", baseApi.Register) // 用户注册
-	Router.POST("base/login", baseApi.Login)       // 用户登录
+	Router.POST("base/register", baseApi.Register)             // 用户注册
+	Router.POST("base/login", baseApi.Login)                   // 用户登录
+	Router.POST("base/login2fa", baseApi.Login2FA)             // 登录第二步：双因子验证
+	Router.POST("base/verifyEmail", baseApi.VerifyEmail)       // 邮箱验证
+	Router.POST("base/forgotPassword", baseApi.ForgotPassword) // 找回密码，发送重置邮件
+	Router.POST("base/resetPassword", baseApi.ResetPassword)   // 通过重置链接设置新密码
+	Router.GET("ref/:code", baseApi.ReferralLink)              // 推广链接，记录点击后跳转注册页
 }
@@ -14,6 +14,8 @@ type RouterGroup struct {
 	CommissionTierRouter
 	WithdrawRouter
 	CommissionDetailRouter
+	NotificationRouter
+	AffiliateRouter
 }
 
 var (
@@ -29,4 +31,6 @@ var (
 	commissionTierApi     = api.ApiGroupApp.WebApiGroup.CommissionTierApi
 	withdrawApi           = api.ApiGroupApp.WebApiGroup.WithdrawApi
 	commissionDetailApi   = api.ApiGroupApp.WebApiGroup.CommissionDetailApi
+	userNotificationApi   = api.ApiGroupApp.WebApiGroup.UserNotificationApi
+	affiliateApi          = api.ApiGroupApp.WebApiGroup.AffiliateApi
 )
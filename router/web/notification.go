@@ -0,0 +1,13 @@
+package web
+
+import "github.com/gin-gonic/gin"
+
+// NotificationRouter 站内通知路由
+type NotificationRouter struct {
+}
+
+func (r *NotificationRouter) InitNotificationRouter(Router *gin.RouterGroup) {
+	Router.GET("notifications", userNotificationApi.GetNotificationList)        //站内通知列表
+	Router.GET("notifications/unreadCount", userNotificationApi.GetUnreadCount) //未读通知数量
+	Router.PUT("notifications/read", userNotificationApi.MarkRead)              //标记已读
+}
@@ -7,6 +7,9 @@ type OrderRouter struct {
 
 func (r *OrderRouter) InitOrderRouter(Router *gin.RouterGroup) {
 	router := Router.Group("order")
-	router.POST("/account", orderApi.StoreAccountOrder)           // 账号商品下单
-	router.POST("/membership", orderApi.StoreMembershipPlanOrder) //会员套餐下单
+	router.POST("/account", orderApi.StoreAccountOrder)                   // 账号商品下单
+	router.POST("/membership", orderApi.StoreMembershipPlanOrder)         //会员套餐下单
+	router.POST("/warrantyClaim", orderApi.SubmitWarrantyClaim)           //账号售后申请
+	router.GET("/warrantyClaims", orderApi.GetWarrantyClaimList)          //我的售后申请
+	router.POST("/accountCredentials", orderApi.RevealAccountCredentials) //查看已购买账号详情
 }
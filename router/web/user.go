@@ -7,7 +7,13 @@ type UserRouter struct {
 
 func (r *UserRouter) InitUserRouter(Router *gin.RouterGroup) {
 	router := Router.Group("user")
-	router.POST("/logout", jwtApi.JsonInBlacklist)         // 用户退出登录
-	router.GET("/getUserInfo", userApi.GetUserInfo)        // 获取登录用户的信息
-	router.POST("/changePassword", userApi.ChangePassword) // 修改用户登录密码
+	router.POST("/logout", jwtApi.JsonInBlacklist)                           // 用户退出登录
+	router.GET("/getUserInfo", userApi.GetUserInfo)                          // 获取登录用户的信息
+	router.POST("/changePassword", userApi.ChangePassword)                   // 修改用户登录密码
+	router.POST("/sendVerifyEmail", userApi.SendVerifyEmail)                 // 发送邮箱验证邮件
+	router.POST("/changeEmail", userApi.ChangeEmail)                         // 修改邮箱
+	router.POST("/twoFactor/setup", userApi.SetupTwoFactor)                  // 获取双因子认证密钥和二维码
+	router.POST("/twoFactor/enable", userApi.EnableTwoFactor)                // 确认并开启双因子认证
+	router.POST("/twoFactor/disable", userApi.DisableTwoFactor)              // 关闭双因子认证
+	router.POST("/twoFactor/recoveryCodes", userApi.RegenerateRecoveryCodes) // 重新生成恢复码
 }
@@ -0,0 +1,229 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/request"
+	"ApkAdmin/model/project"
+	"ApkAdmin/utils/crypto"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const reencryptBatchSize = 200
+
+type AccountReencryptService struct{}
+
+// reencryptRunning 本进程中正在执行的任务，避免同一任务被重复启动
+var reencryptRunning sync.Map
+
+// StartJob 使用当前密钥环配置启动重新加密任务，已暂停的旧任务会被取代
+func (s *AccountReencryptService) StartJob(userID uint) (*project.AccountReencryptJob, error) {
+	if err := crypto.ValidateKeyring(); err != nil {
+		return nil, err
+	}
+	var running int64
+	err := global.GVA_DB.Model(&project.AccountReencryptJob{}).Where("status = ?", constants.ReencryptJobRunning).Count(&running).Error
+	if err != nil {
+		return nil, err
+	}
+	if running > 0 {
+		return nil, errors.New("已有正在执行的重新加密任务")
+	}
+	var total int64
+	if err = global.GVA_DB.Table("app_accounts").Count(&total).Error; err != nil {
+		return nil, err
+	}
+	job := project.AccountReencryptJob{
+		Status:    constants.ReencryptJobRunning,
+		TargetKey: crypto.ActiveKeyID(),
+		Envelope:  global.GVA_CONFIG.Encryption.Envelope,
+		Total:     total,
+		CreatedBy: userID,
+	}
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&project.AccountReencryptJob{}).Where("status = ?", constants.ReencryptJobPaused).
+			Updates(map[string]interface{}{"status": constants.ReencryptJobFailed, "message": "已被新任务取代"}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&job).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	go s.run(job.ID)
+	return &job, nil
+}
+
+// PauseJob 暂停任务，当前批次处理完后停止
+func (s *AccountReencryptService) PauseJob(id uint) error {
+	res := global.GVA_DB.Model(&project.AccountReencryptJob{}).
+		Where("id = ? AND status = ?", id, constants.ReencryptJobRunning).
+		Update("status", constants.ReencryptJobPaused)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("任务不在执行中")
+	}
+	return nil
+}
+
+// ResumeJob 从上次的进度继续已暂停或失败的任务，密钥配置必须与任务启动时一致
+func (s *AccountReencryptService) ResumeJob(id uint) error {
+	var job project.AccountReencryptJob
+	if err := global.GVA_DB.Where("id = ?", id).First(&job).Error; err != nil {
+		return errors.New("任务不存在")
+	}
+	if job.Status != constants.ReencryptJobPaused && job.Status != constants.ReencryptJobFailed {
+		return errors.New("只能继续已暂停或失败的任务")
+	}
+	if job.TargetKey != crypto.ActiveKeyID() || job.Envelope != global.GVA_CONFIG.Encryption.Envelope {
+		return errors.New("密钥配置已变更，请重新开始任务")
+	}
+	res := global.GVA_DB.Model(&project.AccountReencryptJob{}).
+		Where("id = ? AND status = ?", id, job.Status).
+		Updates(map[string]interface{}{"status": constants.ReencryptJobRunning, "message": ""})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("任务状态已变化，请刷新后重试")
+	}
+	go s.run(id)
+	return nil
+}
+
+// ResumeInterruptedJobs 服务启动时继续因进程退出而中断的任务。
+// 启动时本进程还没有执行任何任务，状态为运行中的任务都是上一个进程留下的，不论多久之前更新过都要继续
+func (s *AccountReencryptService) ResumeInterruptedJobs() {
+	var ids []uint
+	err := global.GVA_DB.Model(&project.AccountReencryptJob{}).
+		Where("status = ?", constants.ReencryptJobRunning).
+		Pluck("id", &ids).Error
+	if err != nil {
+		global.GVA_LOG.Error("查询中断的重新加密任务失败!", zap.Error(err))
+		return
+	}
+	for _, id := range ids {
+		go s.run(id)
+	}
+}
+
+// GetJobList 重新加密任务列表
+func (s *AccountReencryptService) GetJobList(info request.PageInfo) (list []project.AccountReencryptJob, total int64, err error) {
+	db := global.GVA_DB.Model(&project.AccountReencryptJob{})
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Scopes(info.Paginate()).Order("id desc").Find(&list).Error
+	return list, total, err
+}
+
+// GetJob 任务进度
+func (s *AccountReencryptService) GetJob(id uint) (job project.AccountReencryptJob, err error) {
+	err = global.GVA_DB.Where("id = ?", id).First(&job).Error
+	return job, err
+}
+
+type accountCipherRow struct {
+	ID            uint
+	AccountDetail string
+}
+
+// run 分批重新加密，每批结束后保存进度；任务被暂停或配置变更时停止
+func (s *AccountReencryptService) run(id uint) {
+	if _, loaded := reencryptRunning.LoadOrStore(id, true); loaded {
+		return
+	}
+	defer reencryptRunning.Delete(id)
+
+	for {
+		var job project.AccountReencryptJob
+		if err := global.GVA_DB.Where("id = ?", id).First(&job).Error; err != nil {
+			global.GVA_LOG.Error("读取重新加密任务失败!", zap.Error(err), zap.Uint("jobID", id))
+			return
+		}
+		if job.Status != constants.ReencryptJobRunning {
+			return
+		}
+		if job.TargetKey != crypto.ActiveKeyID() || job.Envelope != global.GVA_CONFIG.Encryption.Envelope {
+			s.finish(id, constants.ReencryptJobFailed, "密钥配置已变更，请重新开始任务")
+			return
+		}
+
+		// 直接读写表，绕过模型钩子，拿到原始密文；包含已软删除的账号
+		var rows []accountCipherRow
+		err := global.GVA_DB.Table("app_accounts").Select("id, account_detail").
+			Where("id > ?", job.LastID).Order("id asc").Limit(reencryptBatchSize).
+			Scan(&rows).Error
+		if err != nil {
+			s.finish(id, constants.ReencryptJobFailed, err.Error())
+			return
+		}
+		if len(rows) == 0 {
+			s.finish(id, constants.ReencryptJobCompleted, "")
+			return
+		}
+		var migrated, failed int64
+		for _, row := range rows {
+			ok, err := reencryptRow(row)
+			if err != nil {
+				failed++
+				global.GVA_LOG.Error("重新加密账号详情失败!", zap.Error(err), zap.Uint("accountID", row.ID))
+				continue
+			}
+			if ok {
+				migrated++
+			}
+		}
+		// 只在任务仍在执行时保存进度；已暂停时本批会在继续后重新检查，已迁移的行会被跳过
+		err = global.GVA_DB.Model(&project.AccountReencryptJob{}).
+			Where("id = ? AND status = ?", id, constants.ReencryptJobRunning).
+			Updates(map[string]interface{}{
+				"last_id":    rows[len(rows)-1].ID,
+				"processed":  gorm.Expr("processed + ?", len(rows)),
+				"migrated":   gorm.Expr("migrated + ?", migrated),
+				"failed":     gorm.Expr("failed + ?", failed),
+				"updated_at": time.Now(),
+			}).Error
+		if err != nil {
+			global.GVA_LOG.Error("保存重新加密进度失败!", zap.Error(err), zap.Uint("jobID", id))
+			return
+		}
+	}
+}
+
+// reencryptRow 需要时用当前密钥重新加密一行，返回是否写入。只有密文未被并发修改时才写入
+func reencryptRow(row accountCipherRow) (bool, error) {
+	if row.AccountDetail == "" || !crypto.NeedsReencrypt(row.AccountDetail) {
+		return false, nil
+	}
+	plaintext, err := crypto.DecryptAccountDetail(row.AccountDetail)
+	if err != nil {
+		return false, err
+	}
+	encrypted, err := crypto.EncryptAccountDetail(plaintext)
+	if err != nil {
+		return false, err
+	}
+	res := global.GVA_DB.Table("app_accounts").
+		Where("id = ? AND account_detail = ?", row.ID, row.AccountDetail).
+		UpdateColumn("account_detail", encrypted)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (s *AccountReencryptService) finish(id uint, status constants.ReencryptJobStatus, message string) {
+	now := time.Now()
+	err := global.GVA_DB.Model(&project.AccountReencryptJob{}).
+		Where("id = ? AND status = ?", id, constants.ReencryptJobRunning).
+		Updates(map[string]interface{}{"status": status, "message": message, "finished_at": &now}).Error
+	if err != nil {
+		global.GVA_LOG.Error("更新重新加密任务状态失败!", zap.Error(err), zap.Uint("jobID", id))
+	}
+}
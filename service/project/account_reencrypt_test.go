@@ -0,0 +1,101 @@
+package project
+
+import (
+	"ApkAdmin/config"
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/utils/crypto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccountReencryptJob(t *testing.T) {
+	setupTestDB(t, &project.AccountReencryptJob{})
+	if err := global.GVA_DB.Exec(`CREATE TABLE app_accounts (id integer PRIMARY KEY, account_detail text, deleted_at datetime)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	savedSystem, savedEnc := global.GVA_CONFIG.System, global.GVA_CONFIG.Encryption
+	t.Cleanup(func() { global.GVA_CONFIG.System, global.GVA_CONFIG.Encryption = savedSystem, savedEnc })
+	global.GVA_CONFIG.System.EncryptionKey = "old"
+	global.GVA_CONFIG.Encryption = config.Encryption{}
+
+	for i := 1; i <= 5; i++ {
+		ct, _ := crypto.EncryptAccountDetail("secret")
+		global.GVA_DB.Exec("INSERT INTO app_accounts (id, account_detail) VALUES (?, ?)", i, ct)
+	}
+	global.GVA_DB.Exec("UPDATE app_accounts SET deleted_at = CURRENT_TIMESTAMP WHERE id = 2")
+	global.GVA_DB.Exec("UPDATE app_accounts SET account_detail = 'v1:gone:AAAA' WHERE id = 4")
+
+	global.GVA_CONFIG.Encryption = config.Encryption{Active: "k2", Keys: []config.EncryptionKey{{ID: "k2", Secret: "new"}}, Envelope: true}
+	var s AccountReencryptService
+	// 模拟中断：进度停在第1条，继续后从第2条开始
+	job := project.AccountReencryptJob{Status: constants.ReencryptJobPaused, TargetKey: "k2", Envelope: true, LastID: 1, Processed: 1, Total: 5}
+	global.GVA_DB.Create(&job)
+	global.GVA_DB.Model(&job).Update("status", constants.ReencryptJobRunning)
+	s.run(job.ID)
+
+	job, _ = s.GetJob(job.ID)
+	if job.Status != constants.ReencryptJobCompleted || job.LastID != 5 || job.Processed != 5 || job.Migrated != 3 || job.Failed != 1 {
+		t.Fatalf("job = %+v", job)
+	}
+	var rows []accountCipherRow
+	global.GVA_DB.Table("app_accounts").Order("id").Scan(&rows)
+	for _, r := range rows {
+		keyID, envelope := crypto.CiphertextKeyID(r.AccountDetail)
+		switch r.ID {
+		case 1:
+			if keyID != crypto.SystemKeyID {
+				t.Errorf("row before LastID should be untouched, got %s", r.AccountDetail)
+			}
+		case 4:
+			if !strings.HasPrefix(r.AccountDetail, "v1:gone:") {
+				t.Errorf("undecryptable row should be left as is, got %s", r.AccountDetail)
+			}
+		default:
+			if plain, err := crypto.DecryptAccountDetail(r.AccountDetail); keyID != "k2" || !envelope || err != nil || plain != "secret" {
+				t.Errorf("row %d = %s (%v)", r.ID, r.AccountDetail, err)
+			}
+		}
+	}
+
+	// 密钥配置变化后不能继续旧任务
+	global.GVA_DB.Model(&job).Update("status", constants.ReencryptJobPaused)
+	global.GVA_CONFIG.Encryption.Envelope = false
+	if err := s.ResumeJob(job.ID); err == nil {
+		t.Error("resume with changed key config should fail")
+	}
+}
+
+func TestResumeInterruptedReencryptJobs(t *testing.T) {
+	setupTestDB(t, &project.AccountReencryptJob{})
+	if err := global.GVA_DB.Exec(`CREATE TABLE app_accounts (id integer PRIMARY KEY, account_detail text, deleted_at datetime)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	savedSystem, savedEnc := global.GVA_CONFIG.System, global.GVA_CONFIG.Encryption
+	t.Cleanup(func() { global.GVA_CONFIG.System, global.GVA_CONFIG.Encryption = savedSystem, savedEnc })
+	global.GVA_CONFIG.System.EncryptionKey = "old"
+	global.GVA_CONFIG.Encryption = config.Encryption{}
+	ct, _ := crypto.EncryptAccountDetail("secret")
+	global.GVA_DB.Exec("INSERT INTO app_accounts (id, account_detail) VALUES (1, ?)", ct)
+	global.GVA_CONFIG.Encryption = config.Encryption{Active: "k2", Keys: []config.EncryptionKey{{ID: "k2", Secret: "new"}}}
+
+	// 进程在 2 分钟内重启：任务刚更新过进度，仍然要继续
+	job := project.AccountReencryptJob{Status: constants.ReencryptJobRunning, TargetKey: "k2", Total: 1}
+	global.GVA_DB.Create(&job)
+	var s AccountReencryptService
+	s.ResumeInterruptedJobs()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, _ = s.GetJob(job.ID)
+		if job.Status != constants.ReencryptJobRunning || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job.Status != constants.ReencryptJobCompleted || job.Migrated != 1 {
+		t.Fatalf("job after resume = %+v", job)
+	}
+}
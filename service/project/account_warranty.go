@@ -0,0 +1,464 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/common"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils/upload"
+	"errors"
+	"fmt"
+	"math"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxWarrantyEvidence = 5
+	// replacementAttempts 挑选更换账号时被其它请求抢先的重试次数
+	replacementAttempts = 5
+)
+
+var errClaimHandled = errors.New("该申请已处理")
+
+type AccountWarrantyService struct{}
+
+// warrantyUntil 售后截止时间，从订单支付时间起算；应用不提供售后或订单未支付时返回 nil
+func warrantyUntil(order project.Order, warrantyDays int) *time.Time {
+	if warrantyDays <= 0 || order.PaidAt == nil {
+		return nil
+	}
+	until := order.PaidAt.AddDate(0, 0, warrantyDays)
+	return &until
+}
+
+// SubmitClaim 买家对订单中的账号申请售后，需在应用的保修期内，凭证图片可选
+func (s *AccountWarrantyService) SubmitClaim(req request.WarrantyClaimSubmitRequest, userID uint, files []*multipart.FileHeader) (*project.AccountWarrantyClaim, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("请填写申请原因")
+	}
+	if len(files) > maxWarrantyEvidence {
+		return nil, fmt.Errorf("最多上传%d张凭证图片", maxWarrantyEvidence)
+	}
+	for _, f := range files {
+		if !screenshotExts[strings.ToLower(filepath.Ext(f.Filename))] {
+			return nil, fmt.Errorf("%s 不是支持的图片格式（jpg、png、webp）", f.Filename)
+		}
+		if f.Size > maxScreenshotSize {
+			return nil, fmt.Errorf("%s 超过5MB", f.Filename)
+		}
+	}
+
+	var order project.Order
+	err := global.GVA_DB.Where("order_no = ? AND user_id = ?", req.OrderNo, userID).First(&order).Error
+	if err != nil {
+		return nil, errors.New("订单不存在")
+	}
+	if !order.IsAccountProductOrder() || !order.IsPaid() {
+		return nil, errors.New("只有已支付的账号订单可以申请售后")
+	}
+	if !containsAccountID(order.AccountIDs, req.AccountID) {
+		return nil, errors.New("该账号不属于此订单")
+	}
+	var account project.AppAccount
+	if err = global.GVA_DB.Select("id, app_id").Where("id = ?", req.AccountID).First(&account).Error; err != nil {
+		return nil, errors.New("账号不存在")
+	}
+	var app project.Application
+	if err = global.GVA_DB.Select("id, warranty_days").Where("app_id = ?", account.AppID).First(&app).Error; err != nil {
+		return nil, errors.New("应用不存在")
+	}
+	until := warrantyUntil(order, app.WarrantyDays)
+	if until == nil {
+		return nil, errors.New("该应用的账号不提供售后")
+	}
+	if time.Now().After(*until) {
+		return nil, fmt.Errorf("已超过售后期限（%s）", until.Format("2006-01-02 15:04"))
+	}
+	var pending int64
+	err = global.GVA_DB.Model(&project.AccountWarrantyClaim{}).
+		Where("order_id = ? AND account_id = ? AND status = ?", order.ID, req.AccountID, constants.WarrantyClaimPending).
+		Count(&pending).Error
+	if err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, errors.New("该账号已有待处理的售后申请")
+	}
+
+	claim := project.AccountWarrantyClaim{
+		OrderID:      order.ID,
+		OrderNo:      order.OrderNo,
+		UserID:       userID,
+		AccountID:    req.AccountID,
+		AppID:        account.AppID,
+		Reason:       reason,
+		Evidence:     common.JSONSlice{},
+		EvidenceKeys: common.JSONSlice{},
+		Status:       constants.WarrantyClaimPending,
+	}
+	var oss upload.OSS
+	if len(files) > 0 {
+		oss = upload.NewOss()
+	}
+	removeUploaded := func() {
+		for _, key := range claim.EvidenceKeys {
+			if err := oss.DeleteFile(key); err != nil {
+				global.GVA_LOG.Error("删除售后凭证失败!", zap.Error(err), zap.String("key", key))
+			}
+		}
+	}
+	for _, f := range files {
+		url, key, err := oss.UploadFile(f)
+		if err != nil {
+			removeUploaded()
+			return nil, fmt.Errorf("上传 %s 失败：%w", f.Filename, err)
+		}
+		claim.Evidence = append(claim.Evidence, url)
+		claim.EvidenceKeys = append(claim.EvidenceKeys, key)
+	}
+
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&claim).Error; err != nil {
+			return err
+		}
+		return tx.Create(&project.AccountWarrantyEvent{
+			ClaimID:      claim.ID,
+			OrderID:      order.ID,
+			Action:       constants.WarrantyActionSubmit,
+			OldAccountID: claim.AccountID,
+			Note:         reason,
+			OperatorID:   userID,
+		}).Error
+	})
+	if err != nil {
+		removeUploaded()
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// GetClaimList 售后申请列表，userID 不为0时只查该用户的申请
+func (s *AccountWarrantyService) GetClaimList(req request.WarrantyClaimListRequest, userID uint) (list []project.AccountWarrantyClaim, total int64, err error) {
+	db := global.GVA_DB.Model(&project.AccountWarrantyClaim{})
+	if userID != 0 {
+		db = db.Where("user_id = ?", userID)
+	}
+	if req.Status != "" {
+		db = db.Where("status = ?", req.Status)
+	}
+	if req.OrderNo != "" {
+		db = db.Where("order_no = ?", req.OrderNo)
+	}
+	if req.AccountID != 0 {
+		db = db.Where("account_id = ? OR replacement_account_id = ?", req.AccountID, req.AccountID)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Scopes(req.Paginate()).
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).
+		Order("id desc").Find(&list).Error
+	return list, total, err
+}
+
+// ApproveClaim 通过售后申请：更换为同应用的可用账号，或对该账号部分退款。原账号回收为风险状态
+func (s *AccountWarrantyService) ApproveClaim(req request.WarrantyClaimApproveRequest, operatorID uint, operatorName string) error {
+	claim, err := s.pendingClaim(req.ID)
+	if err != nil {
+		return err
+	}
+	note := strings.TrimSpace(req.Note)
+	switch req.Resolution {
+	case "replace":
+		err = s.replace(claim, note, operatorID)
+	case "refund":
+		err = s.refund(claim, req.RefundAmount, note, operatorID, operatorName)
+	default:
+		err = errors.New("不支持的处理方式")
+	}
+	if err != nil {
+		return err
+	}
+	s.notify(claim)
+	return nil
+}
+
+// RejectClaim 驳回售后申请
+func (s *AccountWarrantyService) RejectClaim(req request.WarrantyClaimRejectRequest, operatorID uint) error {
+	claim, err := s.pendingClaim(req.ID)
+	if err != nil {
+		return err
+	}
+	note := strings.TrimSpace(req.Note)
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := closeClaim(tx, claim, constants.WarrantyClaimRejected, note, operatorID, nil); err != nil {
+			return err
+		}
+		return tx.Create(&project.AccountWarrantyEvent{
+			ClaimID:      claim.ID,
+			OrderID:      claim.OrderID,
+			Action:       constants.WarrantyActionReject,
+			OldAccountID: claim.AccountID,
+			Note:         note,
+			OperatorID:   operatorID,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	s.notify(claim)
+	return nil
+}
+
+func (s *AccountWarrantyService) pendingClaim(id uint) (*project.AccountWarrantyClaim, error) {
+	var claim project.AccountWarrantyClaim
+	if err := global.GVA_DB.Where("id = ?", id).First(&claim).Error; err != nil {
+		return nil, errors.New("售后申请不存在")
+	}
+	if claim.Status != constants.WarrantyClaimPending {
+		return nil, errClaimHandled
+	}
+	return &claim, nil
+}
+
+// lockClaimOrder 在事务中锁定售后申请对应的订单，同一订单的多个售后申请串行处理
+func lockClaimOrder(tx *gorm.DB, claim *project.AccountWarrantyClaim) (*project.Order, error) {
+	var order project.Order
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", claim.OrderID).First(&order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("订单不存在")
+	}
+	return &order, err
+}
+
+// replace 从同应用的正常账号中挑选一个标记为已卖出，替换订单账号列表中的原账号
+func (s *AccountWarrantyService) replace(claim *project.AccountWarrantyClaim, note string, operatorID uint) error {
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		// 账号列表以锁定后的订单为准，避免并发更换时互相覆盖
+		order, err := lockClaimOrder(tx, claim)
+		if err != nil {
+			return err
+		}
+		if !containsAccountID(order.AccountIDs, claim.AccountID) {
+			return errors.New("原账号已不在订单中")
+		}
+		var newID uint
+		for i := 0; i < replacementAttempts && newID == 0; i++ {
+			var candidate project.AppAccount
+			err := tx.Select("id").
+				Where("app_id = ? AND account_status = ? AND id <> ?", claim.AppID, constants.AppAccountStatusNormal, claim.AccountID).
+				Order("id asc").Limit(1).Find(&candidate).Error
+			if err != nil {
+				return err
+			}
+			if candidate.ID == 0 {
+				return errors.New("该应用没有可更换的正常账号")
+			}
+			res := tx.Model(&project.AppAccount{}).
+				Where("id = ? AND account_status = ?", candidate.ID, constants.AppAccountStatusNormal).
+				UpdateColumn("account_status", constants.AppAccountStatusSold)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 1 {
+				newID = candidate.ID
+			}
+		}
+		if newID == 0 {
+			return errors.New("挑选更换账号失败，请重试")
+		}
+		reason := fmt.Sprintf("售后申请#%d 更换发放", claim.ID)
+		if err := tx.Create(&project.AppAccountStatusLog{
+			AccountID:  newID,
+			FromStatus: constants.AppAccountStatusNormal,
+			ToStatus:   constants.AppAccountStatusSold,
+			Applied:    true,
+			Source:     constants.AccountStatusSourceManual,
+			Reason:     reason,
+			OperatorID: operatorID,
+		}).Error; err != nil {
+			return err
+		}
+		if err := reclaimAccount(tx, claim, operatorID); err != nil {
+			return err
+		}
+
+		ids := make(project.AccountIDList, len(order.AccountIDs))
+		for i, id := range order.AccountIDs {
+			if id == claim.AccountID {
+				id = newID
+			}
+			ids[i] = id
+		}
+		if err := tx.Model(&project.Order{}).Where("id = ?", order.ID).Update("account_ids", ids).Error; err != nil {
+			return err
+		}
+		if err := closeClaim(tx, claim, constants.WarrantyClaimReplaced, note, operatorID, map[string]interface{}{"replacement_account_id": newID}); err != nil {
+			return err
+		}
+		claim.ReplacementAccountID = &newID
+		return tx.Create(&project.AccountWarrantyEvent{
+			ClaimID:      claim.ID,
+			OrderID:      order.ID,
+			Action:       constants.WarrantyActionReplace,
+			OldAccountID: claim.AccountID,
+			NewAccountID: newID,
+			Note:         note,
+			OperatorID:   operatorID,
+		}).Error
+	})
+}
+
+// refund 对该账号部分退款，金额不超过单个账号的实付金额，也不超过订单剩余可退金额；订单状态保持已支付
+func (s *AccountWarrantyService) refund(claim *project.AccountWarrantyClaim, amount float64, note string, operatorID uint, operatorName string) error {
+	amount = math.Round(amount*100) / 100
+	if amount <= 0 {
+		return errors.New("退款金额必须大于0")
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		// 剩余可退金额在订单锁内计算，避免并发退款超过订单金额
+		order, err := lockClaimOrder(tx, claim)
+		if err != nil {
+			return err
+		}
+		quantity := order.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+		perAccount := math.Round(order.FinalAmount/float64(quantity)*100) / 100
+		if amount > perAccount {
+			return fmt.Errorf("退款金额不能超过单个账号的实付金额 %.2f", perAccount)
+		}
+		var refunded float64
+		err = tx.Model(&project.MembershipOrderRefund{}).
+			Where("order_id = ? AND refund_status IN ?", order.ID, []string{"pending", "processing", "success"}).
+			Select("COALESCE(SUM(refund_amount), 0)").Scan(&refunded).Error
+		if err != nil {
+			return err
+		}
+		if remaining := math.Round((order.FinalAmount-refunded)*100) / 100; amount > remaining {
+			return fmt.Errorf("退款金额不能超过订单剩余可退金额 %.2f", remaining)
+		}
+
+		refund := project.MembershipOrderRefund{
+			OrderID:      uint(order.ID),
+			OrderNo:      order.OrderNo,
+			RefundAmount: amount,
+			RefundReason: fmt.Sprintf("账号售后申请#%d：%s", claim.ID, claim.Reason),
+			RefundType:   "partial",
+			RefundStatus: "pending",
+			OperatorID:   &operatorID,
+			OperatorName: operatorName,
+		}
+		if err := tx.Omit(clause.Associations).Create(&refund).Error; err != nil {
+			return err
+		}
+		if err := reclaimAccount(tx, claim, operatorID); err != nil {
+			return err
+		}
+		if err := closeClaim(tx, claim, constants.WarrantyClaimRefunded, note, operatorID, map[string]interface{}{
+			"refund_id":     refund.ID,
+			"refund_amount": amount,
+		}); err != nil {
+			return err
+		}
+		claim.RefundID, claim.RefundAmount = &refund.ID, amount
+		return tx.Create(&project.AccountWarrantyEvent{
+			ClaimID:      claim.ID,
+			OrderID:      order.ID,
+			Action:       constants.WarrantyActionRefund,
+			OldAccountID: claim.AccountID,
+			Amount:       amount,
+			Note:         note,
+			OperatorID:   operatorID,
+		}).Error
+	})
+}
+
+// reclaimAccount 原账号从已卖出回收为风险状态，等待管理员核查
+func reclaimAccount(tx *gorm.DB, claim *project.AccountWarrantyClaim, operatorID uint) error {
+	res := tx.Model(&project.AppAccount{}).
+		Where("id = ? AND account_status = ?", claim.AccountID, constants.AppAccountStatusSold).
+		UpdateColumn("account_status", constants.AppAccountStatusRisk)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+	return tx.Create(&project.AppAccountStatusLog{
+		AccountID:  claim.AccountID,
+		FromStatus: constants.AppAccountStatusSold,
+		ToStatus:   constants.AppAccountStatusRisk,
+		Applied:    true,
+		Source:     constants.AccountStatusSourceManual,
+		Reason:     fmt.Sprintf("售后申请#%d 回收", claim.ID),
+		OperatorID: operatorID,
+	}).Error
+}
+
+// closeClaim 按待审核状态条件更新，避免并发审核重复处理
+func closeClaim(tx *gorm.DB, claim *project.AccountWarrantyClaim, status constants.WarrantyClaimStatus, note string, operatorID uint, extra map[string]interface{}) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      status,
+		"review_note": note,
+		"reviewer_id": operatorID,
+		"reviewed_at": now,
+	}
+	for k, v := range extra {
+		updates[k] = v
+	}
+	res := tx.Model(&project.AccountWarrantyClaim{}).
+		Where("id = ? AND status = ?", claim.ID, constants.WarrantyClaimPending).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errClaimHandled
+	}
+	claim.Status, claim.ReviewNote, claim.ReviewerID, claim.ReviewedAt = status, note, &operatorID, &now
+	return nil
+}
+
+// notify 通知买家售后处理结果，通知失败只记录日志
+func (s *AccountWarrantyService) notify(claim *project.AccountWarrantyClaim) {
+	var app project.Application
+	global.GVA_DB.Select("id").Where("app_id = ?", claim.AppID).Limit(1).Find(&app)
+	var content string
+	switch claim.Status {
+	case constants.WarrantyClaimReplaced:
+		content = fmt.Sprintf("订单 %s 的售后申请已通过，已为您更换新账号，请在订单详情中查看。", claim.OrderNo)
+	case constants.WarrantyClaimRefunded:
+		content = fmt.Sprintf("订单 %s 的售后申请已通过，将退款 %.2f 元。", claim.OrderNo, claim.RefundAmount)
+	default:
+		content = fmt.Sprintf("订单 %s 的售后申请未通过：%s", claim.OrderNo, claim.ReviewNote)
+	}
+	notification := project.UserNotification{
+		UserID:  claim.UserID,
+		Type:    constants.NotificationWarranty,
+		RefID:   uint64(claim.ID),
+		AppID:   app.ID,
+		Title:   "售后申请处理结果",
+		Content: truncateRunes(content, 1000),
+	}
+	if err := global.GVA_DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&notification).Error; err != nil {
+		global.GVA_LOG.Error("发送售后通知失败!", zap.Error(err), zap.Uint("claimID", claim.ID))
+	}
+}
+
+func containsAccountID(ids project.AccountIDList, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
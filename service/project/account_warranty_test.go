@@ -0,0 +1,149 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccountWarrantyClaims(t *testing.T) {
+	setupTestDB(t, &project.AccountWarrantyClaim{}, &project.AccountWarrantyEvent{}, &project.AppAccountStatusLog{}, &project.UserNotification{})
+	createApplicationTables(t)
+	global.GVA_DB.Exec("INSERT INTO applications (id, app_id, app_name, warranty_days, status) VALUES (1, 'a1', '微信', 7, 'active'), (2, 'a2', '微信读书', 0, 'active')")
+	var err error
+	for _, stmt := range []string{
+		`CREATE TABLE app_accounts (id integer PRIMARY KEY, app_id text, account_detail text, detail_hash text NOT NULL DEFAULT '',
+			category_id integer, account_no text UNIQUE, extra_info text, account_status integer, created_at datetime, updated_at datetime,
+			deleted_at datetime, created_by integer, updated_by integer, last_checked_at datetime)`,
+		`CREATE TABLE orders (id integer PRIMARY KEY, order_no text, user_id integer, order_type text, quantity integer, account_ids text,
+			final_amount real, status text, paid_at datetime, created_at datetime, updated_at datetime)`,
+		`CREATE TABLE membership_order_refunds (id integer PRIMARY KEY, created_at datetime, updated_at datetime, deleted_at datetime,
+			order_id integer, order_no text, refund_amount real, refund_reason text, refund_type text, refund_status text,
+			third_party_refund_id text, operator_id integer, operator_name text, processed_at datetime, completed_at datetime,
+			failure_reason text, metadata text)`,
+	} {
+		if err = global.GVA_DB.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	global.GVA_CONFIG.System.EncryptionKey = "test-key"
+	sold, normal := constants.AppAccountStatusSold, constants.AppAccountStatusNormal
+	global.GVA_DB.Create(&[]project.AppAccount{
+		{ID: 1, AppID: "a1", AccountDetail: "u1", AccountNo: "ACC1", AccountStatus: sold},
+		{ID: 2, AppID: "a1", AccountDetail: "u2", AccountNo: "ACC2", AccountStatus: sold},
+		{ID: 3, AppID: "a1", AccountDetail: "u3", AccountNo: "ACC3", AccountStatus: normal},
+		{ID: 4, AppID: "a2", AccountDetail: "u4", AccountNo: "ACC4", AccountStatus: normal},
+		{ID: 5, AppID: "a1", AccountDetail: "u5", AccountNo: "ACC5", AccountStatus: sold},
+	})
+	global.GVA_DB.Exec(`INSERT INTO orders (id, order_no, user_id, order_type, quantity, account_ids, final_amount, status, paid_at) VALUES
+		(1, 'O1', 5, 'account_product', 2, '[1,2]', 20, 'paid', ?), (2, 'O2', 5, 'account_product', 1, '[5]', 10, 'paid', ?)`,
+		time.Now().Add(-24*time.Hour), time.Now().Add(-10*24*time.Hour))
+
+	var s AccountWarrantyService
+	submit := func(orderNo string, accountID, userID uint) (*project.AccountWarrantyClaim, error) {
+		return s.SubmitClaim(request.WarrantyClaimSubmitRequest{OrderNo: orderNo, AccountID: accountID, Reason: "登录提示密码错误"}, userID, nil)
+	}
+	for _, c := range []struct {
+		orderNo   string
+		accountID uint
+		userID    uint
+		want      string
+	}{
+		{"O1", 1, 6, "订单不存在"},
+		{"O1", 3, 5, "不属于此订单"},
+		{"O2", 5, 5, "已超过售后期限"},
+	} {
+		if _, err = submit(c.orderNo, c.accountID, c.userID); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("submit %s/%d by %d: err = %v, want %s", c.orderNo, c.accountID, c.userID, err, c.want)
+		}
+	}
+	claim1, err := submit("O1", 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = submit("O1", 1, 5); err == nil {
+		t.Error("duplicate pending claim should be rejected")
+	}
+
+	// 更换：原账号回收为风险，新账号标记已卖出，订单账号列表替换
+	if err = s.ApproveClaim(request.WarrantyClaimApproveRequest{ID: claim1.ID, Resolution: "replace"}, 9, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.ApproveClaim(request.WarrantyClaimApproveRequest{ID: claim1.ID, Resolution: "replace"}, 9, "admin"); err != errClaimHandled {
+		t.Errorf("second approve err = %v", err)
+	}
+	statuses := map[uint]constants.AppAccountStatus{}
+	var accounts []project.AppAccount
+	global.GVA_DB.Select("id, account_status").Find(&accounts)
+	for _, a := range accounts {
+		statuses[a.ID] = a.AccountStatus
+	}
+	if statuses[1] != constants.AppAccountStatusRisk || statuses[3] != sold || statuses[4] != normal {
+		t.Errorf("statuses after replace = %v", statuses)
+	}
+	var order project.Order
+	global.GVA_DB.First(&order, 1)
+	if len(order.AccountIDs) != 2 || order.AccountIDs[0] != 3 || order.AccountIDs[1] != 2 {
+		t.Errorf("order account ids = %v", order.AccountIDs)
+	}
+
+	// 部分退款：不超过单个账号实付金额，订单保持已支付
+	claim2, err := submit("O1", 2, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.ApproveClaim(request.WarrantyClaimApproveRequest{ID: claim2.ID, Resolution: "refund", RefundAmount: 15}, 9, "admin"); err == nil {
+		t.Error("refund above per-account price should be rejected")
+	}
+	if err = s.ApproveClaim(request.WarrantyClaimApproveRequest{ID: claim2.ID, Resolution: "refund", RefundAmount: 8}, 9, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	var refund project.MembershipOrderRefund
+	global.GVA_DB.Omit("Order").First(&refund)
+	if refund.OrderID != 1 || refund.RefundAmount != 8 || refund.RefundType != "partial" {
+		t.Errorf("refund = %+v", refund)
+	}
+	global.GVA_DB.First(&order, 1)
+	if order.Status != project.OrderStatusPaid {
+		t.Errorf("order status = %s", order.Status)
+	}
+
+	// 没有可更换的账号时整个更换回滚，之后驳回
+	claim3, err := submit("O1", 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.ApproveClaim(request.WarrantyClaimApproveRequest{ID: claim3.ID, Resolution: "replace"}, 9, "admin"); err == nil {
+		t.Error("replace without spare account should fail")
+	}
+	if err = s.RejectClaim(request.WarrantyClaimRejectRequest{ID: claim3.ID, Note: "账号可正常登录"}, 9); err != nil {
+		t.Fatal(err)
+	}
+
+	var notes int64
+	global.GVA_DB.Model(&project.UserNotification{}).Where("user_id = 5 AND type = ?", constants.NotificationWarranty).Count(&notes)
+	if notes != 3 {
+		t.Errorf("warranty notifications = %d", notes)
+	}
+
+	var accountService AppAccountService
+	detail, err := accountService.GetAppAccountOrderDetail(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.InOrder || detail.Order.ID != 1 || detail.WarrantyDays != 7 || detail.WarrantyUntil == nil || len(detail.Claims) != 3 {
+		t.Fatalf("detail = %+v", detail)
+	}
+	replaced := detail.Claims[2]
+	if replaced.Status != constants.WarrantyClaimReplaced || len(replaced.Events) != 2 ||
+		replaced.Events[1].OldAccountID != 1 || replaced.Events[1].NewAccountID != 3 {
+		t.Errorf("replaced claim = %+v", replaced)
+	}
+	if detail, err = accountService.GetAppAccountOrderDetail(3); err != nil || !detail.InOrder {
+		t.Errorf("detail(3) = %+v, %v", detail, err)
+	}
+}
@@ -0,0 +1,373 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/project/response"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// activeMemberDays 近多少天有消费的下级算活跃
+	activeMemberDays = 30
+	// recentAffiliateStatDays 每轮汇总重新计算的天数
+	recentAffiliateStatDays = 2
+)
+
+// AffiliateService 推广员数据：直属下级、推广趋势、等级进度和推广链接效果
+// 趋势和链接效果读取按天汇总的统计表，由定时任务从用户、订单、佣金和点击记录聚合
+type AffiliateService struct{}
+
+var affiliateService = AffiliateService{}
+
+// ==================== 汇总 ====================
+
+// AggregateRecent 重新计算今天和昨天的推广汇总，由定时任务调用。
+// 更早的订单在最近被退款或修改状态、推荐的用户最近被删除时，同时重新计算这些记录所在的那一天
+func (s *AffiliateService) AggregateRecent() error {
+	today := startOfDay(time.Now())
+	recentStart := today.AddDate(0, 0, 1-recentAffiliateStatDays)
+	days, err := s.changedDays(recentStart)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < recentAffiliateStatDays; i++ {
+		days = append(days, today.AddDate(0, 0, -i))
+	}
+	for _, day := range days {
+		if err = s.aggregateDay(day); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// changedDays 最近汇总窗口内发生变化、但统计日期早于窗口的天：订单按支付日期，删除的用户按注册日期
+func (s *AffiliateService) changedDays(since time.Time) ([]time.Time, error) {
+	var paidAts, createdAts []time.Time
+	err := global.GVA_DB.Model(&project.Order{}).
+		Where("updated_at >= ? AND paid_at < ?", since, since).
+		Distinct().Pluck("paid_at", &paidAts).Error
+	if err != nil {
+		return nil, err
+	}
+	err = global.GVA_DB.Model(&project.User{}).
+		Where("referrer_id IS NOT NULL AND deleted_at >= ? AND created_at < ?", since, since).
+		Distinct().Pluck("created_at", &createdAts).Error
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[time.Time]bool)
+	var days []time.Time
+	for _, t := range append(paidAts, createdAts...) {
+		day := startOfDay(t.Local())
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days, nil
+}
+
+// aggregateDay 重新计算某一天的推广汇总。先删除当天旧数据再写入，订单退款后对应的数据会随之消失
+func (s *AffiliateService) aggregateDay(day time.Time) error {
+	start, end := day, day.AddDate(0, 0, 1)
+	date := day.Format("2006-01-02")
+
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		promoters := make(map[uint]*project.PromoterStatDaily)
+		promoter := func(id uint) *project.PromoterStatDaily {
+			if promoters[id] == nil {
+				promoters[id] = &project.PromoterStatDaily{UserID: id, StatDate: date}
+			}
+			return promoters[id]
+		}
+
+		var members []struct {
+			UserID uint
+			Total  int64
+		}
+		err := tx.Model(&project.User{}).
+			Select("referrer_id AS user_id, COUNT(*) AS total").
+			Where("referrer_id IS NOT NULL AND deleted_at IS NULL AND created_at >= ? AND created_at < ?", start, end).
+			Group("referrer_id").Scan(&members).Error
+		if err != nil {
+			return err
+		}
+		for _, m := range members {
+			promoter(m.UserID).NewMembers = m.Total
+		}
+
+		var orders []struct {
+			UserID uint
+			Total  int64
+			Amount float64
+		}
+		err = tx.Table("orders AS o").Joins("JOIN users u ON u.id = o.user_id").
+			Select("u.referrer_id AS user_id, COUNT(*) AS total, SUM(o.final_amount) AS amount").
+			Where("u.referrer_id IS NOT NULL AND o.status = ? AND o.paid_at >= ? AND o.paid_at < ?", project.OrderStatusPaid, start, end).
+			Group("u.referrer_id").Scan(&orders).Error
+		if err != nil {
+			return err
+		}
+		for _, o := range orders {
+			p := promoter(o.UserID)
+			p.Orders, p.OrderAmount = o.Total, o.Amount
+		}
+
+		var commissions []struct {
+			UserID uint
+			Amount float64
+		}
+		err = tx.Model(&project.CommissionDetail{}).
+			Select("user_id, SUM(commission) AS amount").
+			Where("status <> ? AND create_time >= ? AND create_time < ?", "frozen", start, end).
+			Group("user_id").Scan(&commissions).Error
+		if err != nil {
+			return err
+		}
+		for _, c := range commissions {
+			promoter(c.UserID).Commission = c.Amount
+		}
+
+		if err = tx.Where("stat_date = ?", date).Delete(&project.PromoterStatDaily{}).Error; err != nil {
+			return err
+		}
+		if len(promoters) > 0 {
+			stats := make([]project.PromoterStatDaily, 0, len(promoters))
+			for _, p := range promoters {
+				stats = append(stats, *p)
+			}
+			if err = tx.CreateInBatches(stats, 500).Error; err != nil {
+				return err
+			}
+		}
+		return s.aggregateLinkDay(tx, start, end, date)
+	})
+}
+
+// aggregateLinkDay 按推广员和渠道汇总点击、注册以及通过该渠道注册的用户的订单
+func (s *AffiliateService) aggregateLinkDay(tx *gorm.DB, start, end time.Time, date string) error {
+	type linkKey struct {
+		UserID  uint
+		Channel string
+	}
+	links := make(map[linkKey]*project.ReferralLinkStatDaily)
+	link := func(id uint, channel string) *project.ReferralLinkStatDaily {
+		key := linkKey{id, channel}
+		if links[key] == nil {
+			links[key] = &project.ReferralLinkStatDaily{UserID: id, Channel: channel, StatDate: date}
+		}
+		return links[key]
+	}
+
+	var rows []struct {
+		UserID  uint
+		Channel string
+		Total   int64
+		Amount  float64
+	}
+	err := tx.Model(&project.ReferralClick{}).
+		Select("referrer_id AS user_id, channel, COUNT(*) AS total").
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Group("referrer_id, channel").Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		link(r.UserID, r.Channel).Clicks = r.Total
+	}
+
+	rows = nil
+	err = tx.Model(&project.ReferralClick{}).
+		Select("referrer_id AS user_id, channel, COUNT(*) AS total").
+		Where("registered_user_id IS NOT NULL AND registered_at >= ? AND registered_at < ?", start, end).
+		Group("referrer_id, channel").Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		link(r.UserID, r.Channel).Registrations = r.Total
+	}
+
+	rows = nil
+	err = tx.Table("orders AS o").Joins("JOIN referral_clicks rc ON rc.registered_user_id = o.user_id").
+		Select("rc.referrer_id AS user_id, rc.channel, COUNT(*) AS total, SUM(o.final_amount) AS amount").
+		Where("o.status = ? AND o.paid_at >= ? AND o.paid_at < ?", project.OrderStatusPaid, start, end).
+		Group("rc.referrer_id, rc.channel").Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		l := link(r.UserID, r.Channel)
+		l.Orders, l.OrderAmount = r.Total, r.Amount
+	}
+
+	if err = tx.Where("stat_date = ?", date).Delete(&project.ReferralLinkStatDaily{}).Error; err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	stats := make([]project.ReferralLinkStatDaily, 0, len(links))
+	for _, l := range links {
+		stats = append(stats, *l)
+	}
+	return tx.CreateInBatches(stats, 500).Error
+}
+
+// ==================== 查询 ====================
+
+// GetDownlines 直属下级列表，消费数据来自用户统计表
+func (s *AffiliateService) GetDownlines(userID uint, req request.AffiliateDownlineRequest) (list []response.AffiliateDownline, total int64, err error) {
+	since := time.Now().AddDate(0, 0, -activeMemberDays)
+	db := global.GVA_DB.Table("users AS u").
+		Joins("LEFT JOIN user_statistics us ON us.user_id = u.id").
+		Where("u.referrer_id = ? AND u.deleted_at IS NULL", userID)
+	switch req.Active {
+	case "active":
+		db = db.Where("us.last_order_at >= ?", since)
+	case "inactive":
+		db = db.Where("us.last_order_at IS NULL OR us.last_order_at < ?", since)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []struct {
+		Username    string
+		Email       string
+		CreatedAt   time.Time
+		TotalSpent  float64
+		TotalOrders uint
+		LastOrderAt *time.Time
+	}
+	err = db.Select("u.username, u.email, u.created_at, COALESCE(us.total_spent, 0) AS total_spent, " +
+		"COALESCE(us.total_orders, 0) AS total_orders, us.last_order_at").
+		Scopes(req.Paginate()).Order("u.id DESC").Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	list = make([]response.AffiliateDownline, len(rows))
+	for i, r := range rows {
+		list[i] = response.AffiliateDownline{
+			Username:    maskUsername(r.Username),
+			Email:       maskEmail(r.Email),
+			JoinedAt:    r.CreatedAt,
+			TotalSpent:  r.TotalSpent,
+			TotalOrders: r.TotalOrders,
+			LastOrderAt: r.LastOrderAt,
+			Active:      r.LastOrderAt != nil && r.LastOrderAt.After(since),
+		}
+	}
+	return list, total, nil
+}
+
+// GetTrend 按天的新增下级、下级订单和佣金，没有数据的日期补 0
+func (s *AffiliateService) GetTrend(userID uint, req request.AffiliateStatRequest) ([]response.AffiliateTrendPoint, error) {
+	start, end, err := req.Range()
+	if err != nil {
+		return nil, err
+	}
+	var stats []project.PromoterStatDaily
+	err = global.GVA_DB.Where("user_id = ? AND stat_date >= ? AND stat_date < ?", userID, start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Find(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	// DATE 列在不同驱动下可能扫描为 "2006-01-02" 或 RFC3339 字符串，只取日期部分
+	byDate := make(map[string]project.PromoterStatDaily, len(stats))
+	for _, st := range stats {
+		if len(st.StatDate) >= 10 {
+			byDate[st.StatDate[:10]] = st
+		}
+	}
+	var points []response.AffiliateTrendPoint
+	for t := start; t.Before(end); t = t.AddDate(0, 0, 1) {
+		point := response.AffiliateTrendPoint{Date: t.Format("2006-01-02")}
+		if st, ok := byDate[point.Date]; ok {
+			point.NewMembers, point.Orders = st.NewMembers, st.Orders
+			point.OrderAmount, point.Commission = st.OrderAmount, st.Commission
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// GetTierProgress 当前等级和距离下一等级还差的直属下级人数，人数来自团队统计表
+func (s *AffiliateService) GetTierProgress(userID uint) (resp response.AffiliateTierProgress, err error) {
+	var team project.TeamStatistics
+	err = global.GVA_DB.Where("user_id = ?", userID).First(&team).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return resp, err
+	}
+	resp.TotalMembers, resp.ActiveMembers = team.TotalMembers, team.ActiveMembers
+
+	var tiers []project.CommissionTier
+	if err = global.GVA_DB.Where("status = ?", 1).Order("min_subordinates ASC").Find(&tiers).Error; err != nil {
+		return resp, err
+	}
+	for i := range tiers {
+		if tiers[i].MinSubordinates <= team.TotalMembers {
+			resp.CurrentTier = &tiers[i]
+		} else if resp.NextTier == nil {
+			resp.NextTier = &tiers[i]
+		}
+	}
+	if resp.NextTier == nil {
+		resp.Progress = 100
+		return resp, nil
+	}
+	base := 0
+	if resp.CurrentTier != nil {
+		base = resp.CurrentTier.MinSubordinates
+	}
+	resp.MembersNeeded = resp.NextTier.MinSubordinates - team.TotalMembers
+	resp.Progress = math.Round(float64(team.TotalMembers-base)/float64(resp.NextTier.MinSubordinates-base)*10000) / 100
+	return resp, nil
+}
+
+// GetLinkStats 时间段内各推广渠道的点击、注册和订单，按点击次数排序
+func (s *AffiliateService) GetLinkStats(userID uint, req request.AffiliateStatRequest) ([]response.AffiliateLinkStat, error) {
+	start, end, err := req.Range()
+	if err != nil {
+		return nil, err
+	}
+	var list []response.AffiliateLinkStat
+	err = global.GVA_DB.Model(&project.ReferralLinkStatDaily{}).
+		Select("channel, SUM(clicks) AS clicks, SUM(registrations) AS registrations, SUM(orders) AS orders, SUM(order_amount) AS order_amount").
+		Where("user_id = ? AND stat_date >= ? AND stat_date < ?", userID, start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Group("channel").Scan(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].Clicks > 0 {
+			list[i].ConversionRate = math.Round(float64(list[i].Registrations)/float64(list[i].Clicks)*10000) / 100
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Clicks != list[j].Clicks {
+			return list[i].Clicks > list[j].Clicks
+		}
+		return list[i].Channel < list[j].Channel
+	})
+	return list, nil
+}
+
+// maskEmail 邮箱只显示首字符和域名
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return maskUsername(email)
+	}
+	return string([]rune(email[:at])[0]) + "***" + email[at:]
+}
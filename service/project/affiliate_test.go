@@ -0,0 +1,129 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	commonReq "ApkAdmin/model/common/request"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAffiliateDashboard(t *testing.T) {
+	setupTestDB(t, &project.User{}, &project.UserStatistics{}, &project.TeamStatistics{},
+		&project.CommissionTier{}, &project.CommissionDetail{}, &project.ReferralClick{},
+		&project.PromoterStatDaily{}, &project.ReferralLinkStatDaily{})
+	err := global.GVA_DB.Exec("CREATE TABLE orders (id integer PRIMARY KEY, user_id integer, status text, final_amount real, paid_at datetime, updated_at datetime)").Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	today := startOfDay(time.Now())
+	yesterday := today.AddDate(0, 0, -1)
+	promoter, three := uint(1), uint(3)
+	global.GVA_DB.Create(&project.User{ID: 1, UUID: uuid.New(), Username: "boss", Email: "boss@example.com"})
+	global.GVA_DB.Create(&project.User{ID: 2, UUID: uuid.New(), Username: "alice", Email: "alice@example.com", ReferrerID: &promoter, CreatedAt: yesterday.Add(time.Hour)})
+	global.GVA_DB.Create(&project.User{ID: 3, UUID: uuid.New(), Username: "bob", Email: "bob@example.com", ReferrerID: &promoter, CreatedAt: today.Add(time.Minute)})
+	global.GVA_DB.Create(&project.User{ID: 4, UUID: uuid.New(), Username: "carol", Email: "carol@example.com", ReferrerID: &three, CreatedAt: today.Add(time.Minute)})
+	lastOrder := time.Now().Add(-time.Hour)
+	global.GVA_DB.Create(&project.UserStatistics{UserID: 3, TotalSpent: 30, TotalOrders: 2, LastOrderAt: &lastOrder})
+	global.GVA_DB.Create(&project.TeamStatistics{UserID: 1, TotalMembers: 2, ActiveMembers: 1})
+
+	global.GVA_DB.Exec("INSERT INTO orders (id, user_id, status, final_amount, paid_at) VALUES (1, 3, 'paid', 10, ?), (2, 3, 'paid', 20, ?), (3, 2, 'refunded', 99, ?), (4, 4, 'paid', 5, ?)",
+		today.Add(time.Hour), today.Add(2*time.Hour), today.Add(time.Hour), today.Add(time.Hour))
+	global.GVA_DB.Create(&[]project.CommissionDetail{
+		{UserId: 1, OrderId: 1, OrderNo: "o1", OrderUserId: 3, OrderAmount: 10, CommissionRate: 0.1, Commission: 1, Status: "settled", CreateTime: today.Add(time.Hour)},
+		{UserId: 1, OrderId: 2, OrderNo: "o2", OrderUserId: 3, OrderAmount: 20, CommissionRate: 0.1, Commission: 2, Status: "frozen", CreateTime: today.Add(2 * time.Hour)},
+	})
+	registered := today.Add(time.Minute)
+	global.GVA_DB.Create(&[]project.ReferralClick{
+		{ClickID: "c1", ReferrerID: 1, ReferralCode: "BOSS", Channel: "forum", CreatedAt: today},
+		{ClickID: "c2", ReferrerID: 1, ReferralCode: "BOSS", Channel: "forum", RegisteredUserID: &three, RegisteredAt: &registered, CreatedAt: today},
+		{ClickID: "c3", ReferrerID: 1, ReferralCode: "BOSS", Channel: "", CreatedAt: today},
+	})
+	status := 1
+	global.GVA_DB.Create(&[]project.CommissionTier{
+		{ID: 1, Name: "青铜", MinSubordinates: 0, Rate: 5, Status: &status},
+		{ID: 2, Name: "白银", MinSubordinates: 5, Rate: 8, Status: &status},
+		{ID: 3, Name: "黄金", MinSubordinates: 20, Rate: 10, Status: &status},
+	})
+
+	var s AffiliateService
+	if err = s.AggregateRecent(); err != nil {
+		t.Fatal(err)
+	}
+	// 重复汇总结果不变
+	if err = s.AggregateRecent(); err != nil {
+		t.Fatal(err)
+	}
+
+	trend, err := s.GetTrend(1, request.AffiliateStatRequest{StartDate: yesterday.Format("2006-01-02"), EndDate: today.Format("2006-01-02")})
+	if err != nil || len(trend) != 2 {
+		t.Fatalf("trend = %+v, err = %v", trend, err)
+	}
+	if trend[0].NewMembers != 1 || trend[0].Orders != 0 {
+		t.Errorf("yesterday = %+v", trend[0])
+	}
+	if p := trend[1]; p.NewMembers != 1 || p.Orders != 2 || p.OrderAmount != 30 || p.Commission != 1 {
+		t.Errorf("today = %+v, want 1 member, 2 orders, 30 amount, 1 commission", p)
+	}
+
+	// 早于汇总窗口的订单退款后，下一轮汇总重新计算支付当天的数据
+	oldDay := today.AddDate(0, 0, -10)
+	global.GVA_DB.Exec("INSERT INTO orders (id, user_id, status, final_amount, paid_at, updated_at) VALUES (5, 3, 'paid', 7, ?, ?)",
+		oldDay.Add(time.Hour), oldDay.Add(time.Hour))
+	if err = s.aggregateDay(oldDay); err != nil {
+		t.Fatal(err)
+	}
+	oldTrend := func() int64 {
+		t.Helper()
+		points, err := s.GetTrend(1, request.AffiliateStatRequest{StartDate: oldDay.Format("2006-01-02"), EndDate: oldDay.Format("2006-01-02")})
+		if err != nil || len(points) != 1 {
+			t.Fatalf("old trend = %+v, err = %v", points, err)
+		}
+		return points[0].Orders
+	}
+	if n := oldTrend(); n != 1 {
+		t.Fatalf("old day orders = %d, want 1", n)
+	}
+	global.GVA_DB.Exec("UPDATE orders SET status = 'refunded', updated_at = ? WHERE id = 5", time.Now())
+	if err = s.AggregateRecent(); err != nil {
+		t.Fatal(err)
+	}
+	if n := oldTrend(); n != 0 {
+		t.Errorf("old day orders after refund = %d, want 0", n)
+	}
+
+	links, err := s.GetLinkStats(1, request.AffiliateStatRequest{})
+	if err != nil || len(links) != 2 {
+		t.Fatalf("links = %+v, err = %v", links, err)
+	}
+	if l := links[0]; l.Channel != "forum" || l.Clicks != 2 || l.Registrations != 1 || l.Orders != 2 || l.ConversionRate != 50 {
+		t.Errorf("forum link = %+v", l)
+	}
+
+	downlines, total, err := s.GetDownlines(1, request.AffiliateDownlineRequest{PageInfo: commonReq.PageInfo{Page: 1, PageSize: 10}})
+	if err != nil || total != 2 {
+		t.Fatalf("downlines total = %d, err = %v", total, err)
+	}
+	if d := downlines[0]; d.Username != "b***b" || d.Email != "b***@example.com" || !d.Active || d.TotalSpent != 30 {
+		t.Errorf("latest downline = %+v", d)
+	}
+	if downlines[1].Active {
+		t.Errorf("downline without orders should be inactive: %+v", downlines[1])
+	}
+	_, total, _ = s.GetDownlines(1, request.AffiliateDownlineRequest{Active: "inactive"})
+	if total != 1 {
+		t.Errorf("inactive total = %d", total)
+	}
+
+	progress, err := s.GetTierProgress(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.CurrentTier == nil || progress.CurrentTier.ID != 1 || progress.NextTier.ID != 2 || progress.MembersNeeded != 3 || progress.Progress != 40 {
+		t.Errorf("progress = %+v", progress)
+	}
+}
@@ -8,8 +8,10 @@ import (
 	"ApkAdmin/model/project/response"
 	"ApkAdmin/utils/crypto"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type AppAccountService struct{}
@@ -37,7 +39,13 @@ func (s *AppAccountService) CreateAccount(req request.CreateAppAccountRequest, u
 		CreatedBy:     userId,
 	}
 	// 保存到数据库
-	return global.GVA_DB.Create(&account).Error
+	if err := global.GVA_DB.Create(&account).Error; err != nil {
+		if isDuplicateKeyError(err) {
+			return errAccountDuplicated
+		}
+		return err
+	}
+	return nil
 }
 
 func (s *AppAccountService) UpdateAccount(req request.UpdateAppAccountRequest, userID uint) error {
@@ -54,30 +62,53 @@ func (s *AppAccountService) UpdateAccount(req request.UpdateAppAccountRequest, u
 		global.GVA_LOG.Error("创建应用账号失败，查找应用账号错误", zap.Error(err))
 		return err
 	}
-	encrypted, err := crypto.EncryptAccountDetail(req.AccountDetail)
-	if err != nil {
-		return err
-	}
 	updates := map[string]interface{}{
 		"category_id":    app.CategoryID,
-		"account_detail": encrypted,
 		"extra_info":     req.ExtraInfo,
 		"account_status": req.AccountStatus,
 		"updated_by":     userID,
 	}
-	return global.GVA_DB.Model(&project.AppAccount{}).
-		Where("id = ?", req.ID).
-		Updates(updates).Error
+	// 列表和详情只返回遮盖后的账号详情，未填写或原样提交遮盖值时保留原来的账号详情
+	if req.AccountDetail != "" && req.AccountDetail != project.MaskAccountDetail(account.AccountDetail) {
+		encrypted, err := crypto.EncryptAccountDetail(req.AccountDetail)
+		if err != nil {
+			return err
+		}
+		updates["account_detail"] = encrypted
+		updates["detail_hash"] = crypto.AccountDetailHash(req.AccountDetail)
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&project.AppAccount{}).
+			Where("id = ?", req.ID).
+			Updates(updates).Error; err != nil {
+			if isDuplicateKeyError(err) {
+				return errAccountDuplicated
+			}
+			return err
+		}
+		if req.AccountStatus == 0 || req.AccountStatus == account.AccountStatus {
+			return nil
+		}
+		return tx.Create(&project.AppAccountStatusLog{
+			AccountID:  account.ID,
+			FromStatus: account.AccountStatus,
+			ToStatus:   req.AccountStatus,
+			Applied:    true,
+			Source:     constants.AccountStatusSourceManual,
+			OperatorID: userID,
+		}).Error
+	})
 }
 
 // UpdateAccountStatus 更新账号状态
-func (s *AppAccountService) UpdateAccountStatus(req request.UpdateAccountStatusRequest) (*response.BatchUpdateStatusResponse, error) {
+func (s *AppAccountService) UpdateAccountStatus(req request.UpdateAccountStatusRequest, operatorID uint) (*response.BatchUpdateStatusResponse, error) {
 	if len(req.IDs) == 0 {
 		return nil, fmt.Errorf("账号ID列表不能为空")
 	}
 	var accounts []project.AppAccount
 	err := global.GVA_DB.Model(&project.AppAccount{}).
-		Where("id in ?", req).Find(&accounts).Error
+		Select("id, account_status").
+		Where("id in ?", req.IDs).Find(&accounts).Error
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +118,7 @@ func (s *AppAccountService) UpdateAccountStatus(req request.UpdateAccountStatusR
 	}
 	// 验证每个账号的状态转换是否合法
 	var validAccountIDs []uint
+	var logs []project.AppAccountStatusLog
 	for _, account := range accounts {
 		currentStatus := account.AccountStatus
 		// 验证状态转换
@@ -99,12 +131,25 @@ func (s *AppAccountService) UpdateAccountStatus(req request.UpdateAccountStatusR
 			continue
 		}
 		validAccountIDs = append(validAccountIDs, account.ID)
+		logs = append(logs, project.AppAccountStatusLog{
+			AccountID:  account.ID,
+			FromStatus: currentStatus,
+			ToStatus:   req.Status,
+			Applied:    true,
+			Source:     constants.AccountStatusSourceManual,
+			OperatorID: operatorID,
+		})
 	}
-	// 批量更新合法的账号状态
+	// 批量更新合法的账号状态并记录变更
 	if len(validAccountIDs) > 0 {
-		err = global.GVA_DB.Model(&project.AppAccount{}).
-			Where("id in ?", validAccountIDs).
-			Update("account_status", req.Status).Error
+		err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&project.AppAccount{}).
+				Where("id in ?", validAccountIDs).
+				Update("account_status", req.Status).Error; err != nil {
+				return err
+			}
+			return tx.Create(&logs).Error
+		})
 		if err != nil {
 			return nil, fmt.Errorf("批量更新状态失败: %w", err)
 		}
@@ -169,10 +214,9 @@ func (s *AppAccountService) GetAccountList(req request.GetAppAccountListRequest)
 	return list, total, nil
 }
 
-// GetAccountDetail 获取账号详情（已解密）
+// GetAccountDetail 获取账号详情，账号内容已遮盖，明文通过 CredentialRevealService 查看
 func (s *AppAccountService) GetAccountDetail(id uint) (*response.AppAccountResponse, error) {
 	var account project.AppAccount
-	// AfterFind 会自动解密
 	if err := global.GVA_DB.Preload("Application").Preload("Creator").First(&account, id).Error; err != nil {
 		return nil, err
 	}
@@ -181,7 +225,7 @@ func (s *AppAccountService) GetAccountDetail(id uint) (*response.AppAccountRespo
 		AppID:         account.AppID,
 		AppName:       account.Application.AppName,
 		AccountNo:     account.AccountNo,
-		AccountDetail: account.AccountDetail, // 已解密
+		AccountDetail: project.MaskAccountDetail(account.AccountDetail),
 		AccountStatus: account.AccountStatus.Int(),
 		ExtraInfo:     account.ExtraInfo,
 		CreatedAt:     account.CreatedAt,
@@ -189,11 +233,50 @@ func (s *AppAccountService) GetAccountDetail(id uint) (*response.AppAccountRespo
 	}, nil
 }
 
-// GetAppAccountOrderDetail 查看售出账号的订单详情
+// GetAppAccountOrderDetail 查看售出账号的订单详情，包括售后期限和售后处理记录；售后更换出去的账号显示原订单
 func (s AppAccountService) GetAppAccountOrderDetail(accountID uint) (*response.AppAccountOrderDetailResp, error) {
 	var account project.AppAccount
-	if err := global.GVA_DB.Where("account_status = ?", constants.AppAccountStatusSold).First(&account, accountID).Error; err != nil {
+	if err := global.GVA_DB.Select("id, app_id, account_no, account_status").Where("id = ?", accountID).First(&account).Error; err != nil {
+		return nil, errors.New("账号不存在")
+	}
+	resp := &response.AppAccountOrderDetailResp{
+		AccountID:     account.ID,
+		AccountNo:     account.AccountNo,
+		AccountStatus: account.AccountStatus,
+		Claims:        []project.AccountWarrantyClaim{},
+	}
+	orders, err := accountOrders([]uint{account.ID})
+	if err != nil {
+		return nil, err
+	}
+	orderID := orders[account.ID].ID
+	resp.InOrder = orderID != 0
+	if orderID == 0 {
+		var claim project.AccountWarrantyClaim
+		err = global.GVA_DB.Select("order_id").Where("account_id = ?", account.ID).Order("id desc").Limit(1).Find(&claim).Error
+		if err != nil {
+			return nil, err
+		}
+		if claim.OrderID == 0 {
+			return nil, errors.New("该账号没有关联的订单")
+		}
+		orderID = claim.OrderID
+	}
+	var order project.Order
+	if err = global.GVA_DB.Where("id = ?", orderID).First(&order).Error; err != nil {
 		return nil, err
 	}
-	return nil, nil
+	resp.Order = &order
+
+	var app project.Application
+	if err = global.GVA_DB.Select("id, warranty_days").Where("app_id = ?", account.AppID).Limit(1).Find(&app).Error; err != nil {
+		return nil, err
+	}
+	resp.WarrantyDays = app.WarrantyDays
+	resp.WarrantyUntil = warrantyUntil(order, app.WarrantyDays)
+
+	err = global.GVA_DB.Where("order_id = ?", order.ID).
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).
+		Order("id desc").Find(&resp.Claims).Error
+	return resp, err
 }
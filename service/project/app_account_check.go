@@ -0,0 +1,378 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/project/response"
+	"ApkAdmin/utils/accountcheck"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// accountCheckWindow 定时任务间隔（分钟），每次每个检查器最多检查 RatePerMinute*accountCheckWindow 个账号
+	accountCheckWindow  = 5
+	accountCheckTimeout = 30 * time.Second
+	// accountOrdersChunk 查询账号所在订单时每条 SQL 包含的账号数
+	accountOrdersChunk = 100
+)
+
+// accountCheckMu 同一实例内不重复运行；多实例之间通过抢占 last_checked_at 避免重复检查同一账号
+var accountCheckMu sync.Mutex
+
+type AppAccountCheckService struct{}
+
+// CheckerTypes 支持的检查器类型
+func (s *AppAccountCheckService) CheckerTypes() []string {
+	return accountcheck.Types()
+}
+
+// SaveChecker 新增或修改应用的检查器，保存前校验配置能否创建检查器
+func (s *AppAccountCheckService) SaveChecker(req request.AccountCheckerSaveRequest, userID uint) (*project.AppAccountChecker, error) {
+	if err := checkAppExists(req.AppID); err != nil {
+		return nil, err
+	}
+	if _, err := accountcheck.New(req.CheckerType, req.Config); err != nil {
+		return nil, err
+	}
+	if len(req.Config) == 0 {
+		req.Config = []byte("{}")
+	}
+	if req.IntervalMinutes == 0 {
+		req.IntervalMinutes = 1440
+	}
+	if req.RatePerMinute == 0 {
+		req.RatePerMinute = 30
+	}
+	checker := project.AppAccountChecker{
+		AppID:           req.AppID,
+		CheckerType:     req.CheckerType,
+		Config:          req.Config,
+		Enabled:         req.Enabled,
+		IntervalMinutes: req.IntervalMinutes,
+		RatePerMinute:   req.RatePerMinute,
+		CreatedBy:       userID,
+	}
+	err := global.GVA_DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "app_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"checker_type", "config", "enabled", "interval_minutes", "rate_per_minute", "updated_at"}),
+	}).Create(&checker).Error
+	if err != nil {
+		return nil, err
+	}
+	err = global.GVA_DB.Where("app_id = ?", req.AppID).First(&checker).Error
+	return &checker, err
+}
+
+// DeleteChecker 删除检查器，已有的状态变更记录保留
+func (s *AppAccountCheckService) DeleteChecker(id uint) error {
+	return global.GVA_DB.Where("id = ?", id).Delete(&project.AppAccountChecker{}).Error
+}
+
+// GetCheckerList 检查器列表
+func (s *AppAccountCheckService) GetCheckerList(req request.AccountCheckerListRequest) (list []project.AppAccountChecker, total int64, err error) {
+	db := global.GVA_DB.Model(&project.AppAccountChecker{})
+	if req.AppID != "" {
+		db = db.Where("app_id = ?", req.AppID)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Scopes(req.Paginate()).Order("id desc").Find(&list).Error
+	return list, total, err
+}
+
+// TestAccount 用应用的检查器检查一个账号，只返回结果，不修改状态也不通知
+func (s *AppAccountCheckService) TestAccount(accountID uint) (*response.AccountCheckResult, error) {
+	var account project.AppAccount
+	if err := global.GVA_DB.Where("id = ?", accountID).First(&account).Error; err != nil {
+		return nil, errors.New("账号不存在")
+	}
+	if account.DecryptFailed {
+		return nil, errors.New("账号详情解密失败，无法检查")
+	}
+	var cfg project.AppAccountChecker
+	if err := global.GVA_DB.Where("app_id = ?", account.AppID).First(&cfg).Error; err != nil {
+		return nil, errors.New("该应用未配置检查器")
+	}
+	checker, err := accountcheck.New(cfg.CheckerType, cfg.Config)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), accountCheckTimeout)
+	defer cancel()
+	result, err := checker.Check(ctx, checkTarget(account))
+	if err != nil {
+		return nil, fmt.Errorf("检查失败: %w", err)
+	}
+	return &response.AccountCheckResult{AccountID: account.ID, Verdict: string(result.Verdict), Reason: result.Reason}, nil
+}
+
+// GetStatusLogs 账号状态变更记录
+func (s *AppAccountCheckService) GetStatusLogs(req request.AccountStatusLogRequest) (list []project.AppAccountStatusLog, total int64, err error) {
+	db := global.GVA_DB.Model(&project.AppAccountStatusLog{}).Where("account_id = ?", req.AccountID)
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Scopes(req.Paginate()).Order("id desc").Find(&list).Error
+	return list, total, err
+}
+
+// RunDueChecks 运行所有启用的检查器，检查到期的账号。各检查器并行，检查器内部按 RatePerMinute 限速
+func (s *AppAccountCheckService) RunDueChecks() error {
+	if !accountCheckMu.TryLock() {
+		return nil
+	}
+	defer accountCheckMu.Unlock()
+
+	var checkers []project.AppAccountChecker
+	if err := global.GVA_DB.Where("enabled = ?", true).Find(&checkers).Error; err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	for _, c := range checkers {
+		wg.Add(1)
+		go func(c project.AppAccountChecker) {
+			defer wg.Done()
+			msg := ""
+			if err := s.runChecker(c); err != nil {
+				global.GVA_LOG.Error("账号健康检查失败!", zap.Error(err), zap.String("appID", c.AppID))
+				msg = truncateRunes(err.Error(), 500)
+			}
+			global.GVA_DB.Model(&project.AppAccountChecker{}).Where("id = ?", c.ID).
+				Updates(map[string]interface{}{"last_run_at": time.Now(), "last_error": msg})
+		}(c)
+	}
+	wg.Wait()
+	return nil
+}
+
+// runChecker 检查一个应用中到期的正常和已售出账号。已售出账号检测到问题后不再重复检查
+func (s *AppAccountCheckService) runChecker(c project.AppAccountChecker) error {
+	checker, err := accountcheck.New(c.CheckerType, c.Config)
+	if err != nil {
+		return err
+	}
+	rate := c.RatePerMinute
+	if rate <= 0 {
+		rate = 30
+	}
+	due := time.Now().Add(-time.Duration(c.IntervalMinutes) * time.Minute)
+	var accounts []project.AppAccount
+	err = global.GVA_DB.
+		Where("app_id = ? AND account_status IN ?", c.AppID, []constants.AppAccountStatus{constants.AppAccountStatusNormal, constants.AppAccountStatusSold}).
+		Where("last_checked_at IS NULL OR last_checked_at < ?", due).
+		Where("NOT EXISTS (SELECT 1 FROM app_account_status_logs l WHERE l.account_id = app_accounts.id AND l.applied = ?)", false).
+		Order("last_checked_at asc, id asc").
+		Limit(rate * accountCheckWindow).
+		Find(&accounts).Error
+	if err != nil {
+		return err
+	}
+
+	gap := time.Minute / time.Duration(rate)
+	next := time.Now()
+	sold := make(map[uint]accountcheck.Result)
+	var failed int
+	for _, account := range accounts {
+		if !claimAccountCheck(account) {
+			continue
+		}
+		if account.DecryptFailed {
+			continue
+		}
+		if wait := time.Until(next); wait > 0 {
+			time.Sleep(wait)
+		}
+		next = time.Now().Add(gap)
+
+		ctx, cancel := context.WithTimeout(context.Background(), accountCheckTimeout)
+		result, err := checker.Check(ctx, checkTarget(account))
+		cancel()
+		if err != nil {
+			failed++
+			global.GVA_LOG.Warn("账号检查出错", zap.Error(err), zap.Uint("accountID", account.ID))
+			continue
+		}
+		target, ok := verdictStatus(result.Verdict)
+		if !ok {
+			continue
+		}
+		if account.AccountStatus == constants.AppAccountStatusSold {
+			sold[account.ID] = result
+			continue
+		}
+		if err := applyCheckResult(account, target, result.Reason); err != nil {
+			global.GVA_LOG.Error("更新账号状态失败!", zap.Error(err), zap.Uint("accountID", account.ID))
+		}
+	}
+	if len(sold) > 0 {
+		if err := notifySoldAccountProblems(sold); err != nil {
+			return err
+		}
+	}
+	if failed > 0 && failed == len(accounts) {
+		return fmt.Errorf("%d 个账号检查全部出错，请检查配置", failed)
+	}
+	return nil
+}
+
+func checkTarget(account project.AppAccount) accountcheck.Account {
+	return accountcheck.Account{ID: account.ID, Detail: account.AccountDetail, ExtraInfo: account.ExtraInfo}
+}
+
+func verdictStatus(v accountcheck.Verdict) (constants.AppAccountStatus, bool) {
+	switch v {
+	case accountcheck.VerdictExpired:
+		return constants.AppAccountStatusExpired, true
+	case accountcheck.VerdictRisk:
+		return constants.AppAccountStatusRisk, true
+	}
+	return 0, false
+}
+
+// claimAccountCheck 按读取时的检查时间抢占账号，其它实例已抢占时返回 false
+func claimAccountCheck(account project.AppAccount) bool {
+	db := global.GVA_DB.Model(&project.AppAccount{}).Where("id = ?", account.ID)
+	if account.LastCheckedAt == nil {
+		db = db.Where("last_checked_at IS NULL")
+	} else {
+		db = db.Where("last_checked_at = ?", *account.LastCheckedAt)
+	}
+	res := db.UpdateColumn("last_checked_at", time.Now())
+	if res.Error != nil {
+		global.GVA_LOG.Error("更新账号检查时间失败!", zap.Error(res.Error), zap.Uint("accountID", account.ID))
+		return false
+	}
+	return res.RowsAffected == 1
+}
+
+// applyCheckResult 按状态流转规则修改未售出账号的状态并记录原因，状态已被他人修改时放弃
+func applyCheckResult(account project.AppAccount, target constants.AppAccountStatus, reason string) error {
+	if !account.AccountStatus.CanTransitionTo(target) {
+		return nil
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&project.AppAccount{}).
+			Where("id = ? AND account_status = ?", account.ID, account.AccountStatus).
+			UpdateColumn("account_status", target)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return tx.Create(&project.AppAccountStatusLog{
+			AccountID:  account.ID,
+			FromStatus: account.AccountStatus,
+			ToStatus:   target,
+			Applied:    true,
+			Source:     constants.AccountStatusSourceChecker,
+			Reason:     truncateRunes(reason, 500),
+		}).Error
+	})
+}
+
+// notifySoldAccountProblems 已售出账号保持已卖出状态，记录检测结论并通知购买该账号的用户申请售后
+func notifySoldAccountProblems(results map[uint]accountcheck.Result) error {
+	ids := make([]uint, 0, len(results))
+	for id := range results {
+		ids = append(ids, id)
+	}
+	orders, err := accountOrders(ids)
+	if err != nil {
+		return err
+	}
+	var accounts []project.AppAccount
+	if err = global.GVA_DB.Select("id, app_id, account_no").Where("id IN ?", ids).Find(&accounts).Error; err != nil {
+		return err
+	}
+	appIDs := make(map[string]uint64)
+	for _, account := range accounts {
+		result := results[account.ID]
+		target, _ := verdictStatus(result.Verdict)
+		reason := truncateRunes(result.Reason, 500)
+		err = global.GVA_DB.Create(&project.AppAccountStatusLog{
+			AccountID:  account.ID,
+			FromStatus: constants.AppAccountStatusSold,
+			ToStatus:   target,
+			Applied:    false,
+			Source:     constants.AccountStatusSourceChecker,
+			Reason:     reason,
+		}).Error
+		if err != nil {
+			return err
+		}
+		order, ok := orders[account.ID]
+		if !ok {
+			continue
+		}
+		if _, ok = appIDs[account.AppID]; !ok {
+			var app project.Application
+			global.GVA_DB.Select("id").Where("app_id = ?", account.AppID).Limit(1).Find(&app)
+			appIDs[account.AppID] = app.ID
+		}
+		notification := project.UserNotification{
+			UserID: order.UserID,
+			Type:   constants.NotificationAccountHealth,
+			RefID:  uint64(account.ID),
+			AppID:  appIDs[account.AppID],
+			Title:  fmt.Sprintf("您购买的账号 %s %s", account.AccountNo, target.GetAccountStatusText()),
+			Content: truncateRunes(fmt.Sprintf("订单 %s 中的账号 %s 检测为%s：%s。如无法使用，请在订单详情中申请售后更换。",
+				order.OrderNo, account.AccountNo, target.GetAccountStatusText(), reason), 1000),
+		}
+		if err = global.GVA_DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&notification).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// accountOrders 账号所在的已支付账号订单，同一账号出现在多个订单中时取最新的订单
+// 按账号 ID 用 JSON_CONTAINS 查询 account_ids，只取包含这些账号的订单
+func accountOrders(accountIDs []uint) (map[uint]project.Order, error) {
+	wanted := make(map[uint]bool, len(accountIDs))
+	for _, id := range accountIDs {
+		wanted[id] = true
+	}
+	result := make(map[uint]project.Order)
+	for start := 0; start < len(accountIDs); start += accountOrdersChunk {
+		chunk := accountIDs[start:min(start+accountOrdersChunk, len(accountIDs))]
+		conds := make([]clause.Expression, len(chunk))
+		for i, id := range chunk {
+			conds[i] = clause.Expr{SQL: "JSON_CONTAINS(account_ids, ?)", Vars: []interface{}{strconv.FormatUint(uint64(id), 10)}}
+		}
+		var orders []project.Order
+		err := global.GVA_DB.Select("id, order_no, user_id, account_ids").
+			Where("order_type = ? AND status = ? AND account_ids IS NOT NULL", project.OrderTypeAccountProduct, project.OrderStatusPaid).
+			Where(clause.Or(conds...)).
+			Find(&orders).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			for _, id := range o.AccountIDs {
+				if wanted[id] && result[id].ID < o.ID {
+					result[id] = o
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// truncateRunes 按字符截断，避免截断多字节字符后写入失败
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -0,0 +1,104 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"encoding/json"
+	"testing"
+)
+
+func TestRunDueAccountChecks(t *testing.T) {
+	setupTestDB(t, &project.AppAccountChecker{}, &project.AppAccountStatusLog{}, &project.UserNotification{})
+	createApplicationTables(t)
+	global.GVA_DB.Exec("INSERT INTO applications (id, app_id, app_name, status) VALUES (1, 'a1', '微信', 'active'), (2, 'a2', '微信读书', 'active')")
+	for _, stmt := range []string{
+		`CREATE TABLE app_accounts (id integer PRIMARY KEY, app_id text, account_detail text, detail_hash text NOT NULL DEFAULT '',
+			category_id integer, account_no text UNIQUE, extra_info text, account_status integer, created_at datetime, updated_at datetime,
+			deleted_at datetime, created_by integer, updated_by integer, last_checked_at datetime)`,
+		"CREATE TABLE orders (id integer PRIMARY KEY, order_no text, user_id integer, order_type text, status text, account_ids text)",
+	} {
+		if err := global.GVA_DB.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	global.GVA_CONFIG.System.EncryptionKey = "test-key"
+	accounts := []project.AppAccount{
+		{ID: 1, AppID: "a1", AccountDetail: "u1", AccountNo: "ACC1", ExtraInfo: `{"expire_at":"2020-01-01"}`},
+		{ID: 2, AppID: "a1", AccountDetail: "u2", AccountNo: "ACC2", ExtraInfo: `{"expire_at":"2099-01-01"}`},
+		{ID: 3, AppID: "a1", AccountDetail: "u3", AccountNo: "ACC3", ExtraInfo: `{"expire_at":"2020-01-01"}`, AccountStatus: constants.AppAccountStatusSold},
+		{ID: 4, AppID: "a2", AccountDetail: "u4", AccountNo: "ACC4", ExtraInfo: `{"expire_at":"2020-01-01"}`},
+	}
+	if err := global.GVA_DB.Create(&accounts).Error; err != nil {
+		t.Fatal(err)
+	}
+	global.GVA_DB.Exec("INSERT INTO orders (order_no, user_id, order_type, status, account_ids) VALUES ('OLD', 5, 'account_product', 'refunded', '[3]'), ('NEW', 6, 'account_product', 'paid', '[2,3]')")
+
+	var s AppAccountCheckService
+	if _, err := s.SaveChecker(request.AccountCheckerSaveRequest{AppID: "a1", CheckerType: "http_probe", Config: json.RawMessage(`{}`)}, 1); err == nil {
+		t.Fatal("invalid config should be rejected")
+	}
+	if _, err := s.SaveChecker(request.AccountCheckerSaveRequest{AppID: "a1", CheckerType: "extra_expiry", Enabled: true, RatePerMinute: 600}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := s.TestAccount(1); err != nil || result.Verdict != "expired" {
+		t.Fatalf("TestAccount = %+v, %v", result, err)
+	}
+
+	if err := s.RunDueChecks(); err != nil {
+		t.Fatal(err)
+	}
+	statuses := map[uint]constants.AppAccountStatus{}
+	var rows []project.AppAccount
+	global.GVA_DB.Select("id, account_status, last_checked_at").Find(&rows)
+	for _, r := range rows {
+		statuses[r.ID] = r.AccountStatus
+		if (r.LastCheckedAt == nil) != (r.ID == 4) {
+			t.Errorf("account %d last_checked_at = %v", r.ID, r.LastCheckedAt)
+		}
+	}
+	want := map[uint]constants.AppAccountStatus{1: constants.AppAccountStatusExpired, 2: constants.AppAccountStatusNormal, 3: constants.AppAccountStatusSold, 4: constants.AppAccountStatusNormal}
+	for id, status := range want {
+		if statuses[id] != status {
+			t.Errorf("account %d status = %d, want %d", id, statuses[id], status)
+		}
+	}
+
+	var logs []project.AppAccountStatusLog
+	global.GVA_DB.Order("account_id").Find(&logs)
+	if len(logs) != 2 || logs[0].AccountID != 1 || !logs[0].Applied || logs[0].ToStatus != constants.AppAccountStatusExpired ||
+		logs[1].AccountID != 3 || logs[1].Applied || logs[1].Source != constants.AccountStatusSourceChecker {
+		t.Fatalf("logs = %+v", logs)
+	}
+	var notes []project.UserNotification
+	global.GVA_DB.Find(&notes)
+	if len(notes) != 1 || notes[0].UserID != 6 || notes[0].RefID != 3 || notes[0].Type != constants.NotificationAccountHealth {
+		t.Fatalf("notifications = %+v", notes)
+	}
+
+	// 已通知过的售出账号即使到了检查间隔也不再重复检查
+	global.GVA_DB.Exec("UPDATE app_accounts SET last_checked_at = NULL")
+	if err := s.RunDueChecks(); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	global.GVA_DB.Model(&project.AppAccountStatusLog{}).Count(&count)
+	if count != 2 {
+		t.Errorf("status logs after rerun = %d", count)
+	}
+
+	// 手动修改状态同样记录
+	var accountService AppAccountService
+	resp, err := accountService.UpdateAccountStatus(request.UpdateAccountStatusRequest{IDs: []uint{1, 3}, Status: constants.AppAccountStatusNormal}, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.SuccessCount != 1 || resp.FailCount != 1 {
+		t.Errorf("manual update = %+v", resp)
+	}
+	list, total, err := s.GetStatusLogs(request.AccountStatusLogRequest{AccountID: 1})
+	if err != nil || total != 2 || list[0].Source != constants.AccountStatusSourceManual || list[0].OperatorID != 9 {
+		t.Errorf("status logs = %+v, %d, %v", list, total, err)
+	}
+}
@@ -0,0 +1,444 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/project/response"
+	"ApkAdmin/utils/crypto"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	maxAccountImportRows   = 5000
+	accountImportBatchSize = 200
+	maxAccountDetailLength = 4000
+
+	importResultOK        = "ok"
+	importResultDuplicate = "duplicate"
+	importResultError     = "error"
+)
+
+// errAccountDuplicated 同一应用下已存在相同的账号详情
+var errAccountDuplicated = errors.New("该应用下已存在相同账号")
+
+// accountImportColumns 模板列名，兼容中文表头
+var accountImportColumns = map[string]string{
+	"app_id":         "app_id",
+	"应用标识":           "app_id",
+	"account_detail": "account_detail",
+	"账号详情":           "account_detail",
+	"extra_info":     "extra_info",
+	"额外信息":           "extra_info",
+	"status":         "status",
+	"account_status": "status",
+	"状态":             "status",
+}
+
+// accountImportStatuses 导入时允许的账号状态，已卖出的账号不能导入
+var accountImportStatuses = map[string]constants.AppAccountStatus{
+	"1": constants.AppAccountStatusNormal, "正常": constants.AppAccountStatusNormal,
+	"2": constants.AppAccountStatusBanned, "封禁": constants.AppAccountStatusBanned,
+	"3": constants.AppAccountStatusExpired, "过期": constants.AppAccountStatusExpired,
+	"4": constants.AppAccountStatusRisk, "风险": constants.AppAccountStatusRisk,
+}
+
+// AccountImportTemplate 导入模板（CSV，带 BOM 以便 Excel 正确识别中文）
+func (s *AppAccountService) AccountImportTemplate() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	w.WriteAll([][]string{
+		{"app_id", "account_detail", "extra_info", "status"},
+		{"com.example.app", "账号：user@example.com 密码：123456", `{"region":"US"}`, "1"},
+	})
+	return buf.Bytes()
+}
+
+// ImportAccounts 批量导入账号。逐行校验应用和字段，按账号详情的带密钥哈希查重（文件内和同一应用的已有账号），
+// dryRun 时只返回预览；正式导入在一个事务中分批写入，任何一批失败全部回滚
+func (s *AppAccountService) ImportAccounts(fileName string, rows [][]string, dryRun bool, userID uint) (*response.AccountImportResult, error) {
+	if len(rows) < 2 {
+		return nil, errors.New("文件中没有数据行")
+	}
+	if len(rows)-1 > maxAccountImportRows {
+		return nil, fmt.Errorf("一次最多导入%d行", maxAccountImportRows)
+	}
+	columns, err := accountImportHeader(rows[0])
+	if err != nil {
+		return nil, err
+	}
+
+	result := &response.AccountImportResult{DryRun: dryRun, TotalRows: len(rows) - 1, Rows: make([]response.AccountImportRow, 0, len(rows)-1)}
+	accounts, err := s.validateImportRows(rows, columns, result, userID, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	job := project.AppAccountImportJob{
+		FileName:      fileName,
+		DryRun:        dryRun,
+		Status:        constants.AccountImportStatusPreview,
+		TotalRows:     result.TotalRows,
+		ValidRows:     result.ValidRows,
+		DuplicateRows: result.DuplicateRows,
+		ErrorRows:     result.ErrorRows,
+		CreatedBy:     userID,
+	}
+	if !dryRun {
+		job.Status = constants.AccountImportStatusCompleted
+		if err = s.createImportedAccounts(accounts); err != nil {
+			global.GVA_LOG.Error("批量导入账号失败!", zap.Error(err))
+			job.Status = constants.AccountImportStatusFailed
+			job.Message = err.Error()
+		} else {
+			job.ImportedRows = len(accounts)
+			result.ImportedRows = len(accounts)
+		}
+	}
+
+	var problems []response.AccountImportRow
+	for _, r := range result.Rows {
+		if r.Result != importResultOK {
+			r.Preview = ""
+			problems = append(problems, r)
+		}
+	}
+	report, _ := json.Marshal(problems)
+	job.Report = string(report)
+	if createErr := global.GVA_DB.Create(&job).Error; createErr != nil {
+		global.GVA_LOG.Error("保存导入记录失败!", zap.Error(createErr))
+	}
+	result.JobID = job.ID
+	if job.Status == constants.AccountImportStatusFailed {
+		return result, fmt.Errorf("导入失败，已全部回滚：%s", job.Message)
+	}
+	return result, nil
+}
+
+// accountImportHeader 解析表头，返回字段到列号的映射
+func accountImportHeader(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, name := range header {
+		key, ok := accountImportColumns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			continue
+		}
+		if _, dup := columns[key]; dup {
+			return nil, fmt.Errorf("表头中 %s 列重复", key)
+		}
+		columns[key] = i
+	}
+	for _, required := range []string{"app_id", "account_detail"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("表头缺少 %s 列，请使用导入模板", required)
+		}
+	}
+	return columns, nil
+}
+
+// validateImportRows 逐行校验，结果写入 result，返回可以导入的账号（AccountDetail 为明文，写入时由模型钩子加密）
+func (s *AppAccountService) validateImportRows(rows [][]string, columns map[string]int, result *response.AccountImportResult, userID uint, dryRun bool) ([]project.AppAccount, error) {
+	cell := func(row []string, key string) string {
+		i, ok := columns[key]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	appIDs := make([]string, 0)
+	seenApp := make(map[string]bool)
+	for _, row := range rows[1:] {
+		if id := cell(row, "app_id"); id != "" && !seenApp[id] {
+			seenApp[id] = true
+			appIDs = append(appIDs, id)
+		}
+	}
+	apps := make(map[string]project.Application)
+	if len(appIDs) > 0 {
+		var list []project.Application
+		if err := global.GVA_DB.Select("id, app_id, category_id, status").Where("app_id IN ?", appIDs).Find(&list).Error; err != nil {
+			return nil, err
+		}
+		for _, app := range list {
+			apps[app.AppID] = app
+		}
+	}
+
+	type candidate struct {
+		index   int // result.Rows 中的下标
+		account project.AppAccount
+	}
+	var candidates []candidate
+	firstRow := make(map[string]int) // app_id + 哈希 → 首次出现的行号
+	for i, row := range rows[1:] {
+		line := response.AccountImportRow{Row: i + 2, AppID: cell(row, "app_id"), Result: importResultOK}
+		account, err := parseImportRow(line.AppID, cell(row, "account_detail"), cell(row, "extra_info"), cell(row, "status"), apps)
+		if err != nil {
+			line.Result, line.Message = importResultError, err.Error()
+			result.Rows = append(result.Rows, line)
+			continue
+		}
+		line.Preview = project.MaskAccountDetail(account.AccountDetail)
+		account.DetailHash = crypto.AccountDetailHash(account.AccountDetail)
+		account.CreatedBy = userID
+		key := account.AppID + ":" + account.DetailHash
+		if first, dup := firstRow[key]; dup {
+			line.Result, line.Message = importResultDuplicate, fmt.Sprintf("与第%d行重复", first)
+		} else {
+			firstRow[key] = line.Row
+			candidates = append(candidates, candidate{index: len(result.Rows), account: account})
+		}
+		result.Rows = append(result.Rows, line)
+	}
+
+	// 与已有账号查重
+	hashes := make([]string, len(candidates))
+	for i, c := range candidates {
+		hashes[i] = c.account.DetailHash
+	}
+	existing, err := existingDetailHashes(appIDs, hashes, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]project.AppAccount, 0, len(candidates))
+	for _, c := range candidates {
+		if existing[c.account.AppID+":"+c.account.DetailHash] {
+			result.Rows[c.index].Result = importResultDuplicate
+			result.Rows[c.index].Message = errAccountDuplicated.Error()
+			continue
+		}
+		accounts = append(accounts, c.account)
+	}
+	for _, r := range result.Rows {
+		switch r.Result {
+		case importResultOK:
+			result.ValidRows++
+		case importResultDuplicate:
+			result.DuplicateRows++
+		default:
+			result.ErrorRows++
+		}
+	}
+	return accounts, nil
+}
+
+func parseImportRow(appID, detail, extra, status string, apps map[string]project.Application) (project.AppAccount, error) {
+	var account project.AppAccount
+	if appID == "" {
+		return account, errors.New("app_id 不能为空")
+	}
+	app, ok := apps[appID]
+	if !ok {
+		return account, fmt.Errorf("应用 %s 不存在", appID)
+	}
+	if app.Status == constants.ApplicationStatusDeleted {
+		return account, fmt.Errorf("应用 %s 已删除", appID)
+	}
+	if app.CategoryID == nil {
+		return account, fmt.Errorf("应用 %s 未设置分类", appID)
+	}
+	if detail == "" {
+		return account, errors.New("账号详情不能为空")
+	}
+	if utf8.RuneCountInString(detail) > maxAccountDetailLength {
+		return account, fmt.Errorf("账号详情不能超过%d个字符", maxAccountDetailLength)
+	}
+	accountStatus := constants.AppAccountStatusNormal
+	if status != "" {
+		if accountStatus, ok = accountImportStatuses[status]; !ok {
+			return account, fmt.Errorf("状态 %s 无效，可选 1正常 2封禁 3过期 4风险", status)
+		}
+	}
+	// 额外信息与手动创建一致，保存为 JSON；不是 JSON 时作为字符串保存
+	extraInfo := ""
+	if extra != "" {
+		if json.Valid([]byte(extra)) {
+			var buf bytes.Buffer
+			json.Compact(&buf, []byte(extra))
+			extraInfo = buf.String()
+		} else {
+			data, _ := json.Marshal(extra)
+			extraInfo = string(data)
+		}
+	}
+	return project.AppAccount{
+		AppID:         appID,
+		AccountDetail: detail,
+		ExtraInfo:     extraInfo,
+		CategoryID:    *app.CategoryID,
+		AccountStatus: accountStatus,
+	}, nil
+}
+
+// existingDetailHashes 指定应用下已存在的哈希（包括已删除的账号，与唯一索引一致），键为 app_id:哈希。
+// 历史账号没有哈希时解密计算，正式导入时顺便补齐，预览时不写库
+func existingDetailHashes(appIDs, hashes []string, dryRun bool) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(appIDs) == 0 || len(hashes) == 0 {
+		return existing, nil
+	}
+	wanted := make(map[string]bool, len(hashes))
+	for _, h := range hashes {
+		wanted[h] = true
+	}
+	err := legacyDetailHashes(appIDs, func(account project.AppAccount, hash string) error {
+		if wanted[hash] {
+			existing[account.AppID+":"+hash] = true
+		}
+		if dryRun {
+			return nil
+		}
+		err := global.GVA_DB.Unscoped().Model(&project.AppAccount{}).Where("id = ?", account.ID).
+			UpdateColumn("detail_hash", hash).Error
+		// 历史数据中同一应用下有重复账号，保留先补齐的那条，其余不设置哈希
+		if isDuplicateKeyError(err) {
+			global.GVA_LOG.Warn("历史账号与已有账号重复，跳过补齐哈希", zap.Uint("accountID", account.ID), zap.String("appID", account.AppID))
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var rows []project.AppAccount
+	err = global.GVA_DB.Unscoped().Select("app_id, detail_hash").Where("app_id IN ? AND detail_hash IN ?", appIDs, hashes).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		existing[r.AppID+":"+r.DetailHash] = true
+	}
+	return existing, nil
+}
+
+// legacyDetailHashes 逐个计算指定应用下还没有哈希的账号的哈希，查询时模型钩子会解密账号详情
+func legacyDetailHashes(appIDs []string, fn func(account project.AppAccount, hash string) error) error {
+	var batch []project.AppAccount
+	return global.GVA_DB.Unscoped().Select("id, app_id, account_detail").
+		Where("app_id IN ? AND (detail_hash = '' OR detail_hash IS NULL)", appIDs).
+		FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+			for _, a := range batch {
+				if a.AccountDetail == "" {
+					continue
+				}
+				if err := fn(a, crypto.AccountDetailHash(a.AccountDetail)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// isDuplicateKeyError 是否违反唯一索引：MySQL 1062，或开启 TranslateError 后 gorm 转换的 ErrDuplicatedKey
+func isDuplicateKeyError(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+// createImportedAccounts 在一个事务中分批写入，账号编号在批内和库中都不重复
+func (s *AppAccountService) createImportedAccounts(accounts []project.AppAccount) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := assignAccountNos(tx, accounts); err != nil {
+			return err
+		}
+		err := tx.CreateInBatches(&accounts, accountImportBatchSize).Error
+		// 预览之后其它导入或手动添加了相同账号，唯一索引拦截后整体回滚
+		if isDuplicateKeyError(err) {
+			return errAccountDuplicated
+		}
+		return err
+	})
+}
+
+func assignAccountNos(tx *gorm.DB, accounts []project.AppAccount) error {
+	used := make(map[string]bool, len(accounts))
+	pending := make([]int, len(accounts))
+	for i := range pending {
+		pending[i] = i
+	}
+	for attempt := 0; len(pending) > 0; attempt++ {
+		if attempt >= 10 {
+			return errors.New("生成账号编号失败，请重试")
+		}
+		nos := make([]string, 0, len(pending))
+		for _, i := range pending {
+			no := project.GenerateAccountNo()
+			for used[no] {
+				no = project.GenerateAccountNo()
+			}
+			used[no] = true
+			accounts[i].AccountNo = no
+			nos = append(nos, no)
+		}
+		var taken []string
+		if err := tx.Unscoped().Model(&project.AppAccount{}).Where("account_no IN ?", nos).Pluck("account_no", &taken).Error; err != nil {
+			return err
+		}
+		takenSet := make(map[string]bool, len(taken))
+		for _, no := range taken {
+			takenSet[no] = true
+		}
+		var retry []int
+		for _, i := range pending {
+			if takenSet[accounts[i].AccountNo] {
+				retry = append(retry, i)
+			}
+		}
+		pending = retry
+	}
+	return nil
+}
+
+// GetImportJobList 导入记录列表
+func (s *AppAccountService) GetImportJobList(req request.AccountImportJobListRequest) (list []project.AppAccountImportJob, total int64, err error) {
+	db := global.GVA_DB.Model(&project.AppAccountImportJob{})
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Scopes(req.Paginate()).Order("id desc").Find(&list).Error
+	return list, total, err
+}
+
+// ImportReportCSV 导入问题报告，CSV 格式
+func (s *AppAccountService) ImportReportCSV(id uint) ([]byte, string, error) {
+	var job project.AppAccountImportJob
+	if err := global.GVA_DB.Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, "", errors.New("导入记录不存在")
+	}
+	var rows []response.AccountImportRow
+	if job.Report != "" {
+		if err := json.Unmarshal([]byte(job.Report), &rows); err != nil {
+			return nil, "", err
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"行号", "app_id", "结果", "说明"})
+	for _, r := range rows {
+		w.Write([]string{strconv.Itoa(r.Row), r.AppID, r.Result, r.Message})
+	}
+	w.Flush()
+	name := fmt.Sprintf("account_import_%d_%s.csv", job.ID, job.CreatedAt.Format("20060102150405"))
+	return buf.Bytes(), name, w.Error()
+}
@@ -0,0 +1,106 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"testing"
+)
+
+func TestImportAccounts(t *testing.T) {
+	setupTestDB(t, &project.AppAccountImportJob{})
+	createApplicationTables(t)
+	global.GVA_DB.Exec("INSERT INTO applications (id, app_id, app_name, category_id, status) VALUES (1, 'a1', '微信', 1, 'active'), (2, 'a2', '微信读书', 2, 'active')")
+	for _, ddl := range []string{
+		`CREATE TABLE app_accounts (id integer PRIMARY KEY, app_id text, account_detail text, detail_hash text,
+			category_id integer, account_no text UNIQUE, extra_info text, account_status integer, created_at datetime, updated_at datetime,
+			deleted_at datetime, created_by integer, updated_by integer, last_checked_at datetime)`,
+		`CREATE UNIQUE INDEX uk_app_detail_hash ON app_accounts (app_id, detail_hash)`,
+	} {
+		if err := global.GVA_DB.Exec(ddl).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	global.GVA_CONFIG.System.EncryptionKey = "test-key"
+	var s AppAccountService
+	// 已有账号没有哈希，导入时补齐后参与查重
+	global.GVA_DB.Create(&project.AppAccount{AppID: "a1", AccountDetail: "old@example.com / pass", CategoryID: 1, AccountNo: "ACC1"})
+	global.GVA_DB.Exec("UPDATE app_accounts SET detail_hash = NULL")
+	legacyHashSet := func() bool {
+		var n int64
+		global.GVA_DB.Model(&project.AppAccount{}).Where("account_no = ? AND detail_hash IS NOT NULL", "ACC1").Count(&n)
+		return n == 1
+	}
+
+	rows := [][]string{
+		{"应用标识", "账号详情", "额外信息", "状态"},
+		{"a1", "new@example.com / pass", `{"region": "US"}`, ""},
+		{"a1", " new@example.com / pass ", "", "1"},
+		{"a1", "old@example.com / pass", "", ""},
+		{"missing", "x@example.com", "", ""},
+		{"a2", "y@example.com", "note", "风险"},
+		{"a2", "z@example.com", "", "5"},
+		{"a2", "", "", ""},
+	}
+	preview, err := s.ImportAccounts("accounts.csv", rows, true, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.ValidRows != 2 || preview.DuplicateRows != 2 || preview.ErrorRows != 3 || preview.ImportedRows != 0 {
+		t.Fatalf("preview = %+v", preview)
+	}
+	if r := preview.Rows[1]; r.Row != 3 || r.Result != importResultDuplicate || r.Message != "与第2行重复" {
+		t.Errorf("in-file duplicate = %+v", r)
+	}
+	var count int64
+	global.GVA_DB.Model(&project.AppAccount{}).Count(&count)
+	if count != 1 || legacyHashSet() {
+		t.Fatalf("dry run should not write, count = %d, legacy hash set = %v", count, legacyHashSet())
+	}
+
+	result, err := s.ImportAccounts("accounts.csv", rows, false, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ImportedRows != 2 || !legacyHashSet() {
+		t.Fatalf("result = %+v, legacy hash set = %v", result, legacyHashSet())
+	}
+	var imported []project.AppAccount
+	global.GVA_DB.Where("created_by = ?", 9).Order("id").Find(&imported)
+	if len(imported) != 2 || imported[0].AccountDetail != "new@example.com / pass" || imported[0].ExtraInfo != `{"region":"US"}` ||
+		imported[1].ExtraInfo != `"note"` || imported[1].AccountStatus != constants.AppAccountStatusRisk {
+		t.Errorf("imported = %+v", imported)
+	}
+	var stored string
+	global.GVA_DB.Raw("SELECT account_detail FROM app_accounts WHERE id = ?", imported[0].ID).Scan(&stored)
+	if stored == imported[0].AccountDetail {
+		t.Error("account detail should be encrypted at rest")
+	}
+
+	// 再次导入全部重复
+	result, _ = s.ImportAccounts("accounts.csv", rows[:3], false, 9)
+	if result.ImportedRows != 0 || result.DuplicateRows != 2 {
+		t.Errorf("reimport = %+v", result)
+	}
+
+	// 预览之后同时进行的导入已写入相同账号，唯一索引拦截后整体回滚
+	err = s.createImportedAccounts([]project.AppAccount{
+		{AppID: "a2", AccountDetail: "other@example.com", CategoryID: 1},
+		{AppID: "a1", AccountDetail: "new@example.com / pass", CategoryID: 1},
+	})
+	if err != errAccountDuplicated {
+		t.Errorf("concurrent duplicate err = %v", err)
+	}
+	global.GVA_DB.Model(&project.AppAccount{}).Count(&count)
+	if count != 3 {
+		t.Errorf("conflicting import should roll back, count = %d", count)
+	}
+
+	data, _, err := s.ImportReportCSV(result.JobID)
+	if err != nil || len(data) == 0 {
+		t.Fatalf("report err = %v", err)
+	}
+	if _, err = s.ImportAccounts("a.csv", [][]string{{"app_id"}, {"a1"}}, true, 9); err == nil {
+		t.Error("missing account_detail column should fail")
+	}
+}
@@ -0,0 +1,54 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils/crypto"
+	"encoding/json"
+	"testing"
+)
+
+func TestUpdateAccountKeepsMaskedDetail(t *testing.T) {
+	setupTestDB(t)
+	createApplicationTables(t)
+	global.GVA_DB.Exec("INSERT INTO applications (id, app_id, app_name, category_id, status) VALUES (1, 'a1', '微信', 1, 'active')")
+	if err := global.GVA_DB.Exec(`CREATE TABLE app_accounts (id integer PRIMARY KEY, app_id text, account_detail text, detail_hash text,
+		category_id integer, account_no text UNIQUE, extra_info text, account_status integer, created_at datetime, updated_at datetime,
+		deleted_at datetime, created_by integer, updated_by integer, last_checked_at datetime)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	global.GVA_CONFIG.System.EncryptionKey = "test-key"
+	normal := constants.AppAccountStatusNormal
+	global.GVA_DB.Create(&project.AppAccount{ID: 1, AppID: "a1", AccountDetail: "alice----secret", CategoryID: 1, AccountNo: "ACC1", AccountStatus: normal})
+
+	load := func() project.AppAccount {
+		var account project.AppAccount
+		global.GVA_DB.First(&account, 1)
+		return account
+	}
+	// 编辑页拿到的是遮盖后的详情，原样保存或留空都不能覆盖原账号
+	var shown struct {
+		AccountDetail string `json:"account_detail"`
+	}
+	b, _ := json.Marshal(load())
+	_ = json.Unmarshal(b, &shown)
+	var s AppAccountService
+	for _, detail := range []string{shown.AccountDetail, ""} {
+		err := s.UpdateAccount(request.UpdateAppAccountRequest{ID: 1, AppID: "a1", AccountDetail: detail, AccountStatus: normal}, 9)
+		if err != nil {
+			t.Fatalf("UpdateAccount(%q) error = %v", detail, err)
+		}
+		if got := load(); got.AccountDetail != "alice----secret" || got.DetailHash != crypto.AccountDetailHash("alice----secret") {
+			t.Errorf("after saving %q detail = %q", detail, got.AccountDetail)
+		}
+	}
+
+	if err := s.UpdateAccount(request.UpdateAppAccountRequest{ID: 1, AppID: "a1", AccountDetail: "alice----newpass", AccountStatus: normal}, 9); err != nil {
+		t.Fatal(err)
+	}
+	if got := load(); got.AccountDetail != "alice----newpass" || got.DetailHash != crypto.AccountDetailHash("alice----newpass") {
+		t.Errorf("changed detail = %q", got.AccountDetail)
+	}
+}
@@ -0,0 +1,162 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	projectModel "ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/project/response"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const notifyBatchSize = 500
+
+type AppFollowService struct{}
+
+var appFollowService = AppFollowService{}
+
+// Follow 关注应用，重复关注不报错
+func (s *AppFollowService) Follow(userID uint, appID uint64) error {
+	var count int64
+	err := global.GVA_DB.Model(&projectModel.Application{}).
+		Where("id = ? AND status = ?", appID, constants.ApplicationStatusActive).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("应用不存在或已下架")
+	}
+	follow := projectModel.AppFollow{UserID: userID, AppID: appID}
+	return global.GVA_DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error
+}
+
+// Unfollow 取消关注
+func (s *AppFollowService) Unfollow(userID uint, appID uint64) error {
+	return global.GVA_DB.Where("user_id = ? AND app_id = ?", userID, appID).Delete(&projectModel.AppFollow{}).Error
+}
+
+// IsFollowing 是否已关注
+func (s *AppFollowService) IsFollowing(userID uint, appID uint64) (bool, error) {
+	var count int64
+	err := global.GVA_DB.Model(&projectModel.AppFollow{}).Where("user_id = ? AND app_id = ?", userID, appID).Count(&count).Error
+	return count > 0, err
+}
+
+// GetFollowList 我关注的应用，附带最新发布的版本
+func (s *AppFollowService) GetFollowList(userID uint, req request.FollowListRequest) (list []response.AppFollowItem, total int64, err error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 50 {
+		req.PageSize = 20
+	}
+	db := global.GVA_DB.Model(&projectModel.AppFollow{}).Where("user_id = ?", userID)
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var follows []projectModel.AppFollow
+	err = db.Order("created_at desc, id desc").
+		Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).
+		Find(&follows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uint64, len(follows))
+	for i, f := range follows {
+		ids[i] = f.AppID
+	}
+	apps, err := applicationsByID(ids, "id, app_id, app_name, app_icon")
+	if err != nil {
+		return nil, 0, err
+	}
+	appIDs := make([]string, 0, len(apps))
+	for _, app := range apps {
+		appIDs = append(appIDs, app.AppID)
+	}
+	latest := make(map[string]projectModel.AppPackage)
+	if len(appIDs) > 0 {
+		var packages []projectModel.AppPackage
+		err = global.GVA_DB.Select("app_id, version_name, published_at").
+			Where("app_id IN ? AND status = ?", appIDs, constants.StatusPublished).
+			Order("published_at desc, version_code desc").
+			Find(&packages).Error
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, p := range packages {
+			if _, ok := latest[p.AppID]; !ok {
+				latest[p.AppID] = p
+			}
+		}
+	}
+
+	list = make([]response.AppFollowItem, 0, len(follows))
+	for _, f := range follows {
+		item := response.AppFollowItem{AppID: f.AppID, FollowedAt: f.CreatedAt}
+		if app := apps[f.AppID]; app != nil {
+			item.AppName = app.AppName
+			item.AppIcon = app.AppIcon
+			if p, ok := latest[app.AppID]; ok {
+				item.LatestVersion = p.VersionName
+				item.LatestAt = p.PublishedAt
+			}
+		}
+		list = append(list, item)
+	}
+	return list, total, nil
+}
+
+// NotifyNewVersions 安装包发布后通知关注该应用的用户，同一安装包对同一用户只通知一次
+func (s *AppFollowService) NotifyNewVersions(packageIDs ...uint64) {
+	if len(packageIDs) == 0 {
+		return
+	}
+	var packages []projectModel.AppPackage
+	err := global.GVA_DB.Select("id, app_id, platform, version_name").
+		Where("id IN ? AND status = ?", packageIDs, constants.StatusPublished).
+		Find(&packages).Error
+	if err != nil {
+		global.GVA_LOG.Error("查询新版本安装包失败!", zap.Error(err))
+		return
+	}
+	for _, p := range packages {
+		if err := s.notifyPackage(p); err != nil {
+			global.GVA_LOG.Error("发送新版本通知失败!", zap.Error(err), zap.Uint64("packageID", p.ID))
+		}
+	}
+}
+
+func (s *AppFollowService) notifyPackage(pkg projectModel.AppPackage) error {
+	var app projectModel.Application
+	err := global.GVA_DB.Select("id, app_name").
+		Where("app_id = ? AND status = ?", pkg.AppID, constants.ApplicationStatusActive).
+		Limit(1).Find(&app).Error
+	if err != nil || app.ID == 0 {
+		return err
+	}
+	title := fmt.Sprintf("%s 发布了新版本 %s", app.AppName, pkg.VersionName)
+	content := fmt.Sprintf("您关注的应用 %s 发布了 %s 平台的新版本 %s，快去更新吧。", app.AppName, pkg.Platform, pkg.VersionName)
+
+	var follows []projectModel.AppFollow
+	return global.GVA_DB.Select("id, user_id").Where("app_id = ?", app.ID).
+		FindInBatches(&follows, notifyBatchSize, func(tx *gorm.DB, batch int) error {
+			notifications := make([]projectModel.UserNotification, len(follows))
+			for i, f := range follows {
+				notifications[i] = projectModel.UserNotification{
+					UserID:  f.UserID,
+					Type:    constants.NotificationAppUpdate,
+					RefID:   pkg.ID,
+					AppID:   app.ID,
+					Title:   title,
+					Content: content,
+				}
+			}
+			return global.GVA_DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&notifications).Error
+		}).Error
+}
@@ -0,0 +1,314 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	projectModel "ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/project/response"
+	"ApkAdmin/utils/locale"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxStorePackages = 20
+
+type AppListingService struct{}
+
+// supportedLanguages 国家地区配置中出现的所有语言代码（已统一格式）
+func supportedLanguages() (map[string]bool, error) {
+	var countries []projectModel.CountryRegion
+	if err := global.GVA_DB.Select("language_codes").Find(&countries).Error; err != nil {
+		return nil, err
+	}
+	langs := make(map[string]bool)
+	for _, c := range countries {
+		for _, code := range c.LanguageCodes {
+			if code = locale.Normalize(code); code != "" {
+				langs[code] = true
+			}
+		}
+	}
+	return langs, nil
+}
+
+// checkLanguageCode 校验语言代码必须来自国家地区配置，返回统一格式后的代码；allowEmpty 时空字符串表示通用
+func checkLanguageCode(code string, allowEmpty bool) (string, error) {
+	code = locale.Normalize(code)
+	if code == "" {
+		if allowEmpty {
+			return "", nil
+		}
+		return "", errors.New("语言代码不能为空")
+	}
+	langs, err := supportedLanguages()
+	if err != nil {
+		return "", err
+	}
+	if !langs[code] {
+		return "", fmt.Errorf("语言代码 %s 不在国家地区配置的语言列表中", code)
+	}
+	return code, nil
+}
+
+// ==================== 本地化信息 ====================
+
+// SaveListing 新增或更新应用某个语言的本地化信息
+func (s *AppListingService) SaveListing(req request.AppListingSaveRequest) (*projectModel.AppListing, error) {
+	if err := checkAppExists(req.AppID); err != nil {
+		return nil, err
+	}
+	lang, err := checkLanguageCode(req.LanguageCode, false)
+	if err != nil {
+		return nil, err
+	}
+	listing := projectModel.AppListing{
+		AppID:            req.AppID,
+		LanguageCode:     lang,
+		AppName:          strings.TrimSpace(req.AppName),
+		ShortDescription: strings.TrimSpace(req.ShortDescription),
+		Description:      req.Description,
+	}
+	err = global.GVA_DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "app_id"}, {Name: "language_code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"app_name", "short_description", "description", "updated_at"}),
+	}).Create(&listing).Error
+	if err != nil {
+		return nil, err
+	}
+	err = global.GVA_DB.Where("app_id = ? AND language_code = ?", req.AppID, lang).First(&listing).Error
+	if err != nil {
+		return nil, err
+	}
+	appSearchService.ReindexByAppIDs(req.AppID)
+	return &listing, nil
+}
+
+// DeleteListing 删除本地化信息
+func (s *AppListingService) DeleteListing(id uint) error {
+	var listing projectModel.AppListing
+	if err := global.GVA_DB.Where("id = ?", id).First(&listing).Error; err != nil {
+		return errors.New("记录不存在")
+	}
+	if err := global.GVA_DB.Delete(&listing).Error; err != nil {
+		return err
+	}
+	appSearchService.ReindexByAppIDs(listing.AppID)
+	return nil
+}
+
+// GetListings 应用的所有本地化信息
+func (s *AppListingService) GetListings(appID string) (list []projectModel.AppListing, err error) {
+	err = global.GVA_DB.Where("app_id = ?", appID).Order("language_code asc").Find(&list).Error
+	return list, err
+}
+
+// ==================== 更新说明 ====================
+
+// SaveChangelog 新增或更新安装包某个语言的更新说明
+func (s *AppListingService) SaveChangelog(req request.ChangelogSaveRequest) (*projectModel.AppPackageChangelog, error) {
+	var count int64
+	if err := global.GVA_DB.Model(&projectModel.AppPackage{}).Where("id = ?", req.PackageID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, errors.New("安装包不存在")
+	}
+	lang, err := checkLanguageCode(req.LanguageCode, false)
+	if err != nil {
+		return nil, err
+	}
+	changelog := projectModel.AppPackageChangelog{PackageID: req.PackageID, LanguageCode: lang, Changelog: req.Changelog}
+	err = global.GVA_DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "package_id"}, {Name: "language_code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"changelog", "updated_at"}),
+	}).Create(&changelog).Error
+	if err != nil {
+		return nil, err
+	}
+	err = global.GVA_DB.Where("package_id = ? AND language_code = ?", req.PackageID, lang).First(&changelog).Error
+	return &changelog, err
+}
+
+// DeleteChangelog 删除更新说明
+func (s *AppListingService) DeleteChangelog(id uint) error {
+	return global.GVA_DB.Where("id = ?", id).Delete(&projectModel.AppPackageChangelog{}).Error
+}
+
+// GetChangelogs 安装包的所有更新说明
+func (s *AppListingService) GetChangelogs(packageID uint64) (list []projectModel.AppPackageChangelog, err error) {
+	err = global.GVA_DB.Where("package_id = ?", packageID).Order("language_code asc").Find(&list).Error
+	return list, err
+}
+
+// ==================== 前台详情 ====================
+
+// StoreDetail 前台应用详情。语言优先级：lang 参数、Accept-Language、访问者所在国家的官方语言
+func (s *AppListingService) StoreDetail(req request.AppStoreDetailRequest, acceptLanguage, countryCode string) (*response.AppStoreDetail, error) {
+	preferred, err := preferredLanguages(req.Lang, acceptLanguage, countryCode)
+	if err != nil {
+		return nil, err
+	}
+	var app projectModel.Application
+	err = global.GVA_DB.Where("id = ? AND status = ?", req.AppID, constants.ApplicationStatusActive).
+		Scopes(visibleInCountry(countryCode)).
+		First(&app).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("应用不存在或已下架")
+	}
+	if err != nil {
+		return nil, err
+	}
+	detail := &response.AppStoreDetail{
+		ID:            app.ID,
+		AppID:         app.AppID,
+		AppName:       app.AppName,
+		AppIcon:       app.AppIcon,
+		Rating:        app.Rating,
+		DownloadCount: app.DownloadCount,
+		IsFree:        app.IsFree,
+		CategoryID:    app.CategoryID,
+		Languages:     []string{},
+	}
+	if app.Description != nil {
+		detail.Description = *app.Description
+	}
+
+	listings, err := s.GetListings(app.AppID)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range listings {
+		detail.Languages = append(detail.Languages, l.LanguageCode)
+	}
+	if lang, ok := locale.Match(preferred, detail.Languages); ok {
+		for _, l := range listings {
+			if l.LanguageCode == lang {
+				detail.Language = lang
+				detail.AppName = l.AppName
+				detail.ShortDescription = l.ShortDescription
+				if l.Description != "" {
+					detail.Description = l.Description
+				}
+			}
+		}
+	}
+
+	if detail.Screenshots, err = s.storeScreenshots(app.AppID, req.Platform, preferred); err != nil {
+		return nil, err
+	}
+	if detail.Packages, err = s.storePackages(app.AppID, req.Platform, preferred); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+func preferredLanguages(lang, acceptLanguage, countryCode string) ([]string, error) {
+	var preferred []string
+	if lang != "" {
+		preferred = append(preferred, lang)
+	}
+	preferred = append(preferred, locale.ParseAcceptLanguage(acceptLanguage)...)
+	if countryCode != "" {
+		var country projectModel.CountryRegion
+		err := global.GVA_DB.Select("language_codes").Where("country_code = ?", countryCode).Limit(1).Find(&country).Error
+		if err != nil {
+			return nil, err
+		}
+		preferred = append(preferred, country.LanguageCodes...)
+	}
+	return preferred, nil
+}
+
+// storeScreenshots 选择最匹配语言的截图，没有匹配时使用通用截图
+func (s *AppListingService) storeScreenshots(appID, platform string, preferred []string) ([]projectModel.AppScreenshot, error) {
+	shots, err := (&AppScreenshotService{}).GetScreenshotList(request.ScreenshotListRequest{AppID: appID, Platform: platform})
+	if err != nil {
+		return nil, err
+	}
+	var langs []string
+	seen := make(map[string]bool)
+	for _, shot := range shots {
+		if shot.LanguageCode != "" && !seen[shot.LanguageCode] {
+			seen[shot.LanguageCode] = true
+			langs = append(langs, shot.LanguageCode)
+		}
+	}
+	lang, _ := locale.Match(preferred, langs)
+	list := make([]projectModel.AppScreenshot, 0, len(shots))
+	for _, shot := range shots {
+		if shot.LanguageCode == lang {
+			list = append(list, shot)
+		}
+	}
+	return list, nil
+}
+
+// storePackages 最近发布的安装包及最匹配语言的更新说明
+func (s *AppListingService) storePackages(appID, platform string, preferred []string) ([]response.AppStorePackage, error) {
+	var packages []projectModel.AppPackage
+	db := global.GVA_DB.Select("id, platform, version_name, package_size, published_at").
+		Where("app_id = ? AND status = ?", appID, constants.StatusPublished)
+	if platform != "" {
+		db = db.Where("platform = ?", platform)
+	}
+	if err := db.Order("version_code desc, id desc").Limit(maxStorePackages).Find(&packages).Error; err != nil {
+		return nil, err
+	}
+	return storePackageItems(packages, preferred)
+}
+
+// storePackageItems 转换为前台展示的安装包，附带最匹配语言的更新说明
+func storePackageItems(packages []projectModel.AppPackage, preferred []string) ([]response.AppStorePackage, error) {
+	list := make([]response.AppStorePackage, 0, len(packages))
+	if len(packages) == 0 {
+		return list, nil
+	}
+	ids := make([]uint64, len(packages))
+	for i, p := range packages {
+		ids[i] = p.ID
+	}
+	changelogs, err := localizedChangelogs(ids, preferred)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packages {
+		list = append(list, response.AppStorePackage{
+			ID:          p.ID,
+			Platform:    string(p.Platform),
+			VersionName: p.VersionName,
+			PackageSize: p.PackageSize,
+			PublishedAt: p.PublishedAt,
+			Changelog:   changelogs[p.ID],
+		})
+	}
+	return list, nil
+}
+
+// localizedChangelogs 每个安装包选择最匹配语言的更新说明，没有匹配的语言时不返回
+func localizedChangelogs(packageIDs []uint64, preferred []string) (map[uint64]string, error) {
+	var changelogs []projectModel.AppPackageChangelog
+	if err := global.GVA_DB.Where("package_id IN ?", packageIDs).Order("language_code asc").Find(&changelogs).Error; err != nil {
+		return nil, err
+	}
+	texts := make(map[uint64]map[string]string)
+	langs := make(map[uint64][]string)
+	for _, c := range changelogs {
+		if texts[c.PackageID] == nil {
+			texts[c.PackageID] = make(map[string]string)
+		}
+		texts[c.PackageID][c.LanguageCode] = c.Changelog
+		langs[c.PackageID] = append(langs[c.PackageID], c.LanguageCode)
+	}
+	result := make(map[uint64]string, len(texts))
+	for id, available := range langs {
+		if lang, ok := locale.Match(preferred, available); ok {
+			result[id] = texts[id][lang]
+		}
+	}
+	return result, nil
+}
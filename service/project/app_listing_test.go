@@ -0,0 +1,72 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"testing"
+)
+
+func TestAppStoreDetail(t *testing.T) {
+	setupTestDB(t, &project.AppListing{}, &project.CountryRegion{}, &project.AppPackageChangelog{})
+	createApplicationTables(t)
+	global.GVA_DB.Exec(`INSERT INTO applications (id, app_id, app_name, country_code, description, status) VALUES
+		(1, 'a1', '微信', '', '聊天和朋友圈', 'active'), (3, 'a3', 'Telegram', 'US', NULL, 'active')`)
+	global.GVA_DB.Exec(`INSERT INTO app_packages (id, app_id, platform, status, version_name, version_code) VALUES
+		(1, 'a1', 'android', 'published', '8.0', 800), (2, 'a1', 'ios', 'published', NULL, NULL)`)
+	err := global.GVA_DB.Exec(`CREATE TABLE app_screenshots (id integer PRIMARY KEY, app_id text, language_code text, platform text,
+		screenshot_url text, object_key text, screenshot_type text, display_order integer, created_at datetime, updated_at datetime)`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	global.GVA_DB.Create(&[]project.CountryRegion{
+		{CountryCode: "CN", CountryName: "中国", LanguageCodes: []string{"zh-CN"}},
+		{CountryCode: "US", CountryName: "美国", LanguageCodes: []string{"en"}},
+	})
+
+	var s AppListingService
+	if _, err := s.SaveListing(request.AppListingSaveRequest{AppID: "a1", LanguageCode: "fr", AppName: "WeChat"}); err == nil {
+		t.Error("language not configured in countries should be rejected")
+	}
+	if _, err := s.SaveListing(request.AppListingSaveRequest{AppID: "a1", LanguageCode: "en", AppName: "Weixin"}); err != nil {
+		t.Fatal(err)
+	}
+	// 同一语言再次保存为更新
+	if _, err := s.SaveListing(request.AppListingSaveRequest{AppID: "a1", LanguageCode: "EN", AppName: "WeChat", ShortDescription: "Messaging"}); err != nil {
+		t.Fatal(err)
+	}
+	global.GVA_DB.Exec(`INSERT INTO app_screenshots (app_id, language_code, platform, screenshot_url, display_order) VALUES
+		('a1', '', 'android', 'common.png', 1), ('a1', 'en', 'android', 'en-2.png', 2), ('a1', 'en', 'android', 'en-1.png', 1)`)
+	if _, err := s.SaveChangelog(request.ChangelogSaveRequest{PackageID: 1, LanguageCode: "en", Changelog: "Bug fixes"}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := request.AppStoreDetailRequest{AppID: 1, Platform: "android"}
+	detail, err := s.StoreDetail(req, "en-US,en;q=0.9", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Language != "en" || detail.AppName != "WeChat" || detail.ShortDescription != "Messaging" || detail.Description != "聊天和朋友圈" {
+		t.Errorf("detail = %+v", detail)
+	}
+	if len(detail.Screenshots) != 2 || detail.Screenshots[0].ScreenshotURL != "en-1.png" {
+		t.Errorf("screenshots = %+v", detail.Screenshots)
+	}
+	if len(detail.Packages) != 1 || detail.Packages[0].Changelog != "Bug fixes" {
+		t.Errorf("packages = %+v", detail.Packages)
+	}
+
+	// 没有匹配的语言时使用默认信息和通用截图；访问者国家的官方语言也参与匹配
+	detail, _ = s.StoreDetail(req, "ja", "")
+	if detail.Language != "" || detail.AppName != "微信" || len(detail.Screenshots) != 1 || detail.Packages[0].Changelog != "" {
+		t.Errorf("fallback detail = %+v", detail)
+	}
+	detail, _ = s.StoreDetail(request.AppStoreDetailRequest{AppID: 3}, "ja", "US")
+	if detail == nil || detail.AppName != "Telegram" {
+		t.Errorf("country detail = %+v", detail)
+	}
+	detail, _ = s.StoreDetail(req, "ja", "US")
+	if detail.Language != "en" {
+		t.Errorf("country language detail = %+v", detail)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"ApkAdmin/global"
 	"ApkAdmin/model/project"
 	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
 	"errors"
 	"fmt"
 	"gorm.io/gorm"
@@ -85,6 +86,9 @@ func (a *AppPackageService) CreateAppPackage(uid uint, req request.AppPackageCre
 	appPackage.CountryCode = app.CountryCode
 	appPackage.AppName = app.AppName
 	appPackage.CreatedBy = uint64(uid)
+	// 新安装包在安全扫描通过前处于隔离状态
+	appPackage.Status = string(constants.StatusQuarantined)
+	appPackage.ScanStatus = string(constants.ScanStatusPending)
 	// 创建安装包记录
 	if err := tx.Create(&appPackage).Error; err != nil {
 		tx.Rollback()
@@ -128,6 +132,12 @@ func (a *AppPackageService) UpdateAppPackage(useID uint, req *request.AppPackage
 	if existing == nil {
 		return fmt.Errorf("记录不存在")
 	}
+	fileChanged := req.ObjectName != utils.StringValue(existing.ObjectName) || req.FileURL != utils.StringValue(existing.FileURL)
+	if !fileChanged {
+		if err = checkScanPassed(existing, req.Status); err != nil {
+			return err
+		}
+	}
 
 	// 开始事务
 	tx := global.GVA_DB.Begin()
@@ -154,6 +164,18 @@ func (a *AppPackageService) UpdateAppPackage(useID uint, req *request.AppPackage
 		"updated_at":   time.Now(),
 		"updated_by":   useID,
 	}
+	// 更换了安装包文件，需要重新扫描
+	if fileChanged {
+		for k, v := range packageFileChangedUpdates(nil, nil) {
+			updates[k] = v
+		}
+	}
+	// 由其它状态改为已发布，与批量发布一样记录发布时间并通知关注的用户
+	newlyPublished := !fileChanged && req.Status == constants.StatusPublished && existing.Status != string(constants.StatusPublished)
+	if newlyPublished {
+		updates["published_at"] = time.Now()
+		updates["published_by"] = useID
+	}
 
 	err = tx.Model(&project.AppPackage{}).Where("id = ?", req.ID).Updates(updates).Error
 	if err != nil {
@@ -200,7 +222,15 @@ func (a *AppPackageService) UpdateAppPackage(useID uint, req *request.AppPackage
 	}
 
 	// 提交事务
-	return tx.Commit().Error
+	if err = tx.Commit().Error; err != nil {
+		return err
+	}
+	// 平台和发布状态参与应用搜索的过滤
+	appSearchService.ReindexByAppIDs(existing.AppID)
+	if newlyPublished {
+		go appFollowService.NotifyNewVersions(existing.ID)
+	}
+	return nil
 }
 
 // DeleteAppPackage 删除安装包
@@ -241,10 +271,23 @@ func (a *AppPackageService) DeleteAppPackage(cid int) (err error) {
 	}
 
 	// 提交事务
-	return tx.Commit().Error
+	if err = tx.Commit().Error; err != nil {
+		return err
+	}
+	appSearchService.ReindexByAppIDs(existing.AppID)
+	return nil
 }
 
 func (a *AppPackageService) BatchUpdateApkStatus(useID uint, ids []uint, status constants.PackageStatus) error {
+	var packages []project.AppPackage
+	if err := global.GVA_DB.Select("id, app_id, status, scan_status").Where("id IN ?", ids).Find(&packages).Error; err != nil {
+		return err
+	}
+	for i := range packages {
+		if err := checkScanPassed(&packages[i], status); err != nil {
+			return fmt.Errorf("安装包%d: %w", packages[i].ID, err)
+		}
+	}
 	// 更新安装包基本信息
 	updates := map[string]interface{}{
 		"status":       status,
@@ -252,7 +295,23 @@ func (a *AppPackageService) BatchUpdateApkStatus(useID uint, ids []uint, status
 		"published_by": useID,
 	}
 	err := global.GVA_DB.Model(&project.AppPackage{}).Where("id IN ?", ids).Updates(updates).Error
-	return err
+	if err != nil {
+		return err
+	}
+	appIDs := make([]string, len(packages))
+	var newlyPublished []uint64
+	for i := range packages {
+		appIDs[i] = packages[i].AppID
+		if status == constants.StatusPublished && packages[i].Status != string(constants.StatusPublished) {
+			newlyPublished = append(newlyPublished, packages[i].ID)
+		}
+	}
+	appSearchService.ReindexByAppIDs(appIDs...)
+	// 新发布的版本通知关注该应用的用户
+	if len(newlyPublished) > 0 {
+		go appFollowService.NotifyNewVersions(newlyPublished...)
+	}
+	return nil
 }
 
 func (a *AppPackageService) GetAppPackageList(info request.AppPackageListRequest, order string, desc bool) (list interface{}, total int64, err error) {
@@ -290,3 +349,17 @@ func (a *AppPackageService) GetAppPackageList(info request.AppPackageListRequest
 	}).Order(OrderStr).Find(&countryLists).Error
 	return countryLists, total, err
 }
+
+// checkScanPassed 未通过安全扫描的安装包不能发布，隔离中的安装包也不能手动解除隔离
+func checkScanPassed(pkg *project.AppPackage, status constants.PackageStatus) error {
+	if status == "" || status == constants.StatusQuarantined || pkg.ScanStatus == string(constants.ScanStatusClean) {
+		return nil
+	}
+	if status == constants.StatusPublished {
+		return errors.New("安装包未通过安全扫描，无法发布")
+	}
+	if pkg.Status == string(constants.StatusQuarantined) {
+		return errors.New("安装包处于隔离状态，扫描通过后才能变更状态")
+	}
+	return nil
+}
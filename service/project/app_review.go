@@ -0,0 +1,446 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	projectModel "ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/project/response"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// reviewEditInterval 两次提交评价的最小间隔，防止刷评
+	reviewEditInterval = time.Minute
+	reviewFlagLink     = "包含链接或联系方式，等待审核"
+)
+
+// reviewSpamPattern 链接、域名或长串数字（手机号、QQ号等）
+var reviewSpamPattern = regexp.MustCompile(`(?i)(https?://|www\.|[a-z0-9-]+\.(com|cn|net|org|top|xyz|cc)\b|\d{7,})`)
+
+type AppReviewService struct{}
+
+// ReviewSubmit 提交评价时的上下文信息
+type ReviewSubmit struct {
+	UserID uint
+	IP     string
+	request.SubmitReviewRequest
+}
+
+// Submit 提交或修改评价。只有成功下载过该应用的用户可以评价，版本取最近一次成功下载的安装包
+func (s *AppReviewService) Submit(r ReviewSubmit) (*projectModel.AppReview, error) {
+	if r.UserID == 0 {
+		return nil, errors.New("请先登录")
+	}
+	var app projectModel.Application
+	if err := global.GVA_DB.Select("id, status").Where("id = ?", r.AppID).First(&app).Error; err != nil {
+		return nil, errors.New("应用不存在")
+	}
+	if app.Status != constants.ApplicationStatusActive {
+		return nil, errors.New("应用已下架，无法评价")
+	}
+
+	var download projectModel.DownloadLog
+	err := global.GVA_DB.Where("user_id = ? AND app_id = ? AND success = ?", r.UserID, r.AppID, true).
+		Order("created_at desc, id desc").First(&download).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("下载并使用过该应用后才能评价")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var packageID uint64
+	var versionName string
+	if download.PackageID != nil {
+		var pkg projectModel.AppPackage
+		if global.GVA_DB.Select("id, version_name").Where("id = ?", *download.PackageID).First(&pkg).Error == nil {
+			packageID, versionName = pkg.ID, pkg.VersionName
+		}
+	}
+
+	content := strings.TrimSpace(r.Content)
+	flagged := reviewSpamPattern.MatchString(content)
+	now := time.Now()
+
+	var review projectModel.AppReview
+	var oldPackageID uint64
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND app_id = ?", r.UserID, r.AppID).First(&review).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			review = projectModel.AppReview{
+				AppID:       r.AppID,
+				UserID:      r.UserID,
+				PackageID:   packageID,
+				VersionName: versionName,
+				Rating:      r.Rating,
+				Content:     content,
+				Status:      constants.ReviewStatusVisible,
+				IP:          r.IP,
+			}
+			if flagged {
+				review.Status, review.Flagged, review.FlagReason = constants.ReviewStatusHidden, true, reviewFlagLink
+			}
+			return tx.Create(&review).Error
+		}
+		if err != nil {
+			return err
+		}
+		if now.Sub(review.UpdatedAt) < reviewEditInterval {
+			return errors.New("操作过于频繁，请稍后再试")
+		}
+		oldPackageID = review.PackageID
+		updates := map[string]interface{}{
+			"package_id":   packageID,
+			"version_name": versionName,
+			"rating":       r.Rating,
+			"content":      content,
+			"ip":           r.IP,
+			"edit_count":   gorm.Expr("edit_count + 1"),
+		}
+		// 修改不改变显示状态，已隐藏的评价仍需管理员处理；包含链接时重新标记
+		if flagged {
+			updates["status"] = constants.ReviewStatusHidden
+			updates["flagged"] = true
+			updates["flag_reason"] = reviewFlagLink
+		}
+		if err := tx.Model(&review).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", review.ID).First(&review).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.RecomputeRatings(r.AppID, packageID, oldPackageID); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// Delete 用户删除自己的评价
+func (s *AppReviewService) Delete(userID uint, appID uint64) error {
+	var review projectModel.AppReview
+	if err := global.GVA_DB.Where("user_id = ? AND app_id = ?", userID, appID).First(&review).Error; err != nil {
+		return errors.New("评价不存在")
+	}
+	if err := global.GVA_DB.Delete(&review).Error; err != nil {
+		return err
+	}
+	return s.RecomputeRatings(review.AppID, review.PackageID)
+}
+
+// GetMine 当前用户对应用的评价，没有评价时返回 nil
+func (s *AppReviewService) GetMine(userID uint, appID uint64) (*projectModel.AppReview, error) {
+	var review projectModel.AppReview
+	err := global.GVA_DB.Where("user_id = ? AND app_id = ?", userID, appID).First(&review).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// ListVisible 前台评价列表，只返回公开的评价
+func (s *AppReviewService) ListVisible(req request.AppReviewQuery) (list []response.AppReviewItem, total int64, err error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 50 {
+		req.PageSize = 20
+	}
+	db := global.GVA_DB.Model(&projectModel.AppReview{}).
+		Where("app_id = ? AND status = ?", req.AppID, constants.ReviewStatusVisible)
+	if req.Rating > 0 {
+		db = db.Where("rating = ?", req.Rating)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var reviews []projectModel.AppReview
+	err = db.Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id, username") }).
+		Order("updated_at desc, id desc").
+		Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).
+		Find(&reviews).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	list = make([]response.AppReviewItem, 0, len(reviews))
+	for _, r := range reviews {
+		name := ""
+		if r.User != nil {
+			name = maskUsername(r.User.Username)
+		}
+		list = append(list, response.AppReviewItem{
+			ID:          r.ID,
+			UserName:    name,
+			Rating:      r.Rating,
+			Content:     r.Content,
+			VersionName: r.VersionName,
+			Reply:       r.Reply,
+			RepliedAt:   r.RepliedAt,
+			CreatedAt:   r.CreatedAt,
+			UpdatedAt:   r.UpdatedAt,
+		})
+	}
+	return list, total, nil
+}
+
+// Summary 应用评分汇总和星级分布
+func (s *AppReviewService) Summary(appID uint64) (*response.AppRatingSummary, error) {
+	var rows []struct {
+		Rating int
+		Count  int64
+	}
+	err := global.GVA_DB.Model(&projectModel.AppReview{}).
+		Select("rating, COUNT(*) AS count").
+		Where("app_id = ? AND status = ?", appID, constants.ReviewStatusVisible).
+		Group("rating").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	summary := &response.AppRatingSummary{}
+	var sum int64
+	for _, row := range rows {
+		if row.Rating < 1 || row.Rating > 5 {
+			continue
+		}
+		summary.Distribution[row.Rating-1] = row.Count
+		summary.Count += row.Count
+		sum += int64(row.Rating) * row.Count
+	}
+	if summary.Count > 0 {
+		summary.Average = roundRating(float64(sum) / float64(summary.Count))
+	}
+	return summary, nil
+}
+
+// ==================== 后台管理 ====================
+
+// GetReviewList 后台评价列表
+func (s *AppReviewService) GetReviewList(req request.AppReviewListRequest) (list []projectModel.AppReview, total int64, err error) {
+	db := global.GVA_DB.Model(&projectModel.AppReview{})
+	if req.AppID > 0 {
+		db = db.Where("app_id = ?", req.AppID)
+	}
+	if req.UserID > 0 {
+		db = db.Where("user_id = ?", req.UserID)
+	}
+	if req.Rating > 0 {
+		db = db.Where("rating = ?", req.Rating)
+	}
+	if req.Status != "" {
+		db = db.Where("status = ?", req.Status)
+	}
+	if req.Flagged != nil {
+		db = db.Where("flagged = ?", *req.Flagged)
+	}
+	if req.Keyword != "" {
+		db = db.Where("content LIKE ?", "%"+req.Keyword+"%")
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Scopes(req.Paginate()).
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id, username, email") }).
+		Order("flagged desc, id desc").
+		Find(&list).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	appIDs := make([]uint64, len(list))
+	for i := range list {
+		appIDs[i] = list[i].AppID
+	}
+	apps, err := applicationsByID(appIDs, "id, app_id, app_name")
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range list {
+		list[i].Application = apps[list[i].AppID]
+	}
+	return list, total, nil
+}
+
+// applicationsByID 按主键批量加载应用
+func applicationsByID(ids []uint64, columns string) (map[uint64]*projectModel.Application, error) {
+	result := make(map[uint64]*projectModel.Application, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+	var apps []projectModel.Application
+	if err := global.GVA_DB.Select(columns).Where("id IN ?", ids).Find(&apps).Error; err != nil {
+		return nil, err
+	}
+	for i := range apps {
+		result[apps[i].ID] = &apps[i]
+	}
+	return result, nil
+}
+
+// UpdateStatus 批量显示或隐藏评价，隐藏的评价不计入评分
+func (s *AppReviewService) UpdateStatus(ids []uint64, status constants.ReviewStatus) error {
+	var reviews []projectModel.AppReview
+	if err := global.GVA_DB.Select("id, app_id, package_id").Where("id IN ?", ids).Find(&reviews).Error; err != nil {
+		return err
+	}
+	updates := map[string]interface{}{"status": status}
+	// 处理过的评价取消标记
+	if status == constants.ReviewStatusVisible {
+		updates["flagged"] = false
+		updates["flag_reason"] = ""
+	}
+	if err := global.GVA_DB.Model(&projectModel.AppReview{}).Where("id IN ?", ids).Updates(updates).Error; err != nil {
+		return err
+	}
+	return s.recomputeFor(reviews)
+}
+
+// Flag 标记评价待处理，不影响显示
+func (s *AppReviewService) Flag(req request.ReviewFlagRequest) error {
+	reason := strings.TrimSpace(req.Reason)
+	if !req.Flagged {
+		reason = ""
+	}
+	return global.GVA_DB.Model(&projectModel.AppReview{}).Where("id IN ?", req.IDs).Updates(map[string]interface{}{
+		"flagged":     req.Flagged,
+		"flag_reason": reason,
+	}).Error
+}
+
+// Reply 官方回复评价
+func (s *AppReviewService) Reply(req request.ReviewReplyRequest, adminID uint) error {
+	reply := strings.TrimSpace(req.Reply)
+	updates := map[string]interface{}{"reply": reply, "replied_at": nil, "replied_by": 0}
+	if reply != "" {
+		updates["replied_at"] = time.Now()
+		updates["replied_by"] = adminID
+	}
+	// 回复不改变评价的更新时间，避免影响用户修改频率限制和列表排序
+	res := global.GVA_DB.Model(&projectModel.AppReview{}).Where("id = ?", req.ID).UpdateColumns(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("评价不存在")
+	}
+	return nil
+}
+
+// DeleteReviews 后台删除评价
+func (s *AppReviewService) DeleteReviews(ids []uint64) error {
+	var reviews []projectModel.AppReview
+	if err := global.GVA_DB.Select("id, app_id, package_id").Where("id IN ?", ids).Find(&reviews).Error; err != nil {
+		return err
+	}
+	if err := global.GVA_DB.Where("id IN ?", ids).Delete(&projectModel.AppReview{}).Error; err != nil {
+		return err
+	}
+	return s.recomputeFor(reviews)
+}
+
+func (s *AppReviewService) recomputeFor(reviews []projectModel.AppReview) error {
+	packages := make(map[uint64][]uint64)
+	for _, r := range reviews {
+		packages[r.AppID] = append(packages[r.AppID], r.PackageID)
+	}
+	for appID, ids := range packages {
+		if err := s.RecomputeRatings(appID, ids...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ==================== 评分汇总 ====================
+
+// RecomputeRatings 根据公开评价重新计算应用评分和指定安装包的评分
+func (s *AppReviewService) RecomputeRatings(appID uint64, packageIDs ...uint64) error {
+	summary, err := s.Summary(appID)
+	if err != nil {
+		return err
+	}
+	var rating interface{}
+	if summary.Count > 0 {
+		rating = summary.Average
+	}
+	if err := global.GVA_DB.Model(&projectModel.Application{}).Where("id = ?", appID).UpdateColumn("rating", rating).Error; err != nil {
+		return err
+	}
+
+	seen := make(map[uint64]bool)
+	for _, id := range packageIDs {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		var agg struct {
+			Count int
+			Avg   *float64
+		}
+		err := global.GVA_DB.Model(&projectModel.AppReview{}).
+			Select("COUNT(*) AS count, AVG(rating) AS avg").
+			Where("package_id = ? AND status = ?", id, constants.ReviewStatusVisible).
+			Scan(&agg).Error
+		if err != nil {
+			return err
+		}
+		var average interface{}
+		if agg.Count > 0 && agg.Avg != nil {
+			average = roundRating(*agg.Avg)
+		}
+		err = global.GVA_DB.Model(&projectModel.AppPackage{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"rating_average": average,
+			"rating_count":   agg.Count,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	appSearchService.ReindexApps(appID)
+	return nil
+}
+
+// RecomputeAll 重新计算所有应用和安装包的评分，用于修复数据
+func (s *AppReviewService) RecomputeAll() error {
+	var appIDs []uint64
+	if err := global.GVA_DB.Model(&projectModel.AppReview{}).Distinct("app_id").Pluck("app_id", &appIDs).Error; err != nil {
+		return err
+	}
+	for _, appID := range appIDs {
+		var packageIDs []uint64
+		err := global.GVA_DB.Model(&projectModel.AppReview{}).Where("app_id = ?", appID).Distinct("package_id").Pluck("package_id", &packageIDs).Error
+		if err != nil {
+			return err
+		}
+		if err := s.RecomputeRatings(appID, packageIDs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func roundRating(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// maskUsername 前台只显示用户名首尾字符
+func maskUsername(name string) string {
+	runes := []rune(name)
+	switch len(runes) {
+	case 0:
+		return ""
+	case 1, 2:
+		return string(runes[0]) + "***"
+	default:
+		return string(runes[0]) + "***" + string(runes[len(runes)-1])
+	}
+}
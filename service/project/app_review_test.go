@@ -0,0 +1,101 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	commonReq "ApkAdmin/model/common/request"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"testing"
+	"time"
+)
+
+func TestAppReview(t *testing.T) {
+	setupTestDB(t, &project.AppReview{}, &project.DownloadLog{}, &project.User{})
+	createApplicationTables(t)
+	global.GVA_DB.Exec("INSERT INTO applications (id, app_id, app_name, status) VALUES (1, 'a1', '微信', 'active')")
+	global.GVA_DB.Exec("INSERT INTO app_packages (id, app_id, platform, status, version_name) VALUES (1, 'a1', 'android', 'published', '1.0')")
+	pkg := uint(1)
+	global.GVA_DB.Create(&[]project.DownloadLog{
+		{UserID: 1, AppID: 1, PackageID: &pkg, Platform: constants.PlatformAndroid, Success: true, IP: "1.1.1.1"},
+		{UserID: 2, AppID: 1, PackageID: &pkg, Platform: constants.PlatformAndroid, Success: true, IP: "2.2.2.2"},
+		{UserID: 3, AppID: 1, Platform: constants.PlatformAndroid, Success: false, IP: "3.3.3.3"},
+	})
+
+	var s AppReviewService
+	submit := func(user uint, rating int, content string) error {
+		_, err := s.Submit(ReviewSubmit{UserID: user, SubmitReviewRequest: request.SubmitReviewRequest{AppID: 1, Rating: rating, Content: content}})
+		return err
+	}
+	if err := submit(3, 5, "好用"); err == nil {
+		t.Error("user without successful download should not review")
+	}
+	if err := submit(1, 5, "好用"); err != nil {
+		t.Fatal(err)
+	}
+	if err := submit(2, 2, "一般"); err != nil {
+		t.Fatal(err)
+	}
+	if err := submit(1, 4, "还行"); err == nil {
+		t.Error("edit within interval should be rejected")
+	}
+
+	assertRatings := func(wantApp float64, wantCount int) {
+		t.Helper()
+		var app project.Application
+		global.GVA_DB.Select("rating").First(&app, 1)
+		var pkg struct {
+			RatingAverage *float64
+			RatingCount   int
+		}
+		global.GVA_DB.Raw("SELECT rating_average, rating_count FROM app_packages WHERE id = 1").Scan(&pkg)
+		if app.Rating == nil || *app.Rating != wantApp || pkg.RatingCount != wantCount || pkg.RatingAverage == nil || *pkg.RatingAverage != wantApp {
+			t.Errorf("app rating = %v, package = %v/%d, want %v/%d", app.Rating, pkg.RatingAverage, pkg.RatingCount, wantApp, wantCount)
+		}
+	}
+	assertRatings(3.5, 2)
+
+	// 修改评价保持一条记录，更新评分
+	global.GVA_DB.Model(&project.AppReview{}).Where("user_id = 1").UpdateColumn("updated_at", time.Now().Add(-time.Hour))
+	if err := submit(1, 4, "还行"); err != nil {
+		t.Fatal(err)
+	}
+	assertRatings(3, 2)
+
+	// 包含联系方式的评价自动隐藏，不计入评分
+	global.GVA_DB.Model(&project.AppReview{}).Where("user_id = 2").UpdateColumn("updated_at", time.Now().Add(-time.Hour))
+	if err := submit(2, 1, "加微信 13800138000"); err != nil {
+		t.Fatal(err)
+	}
+	assertRatings(4, 1)
+	list, total, _ := s.ListVisible(request.AppReviewQuery{AppID: 1})
+	if total != 1 || len(list) != 1 || list[0].VersionName != "1.0" {
+		t.Errorf("visible reviews = %+v, total = %d", list, total)
+	}
+
+	// 管理员恢复显示后重新计入
+	var hidden project.AppReview
+	global.GVA_DB.Where("user_id = 2").First(&hidden)
+	if !hidden.Flagged || hidden.Status != constants.ReviewStatusHidden {
+		t.Fatalf("review = %+v, want flagged and hidden", hidden)
+	}
+	if err := s.UpdateStatus([]uint64{hidden.ID}, constants.ReviewStatusVisible); err != nil {
+		t.Fatal(err)
+	}
+	assertRatings(2.5, 2)
+	summary, _ := s.Summary(1)
+	if summary.Count != 2 || summary.Distribution[0] != 1 || summary.Distribution[3] != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	// 后台列表按评价的 app_id 加载应用，不能把 Application.AppID 当作外键
+	reviews, total, err := s.GetReviewList(request.AppReviewListRequest{PageInfo: commonReq.PageInfo{Page: 1, PageSize: 10}})
+	if err != nil || total != 2 {
+		t.Fatalf("review list total = %d, err = %v", total, err)
+	}
+	for _, r := range reviews {
+		if r.Application == nil || r.Application.ID != 1 || r.Application.AppName != "微信" {
+			t.Errorf("review %d application = %+v", r.ID, r.Application)
+		}
+	}
+}
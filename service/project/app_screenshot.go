@@ -0,0 +1,190 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	projectModel "ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils/upload"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	maxScreenshotSize  = 5 << 20
+	maxScreenshotBatch = 20
+)
+
+var screenshotExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".webp": true}
+
+type AppScreenshotService struct{}
+
+// UploadScreenshots 批量上传截图，追加到同一应用同一平台已有截图之后；任意一张失败时删除本次已上传的文件
+func (s *AppScreenshotService) UploadScreenshots(req request.ScreenshotUploadRequest, files []*multipart.FileHeader) ([]projectModel.AppScreenshot, error) {
+	if len(files) == 0 {
+		return nil, errors.New("请选择要上传的截图")
+	}
+	if len(files) > maxScreenshotBatch {
+		return nil, fmt.Errorf("一次最多上传%d张截图", maxScreenshotBatch)
+	}
+	for _, f := range files {
+		if !screenshotExts[strings.ToLower(filepath.Ext(f.Filename))] {
+			return nil, fmt.Errorf("%s 不是支持的图片格式（jpg、png、webp）", f.Filename)
+		}
+		if f.Size > maxScreenshotSize {
+			return nil, fmt.Errorf("%s 超过5MB", f.Filename)
+		}
+	}
+	if err := checkAppExists(req.AppID); err != nil {
+		return nil, err
+	}
+	lang, err := checkLanguageCode(req.LanguageCode, true)
+	if err != nil {
+		return nil, err
+	}
+	screenshotType := req.ScreenshotType
+	if screenshotType == "" {
+		screenshotType = "phone"
+	}
+
+	oss := upload.NewOss()
+	shots := make([]projectModel.AppScreenshot, 0, len(files))
+	removeUploaded := func() {
+		for _, shot := range shots {
+			if err := oss.DeleteFile(shot.ObjectKey); err != nil {
+				global.GVA_LOG.Error("删除截图文件失败!", zap.Error(err), zap.String("key", shot.ObjectKey))
+			}
+		}
+	}
+	for _, f := range files {
+		url, key, err := oss.UploadFile(f)
+		if err != nil {
+			removeUploaded()
+			return nil, fmt.Errorf("上传 %s 失败：%w", f.Filename, err)
+		}
+		shots = append(shots, projectModel.AppScreenshot{
+			AppID:          req.AppID,
+			LanguageCode:   lang,
+			Platform:       req.Platform,
+			ScreenshotURL:  url,
+			ObjectKey:      key,
+			ScreenshotType: screenshotType,
+		})
+	}
+
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		var maxOrder int
+		err := tx.Model(&projectModel.AppScreenshot{}).
+			Where("app_id = ? AND platform = ?", req.AppID, req.Platform).
+			Select("COALESCE(MAX(display_order), 0)").Scan(&maxOrder).Error
+		if err != nil {
+			return err
+		}
+		for i := range shots {
+			shots[i].DisplayOrder = maxOrder + i + 1
+		}
+		return tx.Create(&shots).Error
+	})
+	if err != nil {
+		removeUploaded()
+		return nil, err
+	}
+	return shots, nil
+}
+
+// UpdateScreenshot 修改截图的语言、平台和类型
+func (s *AppScreenshotService) UpdateScreenshot(req request.ScreenshotUpdateRequest) error {
+	lang, err := checkLanguageCode(req.LanguageCode, true)
+	if err != nil {
+		return err
+	}
+	res := global.GVA_DB.Model(&projectModel.AppScreenshot{}).Where("id = ?", req.ID).Updates(map[string]interface{}{
+		"language_code":   lang,
+		"platform":        req.Platform,
+		"screenshot_type": req.ScreenshotType,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("截图不存在")
+	}
+	return nil
+}
+
+// ReorderScreenshots 按传入顺序重新设置显示顺序，截图必须属于同一个应用
+func (s *AppScreenshotService) ReorderScreenshots(ids []uint) error {
+	var shots []projectModel.AppScreenshot
+	if err := global.GVA_DB.Select("id, app_id").Where("id IN ?", ids).Find(&shots).Error; err != nil {
+		return err
+	}
+	if len(shots) != len(ids) {
+		return errors.New("部分截图不存在")
+	}
+	for _, shot := range shots[1:] {
+		if shot.AppID != shots[0].AppID {
+			return errors.New("只能对同一应用的截图排序")
+		}
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			if err := tx.Model(&projectModel.AppScreenshot{}).Where("id = ?", id).Update("display_order", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteScreenshots 删除截图记录和存储中的文件，文件删除失败只记录日志
+func (s *AppScreenshotService) DeleteScreenshots(ids []uint) error {
+	var shots []projectModel.AppScreenshot
+	if err := global.GVA_DB.Where("id IN ?", ids).Find(&shots).Error; err != nil {
+		return err
+	}
+	if err := global.GVA_DB.Where("id IN ?", ids).Delete(&projectModel.AppScreenshot{}).Error; err != nil {
+		return err
+	}
+	oss := upload.NewOss()
+	for _, shot := range shots {
+		if shot.ObjectKey == "" {
+			continue
+		}
+		if err := oss.DeleteFile(shot.ObjectKey); err != nil {
+			global.GVA_LOG.Error("删除截图文件失败!", zap.Error(err), zap.String("key", shot.ObjectKey))
+		}
+	}
+	return nil
+}
+
+// GetScreenshotList 应用截图列表，按平台和显示顺序排列
+func (s *AppScreenshotService) GetScreenshotList(req request.ScreenshotListRequest) (list []projectModel.AppScreenshot, err error) {
+	db := global.GVA_DB.Model(&projectModel.AppScreenshot{}).Where("app_id = ?", req.AppID)
+	if req.Platform != "" {
+		db = db.Where("platform = ?", req.Platform)
+	}
+	if req.LanguageCode != nil {
+		db = db.Where("language_code = ?", *req.LanguageCode)
+	}
+	if req.ScreenshotType != "" {
+		db = db.Where("screenshot_type = ?", req.ScreenshotType)
+	}
+	err = db.Order("platform asc, display_order asc, id asc").Find(&list).Error
+	return list, err
+}
+
+func checkAppExists(appID string) error {
+	var count int64
+	if err := global.GVA_DB.Model(&projectModel.Application{}).Where("app_id = ?", appID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("应用不存在")
+	}
+	return nil
+}
@@ -0,0 +1,421 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	projectModel "ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/project/response"
+	"ApkAdmin/utils/pinyin"
+	"ApkAdmin/utils/search"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+const (
+	facetCategory = "category"
+	facetPlatform = "platform"
+	facetCountry  = "country"
+)
+
+var appSearchService = AppSearchService{}
+
+// AppSearchService 应用全文搜索，索引只包含上架状态的应用
+type AppSearchService struct{}
+
+var (
+	appSearchMu     sync.Mutex
+	appSearchEngine search.Engine
+	// appSearchReady 完成过一次全量构建，之前的搜索回退到数据库查询
+	appSearchReady atomic.Bool
+)
+
+func (s *AppSearchService) engine() (search.Engine, error) {
+	appSearchMu.Lock()
+	defer appSearchMu.Unlock()
+	if appSearchEngine != nil {
+		return appSearchEngine, nil
+	}
+	e, err := search.New(global.GVA_CONFIG.Search, global.GVA_DB)
+	if err != nil {
+		return nil, err
+	}
+	appSearchEngine = e
+	return e, nil
+}
+
+// Rebuild 全量重建索引，启动时和定时任务调用，同时刷新下载量、评分等排序字段
+func (s *AppSearchService) Rebuild() error {
+	e, err := s.engine()
+	if err != nil {
+		return err
+	}
+	docs, err := s.loadDocs(nil)
+	if err != nil {
+		return err
+	}
+	if err = e.Replace(docs); err != nil {
+		return err
+	}
+	appSearchReady.Store(true)
+	return nil
+}
+
+// ReindexApps 重新索引指定应用，非上架状态或已删除的应用从索引中移除。
+// 索引更新失败只记录日志，不影响业务写入，定时全量重建会修正
+func (s *AppSearchService) ReindexApps(ids ...uint64) {
+	if len(ids) == 0 {
+		return
+	}
+	e, err := s.engine()
+	if err == nil {
+		err = s.reindex(e, ids)
+	}
+	if err != nil {
+		global.GVA_LOG.Error("更新应用搜索索引失败!", zap.Error(err), zap.Uint64s("ids", ids))
+	}
+}
+
+func (s *AppSearchService) reindex(e search.Engine, ids []uint64) error {
+	docs, err := s.loadDocs(ids)
+	if err != nil {
+		return err
+	}
+	indexed := make(map[uint64]bool, len(docs))
+	for _, d := range docs {
+		indexed[d.ID] = true
+	}
+	var removed []uint64
+	for _, id := range ids {
+		if !indexed[id] {
+			removed = append(removed, id)
+		}
+	}
+	if err = e.Index(docs...); err != nil {
+		return err
+	}
+	return e.Delete(removed...)
+}
+
+// ReindexByAppIDs 按应用标识重新索引，安装包发布状态变化时调用
+func (s *AppSearchService) ReindexByAppIDs(appIDs ...string) {
+	if len(appIDs) == 0 {
+		return
+	}
+	var ids []uint64
+	if err := global.GVA_DB.Model(&projectModel.Application{}).Where("app_id IN ?", appIDs).Pluck("id", &ids).Error; err != nil {
+		global.GVA_LOG.Error("更新应用搜索索引失败!", zap.Error(err))
+		return
+	}
+	s.ReindexApps(ids...)
+}
+
+// ReindexCategory 分类名称变化时重新索引该分类下的应用
+func (s *AppSearchService) ReindexCategory(categoryID uint) {
+	var ids []uint64
+	err := global.GVA_DB.Model(&projectModel.Application{}).
+		Where("category_id = ? OR subcategory_id = ?", categoryID, categoryID).
+		Pluck("id", &ids).Error
+	if err != nil {
+		global.GVA_LOG.Error("更新应用搜索索引失败!", zap.Error(err))
+		return
+	}
+	s.ReindexApps(ids...)
+}
+
+// loadDocs 从数据库构建索引文档，ids 为空时加载全部上架应用
+func (s *AppSearchService) loadDocs(ids []uint64) ([]search.Document, error) {
+	var apps []projectModel.Application
+	db := global.GVA_DB.Model(&projectModel.Application{}).Where("status = ?", constants.ApplicationStatusActive)
+	if ids != nil {
+		db = db.Where("id IN ?", ids)
+	}
+	if err := db.Find(&apps).Error; err != nil {
+		return nil, err
+	}
+	if len(apps) == 0 {
+		return nil, nil
+	}
+
+	categoryNames, err := s.categoryNames()
+	if err != nil {
+		return nil, err
+	}
+	appIDs := make([]string, len(apps))
+	for i, app := range apps {
+		appIDs[i] = app.AppID
+	}
+	var packages []struct {
+		AppID    string
+		Platform string
+	}
+	err = global.GVA_DB.Model(&projectModel.AppPackage{}).
+		Select("DISTINCT app_id, platform").
+		Where("app_id IN ? AND status = ?", appIDs, constants.StatusPublished).
+		Scan(&packages).Error
+	if err != nil {
+		return nil, err
+	}
+	platforms := make(map[string][]string)
+	for _, p := range packages {
+		platforms[p.AppID] = append(platforms[p.AppID], p.Platform)
+	}
+
+	// 本地化名称作为关键词，用任意语言的名称都能搜到
+	var listings []projectModel.AppListing
+	if err = global.GVA_DB.Select("app_id, app_name").Where("app_id IN ?", appIDs).Find(&listings).Error; err != nil {
+		return nil, err
+	}
+	localizedNames := make(map[string][]string)
+	for _, l := range listings {
+		localizedNames[l.AppID] = append(localizedNames[l.AppID], l.AppName)
+	}
+
+	docs := make([]search.Document, 0, len(apps))
+	for _, app := range apps {
+		doc := search.Document{
+			ID:    app.ID,
+			Title: app.AppName,
+			Facets: map[string][]string{
+				facetPlatform: platforms[app.AppID],
+				facetCountry:  {app.CountryCode},
+			},
+			Sorts: map[string]float64{
+				"hot":       float64(intValue(app.IsHot)),
+				"new":       float64(app.CreatedAt.Unix()),
+				"rating":    floatValue(app.Rating),
+				"downloads": float64(app.DownloadCount),
+			},
+			Boost: appBoost(app),
+		}
+		if app.Description != nil {
+			doc.Body = *app.Description
+		}
+		for _, cid := range []*uint{app.CategoryID, app.SubcategoryID} {
+			if cid == nil || *cid == 0 {
+				continue
+			}
+			doc.Facets[facetCategory] = append(doc.Facets[facetCategory], strconv.FormatUint(uint64(*cid), 10))
+			if name, ok := categoryNames[*cid]; ok {
+				doc.Keywords = append(doc.Keywords, name)
+			}
+		}
+		doc.Keywords = append(doc.Keywords, localizedNames[app.AppID]...)
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+func (s *AppSearchService) categoryNames() (map[uint]string, error) {
+	var categories []projectModel.AppCategory
+	if err := global.GVA_DB.Select("id, category_name").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.CategoryName
+	}
+	return names, nil
+}
+
+// appBoost 热度权重：下载量取对数避免头部应用垄断结果，评分和热门标记适当加分
+func appBoost(app projectModel.Application) float64 {
+	boost := 1 + 0.1*math.Log1p(float64(app.DownloadCount)) + 0.05*floatValue(app.Rating)
+	if intValue(app.IsHot) == 1 {
+		boost += 0.3
+	}
+	return boost
+}
+
+// Search 搜索访问者所在国家可见的上架应用，返回分类和平台的数量统计
+func (s *AppSearchService) Search(req request.SearchAppRequest, countryCode string) (*response.AppSearchResp, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+	if !appSearchReady.Load() {
+		return s.searchDB(req, countryCode)
+	}
+	e, err := s.engine()
+	if err != nil {
+		return nil, err
+	}
+	q := search.Query{
+		Text:    req.Keyword,
+		Filters: map[string][]string{},
+		Facets:  []string{facetCategory, facetPlatform},
+		Offset:  req.PageSize * (req.Page - 1),
+		Limit:   req.PageSize,
+	}
+	if req.SortType != "relevance" {
+		q.SortBy = req.SortType
+	}
+	if countryCode != "" {
+		q.Filters[facetCountry] = []string{countryCode, ""}
+	}
+	if req.CategoryId > 0 {
+		q.Filters[facetCategory] = []string{strconv.FormatUint(uint64(req.CategoryId), 10)}
+	}
+	if req.Platform != "" {
+		q.Filters[facetPlatform] = []string{string(req.Platform)}
+	}
+	res, err := e.Search(q)
+	if err != nil {
+		global.GVA_LOG.Error("搜索索引查询失败，回退到数据库查询!", zap.Error(err))
+		return s.searchDB(req, countryCode)
+	}
+
+	ids := make([]uint64, len(res.Hits))
+	for i, h := range res.Hits {
+		ids[i] = h.ID
+	}
+	var apps []projectModel.Application
+	if len(ids) > 0 {
+		if err = global.GVA_DB.Where("id IN ?", ids).Find(&apps).Error; err != nil {
+			return nil, err
+		}
+	}
+	// 按索引返回的顺序排列
+	byID := make(map[uint64]projectModel.Application, len(apps))
+	for _, app := range apps {
+		byID[app.ID] = app
+	}
+	list := make([]projectModel.Application, 0, len(ids))
+	for _, id := range ids {
+		if app, ok := byID[id]; ok {
+			list = append(list, app)
+		}
+	}
+
+	resp := &response.AppSearchResp{List: list, Total: res.Total}
+	resp.Categories, err = s.categoryFacets(res.Facets[facetCategory])
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range res.Facets[facetPlatform] {
+		resp.Platforms = append(resp.Platforms, response.SearchFacet{Value: f.Value, Name: f.Value, Count: f.Count})
+	}
+	return resp, nil
+}
+
+func (s *AppSearchService) categoryFacets(counts []search.FacetCount) ([]response.SearchFacet, error) {
+	if len(counts) == 0 {
+		return nil, nil
+	}
+	names, err := s.categoryNames()
+	if err != nil {
+		return nil, err
+	}
+	facets := make([]response.SearchFacet, 0, len(counts))
+	for _, f := range counts {
+		id, _ := strconv.ParseUint(f.Value, 10, 64)
+		facets = append(facets, response.SearchFacet{Value: f.Value, Name: names[uint(id)], Count: f.Count})
+	}
+	return facets, nil
+}
+
+// searchDB 索引尚未构建完成时按名称、描述模糊查询，不返回数量统计
+func (s *AppSearchService) searchDB(req request.SearchAppRequest, countryCode string) (*response.AppSearchResp, error) {
+	keyword := "%" + req.Keyword + "%"
+	db := global.GVA_DB.Model(&projectModel.Application{}).
+		Where("status = ?", constants.ApplicationStatusActive).
+		Where("app_name LIKE ? OR description LIKE ?", keyword, keyword).
+		Scopes(visibleInCountry(countryCode))
+	if req.CategoryId > 0 {
+		db = db.Where("category_id = ? OR subcategory_id = ?", req.CategoryId, req.CategoryId)
+	}
+	if req.Platform != "" {
+		db = db.Where("app_id IN (?)", global.GVA_DB.Model(&projectModel.AppPackage{}).
+			Select("app_id").Where("platform = ? AND status = ?", req.Platform, constants.StatusPublished))
+	}
+	resp := &response.AppSearchResp{}
+	if err := db.Count(&resp.Total).Error; err != nil {
+		return nil, err
+	}
+	var apps []projectModel.Application
+	err := db.Order(searchSortString(req.SortType)).
+		Limit(req.PageSize).Offset(req.PageSize * (req.Page - 1)).
+		Find(&apps).Error
+	resp.List = apps
+	return resp, err
+}
+
+func searchSortString(sortType string) string {
+	switch sortType {
+	case "hot":
+		return "is_hot desc, sort_order desc"
+	case "new":
+		return "created_at desc"
+	case "rating":
+		return "rating desc"
+	case "downloads":
+		return "download_count desc"
+	default:
+		return "sort_order desc, download_count desc"
+	}
+}
+
+func intValue(p *int) int {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+func floatValue(p *float64) float64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// Suggest 输入联想：名称匹配的应用和名称或拼音首字母匹配的分类
+func (s *AppSearchService) Suggest(req request.SearchSuggestRequest, countryCode string) (*response.SearchSuggestion, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 8
+	}
+	limit = min(limit, 20)
+	resp := &response.SearchSuggestion{Apps: []response.SuggestApp{}, Categories: []response.SuggestCategory{}}
+	keyword := normalizeSearchQuery(req.Keyword)
+	if keyword == "" {
+		return resp, nil
+	}
+
+	result, err := s.Search(request.SearchAppRequest{
+		PageInfo: request.PageInfo{Page: 1, PageSize: limit},
+		Keyword:  keyword,
+	}, countryCode)
+	if err != nil {
+		return nil, err
+	}
+	apps, _ := result.List.([]projectModel.Application)
+	for _, app := range apps {
+		resp.Apps = append(resp.Apps, response.SuggestApp{ID: app.ID, AppName: app.AppName, AppIcon: app.AppIcon})
+	}
+
+	var categories []projectModel.AppCategory
+	err = global.GVA_DB.Select("id, category_name, emoji_icon").
+		Where("is_active = ?", 1).
+		Order("sort_order desc").
+		Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range categories {
+		if len(resp.Categories) >= limit {
+			break
+		}
+		if strings.Contains(strings.ToLower(c.CategoryName), keyword) || strings.HasPrefix(pinyin.Initials(c.CategoryName), keyword) {
+			resp.Categories = append(resp.Categories, response.SuggestCategory{ID: c.ID, CategoryName: c.CategoryName, EmojiIcon: c.EmojiIcon})
+		}
+	}
+	return resp, nil
+}
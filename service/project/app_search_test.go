@@ -0,0 +1,142 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"testing"
+)
+
+func setupAppSearchTest(t *testing.T) {
+	t.Helper()
+	setupTestDB(t, &project.AppCategory{}, &project.AppListing{})
+	createApplicationTables(t)
+	saved := global.GVA_CONFIG.Search
+	global.GVA_CONFIG.Search.Type = "memory"
+	t.Cleanup(func() {
+		appSearchEngine = nil
+		appSearchReady.Store(false)
+		global.GVA_CONFIG.Search = saved
+	})
+
+	uintPtr := func(v uint) *uint { return &v }
+	strPtr := func(v string) *string { return &v }
+	hot := 1
+	global.GVA_DB.Create(&[]project.AppCategory{
+		{ID: 1, CategoryCode: "social", CategoryName: "社交"},
+		{ID: 2, CategoryCode: "reading", CategoryName: "阅读"},
+	})
+	global.GVA_DB.Create(&[]project.Application{
+		{ID: 1, AppID: "a1", AppName: "微信", CategoryID: uintPtr(1), Description: strPtr("聊天和朋友圈"), DownloadCount: 10000, IsHot: &hot, Status: constants.ApplicationStatusActive},
+		{ID: 2, AppID: "a2", AppName: "微信读书", CategoryID: uintPtr(2), Description: strPtr("电子书"), DownloadCount: 10, Status: constants.ApplicationStatusActive},
+		{ID: 3, AppID: "a3", AppName: "Telegram", CategoryID: uintPtr(1), CountryCode: "US", Status: constants.ApplicationStatusActive},
+		{ID: 4, AppID: "a4", AppName: "微信旧版", CategoryID: uintPtr(1), Status: constants.ApplicationStatusSuspended},
+	})
+	global.GVA_DB.Exec(`INSERT INTO app_packages (app_id, platform, status) VALUES
+		('a1', 'android', 'published'), ('a1', 'ios', 'published'),
+		('a2', 'android', 'published'), ('a2', 'ios', 'review_pending')`)
+}
+
+func searchIDs(t *testing.T, req request.SearchAppRequest, country string) []uint64 {
+	t.Helper()
+	var s AppSearchService
+	resp, err := s.Search(req, country)
+	if err != nil {
+		t.Fatalf("Search(%q) error = %v", req.Keyword, err)
+	}
+	apps := resp.List.([]project.Application)
+	if resp.Total < int64(len(apps)) {
+		t.Fatalf("Search(%q) total = %d < page size %d", req.Keyword, resp.Total, len(apps))
+	}
+	ids := make([]uint64, len(apps))
+	for i, app := range apps {
+		ids[i] = app.ID
+	}
+	return ids
+}
+
+func TestAppSearch(t *testing.T) {
+	setupAppSearchTest(t)
+	var s AppSearchService
+	if err := s.Rebuild(); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+
+	page := request.PageInfo{Page: 1, PageSize: 10}
+	cases := []struct {
+		name    string
+		req     request.SearchAppRequest
+		country string
+		want    []uint64
+	}{
+		{"名称", request.SearchAppRequest{PageInfo: page, Keyword: "微信"}, "", []uint64{1, 2}},
+		{"分类名称", request.SearchAppRequest{PageInfo: page, Keyword: "社交"}, "", []uint64{1, 3}},
+		{"拼音首字母", request.SearchAppRequest{PageInfo: page, Keyword: "wxds"}, "", []uint64{2}},
+		{"拼写错误", request.SearchAppRequest{PageInfo: page, Keyword: "telegarm"}, "", []uint64{3}},
+		{"国家", request.SearchAppRequest{PageInfo: page, Keyword: "社交"}, "CN", []uint64{1}},
+		{"平台只统计已发布的安装包", request.SearchAppRequest{PageInfo: page, Keyword: "微信", Platform: constants.PlatformIOS}, "", []uint64{1}},
+		{"分页", request.SearchAppRequest{PageInfo: request.PageInfo{Page: 2, PageSize: 1}, Keyword: "微信"}, "", []uint64{2}},
+	}
+	for _, c := range cases {
+		if got := searchIDs(t, c.req, c.country); !equalUint64s(got, c.want) {
+			t.Errorf("%s: ids = %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	resp, _ := s.Search(request.SearchAppRequest{PageInfo: page, Keyword: "微信"}, "")
+	if resp.Total != 2 || len(resp.Categories) != 2 || resp.Categories[0].Name == "" {
+		t.Errorf("facets = %+v %+v, total = %d", resp.Categories, resp.Platforms, resp.Total)
+	}
+	if len(resp.Platforms) != 2 || resp.Platforms[0].Value != "android" || resp.Platforms[0].Count != 2 {
+		t.Errorf("platform facets = %+v", resp.Platforms)
+	}
+}
+
+func TestAppSearchReindex(t *testing.T) {
+	setupAppSearchTest(t)
+	var s AppSearchService
+	_ = s.Rebuild()
+	page := request.PageInfo{Page: 1, PageSize: 10}
+
+	// 下架后从索引中移除，恢复后重新出现
+	var apps ApplicationService
+	if err := apps.BatchUpdateApplicationStatus([]uint{1}, constants.ApplicationStatusSuspended); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchIDs(t, request.SearchAppRequest{PageInfo: page, Keyword: "微信"}, ""); !equalUint64s(got, []uint64{2}) {
+		t.Errorf("after suspend ids = %v, want [2]", got)
+	}
+	_ = apps.BatchUpdateApplicationStatus([]uint{1, 4}, constants.ApplicationStatusActive)
+	if got := searchIDs(t, request.SearchAppRequest{PageInfo: page, Keyword: "微信"}, ""); len(got) != 3 {
+		t.Errorf("after activate ids = %v, want 3 apps", got)
+	}
+
+	// 分类改名后可以用新名称搜到
+	global.GVA_DB.Model(&project.AppCategory{}).Where("id = ?", 2).Update("category_name", "图书")
+	s.ReindexCategory(2)
+	if got := searchIDs(t, request.SearchAppRequest{PageInfo: page, Keyword: "图书"}, ""); !equalUint64s(got, []uint64{2}) {
+		t.Errorf("after rename ids = %v, want [2]", got)
+	}
+}
+
+func TestAppSearchFallback(t *testing.T) {
+	setupAppSearchTest(t)
+	// 索引未构建时回退到数据库查询，不包含下架应用
+	got := searchIDs(t, request.SearchAppRequest{PageInfo: request.PageInfo{Page: 1, PageSize: 10}, Keyword: "微信", SortType: "downloads"}, "")
+	if !equalUint64s(got, []uint64{1, 2}) {
+		t.Errorf("fallback ids = %v, want [1 2]", got)
+	}
+}
+
+func equalUint64s(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
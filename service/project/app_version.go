@@ -0,0 +1,162 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	projectModel "ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/project/response"
+	"ApkAdmin/utils/locale"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+const maxReleaseFeedItems = 200
+
+type AppVersionService struct{}
+
+var appVersionService = AppVersionService{}
+
+// VersionHistory 应用已发布版本的历史，按发布时间倒序，更新说明按访问者语言选择
+func (s *AppVersionService) VersionHistory(req request.AppVersionHistoryRequest, acceptLanguage, countryCode string) (list []response.AppStorePackage, total int64, err error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 50 {
+		req.PageSize = 20
+	}
+	var app projectModel.Application
+	err = global.GVA_DB.Select("id, app_id").
+		Where("id = ? AND status = ?", req.AppID, constants.ApplicationStatusActive).
+		Scopes(visibleInCountry(countryCode)).
+		First(&app).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, 0, errors.New("应用不存在或已下架")
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	preferred, err := preferredLanguages(req.Lang, acceptLanguage, countryCode)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	db := global.GVA_DB.Model(&projectModel.AppPackage{}).
+		Where("app_id = ? AND status = ?", app.AppID, constants.StatusPublished)
+	if req.Platform != "" {
+		db = db.Where("platform = ?", req.Platform)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var packages []projectModel.AppPackage
+	err = db.Select("id, platform, version_name, package_size, published_at").
+		Order("published_at desc, version_code desc, id desc").
+		Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).
+		Find(&packages).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	list, err = storePackageItems(packages, preferred)
+	return list, total, err
+}
+
+// RecentReleases 全站最近发布的新版本，只包含访问者所在国家可见的上架应用
+func (s *AppVersionService) RecentReleases(req request.ReleaseFeedRequest, acceptLanguage, countryCode string, limit int) ([]response.AppRelease, error) {
+	if limit <= 0 || limit > maxReleaseFeedItems {
+		limit = 50
+	}
+	preferred, err := preferredLanguages(req.Lang, acceptLanguage, countryCode)
+	if err != nil {
+		return nil, err
+	}
+	visibleApps := global.GVA_DB.Model(&projectModel.Application{}).Select("app_id").
+		Where("status = ?", constants.ApplicationStatusActive).
+		Scopes(visibleInCountry(countryCode))
+	// 灰度中的版本尚未全量，不进入订阅源；同时是其它国家或渠道全量版本的除外
+	staged := global.GVA_DB.Model(&projectModel.PackageRelease{}).Select("rollout_package_id").
+		Where("rollout_package_id IS NOT NULL AND rollout_percent > 0")
+	full := global.GVA_DB.Model(&projectModel.PackageRelease{}).Select("current_package_id")
+	db := global.GVA_DB.Select("id, app_id, platform, version_name, package_size, published_at").
+		Where("status = ? AND published_at IS NOT NULL", constants.StatusPublished).
+		Where("app_id IN (?)", visibleApps).
+		Where("id NOT IN (?) OR id IN (?)", staged, full)
+	if req.Platform != "" {
+		db = db.Where("platform = ?", req.Platform)
+	}
+	var packages []projectModel.AppPackage
+	if err = db.Order("published_at desc, id desc").Limit(limit).Find(&packages).Error; err != nil {
+		return nil, err
+	}
+	items, err := storePackageItems(packages, preferred)
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs := make([]string, 0, len(packages))
+	for _, p := range packages {
+		appIDs = append(appIDs, p.AppID)
+	}
+	var apps []projectModel.Application
+	if len(appIDs) > 0 {
+		err = global.GVA_DB.Select("id, app_id, app_name, app_icon").Where("app_id IN ?", appIDs).Find(&apps).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	appMap := make(map[string]projectModel.Application, len(apps))
+	for _, a := range apps {
+		appMap[a.AppID] = a
+	}
+	// 使用本地化的应用名称
+	names, err := localizedAppNames(appIDs, preferred)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]response.AppRelease, 0, len(items))
+	for i, p := range packages {
+		app := appMap[p.AppID]
+		name := app.AppName
+		if n := names[p.AppID]; n != "" {
+			name = n
+		}
+		list = append(list, response.AppRelease{
+			AppStorePackage: items[i],
+			ApplicationID:   app.ID,
+			AppName:         name,
+			AppIcon:         app.AppIcon,
+		})
+	}
+	return list, nil
+}
+
+// localizedAppNames 每个应用选择最匹配语言的名称，没有匹配的语言时不返回
+func localizedAppNames(appIDs []string, preferred []string) (map[string]string, error) {
+	result := make(map[string]string)
+	if len(appIDs) == 0 {
+		return result, nil
+	}
+	var listings []projectModel.AppListing
+	err := global.GVA_DB.Select("app_id, language_code, app_name").Where("app_id IN ?", appIDs).
+		Order("language_code asc").Find(&listings).Error
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]map[string]string)
+	langs := make(map[string][]string)
+	for _, l := range listings {
+		if names[l.AppID] == nil {
+			names[l.AppID] = make(map[string]string)
+		}
+		names[l.AppID][l.LanguageCode] = l.AppName
+		langs[l.AppID] = append(langs[l.AppID], l.LanguageCode)
+	}
+	for appID, available := range langs {
+		if lang, ok := locale.Match(preferred, available); ok {
+			result[appID] = names[appID][lang]
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,134 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"testing"
+)
+
+// setupAppVersionTest 上架的应用 a1 和下架的应用 a4，a1 有一个已发布的安卓安装包
+func setupAppVersionTest(t *testing.T, models ...interface{}) {
+	t.Helper()
+	setupTestDB(t, models...)
+	createApplicationTables(t)
+	global.GVA_DB.Exec(`INSERT INTO applications (id, app_id, app_name, status) VALUES (1, 'a1', '微信', 'active'), (4, 'a4', '微信旧版', 'suspended')`)
+	global.GVA_DB.Exec(`INSERT INTO app_packages (id, app_id, platform, status) VALUES (1, 'a1', 'android', 'published')`)
+}
+
+func TestVersionHistoryAndRecentReleases(t *testing.T) {
+	setupAppVersionTest(t, &project.AppListing{}, &project.CountryRegion{}, &project.AppPackageChangelog{}, &project.PackageRelease{})
+	global.GVA_DB.Exec(`UPDATE app_packages SET version_name = '1.0', version_code = 100, published_at = '2026-01-01 00:00:00' WHERE id = 1`)
+	global.GVA_DB.Exec(`INSERT INTO app_packages (app_id, platform, status, version_name, version_code, published_at) VALUES
+		('a1', 'android', 'published', '2.0', 200, '2026-03-01 00:00:00'),
+		('a4', 'android', 'published', '9.0', 900, '2026-04-01 00:00:00')`)
+	global.GVA_DB.Exec(`INSERT INTO app_package_changelogs (package_id, language_code, changelog) VALUES (1, 'en', 'First release'), (1, 'zh-CN', '首个版本')`)
+
+	history, total, err := appVersionService.VersionHistory(request.AppVersionHistoryRequest{AppID: 1, Platform: "android"}, "en", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(history) != 2 || history[0].VersionName != "2.0" || history[1].Changelog != "First release" {
+		t.Errorf("history = %d %+v", total, history)
+	}
+	if _, _, err := appVersionService.VersionHistory(request.AppVersionHistoryRequest{AppID: 4}, "", ""); err == nil {
+		t.Error("suspended app should not expose version history")
+	}
+
+	// 下架应用的版本不进入订阅源
+	releases, err := appVersionService.RecentReleases(request.ReleaseFeedRequest{Platform: "android"}, "zh-CN", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range releases {
+		if r.ApplicationID == 4 {
+			t.Errorf("suspended app in feed: %+v", r)
+		}
+	}
+	if len(releases) == 0 || releases[0].VersionName != "2.0" || releases[0].AppName != "微信" {
+		t.Fatalf("releases = %+v", releases)
+	}
+	for _, r := range releases {
+		if r.ID == 1 && r.Changelog != "首个版本" {
+			t.Errorf("changelog = %q", r.Changelog)
+		}
+	}
+
+	// 灰度中的版本不进入订阅源，转全量后出现
+	staged := releases[0].ID
+	release := project.PackageRelease{AppID: "a1", Platform: "android", Channel: "stable", CurrentPackageID: 1, RolloutPackageID: &staged, RolloutPercent: 20}
+	global.GVA_DB.Create(&release)
+	releaseIDs := func() map[uint64]bool {
+		t.Helper()
+		list, err := appVersionService.RecentReleases(request.ReleaseFeedRequest{Platform: "android"}, "zh-CN", "", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make(map[uint64]bool)
+		for _, r := range list {
+			ids[r.ID] = true
+		}
+		return ids
+	}
+	if ids := releaseIDs(); ids[staged] || !ids[1] {
+		t.Errorf("feed during rollout = %v", ids)
+	}
+	global.GVA_DB.Model(&release).Updates(map[string]interface{}{"current_package_id": staged, "rollout_package_id": nil, "rollout_percent": 0})
+	if ids := releaseIDs(); !ids[staged] {
+		t.Errorf("feed after promote = %v", ids)
+	}
+}
+
+func TestFollowNotifications(t *testing.T) {
+	setupAppVersionTest(t, &project.AppFollow{}, &project.UserNotification{})
+	global.GVA_DB.Exec("UPDATE app_packages SET version_name = '8.0' WHERE id = 1")
+
+	if err := appFollowService.Follow(7, 1); err != nil {
+		t.Fatal(err)
+	}
+	// 重复关注不报错
+	if err := appFollowService.Follow(7, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := appFollowService.Follow(8, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := appFollowService.Follow(7, 4); err == nil {
+		t.Error("following a suspended app should fail")
+	}
+
+	appFollowService.NotifyNewVersions(1)
+	// 同一版本再次通知不会重复
+	appFollowService.NotifyNewVersions(1)
+
+	var svc UserNotificationService
+	count, _ := svc.UnreadCount(7)
+	if count != 1 {
+		t.Fatalf("unread = %d", count)
+	}
+	list, _, _ := svc.GetNotificationList(7, request.NotificationListRequest{})
+	if len(list) != 1 || list[0].Title != "微信 发布了新版本 8.0" || list[0].AppID != 1 {
+		t.Errorf("notifications = %+v", list)
+	}
+	if err := svc.MarkRead(7, nil); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ = svc.UnreadCount(7); count != 0 {
+		t.Errorf("unread after mark = %d", count)
+	}
+	// 其他用户的通知不受影响
+	if count, _ = svc.UnreadCount(8); count != 1 {
+		t.Errorf("other user unread = %d", count)
+	}
+
+	if err := appFollowService.Unfollow(8, 1); err != nil {
+		t.Fatal(err)
+	}
+	follows, total, err := appFollowService.GetFollowList(7, request.FollowListRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || follows[0].AppName != "微信" || follows[0].LatestVersion == "" {
+		t.Errorf("follows = %+v", follows)
+	}
+}
@@ -17,42 +17,23 @@ import (
 
 type ApplicationService struct{}
 
-func (a *ApplicationService) SearchApps(req request.SearchAppRequest) (list interface{}, total int64, err error) {
-	var applicationList []project.Application
-	limit := req.PageSize
-	offset := req.PageSize * (req.Page - 1)
-	// 构建查询条件
-	db := global.GVA_DB.Model(&project.Application{}).Where("app_name LIKE ?", "%"+req.Keyword+"%")
-	// 分页和排序
-	db = db.Limit(limit).Offset(offset)
-	sortStr := "sort_order desc"
-	if req.SortType == "hot" {
-		sortStr = "is_hot desc"
-	}
-	if req.SortType == "new" {
-		sortStr = "created_at desc"
-	}
-	if req.SortType == "rating" {
-		sortStr = "rating desc"
-	}
-	if req.SortType == "downloads" {
-		sortStr = "download_count desc"
-	}
-	// 获取总数
-	err = db.Count(&total).Error
-	if err != nil {
-		return applicationList, total, err
-	}
-	// 构建排序条件
-	err = db.Order(sortStr).Find(&applicationList).Error
-	if err != nil {
-		return nil, 0, err
+// visibleInCountry 只返回指定国家的应用和通用应用，国家为空时不过滤
+func visibleInCountry(countryCode string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if countryCode == "" {
+			return db
+		}
+		return db.Where("(country_code = ? OR country_code = '' OR country_code IS NULL)", countryCode)
 	}
-	return applicationList, total, err
 }
 
-// FilterAppsByCategory 按照分类分页获取应用
-func (a *ApplicationService) FilterAppsByCategory(req request.FilterAppRequest) (list interface{}, total int64, err error) {
+// SearchApps 全文搜索访问者所在国家可见的上架应用，按相关度和热度排序
+func (a *ApplicationService) SearchApps(req request.SearchAppRequest, countryCode string) (*response.AppSearchResp, error) {
+	return appSearchService.Search(req, countryCode)
+}
+
+// FilterAppsByCategory 按照分类分页获取访问者所在国家可见的应用
+func (a *ApplicationService) FilterAppsByCategory(req request.FilterAppRequest, countryCode string) (list interface{}, total int64, err error) {
 	var cid []uint
 	if req.CategoryId == 0 {
 		// 查找账号分类
@@ -75,7 +56,7 @@ func (a *ApplicationService) FilterAppsByCategory(req request.FilterAppRequest)
 	limit := req.PageSize
 	offset := req.PageSize * (req.Page - 1)
 	// 构建查询条件
-	db := global.GVA_DB.Model(&project.Application{}).Where("category_id in ?", cid)
+	db := global.GVA_DB.Model(&project.Application{}).Where("category_id in ?", cid).Scopes(visibleInCountry(countryCode))
 	// 获取总数
 	err = db.Count(&total).Error
 	if err != nil {
@@ -162,6 +143,7 @@ func (a *ApplicationService) GetAppByAccountCategory(req request.FilterAccountAp
 			Rating:       v.Rating,
 			Stock:        len(v.Accounts),
 			SalesCount:   accountSalesAccount,
+			WarrantyDays: v.WarrantyDays,
 		}
 		result = append(result, tmp)
 	}
@@ -197,10 +179,13 @@ func (a *ApplicationService) buildSortString(sortType string) string {
 	}
 }
 
-// GetHotOrRecommendApp 获取热门和推荐的应用
-func (a *ApplicationService) GetHotOrRecommendApp() (list interface{}, err error) {
+// GetHotOrRecommendApp 获取访问者所在国家可见的热门和推荐应用
+func (a *ApplicationService) GetHotOrRecommendApp(countryCode string) (list interface{}, err error) {
 	var app []project.Application
-	err = global.GVA_DB.Model(&project.Application{}).Where("is_hot = ? or is_recommend = ?", 1, 1).Scan(&app).Error
+	err = global.GVA_DB.Model(&project.Application{}).
+		Where("is_hot = ? or is_recommend = ?", 1, 1).
+		Scopes(visibleInCountry(countryCode)).
+		Scan(&app).Error
 	if err != nil {
 		return nil, err
 	}
@@ -266,13 +251,14 @@ func (a *ApplicationService) CreateApplication(userID uint, req request.Applicat
 		application.CategoryID = &category.ID
 		application.SubcategoryID = &category.ParentID
 	}
-	randRating := utils.GenerateRatingWithDistribution()
-	application.Rating = &randRating
 	application.AppID = uuid.New().String()
 	application.Status = constants.ApplicationStatusActive
 	application.CreatedBy = int64(userID)
-	err = global.GVA_DB.Create(&application).Error
-	return err
+	if err = global.GVA_DB.Create(&application).Error; err != nil {
+		return err
+	}
+	appSearchService.ReindexApps(application.ID)
+	return nil
 }
 
 // UpdateApplication 更新应用
@@ -307,8 +293,16 @@ func (a *ApplicationService) UpdateApplication(req *request.ApplicationUpdateReq
 	existing.IsRecommend = req.IsRecommend
 	existing.IsFree = &req.IsFree
 	existing.SortOrder = req.SortOrder
-	err = global.GVA_DB.Omit("created_at").Updates(existing).Error
-	return err
+	existing.WarrantyDays = req.WarrantyDays
+	if err = global.GVA_DB.Omit("created_at").Updates(existing).Error; err != nil {
+		return err
+	}
+	// Updates 会忽略零值，保修天数允许改回0
+	if err = global.GVA_DB.Model(existing).UpdateColumn("warranty_days", req.WarrantyDays).Error; err != nil {
+		return err
+	}
+	appSearchService.ReindexApps(existing.ID)
+	return nil
 }
 
 // DeleteApplication 删除应用
@@ -342,7 +336,17 @@ func (a *ApplicationService) DeleteApplication(cid int) (err error) {
 		return errors.New("该应用下存在应用截图，无法删除")
 	}
 
-	return global.GVA_DB.Model(&project.Application{}).Where("id = ?", cid).Delete(&project.Application{}).Error
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("app_id = ?", existing.AppID).Delete(&project.AppListing{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&project.Application{}).Where("id = ?", cid).Delete(&project.Application{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	appSearchService.ReindexApps(existing.ID)
+	return nil
 }
 
 // BatchDeleteApplications 批量删除应用
@@ -359,7 +363,15 @@ func (a *ApplicationService) BatchDeleteApplications(ids []uint) error {
 // BatchUpdateApplicationStatus 批量更新应用状态
 func (a *ApplicationService) BatchUpdateApplicationStatus(ids []uint, status constants.ApplicationStatus) error {
 	err := global.GVA_DB.Model(&project.Application{}).Where("id IN ?", ids).Update("status", status).Error
-	return err
+	if err != nil {
+		return err
+	}
+	appIDs := make([]uint64, len(ids))
+	for i, id := range ids {
+		appIDs[i] = uint64(id)
+	}
+	appSearchService.ReindexApps(appIDs...)
+	return nil
 }
 
 // CloneApplication 克隆应用
@@ -388,6 +400,7 @@ func (a *ApplicationService) CloneApplication(uid uint, req request.ApplicationC
 	if err := global.GVA_DB.Create(&cloneApp).Error; err != nil {
 		return nil, err
 	}
+	appSearchService.ReindexApps(cloneApp.ID)
 
 	return &cloneApp, nil
 }
@@ -31,8 +31,11 @@ func (a *CategoryService) CreateCategory(req request.CreateCategoryRequest) (err
 		IsBanner:      &req.IsBanner,
 		BannerUrl:     req.BannerUrl,
 	}
-	err = global.GVA_DB.Create(&e).Error
-	return err
+	if err = global.GVA_DB.Create(&e).Error; err != nil {
+		return err
+	}
+	invalidateCategoryCache()
+	return nil
 }
 
 func (a *CategoryService) UpdateCategory(req *request.UpdateCategoryRequest) (err error) {
@@ -44,6 +47,15 @@ func (a *CategoryService) UpdateCategory(req *request.UpdateCategoryRequest) (er
 	if category.ID > 0 {
 		return errors.New("存在相同的分类编码，请重新配置")
 	}
+	if req.ParentID != project.TopLevelParentID {
+		var categories []project.AppCategory
+		if err = global.GVA_DB.Select("id, parent_id").Find(&categories).Error; err != nil {
+			return err
+		}
+		if !project.ValidateCircularReference(categories, req.ID, req.ParentID) {
+			return errors.New("不能将分类移动到自身或其子分类下")
+		}
+	}
 	e := project.AppCategory{
 		ParentID:      req.ParentID,
 		CategoryCode:  req.CategoryCode,
@@ -59,7 +71,13 @@ func (a *CategoryService) UpdateCategory(req *request.UpdateCategoryRequest) (er
 		BannerUrl:     req.BannerUrl,
 	}
 	err = global.GVA_DB.Omit("created_at").Where("id = ?", req.ID).Updates(e).Error
-	return err
+	if err != nil {
+		return err
+	}
+	invalidateCategoryCache()
+	// 分类名称参与应用搜索
+	appSearchService.ReindexCategory(req.ID)
+	return nil
 }
 
 func (a *CategoryService) DeleteCategory(cid int) (err error) {
@@ -71,7 +89,12 @@ func (a *CategoryService) DeleteCategory(cid int) (err error) {
 	if total > 0 {
 		return errors.New("该分类下存在应用，请先解除使用")
 	}
-	return global.GVA_DB.Model(&project.AppCategory{}).Where("id = ?", cid).Delete(&project.AppCategory{}).Error
+	if err = global.GVA_DB.Model(&project.AppCategory{}).Where("id = ?", cid).Delete(&project.AppCategory{}).Error; err != nil {
+		return err
+	}
+	invalidateCategoryCache()
+	appSearchService.ReindexCategory(uint(cid))
+	return nil
 }
 
 func (a *CategoryService) FindSelectCategory(info request.SelectCategoryRequest) (list interface{}, err error) {
@@ -104,11 +127,7 @@ func (a *CategoryService) GetCategoryList(info request.CategoryPageInfo, order s
 	if err != nil {
 		return categoryLists, total, err
 	}
-	db = db.Select(`app_categories.*,
-			(SELECT COUNT(*) 
-			 FROM applications 
-			 WHERE applications.category_id = app_categories.id 
-			 AND applications.status = 'active') as app_count`).Limit(limit).Offset(offset)
+	db = db.Limit(limit).Offset(offset)
 	OrderStr := "sort_order desc"
 	if order != "" {
 		OrderStr = order
@@ -116,7 +135,22 @@ func (a *CategoryService) GetCategoryList(info request.CategoryPageInfo, order s
 			OrderStr = order + " desc"
 		}
 	}
-	err = db.Order(OrderStr).Scan(&categoryLists).Error
+	err = db.Order(OrderStr).Find(&categoryLists).Error
+	if err != nil {
+		return categoryLists, total, err
+	}
+	// AppCount 不映射数据库列，按分类批量统计后填充
+	ids := make([]uint, len(categoryLists))
+	for i := range categoryLists {
+		ids[i] = categoryLists[i].ID
+	}
+	counts, err := categoryAppCounts(ids)
+	if err != nil {
+		return categoryLists, total, err
+	}
+	for i := range categoryLists {
+		categoryLists[i].AppCount = counts[categoryLists[i].ID]
+	}
 	if info.ParentId > 0 {
 		return a.getChildrenList(categoryLists, info.ParentId), total, err
 	} else {
@@ -140,18 +174,24 @@ func (a *CategoryService) getChildrenList(categories []project.AppCategory, pare
 func (a *CategoryService) GetTrendingCategory() (list interface{}, err error) {
 	var categoryLists []response.CategoryResponse
 	err = global.GVA_DB.Model(&project.AppCategory{}).
-		Select(`app_categories.*,
-			(SELECT COUNT(*) 
-			 FROM applications 
-			 WHERE applications.category_id = app_categories.id 
-			 AND applications.status = 'active') as app_count`).
 		Where("trending_tag = ?", 1).
 		Order("sort_order desc").
 		Scan(&categoryLists).Error
 	if err != nil {
 		return nil, err
 	}
-	return categoryLists, err
+	ids := make([]uint, len(categoryLists))
+	for i := range categoryLists {
+		ids[i] = categoryLists[i].ID
+	}
+	counts, err := categoryAppCounts(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range categoryLists {
+		categoryLists[i].AppCount = counts[categoryLists[i].ID]
+	}
+	return categoryLists, nil
 }
 
 // GetAllCategory 获取所有分类
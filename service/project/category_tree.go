@@ -0,0 +1,268 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	categoryTreeCacheKey = "category:tree"
+	categoryTreeCacheTTL = 30 * time.Minute
+	maxCategorySortOrder = 9999
+)
+
+// categoryMemCache Redis 不可用时使用的进程内缓存
+var categoryMemCache struct {
+	sync.Mutex
+	list     []project.AppCategory
+	expireAt time.Time
+}
+
+// GetCategoryTree 完整分类树，分类结构走缓存，应用数量每次实时统计
+func (a *CategoryService) GetCategoryTree() ([]*project.AppCategory, error) {
+	categories, err := a.allCategories()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(categories))
+	for i := range categories {
+		ids[i] = categories[i].ID
+	}
+	counts, err := categoryAppCounts(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range categories {
+		categories[i].AppCount = counts[categories[i].ID]
+	}
+	return a.getChildrenList(categories, project.TopLevelParentID), nil
+}
+
+// allCategories 按排序权重排列的全部分类，返回副本，调用方可以修改
+func (a *CategoryService) allCategories() ([]project.AppCategory, error) {
+	if list, ok := getCategoryCache(); ok {
+		return list, nil
+	}
+	var list []project.AppCategory
+	if err := global.GVA_DB.Order("sort_order desc, id asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	setCategoryCache(list)
+	return list, nil
+}
+
+// categoryAppCounts 统计每个分类下的上架应用数量，主分类和子分类都计入，同一应用在同一分类只计一次
+func categoryAppCounts(ids []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+	type row struct {
+		CategoryID uint
+		Total      int64
+	}
+	var main, sub []row
+	err := global.GVA_DB.Model(&project.Application{}).
+		Select("category_id, COUNT(*) AS total").
+		Where("status = ? AND category_id IN ?", constants.ApplicationStatusActive, ids).
+		Group("category_id").Scan(&main).Error
+	if err != nil {
+		return nil, err
+	}
+	err = global.GVA_DB.Model(&project.Application{}).
+		Select("subcategory_id AS category_id, COUNT(*) AS total").
+		Where("status = ? AND subcategory_id IN ?", constants.ApplicationStatusActive, ids).
+		Where("(category_id IS NULL OR category_id <> subcategory_id)").
+		Group("subcategory_id").Scan(&sub).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range append(main, sub...) {
+		counts[r.CategoryID] += r.Total
+	}
+	return counts, nil
+}
+
+// MoveCategories 批量移动分类（连同子树）到新的父分类，按顺序逐个校验循环引用，全部成功或全部失败
+func (a *CategoryService) MoveCategories(moves []request.CategoryMove) error {
+	err := global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		var categories []project.AppCategory
+		if err := tx.Select("id, parent_id").Find(&categories).Error; err != nil {
+			return err
+		}
+		index := make(map[uint]int, len(categories))
+		for i := range categories {
+			index[categories[i].ID] = i
+		}
+		for _, m := range moves {
+			i, ok := index[m.ID]
+			if !ok {
+				return fmt.Errorf("分类%d不存在", m.ID)
+			}
+			if _, ok = index[m.ParentID]; m.ParentID != project.TopLevelParentID && !ok {
+				return fmt.Errorf("父分类%d不存在", m.ParentID)
+			}
+			if !project.ValidateCircularReference(categories, m.ID, m.ParentID) {
+				return fmt.Errorf("不能将分类%d移动到自身或其子分类下", m.ID)
+			}
+			// 后面的移动基于前面移动后的结构校验
+			categories[i].ParentID = m.ParentID
+			if err := tx.Model(&project.AppCategory{}).Where("id = ?", m.ID).Update("parent_id", m.ParentID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	invalidateCategoryCache()
+	return nil
+}
+
+// ReorderCategories 按传入顺序重新设置同级分类的排序权重，排在前面的权重更高
+func (a *CategoryService) ReorderCategories(parentID uint, ids []uint) error {
+	if len(ids) > maxCategorySortOrder {
+		return fmt.Errorf("同级分类最多排序%d个", maxCategorySortOrder)
+	}
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return fmt.Errorf("分类%d重复", id)
+		}
+		seen[id] = true
+	}
+	err := global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&project.AppCategory{}).Where("id IN ? AND parent_id = ?", ids, parentID).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(ids) {
+			return errors.New("只能对同一父分类下的分类排序")
+		}
+		for i, id := range ids {
+			if err := tx.Model(&project.AppCategory{}).Where("id = ?", id).Update("sort_order", len(ids)-i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	invalidateCategoryCache()
+	return nil
+}
+
+// MergeCategories 将 source 分类合并到 target：应用和账号改用 target，source 的子分类移到 target 下，然后删除 source
+func (a *CategoryService) MergeCategories(sourceID, targetID uint) error {
+	if sourceID == targetID {
+		return errors.New("不能合并到自身")
+	}
+	err := global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		var categories []project.AppCategory
+		if err := tx.Select("id, parent_id").Find(&categories).Error; err != nil {
+			return err
+		}
+		var foundSource, foundTarget bool
+		for _, c := range categories {
+			foundSource = foundSource || c.ID == sourceID
+			foundTarget = foundTarget || c.ID == targetID
+		}
+		if !foundSource || !foundTarget {
+			return errors.New("分类不存在")
+		}
+		// target 在 source 的子树中时，子分类移到 target 下会形成循环
+		if !project.ValidateCircularReference(categories, sourceID, targetID) {
+			return errors.New("不能合并到自己的子分类")
+		}
+
+		if err := tx.Model(&project.Application{}).Where("category_id = ?", sourceID).Update("category_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&project.Application{}).Where("subcategory_id = ?", sourceID).Update("subcategory_id", targetID).Error; err != nil {
+			return err
+		}
+		// 合并后主分类和子分类相同的，清空子分类
+		if err := tx.Model(&project.Application{}).Where("subcategory_id = ? AND category_id = ?", targetID, targetID).
+			Update("subcategory_id", nil).Error; err != nil {
+			return err
+		}
+		// 已删除的账号也改用 target，避免引用不存在的分类
+		if err := tx.Unscoped().Model(&project.AppAccount{}).Where("category_id = ?", sourceID).Update("category_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&project.AppCategory{}).Where("parent_id = ?", sourceID).Update("parent_id", targetID).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", sourceID).Delete(&project.AppCategory{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	invalidateCategoryCache()
+	appSearchService.ReindexCategory(targetID)
+	return nil
+}
+
+func getCategoryCache() ([]project.AppCategory, bool) {
+	if global.GVA_REDIS != nil {
+		data, err := global.GVA_REDIS.Get(context.Background(), categoryTreeCacheKey).Bytes()
+		if err != nil {
+			if err != redis.Nil {
+				global.GVA_LOG.Error("读取分类缓存失败!", zap.Error(err))
+			}
+			return nil, false
+		}
+		var list []project.AppCategory
+		return list, json.Unmarshal(data, &list) == nil
+	}
+	categoryMemCache.Lock()
+	defer categoryMemCache.Unlock()
+	if categoryMemCache.list == nil || time.Now().After(categoryMemCache.expireAt) {
+		return nil, false
+	}
+	list := make([]project.AppCategory, len(categoryMemCache.list))
+	copy(list, categoryMemCache.list)
+	return list, true
+}
+
+func setCategoryCache(list []project.AppCategory) {
+	if global.GVA_REDIS != nil {
+		data, _ := json.Marshal(list)
+		if err := global.GVA_REDIS.Set(context.Background(), categoryTreeCacheKey, data, categoryTreeCacheTTL).Err(); err != nil {
+			global.GVA_LOG.Error("写入分类缓存失败!", zap.Error(err))
+		}
+		return
+	}
+	categoryMemCache.Lock()
+	defer categoryMemCache.Unlock()
+	categoryMemCache.list = make([]project.AppCategory, len(list))
+	copy(categoryMemCache.list, list)
+	categoryMemCache.expireAt = time.Now().Add(categoryTreeCacheTTL)
+}
+
+// invalidateCategoryCache 分类变更后清除缓存
+func invalidateCategoryCache() {
+	if global.GVA_REDIS != nil {
+		if err := global.GVA_REDIS.Del(context.Background(), categoryTreeCacheKey).Err(); err != nil {
+			global.GVA_LOG.Error("清除分类缓存失败!", zap.Error(err))
+		}
+		return
+	}
+	categoryMemCache.Lock()
+	defer categoryMemCache.Unlock()
+	categoryMemCache.list = nil
+}
@@ -0,0 +1,141 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"testing"
+)
+
+func setupCategoryTreeTest(t *testing.T) {
+	t.Helper()
+	setupTestDB(t, &project.AppCategory{})
+	createApplicationTables(t)
+	invalidateCategoryCache()
+	t.Cleanup(invalidateCategoryCache)
+	if err := global.GVA_DB.Exec(`CREATE TABLE app_accounts (id integer PRIMARY KEY, app_id text, category_id integer, updated_at datetime, deleted_at datetime)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 1 社交 ─ 3 聊天 ─ 5 群聊；2 阅读 ─ 4 小说
+	global.GVA_DB.Create(&[]project.AppCategory{
+		{ID: 1, CategoryCode: "social", CategoryName: "社交"},
+		{ID: 2, CategoryCode: "reading", CategoryName: "阅读"},
+		{ID: 3, ParentID: 1, CategoryCode: "chat", CategoryName: "聊天"},
+		{ID: 4, ParentID: 2, CategoryCode: "novel", CategoryName: "小说"},
+		{ID: 5, ParentID: 3, CategoryCode: "group", CategoryName: "群聊"},
+	})
+	// 应用 4 已下架，不计入分类的应用数
+	global.GVA_DB.Exec(`INSERT INTO applications (id, app_id, app_name, category_id, subcategory_id, status) VALUES
+		(1, 'a1', '微信', 1, 3, 'active'), (2, 'a2', '微信读书', 2, NULL, 'active'),
+		(3, 'a3', 'Telegram', 1, NULL, 'active'), (4, 'a4', '微信旧版', 1, NULL, 'suspended')`)
+	global.GVA_DB.Exec("INSERT INTO app_accounts (app_id, category_id) VALUES ('a1', 3), ('a2', 2)")
+}
+
+func findCategory(tree []*project.AppCategory, id uint) *project.AppCategory {
+	for _, c := range tree {
+		if c.ID == id {
+			return c
+		}
+		if found := findCategory(c.Children, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func TestCategoryTreeCounts(t *testing.T) {
+	setupCategoryTreeTest(t)
+	var s CategoryService
+	tree, err := s.GetCategoryTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree) != 2 {
+		t.Fatalf("top level = %d", len(tree))
+	}
+	// 下架的应用不计数，子分类也计入
+	if c := findCategory(tree, 1); c.AppCount != 2 || len(c.Children) != 1 {
+		t.Errorf("social = %+v", c)
+	}
+	if c := findCategory(tree, 3); c.AppCount != 1 || findCategory(c.Children, 5) == nil {
+		t.Errorf("chat = %+v", c)
+	}
+
+	// 结构走缓存，变更后需要失效
+	global.GVA_DB.Exec("UPDATE app_categories SET category_name = '改名' WHERE id = 2")
+	tree, _ = s.GetCategoryTree()
+	if findCategory(tree, 2).CategoryName != "阅读" {
+		t.Error("tree should be served from cache")
+	}
+	invalidateCategoryCache()
+	tree, _ = s.GetCategoryTree()
+	if findCategory(tree, 2).CategoryName != "改名" {
+		t.Error("tree should reload after invalidation")
+	}
+}
+
+func TestMoveAndReorderCategories(t *testing.T) {
+	setupCategoryTreeTest(t)
+	var s CategoryService
+	if err := s.MoveCategories([]request.CategoryMove{{ID: 1, ParentID: 5}}); err == nil {
+		t.Error("moving a category under its descendant should fail")
+	}
+	// 后一个移动基于前一个移动后的结构校验，整体失败时不落库
+	err := s.MoveCategories([]request.CategoryMove{{ID: 3, ParentID: 2}, {ID: 2, ParentID: 5}})
+	if err == nil {
+		t.Error("cycle created by an earlier move should be detected")
+	}
+	var parent uint
+	global.GVA_DB.Model(&project.AppCategory{}).Where("id = 3").Pluck("parent_id", &parent)
+	if parent != 1 {
+		t.Errorf("failed batch should roll back, parent = %d", parent)
+	}
+
+	if err = s.MoveCategories([]request.CategoryMove{{ID: 3, ParentID: 2}, {ID: 4, ParentID: 0}}); err != nil {
+		t.Fatal(err)
+	}
+	tree, _ := s.GetCategoryTree()
+	if len(tree) != 3 || findCategory(findCategory(tree, 2).Children, 5) == nil {
+		t.Errorf("tree after move = %+v", tree)
+	}
+
+	if err = s.ReorderCategories(0, []uint{1, 3}); err == nil {
+		t.Error("categories with different parents should not be reordered together")
+	}
+	if err = s.ReorderCategories(0, []uint{4, 2, 1}); err != nil {
+		t.Fatal(err)
+	}
+	tree, _ = s.GetCategoryTree()
+	if tree[0].ID != 4 || tree[1].ID != 2 || tree[2].ID != 1 {
+		t.Errorf("order = %d %d %d", tree[0].ID, tree[1].ID, tree[2].ID)
+	}
+}
+
+func TestMergeCategories(t *testing.T) {
+	setupCategoryTreeTest(t)
+	var s CategoryService
+	if err := s.MergeCategories(1, 5); err == nil {
+		t.Error("merging into a descendant should fail")
+	}
+	// 3 合并到 1：应用的子分类与主分类相同后清空，账号改用 1，5 移到 1 下
+	if err := s.MergeCategories(3, 1); err != nil {
+		t.Fatal(err)
+	}
+	var app project.Application
+	global.GVA_DB.Where("id = 1").First(&app)
+	if app.SubcategoryID != nil || *app.CategoryID != 1 {
+		t.Errorf("app categories = %v %v", app.CategoryID, app.SubcategoryID)
+	}
+	var accountCategory uint
+	global.GVA_DB.Raw("SELECT category_id FROM app_accounts WHERE app_id = 'a1'").Scan(&accountCategory)
+	if accountCategory != 1 {
+		t.Errorf("account category = %d", accountCategory)
+	}
+	tree, _ := s.GetCategoryTree()
+	if findCategory(tree, 3) != nil || findCategory(findCategory(tree, 1).Children, 5) == nil {
+		t.Errorf("tree after merge = %+v", tree)
+	}
+	if c := findCategory(tree, 1); c.AppCount != 2 {
+		t.Errorf("merged app count = %d", c.AppCount)
+	}
+}
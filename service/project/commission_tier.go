@@ -235,7 +235,7 @@ func (s *CommissionTierService) UpdateCommissionTierSort(sorts []map[string]inte
 func (s *CommissionTierService) GetUserCurrentTier(userId int64) (tier project.CommissionTier, err error) {
 	// 统计用户的直属下级人数
 	var count int64
-	err = global.GVA_DB.Model(&project.User{}).Where("inviter_id = ?", userId).Count(&count).Error
+	err = global.GVA_DB.Model(&project.User{}).Where("referrer_id = ? AND deleted_at IS NULL", userId).Count(&count).Error
 	if err != nil {
 		return tier, err
 	}
@@ -7,10 +7,39 @@ import (
 	"errors"
 	"fmt"
 	"gorm.io/gorm"
+	"strings"
+)
+
+var (
+	ErrCountryNotSupported = errors.New("当前国家或地区暂不支持下载")
+	ErrAppNotInCountry     = errors.New("该应用在当前国家或地区不可用")
 )
 
 type CountryService struct{}
 
+// CheckDownloadAllowed 检查访问者所在国家能否下载该应用
+// 国家未识别或未在国家表中配置时不做限制，配置为不支持分发的国家禁止下载
+func (a *CountryService) CheckDownloadAllowed(app *project.Application, countryCode string) error {
+	if countryCode == "" {
+		return nil
+	}
+	if app.CountryCode != "" && !strings.EqualFold(app.CountryCode, countryCode) {
+		return ErrAppNotInCountry
+	}
+	var country project.CountryRegion
+	err := global.GVA_DB.Select("id, is_supported").Where("country_code = ?", countryCode).First(&country).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if country.IsSupported != nil && *country.IsSupported == 0 {
+		return ErrCountryNotSupported
+	}
+	return nil
+}
+
 func (a *CountryService) Exists(code string) (bool, error) {
 	var count int64
 	err := global.GVA_DB.Model(&project.CountryRegion{}).Where("country_code = ?", code).Count(&count).Error
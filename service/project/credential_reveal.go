@@ -0,0 +1,190 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/project/response"
+	"ApkAdmin/model/system"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultAdminRevealHourlyLimit = 30
+	defaultBuyerRevealHourlyLimit = 20
+)
+
+// CredentialRevealService 账号明文查看：列表和详情接口只返回遮盖后的内容，明文必须通过这里获取，每次尝试都会记录
+type CredentialRevealService struct{}
+
+// RevealViewer 发起查看的人及请求来源
+type RevealViewer struct {
+	ID        uint
+	Name      string
+	IP        string
+	UserAgent string
+}
+
+// RevealForAdmin 管理员查看账号明文，需要填写原因，按配置校验谷歌验证码并限制每小时次数
+func (s *CredentialRevealService) RevealForAdmin(req request.AdminRevealAccountRequest, viewer RevealViewer) (*response.RevealedAccountResp, error) {
+	entry := project.CredentialRevealLog{
+		ViewerType: constants.CredentialViewerAdmin,
+		AccountID:  req.ID,
+		Reason:     truncateRunes(strings.TrimSpace(req.Reason), 200),
+	}
+	cfg := global.GVA_CONFIG.CredentialReveal
+	if err := s.reserve(&entry, viewer, cfg.AdminHourlyLimit, defaultAdminRevealHourlyLimit); err != nil {
+		return nil, err
+	}
+	resp, err := s.revealForAdmin(req, entry.Reason, viewer.ID)
+	s.finish(&entry, err)
+	return resp, err
+}
+
+func (s *CredentialRevealService) revealForAdmin(req request.AdminRevealAccountRequest, reason string, adminID uint) (*response.RevealedAccountResp, error) {
+	if reason == "" {
+		return nil, errors.New("请填写查看原因")
+	}
+	if global.GVA_CONFIG.CredentialReveal.RequireTOTP {
+		var user system.SysUser
+		if err := global.GVA_DB.Select("id, google_auth_key, google_auth_status").Where("id = ?", adminID).First(&user).Error; err != nil {
+			return nil, errors.New("管理员不存在")
+		}
+		if !user.GoogleAuthStatus {
+			return nil, errors.New("未绑定谷歌验证器,无法查看")
+		}
+		if !totp.Validate(req.Code, user.GoogleAuthKey) {
+			return nil, errors.New("谷歌验证码不正确！")
+		}
+	}
+	return loadRevealedAccount(req.ID)
+}
+
+// RevealForBuyer 买家查看已支付订单中的账号明文，售后更换后只能查看新账号
+func (s *CredentialRevealService) RevealForBuyer(req request.BuyerRevealAccountRequest, viewer RevealViewer) (*response.RevealedAccountResp, error) {
+	entry := project.CredentialRevealLog{
+		ViewerType: constants.CredentialViewerBuyer,
+		AccountID:  req.AccountID,
+	}
+	var order project.Order
+	orderErr := global.GVA_DB.Select("id, order_type, status, account_ids").
+		Where("order_no = ? AND user_id = ?", req.OrderNo, viewer.ID).First(&order).Error
+	if orderErr == nil {
+		entry.OrderID = order.ID
+	}
+	if err := s.reserve(&entry, viewer, global.GVA_CONFIG.CredentialReveal.BuyerHourlyLimit, defaultBuyerRevealHourlyLimit); err != nil {
+		return nil, err
+	}
+	var err error
+	switch {
+	case orderErr != nil:
+		err = errors.New("订单不存在")
+	case !order.IsAccountProductOrder() || !order.IsPaid():
+		err = errors.New("订单未支付")
+	case !containsAccountID(order.AccountIDs, req.AccountID):
+		err = errors.New("该账号不属于此订单")
+	}
+	var resp *response.RevealedAccountResp
+	if err == nil {
+		resp, err = loadRevealedAccount(req.AccountID)
+	}
+	s.finish(&entry, err)
+	return resp, err
+}
+
+// GetRevealLogs 明文查看记录
+func (s *CredentialRevealService) GetRevealLogs(req request.CredentialRevealLogRequest) (list []project.CredentialRevealLog, total int64, err error) {
+	db := global.GVA_DB.Model(&project.CredentialRevealLog{})
+	if req.ViewerType != "" {
+		db = db.Where("viewer_type = ?", req.ViewerType)
+	}
+	if req.ViewerID > 0 {
+		db = db.Where("viewer_id = ?", req.ViewerID)
+	}
+	if req.AccountID > 0 {
+		db = db.Where("account_id = ?", req.AccountID)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Scopes(req.Paginate()).Order("id desc").Find(&list).Error
+	return list, total, err
+}
+
+// reserve 锁定查看人后统计最近一小时的查看次数（包括被拒绝的），并在同一事务中写入审计记录占用名额，
+// 并发请求不会超过限制；超限时记录写为拒绝并返回错误。limit 未配置时使用默认值
+func (s *CredentialRevealService) reserve(entry *project.CredentialRevealLog, viewer RevealViewer, limit, defaultLimit int) error {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	entry.ViewerID = viewer.ID
+	entry.ViewerName = truncateRunes(viewer.Name, 100)
+	entry.IP = truncateRunes(viewer.IP, 64)
+	entry.UserAgent = truncateRunes(viewer.UserAgent, 255)
+	var limitErr error
+	err := global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		var viewerModel interface{} = &project.User{}
+		if entry.ViewerType == constants.CredentialViewerAdmin {
+			viewerModel = &system.SysUser{}
+		}
+		var ids []uint
+		if err := tx.Model(viewerModel).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", entry.ViewerID).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		var count int64
+		err := tx.Model(&project.CredentialRevealLog{}).
+			Where("viewer_type = ? AND viewer_id = ? AND created_at > ?", entry.ViewerType, entry.ViewerID, time.Now().Add(-time.Hour)).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count >= int64(limit) {
+			limitErr = fmt.Errorf("查看过于频繁，每小时最多查看%d次", limit)
+			entry.Message = limitErr.Error()
+		}
+		return tx.Create(entry).Error
+	})
+	if err != nil {
+		global.GVA_LOG.Error("写入账号查看记录失败!", zap.Error(err), zap.Uint("accountID", entry.AccountID))
+		return errors.New("查看账号失败")
+	}
+	return limitErr
+}
+
+// finish 回写查看结果，写入失败只记日志，不影响已通过的查看
+func (s *CredentialRevealService) finish(entry *project.CredentialRevealLog, revealErr error) {
+	updates := map[string]interface{}{"allowed": revealErr == nil}
+	if revealErr != nil {
+		updates["message"] = truncateRunes(revealErr.Error(), 200)
+	}
+	if err := global.GVA_DB.Model(entry).Updates(updates).Error; err != nil {
+		global.GVA_LOG.Error("更新账号查看记录失败!", zap.Error(err), zap.Uint64("id", entry.ID))
+	}
+}
+
+func loadRevealedAccount(id uint) (*response.RevealedAccountResp, error) {
+	var account project.AppAccount
+	// AfterFind 会自动解密
+	if err := global.GVA_DB.Where("id = ?", id).First(&account).Error; err != nil {
+		return nil, errors.New("账号不存在")
+	}
+	if account.DecryptFailed {
+		return nil, errors.New("账号详情解密失败，请检查加密密钥配置")
+	}
+	return &response.RevealedAccountResp{
+		AccountID:     account.ID,
+		AccountNo:     account.AccountNo,
+		AccountDetail: account.AccountDetail,
+		ExtraInfo:     account.ExtraInfo,
+	}, nil
+}
@@ -0,0 +1,163 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	commonReq "ApkAdmin/model/common/request"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+func TestCredentialReveal(t *testing.T) {
+	setupTestDB(t, &project.CredentialRevealLog{}, &project.User{})
+	for _, stmt := range []string{
+		`CREATE TABLE app_accounts (id integer PRIMARY KEY, app_id text, account_detail text, detail_hash text NOT NULL DEFAULT '',
+			category_id integer, account_no text UNIQUE, extra_info text, account_status integer, created_at datetime, updated_at datetime,
+			deleted_at datetime, created_by integer, updated_by integer, last_checked_at datetime)`,
+		`CREATE TABLE orders (id integer PRIMARY KEY, order_no text, user_id integer, order_type text, quantity integer, account_ids text,
+			final_amount real, status text, paid_at datetime, created_at datetime, updated_at datetime)`,
+		`CREATE TABLE sys_users (id integer PRIMARY KEY, google_auth_key text, google_auth_status numeric, deleted_at datetime)`,
+	} {
+		if err := global.GVA_DB.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	global.GVA_CONFIG.System.EncryptionKey = "test-key"
+	saved := global.GVA_CONFIG.CredentialReveal
+	t.Cleanup(func() { global.GVA_CONFIG.CredentialReveal = saved })
+	global.GVA_CONFIG.CredentialReveal.AdminHourlyLimit = 3
+	global.GVA_CONFIG.CredentialReveal.BuyerHourlyLimit = 0
+	sold := constants.AppAccountStatusSold
+	global.GVA_DB.Create(&[]project.AppAccount{
+		{ID: 1, AppID: "a1", AccountDetail: "alice----secret", AccountNo: "ACC1", AccountStatus: sold},
+		{ID: 2, AppID: "a1", AccountDetail: "bob----secret", AccountNo: "ACC2", AccountStatus: sold},
+	})
+	global.GVA_DB.Exec(`INSERT INTO orders (id, order_no, user_id, order_type, quantity, account_ids, final_amount, status, paid_at) VALUES
+		(1, 'O1', 5, 'account_product', 1, '[1]', 10, 'paid', ?), (2, 'O2', 5, 'account_product', 1, '[2]', 10, 'pending', NULL)`, time.Now())
+
+	// 列表和详情输出的 JSON 不包含明文
+	var account project.AppAccount
+	global.GVA_DB.First(&account, 1)
+	b, _ := json.Marshal(account)
+	if strings.Contains(string(b), "secret") || !strings.Contains(string(b), `"account_detail":"alic****"`) {
+		t.Errorf("account json = %s", b)
+	}
+
+	var s CredentialRevealService
+	admin := RevealViewer{ID: 9, Name: "admin", IP: "10.0.0.1"}
+	if _, err := s.RevealForAdmin(request.AdminRevealAccountRequest{ID: 1, Reason: " "}, admin); err == nil {
+		t.Error("reveal without reason should be denied")
+	}
+	resp, err := s.RevealForAdmin(request.AdminRevealAccountRequest{ID: 1, Reason: "核对买家反馈"}, admin)
+	if err != nil || resp.AccountDetail != "alice----secret" {
+		t.Fatalf("admin reveal = %+v, %v", resp, err)
+	}
+
+	// 开启谷歌验证码后，未绑定或验证码错误都会被拒绝
+	global.GVA_CONFIG.CredentialReveal.RequireTOTP = true
+	global.GVA_DB.Exec("INSERT INTO sys_users (id, google_auth_key, google_auth_status) VALUES (9, 'JBSWY3DPEHPK3PXP', 1), (10, '', 0)")
+	if _, err = s.RevealForAdmin(request.AdminRevealAccountRequest{ID: 1, Reason: "x", Code: "000000"}, admin); err == nil || !strings.Contains(err.Error(), "谷歌验证码") {
+		t.Errorf("wrong code err = %v", err)
+	}
+	if _, err = s.RevealForAdmin(request.AdminRevealAccountRequest{ID: 1, Reason: "x"}, RevealViewer{ID: 10}); err == nil || !strings.Contains(err.Error(), "未绑定") {
+		t.Errorf("unbound err = %v", err)
+	}
+	code, _ := totp.GenerateCode("JBSWY3DPEHPK3PXP", time.Now())
+	if _, err = s.RevealForAdmin(request.AdminRevealAccountRequest{ID: 1, Reason: "x", Code: code}, admin); err == nil || !strings.Contains(err.Error(), "过于频繁") {
+		t.Errorf("4th attempt within an hour err = %v", err)
+	}
+	global.GVA_CONFIG.CredentialReveal.RequireTOTP = false
+
+	// 买家只能查看自己已支付订单中的账号
+	buyer := RevealViewer{ID: 5, Name: "buyer"}
+	for _, c := range []struct {
+		orderNo   string
+		accountID uint
+		viewer    RevealViewer
+		want      string
+	}{
+		{"O1", 1, RevealViewer{ID: 6}, "订单不存在"},
+		{"O1", 2, buyer, "不属于此订单"},
+		{"O2", 2, buyer, "未支付"},
+	} {
+		if _, err = s.RevealForBuyer(request.BuyerRevealAccountRequest{OrderNo: c.orderNo, AccountID: c.accountID}, c.viewer); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("buyer reveal %s/%d: err = %v, want %s", c.orderNo, c.accountID, err, c.want)
+		}
+	}
+	resp, err = s.RevealForBuyer(request.BuyerRevealAccountRequest{OrderNo: "O1", AccountID: 1}, buyer)
+	if err != nil || resp.AccountDetail != "alice----secret" {
+		t.Fatalf("buyer reveal = %+v, %v", resp, err)
+	}
+
+	var logs []project.CredentialRevealLog
+	global.GVA_DB.Order("id").Find(&logs)
+	if len(logs) != 9 {
+		t.Fatalf("logs = %d, want 9", len(logs))
+	}
+	allowed := 0
+	for _, l := range logs {
+		if l.Allowed {
+			allowed++
+		}
+	}
+	if allowed != 2 || logs[1].Reason != "核对买家反馈" || logs[1].IP != "10.0.0.1" || logs[8].OrderID != 1 {
+		t.Errorf("unexpected logs: allowed=%d %+v", allowed, logs[1])
+	}
+	list, total, err := s.GetRevealLogs(request.CredentialRevealLogRequest{PageInfo: commonReq.PageInfo{Page: 1, PageSize: 10}, ViewerType: constants.CredentialViewerBuyer, ViewerID: 5})
+	if err != nil || total != 3 || len(list) != 3 {
+		t.Errorf("buyer logs total = %d, err = %v", total, err)
+	}
+}
+
+func TestCredentialRevealConcurrentLimit(t *testing.T) {
+	setupTestDB(t, &project.CredentialRevealLog{}, &project.User{})
+	for _, stmt := range []string{
+		`CREATE TABLE app_accounts (id integer PRIMARY KEY, app_id text, account_detail text, detail_hash text,
+			category_id integer, account_no text UNIQUE, extra_info text, account_status integer, created_at datetime, updated_at datetime,
+			deleted_at datetime, created_by integer, updated_by integer, last_checked_at datetime)`,
+		`CREATE TABLE sys_users (id integer PRIMARY KEY, google_auth_key text, google_auth_status numeric, deleted_at datetime)`,
+		`INSERT INTO sys_users (id) VALUES (9)`,
+	} {
+		if err := global.GVA_DB.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	global.GVA_CONFIG.System.EncryptionKey = "test-key"
+	saved := global.GVA_CONFIG.CredentialReveal
+	t.Cleanup(func() { global.GVA_CONFIG.CredentialReveal = saved })
+	global.GVA_CONFIG.CredentialReveal.AdminHourlyLimit = 3
+	global.GVA_DB.Create(&project.AppAccount{ID: 1, AppID: "a1", AccountDetail: "alice----secret", AccountNo: "ACC1"})
+
+	// 并发查看时先占用名额再解密，返回明文的次数不能超过限制
+	var (
+		s       CredentialRevealService
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.RevealForAdmin(request.AdminRevealAccountRequest{ID: 1, Reason: "核对"}, RevealViewer{ID: 9})
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				allowed++
+			}
+		}()
+	}
+	wg.Wait()
+	var logged int64
+	global.GVA_DB.Model(&project.CredentialRevealLog{}).Where("allowed = ?", true).Count(&logged)
+	if allowed != 3 || logged != 3 {
+		t.Errorf("allowed = %d, logged allowed = %d, want 3", allowed, logged)
+	}
+}
@@ -0,0 +1,375 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	projectModel "ApkAdmin/model/project"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLogQueueSize     = 10000
+	defaultLogWorkers       = 2
+	defaultLogBatchSize     = 200
+	defaultLogFlushInterval = time.Second
+	defaultLogEnqueueWait   = 50 * time.Millisecond
+	defaultLogSpillDir      = "./resource/download_log_spill"
+
+	// spillFileName 正在追加写入的溢出文件，导入时改名为 *.replay 后再读取
+	spillFileName = "download_logs.jsonl"
+)
+
+// DownloadEvent 下载事件，在请求处理过程中生成，入队后不再修改
+type DownloadEvent struct {
+	UserID       uint               `json:"userId"`
+	AppID        uint               `json:"appId"`
+	PackageID    uint               `json:"packageId,omitempty"`
+	MembershipID uint               `json:"membershipId,omitempty"`
+	Platform     constants.Platform `json:"platform"`
+	CountryCode  string             `json:"countryCode,omitempty"`
+	Success      bool               `json:"success"`
+	FailReason   string             `json:"failReason,omitempty"`
+	IP           string             `json:"ip"`
+	UserAgent    string             `json:"userAgent,omitempty"`
+	DeviceType   string             `json:"deviceType,omitempty"`
+	CreatedAt    time.Time          `json:"createdAt"`
+}
+
+func (e DownloadEvent) toLog() projectModel.DownloadLog {
+	log := projectModel.DownloadLog{
+		UserID:      e.UserID,
+		AppID:       e.AppID,
+		Platform:    e.Platform,
+		CountryCode: e.CountryCode,
+		Success:     e.Success,
+		IP:          e.IP,
+		CreatedAt:   e.CreatedAt,
+	}
+	if e.PackageID != 0 {
+		packageID := e.PackageID
+		log.PackageID = &packageID
+	}
+	if e.MembershipID != 0 {
+		membershipID := e.MembershipID
+		log.MembershipID = &membershipID
+	}
+	if e.FailReason != "" {
+		log.SetFailReason(truncateReason(e.FailReason))
+	}
+	log.SetUserAgent(e.UserAgent)
+	log.SetDeviceType(e.DeviceType)
+	return log
+}
+
+// downloadLogPipeline 下载日志写入管道：有界队列 + 批量写入协程，队列满或写库失败时落盘
+type downloadLogPipeline struct {
+	queue         chan DownloadEvent
+	batchSize     int
+	flushInterval time.Duration
+	enqueueWait   time.Duration
+
+	mu     sync.RWMutex // 保护 closed，关闭队列前需等待正在入队的请求
+	closed bool
+	wg     sync.WaitGroup
+}
+
+var (
+	logPipeline atomic.Pointer[downloadLogPipeline]
+	// spillMu 串行化溢出文件的追加与改名
+	spillMu sync.Mutex
+	// replayMu 同一时间只允许一个导入任务
+	replayMu sync.Mutex
+)
+
+// StartPipeline 启动下载日志写入协程，重复调用无副作用
+func (s *DownloadLogService) StartPipeline() {
+	cfg := global.GVA_CONFIG.DownloadLog
+	p := &downloadLogPipeline{
+		batchSize:     positiveOr(cfg.BatchSize, defaultLogBatchSize),
+		flushInterval: durationOr(cfg.FlushInterval, defaultLogFlushInterval),
+		enqueueWait:   durationOr(cfg.EnqueueWait, defaultLogEnqueueWait),
+	}
+	p.queue = make(chan DownloadEvent, positiveOr(cfg.QueueSize, defaultLogQueueSize))
+	if !logPipeline.CompareAndSwap(nil, p) {
+		return
+	}
+	workers := positiveOr(cfg.Workers, defaultLogWorkers)
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.run()
+	}
+}
+
+// StopPipeline 停止接收新事件并写完队列中的日志，超时后剩余事件写入溢出文件
+func (s *DownloadLogService) StopPipeline(ctx context.Context) error {
+	p := logPipeline.Load()
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.queue)
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		// 与写入协程一起消费剩余事件，落盘的部分由定时任务重新导入
+		var rest []DownloadEvent
+		for e := range p.queue {
+			rest = append(rest, e)
+		}
+		spillEvents(rest)
+		return ctx.Err()
+	}
+}
+
+// Enqueue 投递下载事件，不会阻塞超过 enqueue-wait；管道未启动时直接写库
+func (s *DownloadLogService) Enqueue(e DownloadEvent) {
+	p := logPipeline.Load()
+	if p == nil {
+		writeEvents([]DownloadEvent{e})
+		return
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		spillEvents([]DownloadEvent{e})
+		return
+	}
+	select {
+	case p.queue <- e:
+		return
+	default:
+	}
+	// 队列已满，短暂等待写入协程消费，仍然满则落盘
+	timer := time.NewTimer(p.enqueueWait)
+	defer timer.Stop()
+	select {
+	case p.queue <- e:
+	case <-timer.C:
+		spillEvents([]DownloadEvent{e})
+	}
+}
+
+func (p *downloadLogPipeline) run() {
+	defer p.wg.Done()
+	batch := make([]DownloadEvent, 0, p.batchSize)
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case e, ok := <-p.queue:
+			if !ok {
+				writeEvents(batch)
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= p.batchSize {
+				writeEvents(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				writeEvents(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// writeEvents 批量写入下载日志，失败时落盘等待重新导入
+func writeEvents(events []DownloadEvent) {
+	if len(events) == 0 {
+		return
+	}
+	if err := insertEvents(events); err != nil {
+		global.GVA_LOG.Error("批量写入下载日志失败!", zap.Error(err), zap.Int("count", len(events)))
+		spillEvents(events)
+	}
+}
+
+func insertEvents(events []DownloadEvent) error {
+	if global.GVA_DB == nil {
+		return errors.New("数据库未初始化")
+	}
+	logs := make([]projectModel.DownloadLog, len(events))
+	for i := range events {
+		logs[i] = events[i].toLog()
+	}
+	if err := global.GVA_DB.CreateInBatches(logs, len(logs)).Error; err != nil {
+		return err
+	}
+	// 下载记录入库后再清除推荐缓存，保证重新计算时能读到新的下载
+	var users []uint
+	for _, e := range events {
+		if e.Success && e.UserID > 0 {
+			users = append(users, e.UserID)
+		}
+	}
+	recommendService.InvalidateUsers(users...)
+	return nil
+}
+
+// spillEvents 将事件以 JSON Lines 格式追加到溢出文件
+func spillEvents(events []DownloadEvent) {
+	if len(events) == 0 {
+		return
+	}
+	spillMu.Lock()
+	defer spillMu.Unlock()
+
+	err := func() error {
+		dir := spillDir()
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(filepath.Join(dir, spillFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		if err = encodeEvents(f, events); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}()
+	if err != nil {
+		global.GVA_LOG.Error("下载日志写入溢出文件失败，日志已丢弃!", zap.Error(err), zap.Int("count", len(events)))
+	}
+}
+
+// ReplaySpill 将溢出文件中的下载日志重新写入数据库，由定时任务调用
+func (s *DownloadLogService) ReplaySpill() error {
+	if !replayMu.TryLock() {
+		return nil
+	}
+	defer replayMu.Unlock()
+
+	dir := spillDir()
+	spillMu.Lock()
+	err := os.Rename(filepath.Join(dir, spillFileName), filepath.Join(dir, fmt.Sprintf("download_logs.%d.replay", time.Now().UnixNano())))
+	spillMu.Unlock()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// 包含上次导入中断遗留的文件
+	files, err := filepath.Glob(filepath.Join(dir, "download_logs.*.replay"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err = replayFile(file); err != nil {
+			return fmt.Errorf("导入溢出文件 %s 失败: %w", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+
+// replayFile 按批导入单个溢出文件，失败时把未导入的部分写回文件，避免重复导入
+func replayFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var events []DownloadEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e DownloadEvent
+		if err = json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// 进程异常退出可能留下不完整的最后一行，跳过即可
+			global.GVA_LOG.Warn("跳过无法解析的下载日志", zap.Error(err), zap.String("file", filepath.Base(file)))
+			continue
+		}
+		events = append(events, e)
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	batchSize := positiveOr(global.GVA_CONFIG.DownloadLog.BatchSize, defaultLogBatchSize)
+	for start := 0; start < len(events); start += batchSize {
+		end := min(start+batchSize, len(events))
+		if err = insertEvents(events[start:end]); err != nil {
+			if rewriteErr := rewriteSpill(file, events[start:]); rewriteErr != nil {
+				global.GVA_LOG.Error("回写溢出文件失败!", zap.Error(rewriteErr))
+			}
+			return err
+		}
+	}
+	return os.Remove(file)
+}
+
+func rewriteSpill(file string, events []DownloadEvent) error {
+	tmp := file + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err = encodeEvents(f, events); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+func encodeEvents(w io.Writer, events []DownloadEvent) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func spillDir() string {
+	if dir := global.GVA_CONFIG.DownloadLog.SpillDir; dir != "" {
+		return dir
+	}
+	return defaultLogSpillDir
+}
+
+func positiveOr(v, def int) int {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+func durationOr(ms int, def time.Duration) time.Duration {
+	if ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return def
+}
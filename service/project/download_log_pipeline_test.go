@@ -0,0 +1,119 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func setupDownloadLogTest(t *testing.T) string {
+	t.Helper()
+	dir := setupTestDB(t, &project.DownloadLog{})
+	saved := global.GVA_CONFIG.DownloadLog
+	global.GVA_CONFIG.DownloadLog.QueueSize = 16
+	global.GVA_CONFIG.DownloadLog.BatchSize = 10
+	global.GVA_CONFIG.DownloadLog.FlushInterval = 20
+	global.GVA_CONFIG.DownloadLog.SpillDir = filepath.Join(dir, "spill")
+	t.Cleanup(func() {
+		logPipeline.Store(nil)
+		global.GVA_CONFIG.DownloadLog = saved
+	})
+	return dir
+}
+
+func countDownloadLogs(t *testing.T) int64 {
+	t.Helper()
+	var count int64
+	if err := global.GVA_DB.Model(&project.DownloadLog{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestDownloadLogPipelineDrainOnStop(t *testing.T) {
+	setupDownloadLogTest(t)
+	var s DownloadLogService
+	s.StartPipeline()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				s.Enqueue(DownloadEvent{
+					UserID:       uint(i + 1),
+					AppID:        1,
+					PackageID:    2,
+					MembershipID: 3,
+					Platform:     constants.PlatformAndroid,
+					FailReason:   "今日下载次数已用完，请明天再试",
+					IP:           "127.0.0.1",
+					DeviceType:   "mobile",
+					CreatedAt:    time.Now(),
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := s.StopPipeline(context.Background()); err != nil {
+		t.Fatalf("StopPipeline() error = %v", err)
+	}
+	// 停止后投递的事件落盘，导入后不丢失
+	s.Enqueue(DownloadEvent{UserID: 99, AppID: 1, Platform: constants.PlatformAndroid, Success: true, CreatedAt: time.Now()})
+	if err := s.ReplaySpill(); err != nil {
+		t.Fatalf("ReplaySpill() error = %v", err)
+	}
+
+	if got := countDownloadLogs(t); got != 201 {
+		t.Fatalf("download_logs count = %d, want 201", got)
+	}
+	var log project.DownloadLog
+	if err := global.GVA_DB.Where("user_id = ?", 1).First(&log).Error; err != nil {
+		t.Fatal(err)
+	}
+	if log.PackageID == nil || *log.PackageID != 2 || log.MembershipID == nil || *log.MembershipID != 3 ||
+		log.FailReason == nil || log.DeviceType == nil || *log.DeviceType != "mobile" {
+		t.Errorf("download log fields not populated: %+v", log)
+	}
+}
+
+func TestDownloadLogReplayKeepsFailedRest(t *testing.T) {
+	setupDownloadLogTest(t)
+	var s DownloadLogService
+
+	events := make([]DownloadEvent, 25)
+	for i := range events {
+		events[i] = DownloadEvent{UserID: uint(i + 1), AppID: 1, Platform: constants.PlatformIOS, Success: true, CreatedAt: time.Now()}
+	}
+	spillEvents(events)
+
+	// 数据库不可用时导入失败，溢出文件保留
+	db := global.GVA_DB
+	global.GVA_DB = nil
+	if err := s.ReplaySpill(); err == nil {
+		t.Fatal("ReplaySpill() error = nil, want error")
+	}
+	global.GVA_DB = db
+	files, _ := filepath.Glob(filepath.Join(spillDir(), "download_logs.*.replay"))
+	if len(files) != 1 {
+		t.Fatalf("replay files = %d, want 1", len(files))
+	}
+
+	if err := s.ReplaySpill(); err != nil {
+		t.Fatalf("ReplaySpill() error = %v", err)
+	}
+	if got := countDownloadLogs(t); got != 25 {
+		t.Fatalf("download_logs count = %d, want 25", got)
+	}
+	entries, _ := os.ReadDir(spillDir())
+	if len(entries) != 0 {
+		t.Errorf("spill dir not empty after replay: %d entries", len(entries))
+	}
+}
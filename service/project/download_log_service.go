@@ -4,6 +4,8 @@ import (
 	"ApkAdmin/constants"
 	"ApkAdmin/global"
 	projectModel "ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"fmt"
 	"time"
 )
 
@@ -170,9 +172,28 @@ func (s *DownloadLogService) GetMonthlyDownloadCount(userID uint, year int, mont
 
 // ==================== 清理日志 ====================
 
-// DeleteOldLogs 删除旧日志
+// DeleteOldLogs 删除旧日志，删除前先重建这些日期的汇总，汇总表中的历史数据不受影响
 func (s *DownloadLogService) DeleteOldLogs(days int) error {
-	cutoffDate := time.Now().AddDate(0, 0, -days)
+	if days < minRawLogRetentionDays {
+		return fmt.Errorf("原始日志至少保留%d天", minRawLogRetentionDays)
+	}
+	// 按整天清理，保证剩余最早的一天日志完整，重建汇总时不会覆盖出错误数据
+	cutoffDate := startOfDay(time.Now().AddDate(0, 0, -days))
+
+	earliest, err := earliestDownloadLog()
+	if err != nil || earliest == nil || !earliest.Before(cutoffDate) {
+		return err
+	}
+	for start := startOfDay(*earliest); start.Before(cutoffDate); start = start.AddDate(0, 0, 30) {
+		end := start.AddDate(0, 0, 30)
+		if end.After(cutoffDate) {
+			end = cutoffDate
+		}
+		if err := downloadStatService.RebuildRange(start, end); err != nil {
+			return fmt.Errorf("重建下载汇总失败: %w", err)
+		}
+	}
+
 	return global.GVA_DB.
 		Where("created_at < ?", cutoffDate).
 		Delete(&projectModel.DownloadLog{}).Error
@@ -180,51 +201,31 @@ func (s *DownloadLogService) DeleteOldLogs(days int) error {
 
 // ==================== 高级统计 ====================
 
-// GetTopDownloadApps 获取下载最多的应用
+// GetTopDownloadApps 获取最近一年成功下载最多的应用
 func (s *DownloadLogService) GetTopDownloadApps(limit int) ([]projectModel.AppDownloadStats, error) {
-	var stats []projectModel.AppDownloadStats
-
-	err := global.GVA_DB.Raw(`
-		SELECT 
-			app_id,
-			COUNT(*) as total_downloads,
-			SUM(CASE WHEN platform = 'ios' THEN 1 ELSE 0 END) as ios_downloads,
-			SUM(CASE WHEN platform = 'android' THEN 1 ELSE 0 END) as android_downloads
-		FROM download_logs
-		GROUP BY app_id
-		ORDER BY total_downloads DESC
-		LIMIT ?
-	`, limit).Scan(&stats).Error
-
-	return stats, err
+	now := time.Now()
+	return downloadStatService.GetTopApps(request.DownloadStatRequest{
+		StartTime: now.AddDate(0, 0, -365).Format("2006-01-02"),
+		EndTime:   now.Format("2006-01-02"),
+		Limit:     limit,
+	})
 }
 
 // GetDownloadTrend 获取下载趋势（最近N天）
 func (s *DownloadLogService) GetDownloadTrend(days int) (map[string]int, error) {
-	startDate := time.Now().AddDate(0, 0, -days)
-
-	var results []struct {
-		Date  string `gorm:"column:date"`
-		Count int    `gorm:"column:count"`
-	}
-
-	err := global.GVA_DB.Raw(`
-		SELECT 
-			DATE(created_at) as date,
-			COUNT(*) as count
-		FROM download_logs
-		WHERE created_at >= ?
-		GROUP BY DATE(created_at)
-		ORDER BY date
-	`, startDate).Scan(&results).Error
-
+	now := time.Now()
+	points, err := downloadStatService.GetTrend(request.DownloadStatRequest{
+		StartTime:   now.AddDate(0, 0, -days).Format("2006-01-02"),
+		EndTime:     now.Format("2006-01-02"),
+		Granularity: request.StatGranularityDay,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	trend := make(map[string]int)
-	for _, r := range results {
-		trend[r.Date] = r.Count
+	trend := make(map[string]int, len(points))
+	for _, p := range points {
+		trend[p.Time] = int(p.TotalCount)
 	}
 
 	return trend, nil
@@ -0,0 +1,285 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoMembership            = errors.New("普通用户无法下载，请升级VIP后下载")
+	ErrPlatformNotSupported    = errors.New("当前会员套餐不支持该平台，请升级套餐")
+	ErrDailyQuotaExceeded      = errors.New("今日下载次数已用完，请明天再试")
+	ErrMonthlyQuotaExceeded    = errors.New("本月下载次数已用完")
+	errQuotaMembershipNotFound = errors.New("会员记录不存在")
+)
+
+// reserveQuotaScript 预占一次下载额度
+// KEYS: 日计数key, 月计数key
+// ARGV: 日限制, 月限制(-1 不限), 日过期时间戳(ms), 月过期时间戳(ms), 日计数初始值, 月计数初始值
+// 返回 0 成功, 1 超出日限制, 2 超出月限制
+var reserveQuotaScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	redis.call('SET', KEYS[1], ARGV[5])
+	redis.call('PEXPIREAT', KEYS[1], ARGV[3])
+end
+if redis.call('EXISTS', KEYS[2]) == 0 then
+	redis.call('SET', KEYS[2], ARGV[6])
+	redis.call('PEXPIREAT', KEYS[2], ARGV[4])
+end
+local daily = redis.call('INCR', KEYS[1])
+if tonumber(ARGV[1]) >= 0 and daily > tonumber(ARGV[1]) then
+	redis.call('DECR', KEYS[1])
+	return 1
+end
+local monthly = redis.call('INCR', KEYS[2])
+if tonumber(ARGV[2]) >= 0 and monthly > tonumber(ARGV[2]) then
+	redis.call('DECR', KEYS[1])
+	redis.call('DECR', KEYS[2])
+	return 2
+end
+return 0
+`)
+
+// releaseQuotaScript 归还预占的额度，计数不会减到负数
+var releaseQuotaScript = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	local v = tonumber(redis.call('GET', key) or '0')
+	if v > 0 then
+		redis.call('DECR', key)
+	end
+end
+return 0
+`)
+
+// quotaPeriod 用户时区下的统计周期
+type quotaPeriod struct {
+	Day      string    // 2006-01-02
+	Month    string    // 2006-01-01，与 last_reset_monthly 的存储格式一致
+	DayEnd   time.Time // 下一个自然日零点
+	MonthEnd time.Time // 下个月1号零点
+}
+
+func newQuotaPeriod(now time.Time, loc *time.Location) quotaPeriod {
+	now = now.In(loc)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	return quotaPeriod{
+		Day:      dayStart.Format("2006-01-02"),
+		Month:    monthStart.Format("2006-01-02"),
+		DayEnd:   dayStart.AddDate(0, 0, 1),
+		MonthEnd: monthStart.AddDate(0, 1, 0),
+	}
+}
+
+// QuotaReservation 一次下载额度的预占，必须调用 Commit 或 Release 结束
+type QuotaReservation struct {
+	Membership *project.UserMembership
+	period     quotaPeriod
+	viaRedis   bool
+	finished   bool
+}
+
+type DownloadQuotaService struct{}
+
+// ReserveForUser 在用户支持该平台的有效会员中依次尝试预占一次下载额度
+func (s *DownloadQuotaService) ReserveForUser(userID uint, platform constants.Platform) (*QuotaReservation, error) {
+	var memberships []project.UserMembership
+	err := global.GVA_DB.
+		Where("user_id = ?", userID).
+		Where("status = ?", constants.MembershipStatusActive).
+		Where("(end_date IS NULL OR end_date > ?)", time.Now()).
+		Preload("Plan").
+		Order("end_date DESC").
+		Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(memberships) == 0 {
+		return nil, ErrNoMembership
+	}
+
+	loc := s.userLocation(userID)
+	lastErr := ErrPlatformNotSupported
+	for i := range memberships {
+		if !memberships[i].SupportsPlatform(platform.String()) {
+			continue
+		}
+		reservation, err := s.Reserve(&memberships[i], loc)
+		if err == nil {
+			return reservation, nil
+		}
+		if !errors.Is(err, ErrDailyQuotaExceeded) && !errors.Is(err, ErrMonthlyQuotaExceeded) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Reserve 原子预占一次下载额度，优先使用 Redis，Redis 不可用时回退到数据库条件更新
+func (s *DownloadQuotaService) Reserve(membership *project.UserMembership, loc *time.Location) (*QuotaReservation, error) {
+	if membership.Plan == nil {
+		return nil, errors.New("会员套餐信息缺失")
+	}
+	now := time.Now()
+	r := &QuotaReservation{Membership: membership, period: newQuotaPeriod(now, loc)}
+	dailyLimit, monthlyLimit := quotaLimits(membership.Plan)
+
+	if global.GVA_REDIS != nil {
+		dayAt := now.In(loc)
+		seedDaily, seedMonthly := membership.UsedDaily(dayAt), membership.UsedMonthly(dayAt)
+		code, err := reserveQuotaScript.Run(context.Background(), global.GVA_REDIS,
+			s.redisKeys(membership.ID, r.period),
+			dailyLimit, monthlyLimit,
+			r.period.DayEnd.UnixMilli(), r.period.MonthEnd.UnixMilli(),
+			seedDaily, seedMonthly,
+		).Int()
+		if err == nil {
+			switch code {
+			case 1:
+				return nil, ErrDailyQuotaExceeded
+			case 2:
+				return nil, ErrMonthlyQuotaExceeded
+			}
+			r.viaRedis = true
+			return r, nil
+		}
+		global.GVA_LOG.Warn("Redis预占下载额度失败，回退到数据库", zap.Error(err), zap.Uint("membershipId", membership.ID))
+	}
+
+	if err := s.incrementUsage(membership.ID, r.period, dailyLimit, monthlyLimit); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Commit 下载地址已下发，确认扣减；Redis 预占的额度在这里同步到数据库
+func (s *DownloadQuotaService) Commit(r *QuotaReservation) {
+	if r == nil || r.finished {
+		return
+	}
+	r.finished = true
+	if !r.viaRedis {
+		return
+	}
+	if err := s.incrementUsage(r.Membership.ID, r.period, -1, -1); err != nil {
+		global.GVA_LOG.Error("同步下载计数失败", zap.Error(err), zap.Uint("membershipId", r.Membership.ID))
+	}
+}
+
+// Release 下载地址生成失败，归还预占的额度
+func (s *DownloadQuotaService) Release(r *QuotaReservation) {
+	if r == nil || r.finished {
+		return
+	}
+	r.finished = true
+	if r.viaRedis {
+		err := releaseQuotaScript.Run(context.Background(), global.GVA_REDIS, s.redisKeys(r.Membership.ID, r.period)).Err()
+		if err != nil {
+			global.GVA_LOG.Error("归还下载额度失败", zap.Error(err), zap.Uint("membershipId", r.Membership.ID))
+		}
+		return
+	}
+	err := global.GVA_DB.Model(&project.UserMembership{}).
+		Where("id = ? AND last_reset_daily = ? AND download_used_daily > 0", r.Membership.ID, r.period.Day).
+		UpdateColumn("download_used_daily", gorm.Expr("download_used_daily - 1")).Error
+	if err == nil {
+		err = global.GVA_DB.Model(&project.UserMembership{}).
+			Where("id = ? AND last_reset_monthly = ? AND download_used_monthly > 0", r.Membership.ID, r.period.Month).
+			UpdateColumn("download_used_monthly", gorm.Expr("download_used_monthly - 1")).Error
+	}
+	if err != nil {
+		global.GVA_LOG.Error("归还下载额度失败", zap.Error(err), zap.Uint("membershipId", r.Membership.ID))
+	}
+}
+
+// incrementUsage 用一条条件 UPDATE 完成跨周期清零、限额判断和计数，限制为 -1 表示不检查
+// 注意 MySQL 按从左到右的顺序赋值，计数列必须写在对应的日期列之前
+func (s *DownloadQuotaService) incrementUsage(membershipID uint, p quotaPeriod, dailyLimit, monthlyLimit int) error {
+	const dailyUsed = "(CASE WHEN last_reset_daily IS NULL OR last_reset_daily < @day THEN 0 ELSE download_used_daily END)"
+	const monthlyUsed = "(CASE WHEN last_reset_monthly IS NULL OR last_reset_monthly < @month THEN 0 ELSE download_used_monthly END)"
+	args := map[string]interface{}{
+		"id":           membershipID,
+		"day":          p.Day,
+		"month":        p.Month,
+		"dailyLimit":   dailyLimit,
+		"monthlyLimit": monthlyLimit,
+	}
+	result := global.GVA_DB.Exec(
+		"UPDATE user_memberships SET "+
+			"download_used_daily = "+dailyUsed+" + 1, last_reset_daily = @day, "+
+			"download_used_monthly = "+monthlyUsed+" + 1, last_reset_monthly = @month "+
+			"WHERE id = @id "+
+			"AND (@dailyLimit < 0 OR "+dailyUsed+" < @dailyLimit) "+
+			"AND (@monthlyLimit < 0 OR "+monthlyUsed+" < @monthlyLimit)",
+		args,
+	)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	// 没有更新到记录：判断是会员不存在还是超出了哪一项限制
+	var usage struct {
+		DailyUsed   int
+		MonthlyUsed int
+	}
+	err := global.GVA_DB.Raw("SELECT "+dailyUsed+" AS daily_used, "+monthlyUsed+" AS monthly_used FROM user_memberships WHERE id = @id", args).
+		Scan(&usage).Error
+	if err != nil {
+		return err
+	}
+	switch {
+	case dailyLimit >= 0 && usage.DailyUsed >= dailyLimit:
+		return ErrDailyQuotaExceeded
+	case monthlyLimit >= 0 && usage.MonthlyUsed >= monthlyLimit:
+		return ErrMonthlyQuotaExceeded
+	default:
+		return errQuotaMembershipNotFound
+	}
+}
+
+// redisKeys 使用 hash tag 保证同一会员的 key 落在同一个槽位，便于在集群中执行脚本
+func (s *DownloadQuotaService) redisKeys(membershipID uint, p quotaPeriod) []string {
+	return []string{
+		fmt.Sprintf("download_quota:{%d}:day:%s", membershipID, p.Day),
+		fmt.Sprintf("download_quota:{%d}:month:%s", membershipID, p.Month[:7]),
+	}
+}
+
+// userLocation 用户时区，未设置或无效时使用服务器时区
+func (s *DownloadQuotaService) userLocation(userID uint) *time.Location {
+	var timezone string
+	global.GVA_DB.Model(&project.User{}).Where("id = ?", userID).Select("timezone").Scan(&timezone)
+	if timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// quotaLimits 套餐的日/月下载限制，NULL 表示不限，返回 -1
+func quotaLimits(plan *project.MembershipPlan) (daily, monthly int) {
+	daily, monthly = -1, -1
+	if plan.DownloadLimitDaily != nil {
+		daily = *plan.DownloadLimitDaily
+	}
+	if plan.DownloadLimitMonthly != nil {
+		monthly = *plan.DownloadLimitMonthly
+	}
+	return daily, monthly
+}
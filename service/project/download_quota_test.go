@@ -0,0 +1,199 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupQuotaTest(t *testing.T) {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "quota.db") + "?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 套餐表使用了 MySQL 的 enum 类型，这里只建测试需要的会员表
+	err = db.Exec(`CREATE TABLE user_memberships (
+		id integer PRIMARY KEY AUTOINCREMENT,
+		user_id integer NOT NULL,
+		order_id integer,
+		plan_id integer NOT NULL,
+		plan_code text NOT NULL,
+		plan_name text NOT NULL,
+		detail text,
+		status integer NOT NULL DEFAULT 1,
+		start_date datetime NOT NULL,
+		end_date datetime,
+		auto_renew numeric DEFAULT 0,
+		download_used_daily integer DEFAULT 0,
+		download_used_monthly integer DEFAULT 0,
+		last_reset_daily date,
+		last_reset_monthly date,
+		replaced_by integer,
+		created_at datetime,
+		updated_at datetime
+	)`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	global.GVA_DB = db
+	global.GVA_LOG = zap.NewNop()
+	global.GVA_REDIS = nil
+	// 设置 REDIS_ADDR 时同时验证 Redis 路径
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		if err := client.Ping(context.Background()).Err(); err != nil {
+			t.Fatalf("连接Redis失败: %v", err)
+		}
+		client.FlushDB(context.Background())
+		global.GVA_REDIS = client
+	}
+	t.Cleanup(func() {
+		if global.GVA_REDIS != nil {
+			global.GVA_REDIS.Close()
+			global.GVA_REDIS = nil
+		}
+	})
+}
+
+func newQuotaMembership(t *testing.T, daily, monthly *int) *project.UserMembership {
+	t.Helper()
+	m := &project.UserMembership{
+		UserID:    1,
+		PlanID:    1,
+		PlanCode:  "test",
+		PlanName:  "test",
+		Status:    constants.MembershipStatusActive,
+		StartDate: time.Now(),
+	}
+	if err := global.GVA_DB.Create(m).Error; err != nil {
+		t.Fatal(err)
+	}
+	m.Plan = &project.MembershipPlan{DownloadLimitDaily: daily, DownloadLimitMonthly: monthly}
+	return m
+}
+
+// reserveConcurrently 并发预占 n 次，返回成功的预占和各类错误数
+func reserveConcurrently(t *testing.T, m *project.UserMembership, n int) ([]*QuotaReservation, map[error]int) {
+	t.Helper()
+	var s DownloadQuotaService
+	var (
+		mu           sync.Mutex
+		wg           sync.WaitGroup
+		reservations []*QuotaReservation
+		failures     = map[error]int{}
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 每个请求各自持有会员快照，模拟并发的下载请求
+			snapshot := *m
+			r, err := s.Reserve(&snapshot, time.Local)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures[err]++
+				return
+			}
+			reservations = append(reservations, r)
+		}()
+	}
+	wg.Wait()
+	return reservations, failures
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestDownloadQuotaConcurrentReserve(t *testing.T) {
+	setupQuotaTest(t)
+	var s DownloadQuotaService
+	m := newQuotaMembership(t, intPtr(5), intPtr(100))
+
+	reservations, failures := reserveConcurrently(t, m, 50)
+	if len(reservations) != 5 {
+		t.Fatalf("reserved %d downloads, want 5 (failures: %v)", len(reservations), failures)
+	}
+	if failures[ErrDailyQuotaExceeded] != 45 {
+		t.Fatalf("daily quota failures = %d, want 45 (failures: %v)", failures[ErrDailyQuotaExceeded], failures)
+	}
+
+	// 归还一次后可以再预占一次
+	for _, r := range reservations[1:] {
+		s.Commit(r)
+	}
+	s.Release(reservations[0])
+	s.Release(reservations[0]) // 重复归还不应多加额度
+	reservations, _ = reserveConcurrently(t, m, 10)
+	if len(reservations) != 1 {
+		t.Fatalf("reserved %d downloads after release, want 1", len(reservations))
+	}
+	s.Commit(reservations[0])
+
+	var stored project.UserMembership
+	global.GVA_DB.First(&stored, m.ID)
+	if stored.DownloadUsedDaily != 5 || stored.DownloadUsedMonthly != 5 {
+		t.Fatalf("stored usage = %d/%d, want 5/5", stored.DownloadUsedDaily, stored.DownloadUsedMonthly)
+	}
+}
+
+func TestDownloadQuotaMonthlyLimit(t *testing.T) {
+	setupQuotaTest(t)
+	m := newQuotaMembership(t, nil, intPtr(3))
+
+	reservations, failures := reserveConcurrently(t, m, 20)
+	if len(reservations) != 3 || failures[ErrMonthlyQuotaExceeded] != 17 {
+		t.Fatalf("reserved %d, failures %v; want 3 reserved and 17 monthly failures", len(reservations), failures)
+	}
+}
+
+func TestDownloadQuotaUnlimited(t *testing.T) {
+	setupQuotaTest(t)
+	m := newQuotaMembership(t, nil, nil)
+
+	// 套餐未设置任何限制时不能拒绝下载
+	if !(&project.UserMembership{Status: constants.MembershipStatusActive, Plan: m.Plan}).CanDownload(true, true) {
+		t.Fatal("CanDownload() = false for plan without limits")
+	}
+	reservations, failures := reserveConcurrently(t, m, 30)
+	if len(reservations) != 30 {
+		t.Fatalf("reserved %d downloads, want 30 (failures: %v)", len(reservations), failures)
+	}
+}
+
+func TestDownloadQuotaNewPeriod(t *testing.T) {
+	setupQuotaTest(t)
+	m := newQuotaMembership(t, intPtr(1), intPtr(1))
+	// 上个月用完的额度不影响本月
+	lastMonth := time.Now().AddDate(0, -1, 0)
+	global.GVA_DB.Model(m).Updates(map[string]interface{}{
+		"download_used_daily":   1,
+		"download_used_monthly": 1,
+		"last_reset_daily":      lastMonth.Format("2006-01-02"),
+		"last_reset_monthly":    lastMonth.Format("2006-01") + "-01",
+	})
+
+	var s DownloadQuotaService
+	if _, err := s.Reserve(m, time.Local); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if _, err := s.Reserve(m, time.Local); !errors.Is(err, ErrDailyQuotaExceeded) {
+		t.Fatalf("Reserve() error = %v, want ErrDailyQuotaExceeded", err)
+	}
+}
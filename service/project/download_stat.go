@@ -0,0 +1,385 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	projectModel "ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/project/response"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// recentStatHours 每轮汇总重新计算的小时数，覆盖延迟写入（如溢出文件导入）的日志
+	recentStatHours = 3
+	// recentStatDays 每轮汇总重新计算的天数
+	recentStatDays = 2
+	// maxRebuildDays 单次重建汇总的最大天数
+	maxRebuildDays = 366
+	// minRawLogRetentionDays 原始日志最少保留天数，需大于汇总重新计算的范围
+	minRawLogRetentionDays = 7
+)
+
+var downloadStatService = DownloadStatService{}
+
+type DownloadStatService struct{}
+
+// ==================== 汇总 ====================
+
+// AggregateRecent 重新计算最近几个小时和最近两天的汇总，由定时任务调用
+func (s *DownloadStatService) AggregateRecent() error {
+	now := time.Now()
+	hour := now.Truncate(time.Hour)
+	for i := 0; i < recentStatHours; i++ {
+		if err := s.aggregateHour(hour.Add(-time.Duration(i) * time.Hour)); err != nil {
+			return err
+		}
+	}
+	today := startOfDay(now)
+	for i := 0; i < recentStatDays; i++ {
+		if err := s.aggregateDay(today.AddDate(0, 0, -i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RebuildRange 从原始日志重建 [start, end) 内各天的汇总
+// 早于最早原始日志的日期已被清理，跳过以免覆盖历史汇总
+func (s *DownloadStatService) RebuildRange(start, end time.Time) error {
+	start, end = startOfDay(start), startOfDay(end)
+	if !end.After(start) {
+		end = start.AddDate(0, 0, 1)
+	}
+	if end.Sub(start) > maxRebuildDays*24*time.Hour {
+		return errors.New("重建范围不能超过366天")
+	}
+
+	earliest, err := earliestDownloadLog()
+	if err != nil || earliest == nil {
+		return err
+	}
+	if first := startOfDay(*earliest); start.Before(first) {
+		start = first
+	}
+
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		for hour := day; hour.Before(day.AddDate(0, 0, 1)); hour = hour.Add(time.Hour) {
+			if err := s.aggregateHour(hour); err != nil {
+				return err
+			}
+		}
+		if err := s.aggregateDay(day); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statRow 按维度聚合原始日志的结果
+type statRow struct {
+	AppID       uint
+	PackageID   uint
+	Platform    constants.Platform
+	CountryCode string
+	Success     bool
+	TotalCount  int64
+	UniqueUsers int64
+}
+
+func (s *DownloadStatService) queryStatRows(start, end time.Time) ([]statRow, error) {
+	var rows []statRow
+	err := global.GVA_DB.Model(&projectModel.DownloadLog{}).
+		Select("app_id, COALESCE(package_id, 0) AS package_id, platform, country_code, success, "+
+			"COUNT(*) AS total_count, COUNT(DISTINCT user_id) AS unique_users").
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Group("app_id, COALESCE(package_id, 0), platform, country_code, success").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (s *DownloadStatService) aggregateHour(hour time.Time) error {
+	rows, err := s.queryStatRows(hour, hour.Add(time.Hour))
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	stats := make([]projectModel.DownloadStatHourly, len(rows))
+	for i, r := range rows {
+		stats[i] = projectModel.DownloadStatHourly{
+			StatHour:    hour,
+			AppID:       r.AppID,
+			PackageID:   r.PackageID,
+			Platform:    r.Platform,
+			CountryCode: r.CountryCode,
+			Success:     r.Success,
+			TotalCount:  r.TotalCount,
+			UniqueUsers: r.UniqueUsers,
+		}
+	}
+	return global.GVA_DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "stat_hour"}, {Name: "app_id"}, {Name: "package_id"}, {Name: "platform"}, {Name: "country_code"}, {Name: "success"}},
+		DoUpdates: clause.AssignmentColumns([]string{"total_count", "unique_users", "updated_at"}),
+	}).CreateInBatches(stats, 500).Error
+}
+
+func (s *DownloadStatService) aggregateDay(day time.Time) error {
+	start, end := day, day.AddDate(0, 0, 1)
+	date := day.Format("2006-01-02")
+
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		rows, err := s.queryStatRows(start, end)
+		if err != nil {
+			return err
+		}
+		if len(rows) > 0 {
+			stats := make([]projectModel.DownloadStatDaily, len(rows))
+			for i, r := range rows {
+				stats[i] = projectModel.DownloadStatDaily{
+					StatDate:    date,
+					AppID:       r.AppID,
+					PackageID:   r.PackageID,
+					Platform:    r.Platform,
+					CountryCode: r.CountryCode,
+					Success:     r.Success,
+					TotalCount:  r.TotalCount,
+					UniqueUsers: r.UniqueUsers,
+				}
+			}
+			err = tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "stat_date"}, {Name: "app_id"}, {Name: "package_id"}, {Name: "platform"}, {Name: "country_code"}, {Name: "success"}},
+				DoUpdates: clause.AssignmentColumns([]string{"total_count", "unique_users", "updated_at"}),
+			}).CreateInBatches(stats, 500).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		var reasons []projectModel.DownloadFailReasonDaily
+		err = tx.Model(&projectModel.DownloadLog{}).
+			Select("app_id, platform, COALESCE(fail_reason, '') AS fail_reason, COUNT(*) AS total_count").
+			Where("created_at >= ? AND created_at < ? AND success = ?", start, end, false).
+			Group("app_id, platform, COALESCE(fail_reason, '')").
+			Scan(&reasons).Error
+		if err != nil {
+			return err
+		}
+		if len(reasons) > 0 {
+			for i := range reasons {
+				reasons[i].StatDate = date
+			}
+			err = tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "stat_date"}, {Name: "app_id"}, {Name: "platform"}, {Name: "fail_reason"}},
+				DoUpdates: clause.AssignmentColumns([]string{"total_count", "updated_at"}),
+			}).CreateInBatches(reasons, 500).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// 只统计成功的下载，作为去重下载用户数的依据
+		var users []projectModel.DownloadUserDaily
+		err = tx.Model(&projectModel.DownloadLog{}).
+			Select("app_id, user_id, platform, COUNT(*) AS total_count").
+			Where("created_at >= ? AND created_at < ? AND success = ?", start, end, true).
+			Group("app_id, user_id, platform").
+			Scan(&users).Error
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return nil
+		}
+		for i := range users {
+			users[i].StatDate = date
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "stat_date"}, {Name: "app_id"}, {Name: "user_id"}, {Name: "platform"}},
+			DoUpdates: clause.AssignmentColumns([]string{"total_count"}),
+		}).CreateInBatches(users, 1000).Error
+	})
+}
+
+// ==================== 查询 ====================
+
+// statFilter 汇总表的公共筛选条件
+func statFilter(req request.DownloadStatRequest) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if req.AppID != 0 {
+			db = db.Where("app_id = ?", req.AppID)
+		}
+		if req.Platform != "" {
+			db = db.Where("platform = ?", req.Platform)
+		}
+		return db
+	}
+}
+
+// GetTrend 下载趋势，按小时或按天返回，没有数据的时间点补 0
+func (s *DownloadStatService) GetTrend(req request.DownloadStatRequest) ([]response.DownloadTrendPoint, error) {
+	start, end, err := req.Range()
+	if err != nil {
+		return nil, err
+	}
+
+	type trendRow struct {
+		TotalCount   int64
+		SuccessCount int64
+	}
+	byBucket := make(map[string]trendRow)
+	layout := "2006-01-02"
+	next := func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	if req.Granularity == request.StatGranularityHour {
+		layout = "2006-01-02 15:00"
+		next = func(t time.Time) time.Time { return t.Add(time.Hour) }
+		var rows []struct {
+			Bucket       time.Time
+			TotalCount   int64
+			SuccessCount int64
+		}
+		db := global.GVA_DB.Model(&projectModel.DownloadStatHourly{}).
+			Select("stat_hour AS bucket, SUM(total_count) AS total_count, SUM(CASE WHEN success THEN total_count ELSE 0 END) AS success_count").
+			Where("stat_hour >= ? AND stat_hour < ?", start, end)
+		if req.CountryCode != "" {
+			db = db.Where("country_code = ?", req.CountryCode)
+		}
+		if err = db.Scopes(statFilter(req)).Group("stat_hour").Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			byBucket[r.Bucket.In(start.Location()).Format(layout)] = trendRow{r.TotalCount, r.SuccessCount}
+		}
+	} else {
+		// DATE 列在不同驱动下可能扫描为 "2006-01-02" 或 RFC3339 字符串，只取日期部分
+		var rows []struct {
+			Bucket       string
+			TotalCount   int64
+			SuccessCount int64
+		}
+		db := global.GVA_DB.Model(&projectModel.DownloadStatDaily{}).
+			Select("stat_date AS bucket, SUM(total_count) AS total_count, SUM(CASE WHEN success THEN total_count ELSE 0 END) AS success_count").
+			Where("stat_date >= ? AND stat_date < ?", start.Format("2006-01-02"), end.Format("2006-01-02"))
+		if req.CountryCode != "" {
+			db = db.Where("country_code = ?", req.CountryCode)
+		}
+		if err = db.Scopes(statFilter(req)).Group("stat_date").Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			if len(r.Bucket) >= len(layout) {
+				byBucket[r.Bucket[:len(layout)]] = trendRow{r.TotalCount, r.SuccessCount}
+			}
+		}
+	}
+
+	var points []response.DownloadTrendPoint
+	for t := start; t.Before(end); t = next(t) {
+		point := response.DownloadTrendPoint{Time: t.Format(layout)}
+		if r, ok := byBucket[point.Time]; ok {
+			point.TotalCount = r.TotalCount
+			point.SuccessCount = r.SuccessCount
+			point.FailCount = r.TotalCount - r.SuccessCount
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// GetTopApps 时间段内成功下载次数最多的应用
+func (s *DownloadStatService) GetTopApps(req request.DownloadStatRequest) ([]projectModel.AppDownloadStats, error) {
+	// 排行与分布只使用按天汇总
+	req.Granularity = request.StatGranularityDay
+	start, end, err := req.Range()
+	if err != nil {
+		return nil, err
+	}
+	db := global.GVA_DB.Table("download_stat_daily AS s").
+		Select("s.app_id, MAX(a.app_name) AS app_name, SUM(s.total_count) AS total_downloads, "+
+			"SUM(CASE WHEN s.platform = 'ios' THEN s.total_count ELSE 0 END) AS ios_downloads, "+
+			"SUM(CASE WHEN s.platform = 'android' THEN s.total_count ELSE 0 END) AS android_downloads").
+		Joins("LEFT JOIN applications AS a ON a.id = s.app_id").
+		Where("s.stat_date >= ? AND s.stat_date < ? AND s.success = ?", start.Format("2006-01-02"), end.Format("2006-01-02"), true)
+	if req.Platform != "" {
+		db = db.Where("s.platform = ?", req.Platform)
+	}
+	if req.CountryCode != "" {
+		db = db.Where("s.country_code = ?", req.CountryCode)
+	}
+	var stats []projectModel.AppDownloadStats
+	err = db.Group("s.app_id").Order("total_downloads DESC").Limit(req.TopLimit()).Scan(&stats).Error
+	return stats, err
+}
+
+// GetFailReasons 时间段内下载失败原因分布
+func (s *DownloadStatService) GetFailReasons(req request.DownloadStatRequest) ([]response.DownloadFailReasonStat, error) {
+	// 排行与分布只使用按天汇总
+	req.Granularity = request.StatGranularityDay
+	start, end, err := req.Range()
+	if err != nil {
+		return nil, err
+	}
+	var stats []response.DownloadFailReasonStat
+	err = global.GVA_DB.Model(&projectModel.DownloadFailReasonDaily{}).
+		Select("fail_reason, SUM(total_count) AS total_count").
+		Where("stat_date >= ? AND stat_date < ?", start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Scopes(statFilter(req)).
+		Group("fail_reason").
+		Order("total_count DESC").
+		Limit(req.TopLimit()).
+		Scan(&stats).Error
+	return stats, err
+}
+
+// GetDownloaderSummary 时间段内的下载次数与去重下载用户数
+func (s *DownloadStatService) GetDownloaderSummary(req request.DownloadStatRequest) (*response.DownloaderSummary, error) {
+	// 排行与分布只使用按天汇总
+	req.Granularity = request.StatGranularityDay
+	start, end, err := req.Range()
+	if err != nil {
+		return nil, err
+	}
+	from, to := start.Format("2006-01-02"), end.Format("2006-01-02")
+
+	summary := &response.DownloaderSummary{}
+	db := global.GVA_DB.Model(&projectModel.DownloadStatDaily{}).
+		Select("COALESCE(SUM(total_count), 0) AS total_attempts, COALESCE(SUM(CASE WHEN success THEN total_count ELSE 0 END), 0) AS total_downloads").
+		Where("stat_date >= ? AND stat_date < ?", from, to).
+		Scopes(statFilter(req))
+	if req.CountryCode != "" {
+		db = db.Where("country_code = ?", req.CountryCode)
+	}
+	if err = db.Scan(summary).Error; err != nil {
+		return nil, err
+	}
+
+	// 去重用户数不区分国家
+	err = global.GVA_DB.Model(&projectModel.DownloadUserDaily{}).
+		Select("COUNT(DISTINCT user_id)").
+		Where("stat_date >= ? AND stat_date < ?", from, to).
+		Scopes(statFilter(req)).
+		Scan(&summary.UniqueDownloaders).Error
+	if err != nil {
+		return nil, err
+	}
+	if summary.UniqueDownloaders > 0 {
+		summary.DownloadsPerUser = float64(summary.TotalDownloads) / float64(summary.UniqueDownloaders)
+	}
+	return summary, nil
+}
+
+// earliestDownloadLog 最早一条原始下载日志的时间，没有日志时返回 nil
+func earliestDownloadLog() (*time.Time, error) {
+	var log projectModel.DownloadLog
+	err := global.GVA_DB.Select("created_at").Order("created_at").Limit(1).Find(&log).Error
+	if err != nil || log.CreatedAt.IsZero() {
+		return nil, err
+	}
+	return &log.CreatedAt, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
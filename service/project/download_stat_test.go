@@ -0,0 +1,105 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"testing"
+	"time"
+)
+
+func TestDownloadStatSurvivesPrune(t *testing.T) {
+	setupDownloadLogTest(t)
+	if err := global.GVA_DB.AutoMigrate(&project.DownloadStatHourly{}, &project.DownloadStatDaily{},
+		&project.DownloadFailReasonDaily{}, &project.DownloadUserDaily{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := global.GVA_DB.Exec("CREATE TABLE applications (id integer PRIMARY KEY, app_name text)").Error; err != nil {
+		t.Fatal(err)
+	}
+	global.GVA_DB.Exec("INSERT INTO applications (id, app_name) VALUES (1, 'app1'), (2, 'app2')")
+
+	today := startOfDay(time.Now())
+	old := today.AddDate(0, 0, -10).Add(9 * time.Hour)
+	reason := "今日下载次数已用完，请明天再试"
+	logs := []project.DownloadLog{
+		{UserID: 1, AppID: 1, Platform: constants.PlatformAndroid, CountryCode: "CN", Success: true, IP: "1", CreatedAt: old},
+		{UserID: 1, AppID: 1, Platform: constants.PlatformAndroid, CountryCode: "CN", Success: true, IP: "1", CreatedAt: old.Add(time.Minute)},
+		{UserID: 2, AppID: 1, Platform: constants.PlatformIOS, Success: true, IP: "1", CreatedAt: old.Add(2 * time.Hour)},
+		{UserID: 3, AppID: 1, Platform: constants.PlatformAndroid, Success: false, FailReason: &reason, IP: "1", CreatedAt: old},
+		{UserID: 1, AppID: 2, Platform: constants.PlatformAndroid, Success: true, IP: "1", CreatedAt: today.Add(time.Minute)},
+	}
+	if err := global.GVA_DB.Create(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var s DownloadLogService
+	if err := s.DeleteOldLogs(3); err == nil {
+		t.Fatal("DeleteOldLogs(3) error = nil, want retention error")
+	}
+	if err := s.DeleteOldLogs(7); err != nil {
+		t.Fatalf("DeleteOldLogs() error = %v", err)
+	}
+	if got := countDownloadLogs(t); got != 1 {
+		t.Fatalf("raw logs after prune = %d, want 1", got)
+	}
+	if err := downloadStatService.AggregateRecent(); err != nil {
+		t.Fatalf("AggregateRecent() error = %v", err)
+	}
+
+	req := request.DownloadStatRequest{
+		StartTime: today.AddDate(0, 0, -30).Format("2006-01-02"),
+		EndTime:   today.Format("2006-01-02"),
+	}
+	if err := req.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := downloadStatService.GetDownloaderSummary(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.TotalAttempts != 5 || summary.TotalDownloads != 4 || summary.UniqueDownloaders != 2 {
+		t.Errorf("summary = %+v, want attempts 5, downloads 4, unique 2", summary)
+	}
+
+	trend, err := downloadStatService.GetTrend(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trend) != 31 {
+		t.Fatalf("trend points = %d, want 31", len(trend))
+	}
+	if p := trend[20]; p.Time != old.Format("2006-01-02") || p.TotalCount != 4 || p.FailCount != 1 {
+		t.Errorf("trend point = %+v, want 4 downloads with 1 failure on %s", p, old.Format("2006-01-02"))
+	}
+
+	top, err := downloadStatService.GetTopApps(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 2 || top[0].AppID != 1 || top[0].AppName != "app1" || top[0].TotalDownloads != 3 || top[0].AndroidDownloads != 2 {
+		t.Errorf("top apps = %+v", top)
+	}
+
+	reasons, err := downloadStatService.GetFailReasons(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reasons) != 1 || reasons[0].FailReason != reason || reasons[0].TotalCount != 1 {
+		t.Errorf("fail reasons = %+v", reasons)
+	}
+
+	hourly := req
+	hourly.Granularity = request.StatGranularityHour
+	hourly.StartTime = old.Format("2006-01-02")
+	hourly.EndTime = old.Format("2006-01-02")
+	points, err := downloadStatService.GetTrend(hourly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 24 || points[9].TotalCount != 3 || points[11].TotalCount != 1 {
+		t.Errorf("hourly trend = %+v", points)
+	}
+}
@@ -17,4 +17,29 @@ type ServiceGroup struct {
 	SystemAnnouncementService
 	CommissionTierService
 	CommissionDetailService
+	UploadSessionService
+	PackageReleaseService
+	PackagePatchService
+	PackageIntegrityService
+	DownloadQuotaService
+	DownloadLogService
+	DownloadStatService
+	AppSearchService
+	SearchQueryService
+	AppReviewService
+	RecommendService
+	AppScreenshotService
+	AppListingService
+	AppVersionService
+	AppFollowService
+	UserNotificationService
+	AccountReencryptService
+	AppAccountCheckService
+	AccountWarrantyService
+	CredentialRevealService
+	UserEmailService
+	UserTwoFactorService
+	ReferralService
+	AffiliateService
+	TeamStatService
 }
@@ -176,9 +176,10 @@ func (m *MembershipOrderService) ConfirmPayment(req projectReq.ConfirmPaymentReq
 
 	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
 		// 更新订单状态
+		paidAt := time.Now()
 		err := tx.Model(&order).Updates(map[string]interface{}{
 			"status":       "paid",
-			"paid_at":      time.Now(),
+			"paid_at":      paidAt,
 			"payment_id":   req.PaymentID,
 			"confirm_note": req.Note,
 			"updated_at":   time.Now(),
@@ -190,7 +191,7 @@ func (m *MembershipOrderService) ConfirmPayment(req projectReq.ConfirmPaymentReq
 		// TODO: 激活会员服务
 		// 这里应该调用会员服务激活相关功能
 
-		return nil
+		return teamStatService.OnOrderPaid(tx, order.UserID, order.FinalAmount, paidAt)
 	})
 }
 
@@ -208,8 +209,11 @@ func (m *MembershipOrderService) HandlePaymentCallback(req projectReq.PaymentCal
 		return err
 	}
 
+	// 重复的成功回调不再计入统计
+	becamePaid := req.Status == "success" && order.Status != project.OrderStatusPaid
 	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
 		// 根据回调状态更新订单
+		paidAt := time.Now()
 		updates := map[string]interface{}{
 			"payment_id": req.PaymentID,
 			"updated_at": time.Now(),
@@ -217,7 +221,7 @@ func (m *MembershipOrderService) HandlePaymentCallback(req projectReq.PaymentCal
 
 		if req.Status == "success" {
 			updates["status"] = "paid"
-			updates["paid_at"] = time.Now()
+			updates["paid_at"] = paidAt
 		} else if req.Status == "failed" {
 			updates["status"] = "failed"
 			updates["fail_reason"] = req.FailReason
@@ -233,6 +237,9 @@ func (m *MembershipOrderService) HandlePaymentCallback(req projectReq.PaymentCal
 			// TODO: 激活会员服务
 		}
 
+		if becamePaid {
+			return teamStatService.OnOrderPaid(tx, order.UserID, order.FinalAmount, paidAt)
+		}
 		return nil
 	})
 }
@@ -445,10 +452,11 @@ func (m *MembershipOrderService) ManualProcessOrder(req projectReq.ManualProcess
 			"updated_at":   time.Now(),
 		}
 
+		paidAt := time.Now()
 		switch req.ProcessType {
 		case "confirm_payment":
 			updates["status"] = "paid"
-			updates["paid_at"] = time.Now()
+			updates["paid_at"] = paidAt
 		case "mark_failed":
 			updates["status"] = "failed"
 			updates["fail_reason"] = req.Note
@@ -457,7 +465,18 @@ func (m *MembershipOrderService) ManualProcessOrder(req projectReq.ManualProcess
 			updates["refund_reason"] = req.Note
 		}
 
-		return tx.Model(&order).Updates(updates).Error
+		wasPaid := order.Status == project.OrderStatusPaid
+		if err := tx.Model(&order).Updates(updates).Error; err != nil {
+			return err
+		}
+		// 已支付状态发生变化时同步推广统计
+		switch {
+		case req.ProcessType == "confirm_payment" && !wasPaid:
+			return teamStatService.OnOrderPaid(tx, order.UserID, order.FinalAmount, paidAt)
+		case (req.ProcessType == "mark_failed" || req.ProcessType == "force_refund") && wasPaid:
+			return teamStatService.OnOrderRefunded(tx, order.UserID, order.FinalAmount)
+		}
+		return nil
 	})
 }
 
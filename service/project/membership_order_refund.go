@@ -83,6 +83,9 @@ func (r *MembershipOrderRefundService) RefundMembershipOrder(req projectReq.Refu
 		if err != nil {
 			return err
 		}
+		if err = teamStatService.OnOrderRefunded(tx, order.UserID, order.FinalAmount); err != nil {
+			return err
+		}
 
 		// TODO: 调用第三方支付接口进行退款
 		// 这里应该调用具体的支付服务进行退款处理
@@ -235,10 +238,23 @@ func (r *MembershipOrderRefundService) CancelRefund(refundID uint, reason string
 			return err
 		}
 
-		// 恢复订单状态为已支付
-		return tx.Model(&project.Order{}).
-			Where("id = ?", refund.OrderID).
-			Update("status", "paid").Error
+		// 恢复订单状态为已支付，重新计入推广统计
+		var order project.Order
+		if err := tx.Where("id = ?", refund.OrderID).First(&order).Error; err != nil {
+			return err
+		}
+		if order.Status == project.OrderStatusPaid {
+			// 售后部分退款不会改变订单状态，已计入统计
+			return nil
+		}
+		if err := tx.Model(&order).Update("status", "paid").Error; err != nil {
+			return err
+		}
+		paidAt := time.Now()
+		if order.PaidAt != nil {
+			paidAt = *order.PaidAt
+		}
+		return teamStatService.OnOrderPaid(tx, order.UserID, order.FinalAmount, paidAt)
 	})
 }
 
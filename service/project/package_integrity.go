@@ -0,0 +1,213 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/utils/scanner"
+	"ApkAdmin/utils/upload"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxScanPerRun 每轮最多扫描的安装包数
+	maxScanPerRun = 20
+	// maxVerifyPerRun 每轮最多复核哈希的安装包数
+	maxVerifyPerRun = 50
+)
+
+type PackageIntegrityService struct{}
+
+// ScanPendingPackages 扫描等待中及上次扫描失败的安装包，由定时任务调用
+func (s *PackageIntegrityService) ScanPendingPackages() error {
+	var packages []project.AppPackage
+	err := global.GVA_DB.
+		Where("scan_status IN ?", []constants.PackageScanStatus{constants.ScanStatusPending, constants.ScanStatusError}).
+		Order("updated_at").
+		Limit(maxScanPerRun).
+		Find(&packages).Error
+	if err != nil {
+		return err
+	}
+	for i := range packages {
+		if err := s.ScanPackage(&packages[i]); err != nil {
+			global.GVA_LOG.Error("扫描安装包失败!", zap.Error(err), zap.Uint64("packageId", packages[i].ID))
+		}
+	}
+	return nil
+}
+
+// ScanPackage 读取安装包，计算 SHA-256/MD5 并做安全扫描
+// 扫描通过的隔离包转为待审核；发现威胁或哈希与上传时不一致则保持隔离
+func (s *PackageIntegrityService) ScanPackage(pkg *project.AppPackage) error {
+	sc := scanner.New(global.GVA_CONFIG.Scanner)
+	now := time.Now()
+
+	body, err := openPackage(pkg)
+	if err != nil {
+		s.saveScan(pkg, map[string]interface{}{
+			"scan_status": constants.ScanStatusError,
+			"scan_result": truncateReason(err.Error()),
+			"scanned_at":  now,
+		})
+		return err
+	}
+	defer body.Close()
+
+	ins, err := scanner.Inspect(body, sc)
+	if err != nil {
+		s.saveScan(pkg, map[string]interface{}{
+			"scan_status": constants.ScanStatusError,
+			"scan_result": truncateReason(sc.Name() + ": " + err.Error()),
+			"scanned_at":  now,
+		})
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"file_md5":    ins.Md5,
+		"scanned_at":  now,
+		"verified_at": now,
+	}
+	switch {
+	case pkg.FileSha256 != nil && *pkg.FileSha256 != "" && *pkg.FileSha256 != ins.Sha256:
+		updates["status"] = constants.StatusQuarantined
+		updates["scan_status"] = constants.ScanStatusHashMismatch
+		updates["scan_result"] = "存储中的文件与上传时的哈希不一致"
+		global.GVA_LOG.Error("安装包哈希与上传时不一致", zap.Uint64("packageId", pkg.ID),
+			zap.String("expected", *pkg.FileSha256), zap.String("actual", ins.Sha256))
+	case !ins.Result.Clean:
+		updates["status"] = constants.StatusQuarantined
+		updates["scan_status"] = constants.ScanStatusInfected
+		updates["scan_result"] = truncateReason(sc.Name() + ": " + ins.Result.Signature)
+		global.GVA_LOG.Error("安装包发现威胁", zap.Uint64("packageId", pkg.ID), zap.String("signature", ins.Result.Signature))
+	default:
+		updates["file_sha256"] = ins.Sha256
+		updates["scan_status"] = constants.ScanStatusClean
+		updates["scan_result"] = nil
+		if pkg.Status == string(constants.StatusQuarantined) {
+			updates["status"] = constants.StatusReviewPending
+		}
+	}
+	return s.saveScan(pkg, updates)
+}
+
+// saveScan 只有文件未在扫描期间被替换时才写入结果，避免旧文件的结论覆盖新文件
+func (s *PackageIntegrityService) saveScan(pkg *project.AppPackage, updates map[string]interface{}) error {
+	err := global.GVA_DB.Model(&project.AppPackage{}).
+		Where("id = ? AND object_name <=> ? AND file_url <=> ?", pkg.ID, pkg.ObjectName, pkg.FileURL).
+		Updates(updates).Error
+	if err != nil {
+		global.GVA_LOG.Error("保存扫描结果失败", zap.Error(err), zap.Uint64("packageId", pkg.ID))
+	}
+	return err
+}
+
+// VerifyPackageHashes 重新计算已通过扫描的安装包哈希，发现存储中的文件被替换时隔离该安装包
+func (s *PackageIntegrityService) VerifyPackageHashes() error {
+	var packages []project.AppPackage
+	err := global.GVA_DB.
+		Where("scan_status = ? AND file_sha256 IS NOT NULL", constants.ScanStatusClean).
+		Order("verified_at IS NOT NULL, verified_at").
+		Limit(maxVerifyPerRun).
+		Find(&packages).Error
+	if err != nil {
+		return err
+	}
+	for i := range packages {
+		pkg := &packages[i]
+		actual, err := s.hashPackage(pkg)
+		if err != nil {
+			// 读取失败可能是网络抖动，不据此隔离，下一轮再复核
+			global.GVA_LOG.Warn("复核安装包哈希失败", zap.Error(err), zap.Uint64("packageId", pkg.ID))
+			continue
+		}
+		updates := map[string]interface{}{"verified_at": time.Now()}
+		if actual != *pkg.FileSha256 {
+			updates["status"] = constants.StatusQuarantined
+			updates["scan_status"] = constants.ScanStatusHashMismatch
+			updates["scan_result"] = "存储中的文件哈希发生变化"
+			global.GVA_LOG.Error("安装包哈希发生变化，已隔离", zap.Uint64("packageId", pkg.ID),
+				zap.String("expected", *pkg.FileSha256), zap.String("actual", actual))
+		}
+		_ = s.saveScan(pkg, updates)
+	}
+	return nil
+}
+
+// RescanPackage 重新扫描安装包
+func (s *PackageIntegrityService) RescanPackage(id uint64) error {
+	result := global.GVA_DB.Model(&project.AppPackage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"scan_status": constants.ScanStatusPending,
+		"scan_result": nil,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("安装包不存在")
+	}
+	return nil
+}
+
+func (s *PackageIntegrityService) hashPackage(pkg *project.AppPackage) (string, error) {
+	body, err := openPackage(pkg)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// openPackage 打开安装包文件，优先按对象路径从存储读取，否则通过文件地址下载
+func openPackage(pkg *project.AppPackage) (io.ReadCloser, error) {
+	storage := upload.NewObjectStorage()
+	switch {
+	case storage != nil && pkg.ObjectName != nil && *pkg.ObjectName != "":
+		return storage.GetObject(*pkg.ObjectName)
+	case pkg.FileURL != nil && *pkg.FileURL != "":
+		client := http.Client{Timeout: 10 * time.Minute}
+		resp, err := client.Get(*pkg.FileURL)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("下载安装包失败，状态码: %d", resp.StatusCode)
+		}
+		return resp.Body, nil
+	default:
+		return nil, errors.New("安装包没有可用的文件地址")
+	}
+}
+
+// packageFileChangedUpdates 安装包文件变更后需要重新扫描，扫描通过前保持隔离
+func packageFileChangedUpdates(sha256, md5 *string) map[string]interface{} {
+	return map[string]interface{}{
+		"status":      constants.StatusQuarantined,
+		"scan_status": constants.ScanStatusPending,
+		"scan_result": nil,
+		"file_sha256": sha256,
+		"file_md5":    md5,
+		"verified_at": nil,
+	}
+}
+
+func truncateReason(reason string) string {
+	if len(reason) > 255 {
+		return reason[:255]
+	}
+	return reason
+}
@@ -0,0 +1,228 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"ApkAdmin/utils/bsdiff"
+	"ApkAdmin/utils/upload"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// PatchFormat 补丁格式标识，客户端据此选择合成算法
+	PatchFormat = "bsdiff-gz"
+	// maxPatchSourceSize 参与差分的安装包大小上限，bsdiff 内存占用约为旧包的 9 倍
+	maxPatchSourceSize = 200 * 1024 * 1024
+	// maxPatchRatio 补丁超过新包该比例时不提供差分更新
+	maxPatchRatio = 0.7
+	// maxPatchesPerRun 每轮最多生成的补丁数，避免单次任务占用过长时间
+	maxPatchesPerRun = 20
+	// patchRetryInterval 生成失败的补丁间隔多久后重试
+	patchRetryInterval = 6 * time.Hour
+)
+
+var errPatchNotWorth = errors.New("补丁体积过大，不提供差分更新")
+
+type PackagePatchService struct{}
+
+// GeneratePatches 为相邻的已发布安卓版本生成差分补丁，由定时任务调用
+func (s *PackagePatchService) GeneratePatches() error {
+	var packages []project.AppPackage
+	err := global.GVA_DB.
+		Select("id, app_id, country_code, version_code, platform, object_name, file_url, package_size").
+		Where("platform = ? AND status = ? AND version_code IS NOT NULL", constants.PlatformAndroid, "published").
+		Order("app_id, country_code, version_code").
+		Find(&packages).Error
+	if err != nil {
+		return err
+	}
+
+	var patches []project.PackagePatch
+	if err = global.GVA_DB.Select("id, from_package_id, to_package_id, status, updated_at").
+		Where("platform = ?", constants.PlatformAndroid).
+		Find(&patches).Error; err != nil {
+		return err
+	}
+	existing := make(map[[2]uint64]project.PackagePatch, len(patches))
+	for _, p := range patches {
+		existing[[2]uint64{p.FromPackageID, p.ToPackageID}] = p
+	}
+
+	generated := 0
+	for i := 1; i < len(packages) && generated < maxPatchesPerRun; i++ {
+		from, to := &packages[i-1], &packages[i]
+		// 只在同一应用、同一国家版本的相邻版本之间生成
+		if from.AppID != to.AppID || from.CountryCode != to.CountryCode || *from.VersionCode >= *to.VersionCode {
+			continue
+		}
+		if p, ok := existing[[2]uint64{from.ID, to.ID}]; ok {
+			if p.Status != constants.PackagePatchFailed || time.Since(p.UpdatedAt) < patchRetryInterval {
+				continue
+			}
+		}
+		generated++
+		if err := s.generatePatch(from, to); err != nil {
+			global.GVA_LOG.Error("生成差分补丁失败!", zap.Error(err),
+				zap.Uint64("fromPackageId", from.ID), zap.Uint64("toPackageId", to.ID))
+		}
+	}
+	return nil
+}
+
+// generatePatch 生成补丁并校验合成结果，结果（包括失败和跳过）都会落库
+func (s *PackagePatchService) generatePatch(from, to *project.AppPackage) error {
+	patch := project.PackagePatch{
+		AppID:           to.AppID,
+		Platform:        to.Platform,
+		FromPackageID:   from.ID,
+		ToPackageID:     to.ID,
+		FromVersionCode: *from.VersionCode,
+		ToVersionCode:   *to.VersionCode,
+	}
+
+	err := s.buildPatch(from, to, &patch)
+	switch {
+	case err == nil:
+		patch.Status = constants.PackagePatchReady
+	case errors.Is(err, errPatchNotWorth):
+		patch.Status = constants.PackagePatchSkipped
+	default:
+		patch.Status = constants.PackagePatchFailed
+	}
+	if err != nil {
+		reason := truncateReason(err.Error())
+		patch.FailReason = &reason
+	}
+
+	if saveErr := global.GVA_DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "from_package_id"}, {Name: "to_package_id"}},
+		UpdateAll: true,
+	}).Create(&patch).Error; saveErr != nil {
+		return saveErr
+	}
+	if errors.Is(err, errPatchNotWorth) {
+		return nil
+	}
+	return err
+}
+
+func (s *PackagePatchService) buildPatch(from, to *project.AppPackage, patch *project.PackagePatch) error {
+	oldData, err := s.readPackage(from)
+	if err != nil {
+		return fmt.Errorf("读取旧版本安装包失败: %w", err)
+	}
+	newData, err := s.readPackage(to)
+	if err != nil {
+		return fmt.Errorf("读取新版本安装包失败: %w", err)
+	}
+	patch.FromSha256 = utils.SHA256V(oldData)
+	patch.ToSha256 = utils.SHA256V(newData)
+
+	data, err := bsdiff.Diff(oldData, newData)
+	if err != nil {
+		return err
+	}
+	if float64(len(data)) > float64(len(newData))*maxPatchRatio {
+		return errPatchNotWorth
+	}
+	// 在服务端先合成一次，保证下发的补丁一定能还原出新包
+	restored, err := bsdiff.Patch(oldData, data)
+	if err != nil {
+		return fmt.Errorf("补丁校验失败: %w", err)
+	}
+	if utils.SHA256V(restored) != patch.ToSha256 {
+		return errors.New("补丁校验失败: 合成结果与新包不一致")
+	}
+
+	storage := upload.NewObjectStorage()
+	if storage == nil {
+		return errors.New("当前存储类型不支持保存补丁")
+	}
+	objectName := fmt.Sprintf("private/patch/%s/%d_%d.patch", to.AppID, from.ID, to.ID)
+	fileURL, err := storage.PutObject(objectName, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("上传补丁失败: %w", err)
+	}
+	patch.ObjectName = &objectName
+	patch.FileURL = &fileURL
+	patch.PatchSize = int64(len(data))
+	patch.PatchSha256 = utils.SHA256V(data)
+	return nil
+}
+
+// readPackage 读取安装包全部内容，超过大小上限的安装包不参与差分
+func (s *PackagePatchService) readPackage(pkg *project.AppPackage) ([]byte, error) {
+	if pkg.PackageSize > maxPatchSourceSize {
+		return nil, errPatchNotWorth
+	}
+
+	body, err := openPackage(pkg)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxPatchSourceSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPatchSourceSize {
+		return nil, errPatchNotWorth
+	}
+	return data, nil
+}
+
+// FindPatch 查找从已安装版本升级到目标安装包的可用补丁，已安装包哈希不匹配时返回 nil
+func (s *PackagePatchService) FindPatch(target *project.AppPackage, installedVersionCode int, apkSha256 string) (*project.PackagePatch, error) {
+	if target == nil || apkSha256 == "" {
+		return nil, nil
+	}
+	var patch project.PackagePatch
+	err := global.GVA_DB.
+		Where("to_package_id = ? AND from_version_code = ? AND from_sha256 = ? AND status = ?",
+			target.ID, installedVersionCode, apkSha256, constants.PackagePatchReady).
+		First(&patch).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &patch, nil
+}
+
+// GetPatchList 分页获取应用的差分补丁
+func (s *PackagePatchService) GetPatchList(req request.PackagePatchListRequest) (list []project.PackagePatch, total int64, err error) {
+	db := global.GVA_DB.Model(&project.PackagePatch{}).Where("app_id = ?", req.AppID)
+	if req.Status != "" {
+		db = db.Where("status = ?", req.Status)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Scopes(req.Paginate()).Order("to_version_code DESC, id DESC").Find(&list).Error
+	return list, total, err
+}
+
+// RegeneratePatch 删除补丁记录，下一轮定时任务会重新生成
+func (s *PackagePatchService) RegeneratePatch(id uint64) error {
+	result := global.GVA_DB.Where("id = ?", id).Delete(&project.PackagePatch{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("补丁不存在")
+	}
+	return nil
+}
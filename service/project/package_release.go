@@ -0,0 +1,329 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoAvailablePackage 没有符合发布规则的安装包
+var ErrNoAvailablePackage = errors.New("暂无可用的安装包")
+
+type PackageReleaseService struct{}
+
+// GetReleaseList 获取应用的发布配置
+func (s *PackageReleaseService) GetReleaseList(req request.PackageReleaseListRequest) (list []project.PackageRelease, err error) {
+	db := global.GVA_DB.Model(&project.PackageRelease{}).Where("app_id = ?", req.AppID)
+	if req.Platform != "" {
+		db = db.Where("platform = ?", req.Platform)
+	}
+	err = db.Preload("CurrentPackage").
+		Preload("RolloutPackage").
+		Order("platform, channel, country_code").
+		Find(&list).Error
+	return list, err
+}
+
+// Publish 发布安装包：首次发布或比例为100时全量，否则进入灰度
+func (s *PackageReleaseService) Publish(uid uint, req request.PackageReleasePublishRequest) (*project.PackageRelease, error) {
+	if _, err := s.getReleasablePackage(req.PackageID, req.AppID, req.Platform); err != nil {
+		return nil, err
+	}
+	var release project.PackageRelease
+	err := global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("app_id = ? AND platform = ? AND channel = ? AND country_code = ?", req.AppID, req.Platform, req.Channel, req.CountryCode).
+			First(&release).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		// 首次发布没有可回退的版本，直接全量
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			release = project.PackageRelease{
+				AppID:            req.AppID,
+				Platform:         req.Platform,
+				Channel:          req.Channel,
+				CountryCode:      req.CountryCode,
+				CurrentPackageID: req.PackageID,
+				UpdatedBy:        uid,
+			}
+			if err := tx.Create(&release).Error; err != nil {
+				return err
+			}
+			return s.addHistory(tx, &release, constants.ReleaseActionPublish, nil, req.PackageID, uid, req.Remark)
+		}
+
+		if release.CurrentPackageID == req.PackageID {
+			return errors.New("该安装包已是当前全量版本")
+		}
+		from := release.CurrentPackageID
+		action := constants.ReleaseActionRollout
+		if req.RolloutPercent >= 100 {
+			action = constants.ReleaseActionPublish
+			release.CurrentPackageID = req.PackageID
+			release.RolloutPackageID = nil
+			release.RolloutPercent = 0
+		} else {
+			release.RolloutPackageID = &req.PackageID
+			release.RolloutPercent = req.RolloutPercent
+		}
+		release.UpdatedBy = uid
+		if err := s.saveRelease(tx, &release); err != nil {
+			return err
+		}
+		return s.addHistory(tx, &release, action, &from, req.PackageID, uid, req.Remark)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// UpdateRollout 调整灰度比例，100 转全量，0 终止灰度
+func (s *PackageReleaseService) UpdateRollout(uid uint, req request.PackageReleaseRolloutRequest) error {
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		release, err := s.lockRelease(tx, req.ID)
+		if err != nil {
+			return err
+		}
+		if release.RolloutPackageID == nil {
+			return errors.New("当前没有进行中的灰度发布")
+		}
+		rolloutID := *release.RolloutPackageID
+		from := release.CurrentPackageID
+		percent := *req.RolloutPercent
+		action := constants.ReleaseActionRollout
+		switch percent {
+		case 100:
+			if _, err := s.getReleasablePackage(rolloutID, release.AppID, release.Platform); err != nil {
+				return err
+			}
+			action = constants.ReleaseActionPromote
+			release.CurrentPackageID = rolloutID
+			release.RolloutPackageID = nil
+			release.RolloutPercent = 0
+		case 0:
+			action = constants.ReleaseActionHalt
+			release.RolloutPackageID = nil
+			release.RolloutPercent = 0
+		default:
+			release.RolloutPercent = percent
+		}
+		release.UpdatedBy = uid
+		if err := s.saveRelease(tx, release); err != nil {
+			return err
+		}
+		return s.addHistory(tx, release, action, &from, rolloutID, uid, req.Remark)
+	})
+}
+
+// Rollback 一键回滚，未指定安装包时回到上一个全量版本，同时终止灰度
+func (s *PackageReleaseService) Rollback(uid uint, req request.PackageReleaseRollbackRequest) error {
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		release, err := s.lockRelease(tx, req.ID)
+		if err != nil {
+			return err
+		}
+		var targetID uint64
+		if req.PackageID != nil {
+			targetID = *req.PackageID
+		} else {
+			targetID, err = s.findPreviousPackageID(tx, release)
+			if err != nil {
+				return err
+			}
+		}
+		if targetID == release.CurrentPackageID {
+			return errors.New("该安装包已是当前全量版本")
+		}
+		if _, err := s.getReleasablePackage(targetID, release.AppID, release.Platform); err != nil {
+			return err
+		}
+		from := release.CurrentPackageID
+		release.CurrentPackageID = targetID
+		release.RolloutPackageID = nil
+		release.RolloutPercent = 0
+		release.UpdatedBy = uid
+		if err := s.saveRelease(tx, release); err != nil {
+			return err
+		}
+		return s.addHistory(tx, release, constants.ReleaseActionRollback, &from, targetID, uid, req.Remark)
+	})
+}
+
+// DeleteRelease 删除发布配置（取消国家固定版本等），删除后回落到通用配置
+func (s *PackageReleaseService) DeleteRelease(id uint64) error {
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("release_id = ?", id).Delete(&project.PackageReleaseHistory{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&project.PackageRelease{}).Error
+	})
+}
+
+// GetReleaseHistory 获取发布历史
+func (s *PackageReleaseService) GetReleaseHistory(req request.PackageReleaseHistoryRequest) (list []project.PackageReleaseHistory, total int64, err error) {
+	db := global.GVA_DB.Model(&project.PackageReleaseHistory{}).Where("release_id = ?", req.ReleaseID)
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Scopes(req.Paginate()).Order("id DESC").Find(&list).Error
+	return list, total, err
+}
+
+// ResolvePackage 按发布规则为用户选择安装包
+// 优先级：请求渠道 > 正式渠道；国家固定配置 > 通用配置；没有任何配置时取最新已发布版本
+func (s *PackageReleaseService) ResolvePackage(app *project.Application, platform constants.Platform, userID uint, countryCode string, channel constants.ReleaseChannel) (*project.AppPackage, error) {
+	channels := []constants.ReleaseChannel{constants.ReleaseChannelStable}
+	if channel.IsValid() && channel != constants.ReleaseChannelStable {
+		channels = append([]constants.ReleaseChannel{channel}, channels...)
+	}
+	bucket := utils.RolloutBucket(app.AppID, userID)
+	for _, ch := range channels {
+		var releases []project.PackageRelease
+		err := global.GVA_DB.
+			Where("app_id = ? AND platform = ? AND channel = ?", app.AppID, platform, ch).
+			Where("country_code IN ?", []string{countryCode, ""}).
+			Find(&releases).Error
+		if err != nil {
+			return nil, err
+		}
+		release := pickCountryRelease(releases, countryCode)
+		if release == nil {
+			continue
+		}
+		pkg, err := s.getPublishedPackage(release.PickPackageID(bucket))
+		if err != nil {
+			return nil, err
+		}
+		if pkg != nil {
+			return pkg, nil
+		}
+	}
+
+	// 尚未配置发布规则的应用，使用最新发布的版本
+	var pkg project.AppPackage
+	err := global.GVA_DB.
+		Where("app_id = ? AND platform = ? AND status = ?", app.AppID, platform, constants.StatusPublished).
+		Order("version_code DESC, published_at DESC").
+		First(&pkg).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoAvailablePackage
+		}
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// ==================== 辅助方法 ====================
+
+// pickCountryRelease 国家固定配置优先于通用配置
+func pickCountryRelease(releases []project.PackageRelease, countryCode string) *project.PackageRelease {
+	var fallback *project.PackageRelease
+	for i := range releases {
+		if countryCode != "" && releases[i].CountryCode == countryCode {
+			return &releases[i]
+		}
+		if releases[i].CountryCode == "" {
+			fallback = &releases[i]
+		}
+	}
+	return fallback
+}
+
+func (s *PackageReleaseService) getPublishedPackage(id uint64) (*project.AppPackage, error) {
+	var pkg project.AppPackage
+	err := global.GVA_DB.Where("id = ? AND status = ?", id, constants.StatusPublished).First(&pkg).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// getReleasablePackage 校验安装包属于该应用和平台且已发布
+func (s *PackageReleaseService) getReleasablePackage(id uint64, appID string, platform constants.Platform) (*project.AppPackage, error) {
+	var pkg project.AppPackage
+	if err := global.GVA_DB.Where("id = ?", id).First(&pkg).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("安装包不存在")
+		}
+		return nil, err
+	}
+	if pkg.AppID != appID || pkg.Platform != platform {
+		return nil, errors.New("安装包与应用或平台不匹配")
+	}
+	if pkg.Status != string(constants.StatusPublished) {
+		return nil, errors.New("只能发布已审核通过的安装包")
+	}
+	return &pkg, nil
+}
+
+func (s *PackageReleaseService) lockRelease(tx *gorm.DB, id uint64) (*project.PackageRelease, error) {
+	var release project.PackageRelease
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&release).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("发布配置不存在")
+		}
+		return nil, err
+	}
+	return &release, nil
+}
+
+func (s *PackageReleaseService) saveRelease(tx *gorm.DB, release *project.PackageRelease) error {
+	return tx.Model(&project.PackageRelease{}).Where("id = ?", release.ID).Updates(map[string]interface{}{
+		"current_package_id": release.CurrentPackageID,
+		"rollout_package_id": release.RolloutPackageID,
+		"rollout_percent":    release.RolloutPercent,
+		"updated_by":         release.UpdatedBy,
+	}).Error
+}
+
+func (s *PackageReleaseService) addHistory(tx *gorm.DB, release *project.PackageRelease, action constants.ReleaseAction, from *uint64, to uint64, uid uint, remark string) error {
+	return tx.Create(&project.PackageReleaseHistory{
+		ReleaseID:      release.ID,
+		Action:         action,
+		FromPackageID:  from,
+		ToPackageID:    to,
+		RolloutPercent: release.RolloutPercent,
+		Remark:         remark,
+		OperatorID:     uid,
+	}).Error
+}
+
+// findPreviousPackageID 按发布顺序重放历史得到全量版本栈，返回当前版本之前最近的一个
+// 重新发布过的版本会再次入栈，仍然可以作为回滚目标
+func (s *PackageReleaseService) findPreviousPackageID(tx *gorm.DB, release *project.PackageRelease) (uint64, error) {
+	var histories []project.PackageReleaseHistory
+	err := tx.Where("release_id = ?", release.ID).
+		Where("action IN ?", []constants.ReleaseAction{constants.ReleaseActionPublish, constants.ReleaseActionPromote, constants.ReleaseActionRollback}).
+		Order("id ASC").
+		Find(&histories).Error
+	if err != nil {
+		return 0, err
+	}
+	var stack []uint64
+	for _, h := range histories {
+		// 回滚到上一个版本时出栈；回滚到指定的其它版本相当于重新发布该版本
+		if n := len(stack); h.Action == constants.ReleaseActionRollback && n >= 2 && stack[n-2] == h.ToPackageID {
+			stack = stack[:n-1]
+			continue
+		}
+		stack = append(stack, h.ToPackageID)
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] != release.CurrentPackageID {
+			return stack[i], nil
+		}
+	}
+	return 0, errors.New("没有可回滚的历史版本")
+}
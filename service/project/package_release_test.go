@@ -0,0 +1,169 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"testing"
+)
+
+func setupPackageReleaseTest(t *testing.T) *project.Application {
+	t.Helper()
+	setupTestDB(t, &project.PackageRelease{}, &project.PackageReleaseHistory{})
+	// sqlite 不支持 enum 类型，手动建表
+	err := global.GVA_DB.Exec(`CREATE TABLE app_packages (id integer PRIMARY KEY, app_id text, platform text, status text,
+		version_name text, version_code integer, published_at datetime)`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	global.GVA_DB.Exec(`INSERT INTO app_packages (id, app_id, platform, status, version_code) VALUES
+		(1, 'a1', 'android', 'published', 1), (2, 'a1', 'android', 'published', 2),
+		(3, 'a1', 'android', 'published', 3), (4, 'a1', 'android', 'review_pending', 4)`)
+	return &project.Application{ID: 1, AppID: "a1"}
+}
+
+func resolvedPackageID(t *testing.T, app *project.Application, userID uint) uint64 {
+	t.Helper()
+	var s PackageReleaseService
+	pkg, err := s.ResolvePackage(app, constants.PlatformAndroid, userID, "", constants.ReleaseChannelStable)
+	if err != nil {
+		t.Fatalf("ResolvePackage(user %d) error = %v", userID, err)
+	}
+	return pkg.ID
+}
+
+func TestPackageReleaseRollout(t *testing.T) {
+	app := setupPackageReleaseTest(t)
+	var s PackageReleaseService
+	publish := func(pkgID uint64, percent int) (*project.PackageRelease, error) {
+		req := request.PackageReleasePublishRequest{AppID: "a1", Platform: constants.PlatformAndroid, PackageID: pkgID, RolloutPercent: percent}
+		if err := req.Validate(); err != nil {
+			return nil, err
+		}
+		return s.Publish(1, req)
+	}
+
+	// 没有发布配置时使用最新已发布版本
+	if id := resolvedPackageID(t, app, 1); id != 3 {
+		t.Errorf("without release = %d, want 3", id)
+	}
+	if _, err := publish(4, 100); err == nil {
+		t.Error("unpublished package should be rejected")
+	}
+	// 首次发布即使指定灰度比例也是全量
+	release, err := publish(1, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.CurrentPackageID != 1 || release.RolloutPackageID != nil {
+		t.Fatalf("first publish = %+v", release)
+	}
+
+	if release, err = publish(2, 30); err != nil {
+		t.Fatal(err)
+	}
+	const users = 1000
+	inRollout := func() map[uint]bool {
+		got := make(map[uint]bool)
+		for uid := uint(1); uid <= users; uid++ {
+			id := resolvedPackageID(t, app, uid)
+			if want := utils.RolloutBucket("a1", uid) < release.RolloutPercent; (id == 2) != want {
+				t.Fatalf("user %d got package %d, bucket %d, percent %d", uid, id, utils.RolloutBucket("a1", uid), release.RolloutPercent)
+			}
+			if id == 2 {
+				got[uid] = true
+			}
+		}
+		return got
+	}
+	first := inRollout()
+	if n := len(first); n < 200 || n > 400 {
+		t.Errorf("30%% rollout hit %d of %d users", n, users)
+	}
+
+	// 扩大比例时已经拿到新版本的用户保持不变
+	percent := 60
+	if err = s.UpdateRollout(1, request.PackageReleaseRolloutRequest{ID: release.ID, RolloutPercent: &percent}); err != nil {
+		t.Fatal(err)
+	}
+	release.RolloutPercent = percent
+	second := inRollout()
+	for uid := range first {
+		if !second[uid] {
+			t.Fatalf("user %d dropped out of rollout when percent increased", uid)
+		}
+	}
+
+	// 终止灰度后全部回到全量版本
+	halt := 0
+	if err = s.UpdateRollout(1, request.PackageReleaseRolloutRequest{ID: release.ID, RolloutPercent: &halt}); err != nil {
+		t.Fatal(err)
+	}
+	for _, uid := range []uint{1, 2, 3} {
+		if id := resolvedPackageID(t, app, uid); id != 1 {
+			t.Errorf("after halt user %d got %d", uid, id)
+		}
+	}
+	if err = s.UpdateRollout(1, request.PackageReleaseRolloutRequest{ID: release.ID, RolloutPercent: &percent}); err == nil {
+		t.Error("update without rollout should fail")
+	}
+}
+
+func TestPackageReleaseRollback(t *testing.T) {
+	app := setupPackageReleaseTest(t)
+	var s PackageReleaseService
+	publish := func(pkgID uint64, percent int) uint64 {
+		t.Helper()
+		release, err := s.Publish(1, request.PackageReleasePublishRequest{AppID: "a1", Platform: constants.PlatformAndroid,
+			Channel: constants.ReleaseChannelStable, PackageID: pkgID, RolloutPercent: percent})
+		if err != nil {
+			t.Fatalf("publish %d: %v", pkgID, err)
+		}
+		return release.ID
+	}
+	rollback := func(id uint64, want uint64) {
+		t.Helper()
+		if err := s.Rollback(1, request.PackageReleaseRollbackRequest{ID: id}); err != nil {
+			t.Fatalf("rollback to %d: %v", want, err)
+		}
+		if got := resolvedPackageID(t, app, 1); got != want {
+			t.Fatalf("after rollback current = %d, want %d", got, want)
+		}
+	}
+
+	id := publish(1, 100)
+	if err := s.Rollback(1, request.PackageReleaseRollbackRequest{ID: id}); err == nil {
+		t.Error("rollback without history should fail")
+	}
+	// 灰度转全量后回滚到灰度前的版本，同时终止灰度
+	publish(2, 50)
+	full := 100
+	if err := s.UpdateRollout(1, request.PackageReleaseRolloutRequest{ID: id, RolloutPercent: &full}); err != nil {
+		t.Fatal(err)
+	}
+	rollback(id, 1)
+
+	// 被回滚过的版本重新发布后，仍然可以作为回滚目标
+	publish(2, 100)
+	publish(3, 100)
+	rollback(id, 2)
+	rollback(id, 1)
+	if err := s.Rollback(1, request.PackageReleaseRollbackRequest{ID: id}); err == nil {
+		t.Error("rollback past the first release should fail")
+	}
+
+	// 指定安装包回滚
+	target := uint64(3)
+	if err := s.Rollback(1, request.PackageReleaseRollbackRequest{ID: id, PackageID: &target}); err != nil {
+		t.Fatal(err)
+	}
+	rollback(id, 1)
+
+	var release project.PackageRelease
+	global.GVA_DB.First(&release, id)
+	if release.RolloutPackageID != nil || release.RolloutPercent != 0 {
+		t.Errorf("release after rollback = %+v", release)
+	}
+}
@@ -0,0 +1,440 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	projectModel "ApkAdmin/model/project"
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultRecommendWindowDays   = 90
+	defaultRecommendTopK         = 20
+	defaultRecommendMinCoCount   = 2
+	defaultRecommendCacheMinutes = 30
+	// recommendMaxUserItems 交互应用过多的用户（多为批量下载的异常账号）不参与相似度计算，避免组合数爆炸
+	recommendMaxUserItems = 200
+	// recommendCandidates 每个用户缓存的候选应用数量
+	recommendCandidates = 100
+	maxRecommendLimit   = 50
+	// recommendPurchaseWeight 购买比下载更能代表用户兴趣
+	recommendPurchaseWeight = 2.0
+	recommendCacheKeyPrefix = "recommend:user:"
+)
+
+var recommendService = RecommendService{}
+
+type RecommendService struct{}
+
+// userRecommendCache 个性化推荐的候选结果，返回前再按国家和上架状态过滤
+type userRecommendCache struct {
+	Candidates []uint64 `json:"candidates"`
+	Seen       []uint64 `json:"seen"` // 用户下载或购买过的应用，兜底推荐时排除
+}
+
+// recommendMemCache Redis 不可用时使用的进程内缓存
+var recommendMemCache struct {
+	sync.Mutex
+	entries map[uint]recommendMemEntry
+}
+
+type recommendMemEntry struct {
+	value    userRecommendCache
+	expireAt time.Time
+}
+
+// ==================== 离线计算 ====================
+
+// RebuildSimilarity 根据时间窗口内的下载和购买记录计算应用之间的余弦相似度，全量替换相似度表
+func (s *RecommendService) RebuildSimilarity() error {
+	cfg := global.GVA_CONFIG.Recommend
+	since := time.Now().AddDate(0, 0, -positiveOr(cfg.WindowDays, defaultRecommendWindowDays))
+	interactions, err := s.loadInteractions(since, 0)
+	if err != nil {
+		return err
+	}
+	rows := computeSimilarity(interactions,
+		positiveOr(cfg.MinCoCount, defaultRecommendMinCoCount),
+		positiveOr(cfg.TopK, defaultRecommendTopK))
+
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&projectModel.AppSimilarity{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+	if err != nil {
+		return err
+	}
+	global.GVA_LOG.Info("应用相似度计算完成", zap.Int("users", len(interactions)), zap.Int("pairs", len(rows)))
+	return nil
+}
+
+// loadInteractions 读取用户交互过的应用及权重，userID 为 0 时读取所有用户
+func (s *RecommendService) loadInteractions(since time.Time, userID uint) (map[uint]map[uint64]float64, error) {
+	result := make(map[uint]map[uint64]float64)
+	add := func(user uint, app uint64, weight float64) {
+		items := result[user]
+		if items == nil {
+			items = make(map[uint64]float64)
+			result[user] = items
+		}
+		items[app] = max(items[app], weight)
+	}
+
+	var downloads []struct {
+		UserID uint
+		AppID  uint64
+	}
+	db := global.GVA_DB.Model(&projectModel.DownloadLog{}).
+		Distinct("user_id", "app_id").
+		Where("success = ? AND user_id > 0 AND created_at >= ?", true, since)
+	if userID > 0 {
+		db = db.Where("user_id = ?", userID)
+	}
+	if err := db.Scan(&downloads).Error; err != nil {
+		return nil, err
+	}
+	for _, d := range downloads {
+		add(d.UserID, d.AppID, 1)
+	}
+
+	purchases, err := s.loadPurchases(since, userID)
+	if err != nil {
+		return nil, err
+	}
+	for user, apps := range purchases {
+		for _, app := range apps {
+			add(user, app, recommendPurchaseWeight)
+		}
+	}
+	return result, nil
+}
+
+// loadPurchases 已支付的账号订单，按分配的账号找到对应应用
+func (s *RecommendService) loadPurchases(since time.Time, userID uint) (map[uint][]uint64, error) {
+	var orders []projectModel.Order
+	db := global.GVA_DB.Select("user_id, account_ids").
+		Where("order_type = ? AND status = ? AND paid_at >= ?", projectModel.OrderTypeAccountProduct, projectModel.OrderStatusPaid, since)
+	if userID > 0 {
+		db = db.Where("user_id = ?", userID)
+	}
+	if err := db.Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	var accountIDs []uint
+	for _, o := range orders {
+		accountIDs = append(accountIDs, o.AccountIDs...)
+	}
+	if len(accountIDs) == 0 {
+		return nil, nil
+	}
+
+	// 账号售出后可能被删除，仍然按原应用统计
+	var accounts []struct {
+		ID    uint
+		AppID uint64
+	}
+	err := global.GVA_DB.Table("app_accounts AS acc").
+		Select("acc.id, app.id AS app_id").
+		Joins("JOIN applications app ON app.app_id = acc.app_id").
+		Where("acc.id IN ?", accountIDs).
+		Scan(&accounts).Error
+	if err != nil {
+		return nil, err
+	}
+	accountApp := make(map[uint]uint64, len(accounts))
+	for _, a := range accounts {
+		accountApp[a.ID] = a.AppID
+	}
+	result := make(map[uint][]uint64)
+	for _, o := range orders {
+		for _, id := range o.AccountIDs {
+			if app, ok := accountApp[id]; ok {
+				result[o.UserID] = append(result[o.UserID], app)
+			}
+		}
+	}
+	return result, nil
+}
+
+type appPair struct {
+	a, b uint64
+}
+
+// computeSimilarity 物品间余弦相似度，每个应用只保留得分最高的 topK 个相似应用
+func computeSimilarity(interactions map[uint]map[uint64]float64, minCoCount, topK int) []projectModel.AppSimilarity {
+	norms := make(map[uint64]float64)
+	dots := make(map[appPair]float64)
+	counts := make(map[appPair]int)
+	for _, items := range interactions {
+		if len(items) < 2 || len(items) > recommendMaxUserItems {
+			continue
+		}
+		apps := make([]uint64, 0, len(items))
+		for app, w := range items {
+			apps = append(apps, app)
+			norms[app] += w * w
+		}
+		sort.Slice(apps, func(i, j int) bool { return apps[i] < apps[j] })
+		for i := range apps {
+			for j := i + 1; j < len(apps); j++ {
+				p := appPair{apps[i], apps[j]}
+				dots[p] += items[apps[i]] * items[apps[j]]
+				counts[p]++
+			}
+		}
+	}
+
+	neighbors := make(map[uint64][]projectModel.AppSimilarity)
+	for p, dot := range dots {
+		if counts[p] < minCoCount {
+			continue
+		}
+		score := dot / math.Sqrt(norms[p.a]*norms[p.b])
+		neighbors[p.a] = append(neighbors[p.a], projectModel.AppSimilarity{AppID: p.a, SimilarAppID: p.b, Score: score, CoCount: counts[p]})
+		neighbors[p.b] = append(neighbors[p.b], projectModel.AppSimilarity{AppID: p.b, SimilarAppID: p.a, Score: score, CoCount: counts[p]})
+	}
+	var rows []projectModel.AppSimilarity
+	for _, list := range neighbors {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Score != list[j].Score {
+				return list[i].Score > list[j].Score
+			}
+			return list[i].SimilarAppID < list[j].SimilarAppID
+		})
+		if len(list) > topK {
+			list = list[:topK]
+		}
+		rows = append(rows, list...)
+	}
+	return rows
+}
+
+// ==================== 推荐查询 ====================
+
+// AlsoDownloaded 下载了该应用的用户还下载了，没有足够数据时用人工推荐补齐
+func (s *RecommendService) AlsoDownloaded(appID uint64, countryCode string, limit int) ([]projectModel.Application, error) {
+	limit = recommendLimit(limit)
+	var apps []projectModel.Application
+	err := global.GVA_DB.Model(&projectModel.Application{}).
+		Joins("JOIN app_similarities s ON s.similar_app_id = applications.id").
+		Where("s.app_id = ? AND applications.status = ?", appID, constants.ApplicationStatusActive).
+		Scopes(visibleInCountry(countryCode)).
+		Order("s.score DESC").
+		Limit(limit).
+		Find(&apps).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.fillCurated(apps, []uint64{appID}, countryCode, limit)
+}
+
+// ForYou 个性化推荐：按用户交互过的应用聚合相似应用，未登录或没有历史记录时返回人工推荐
+func (s *RecommendService) ForYou(userID uint, countryCode string, limit int) ([]projectModel.Application, error) {
+	limit = recommendLimit(limit)
+	if userID == 0 {
+		return s.fillCurated(nil, nil, countryCode, limit)
+	}
+	cache, err := s.userCandidates(userID)
+	if err != nil {
+		return nil, err
+	}
+	var apps []projectModel.Application
+	if len(cache.Candidates) > 0 {
+		var found []projectModel.Application
+		err = global.GVA_DB.Model(&projectModel.Application{}).
+			Where("id IN ? AND status = ?", cache.Candidates, constants.ApplicationStatusActive).
+			Scopes(visibleInCountry(countryCode)).
+			Find(&found).Error
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[uint64]projectModel.Application, len(found))
+		for _, app := range found {
+			byID[app.ID] = app
+		}
+		for _, id := range cache.Candidates {
+			if app, ok := byID[id]; ok && len(apps) < limit {
+				apps = append(apps, app)
+			}
+		}
+	}
+	return s.fillCurated(apps, cache.Seen, countryCode, limit)
+}
+
+// userCandidates 读取或计算用户的推荐候选
+func (s *RecommendService) userCandidates(userID uint) (userRecommendCache, error) {
+	if cache, ok := s.getCache(userID); ok {
+		return cache, nil
+	}
+	since := time.Now().AddDate(0, 0, -positiveOr(global.GVA_CONFIG.Recommend.WindowDays, defaultRecommendWindowDays))
+	interactions, err := s.loadInteractions(since, userID)
+	if err != nil {
+		return userRecommendCache{}, err
+	}
+	seeds := interactions[userID]
+	cache := userRecommendCache{Candidates: []uint64{}, Seen: make([]uint64, 0, len(seeds))}
+	for app := range seeds {
+		cache.Seen = append(cache.Seen, app)
+	}
+	if len(seeds) > 0 {
+		var sims []projectModel.AppSimilarity
+		if err = global.GVA_DB.Where("app_id IN ?", cache.Seen).Find(&sims).Error; err != nil {
+			return userRecommendCache{}, err
+		}
+		scores := make(map[uint64]float64)
+		for _, sim := range sims {
+			if _, seen := seeds[sim.SimilarAppID]; !seen {
+				scores[sim.SimilarAppID] += sim.Score * seeds[sim.AppID]
+			}
+		}
+		for app := range scores {
+			cache.Candidates = append(cache.Candidates, app)
+		}
+		sort.Slice(cache.Candidates, func(i, j int) bool {
+			a, b := cache.Candidates[i], cache.Candidates[j]
+			if scores[a] != scores[b] {
+				return scores[a] > scores[b]
+			}
+			return a < b
+		})
+		if len(cache.Candidates) > recommendCandidates {
+			cache.Candidates = cache.Candidates[:recommendCandidates]
+		}
+	}
+	s.setCache(userID, cache)
+	return cache, nil
+}
+
+// fillCurated 用人工标记的热门和推荐应用补齐数量，跳过已有的和 exclude 中的应用
+func (s *RecommendService) fillCurated(apps []projectModel.Application, exclude []uint64, countryCode string, limit int) ([]projectModel.Application, error) {
+	if len(apps) >= limit {
+		return apps, nil
+	}
+	skip := make(map[uint64]bool, len(apps)+len(exclude))
+	for _, id := range exclude {
+		skip[id] = true
+	}
+	for _, app := range apps {
+		skip[app.ID] = true
+	}
+	var curated []projectModel.Application
+	err := global.GVA_DB.Model(&projectModel.Application{}).
+		Where("(is_hot = ? OR is_recommend = ?) AND status = ?", 1, 1, constants.ApplicationStatusActive).
+		Scopes(visibleInCountry(countryCode)).
+		Order("sort_order DESC, download_count DESC, id DESC").
+		Limit(limit + len(skip)).
+		Find(&curated).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, app := range curated {
+		if len(apps) >= limit {
+			break
+		}
+		if !skip[app.ID] {
+			apps = append(apps, app)
+		}
+	}
+	if apps == nil {
+		apps = []projectModel.Application{}
+	}
+	return apps, nil
+}
+
+func recommendLimit(limit int) int {
+	if limit <= 0 {
+		return 10
+	}
+	return min(limit, maxRecommendLimit)
+}
+
+// ==================== 缓存 ====================
+
+func recommendCacheTTL() time.Duration {
+	return time.Duration(positiveOr(global.GVA_CONFIG.Recommend.CacheMinutes, defaultRecommendCacheMinutes)) * time.Minute
+}
+
+func recommendCacheKey(userID uint) string {
+	return recommendCacheKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+}
+
+func (s *RecommendService) getCache(userID uint) (userRecommendCache, bool) {
+	var cache userRecommendCache
+	if global.GVA_REDIS != nil {
+		data, err := global.GVA_REDIS.Get(context.Background(), recommendCacheKey(userID)).Bytes()
+		if err != nil {
+			if err != redis.Nil {
+				global.GVA_LOG.Error("读取推荐缓存失败!", zap.Error(err))
+			}
+			return cache, false
+		}
+		return cache, json.Unmarshal(data, &cache) == nil
+	}
+	recommendMemCache.Lock()
+	defer recommendMemCache.Unlock()
+	entry, ok := recommendMemCache.entries[userID]
+	if !ok || time.Now().After(entry.expireAt) {
+		return cache, false
+	}
+	return entry.value, true
+}
+
+func (s *RecommendService) setCache(userID uint, cache userRecommendCache) {
+	if global.GVA_REDIS != nil {
+		data, _ := json.Marshal(cache)
+		if err := global.GVA_REDIS.Set(context.Background(), recommendCacheKey(userID), data, recommendCacheTTL()).Err(); err != nil {
+			global.GVA_LOG.Error("写入推荐缓存失败!", zap.Error(err))
+		}
+		return
+	}
+	recommendMemCache.Lock()
+	defer recommendMemCache.Unlock()
+	if recommendMemCache.entries == nil {
+		recommendMemCache.entries = make(map[uint]recommendMemEntry)
+	}
+	now := time.Now()
+	// 顺带清理过期条目，避免进程内缓存无限增长
+	for id, entry := range recommendMemCache.entries {
+		if now.After(entry.expireAt) {
+			delete(recommendMemCache.entries, id)
+		}
+	}
+	recommendMemCache.entries[userID] = recommendMemEntry{value: cache, expireAt: now.Add(recommendCacheTTL())}
+}
+
+// InvalidateUsers 用户产生新的下载后清除其推荐缓存
+func (s *RecommendService) InvalidateUsers(userIDs ...uint) {
+	if len(userIDs) == 0 {
+		return
+	}
+	if global.GVA_REDIS != nil {
+		keys := make([]string, len(userIDs))
+		for i, id := range userIDs {
+			keys[i] = recommendCacheKey(id)
+		}
+		if err := global.GVA_REDIS.Del(context.Background(), keys...).Err(); err != nil {
+			global.GVA_LOG.Error("清除推荐缓存失败!", zap.Error(err))
+		}
+		return
+	}
+	recommendMemCache.Lock()
+	defer recommendMemCache.Unlock()
+	for _, id := range userIDs {
+		delete(recommendMemCache.entries, id)
+	}
+}
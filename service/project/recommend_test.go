@@ -0,0 +1,98 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"testing"
+	"time"
+)
+
+func TestComputeSimilarity(t *testing.T) {
+	interactions := map[uint]map[uint64]float64{
+		1: {1: 1, 2: 1, 3: 1},
+		2: {1: 1, 2: 1},
+		3: {1: 1, 3: 2},
+		4: {4: 1},
+	}
+	rows := computeSimilarity(interactions, 2, 1)
+	got := make(map[uint64]project.AppSimilarity)
+	for _, r := range rows {
+		got[r.AppID] = r
+	}
+	// 1-2、1-3 各有两个用户共同下载，2-3 只有一个用户；用户 3 对应用 3 的权重更高，1-3 的相似度低于 1-2
+	if len(rows) != 3 || got[1].SimilarAppID != 2 || got[2].SimilarAppID != 1 || got[3].SimilarAppID != 1 || got[1].CoCount != 2 {
+		t.Errorf("rows = %+v", rows)
+	}
+	if _, ok := got[4]; ok {
+		t.Error("app without co-downloads should have no neighbors")
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	setupTestDB(t, &project.AppSimilarity{}, &project.DownloadLog{})
+	createApplicationTables(t)
+	global.GVA_DB.Exec(`INSERT INTO applications (id, app_id, app_name, is_hot, status) VALUES
+		(1, 'a1', '微信', 1, 'active'), (2, 'a2', '微信读书', 0, 'active'), (3, 'a3', 'Telegram', 0, 'active'), (4, 'a4', '微信旧版', 0, 'suspended')`)
+	for _, ddl := range []string{
+		"CREATE TABLE orders (id integer PRIMARY KEY, user_id integer, order_type text, status text, account_ids text, paid_at datetime)",
+		"CREATE TABLE app_accounts (id integer PRIMARY KEY, app_id text)",
+	} {
+		if err := global.GVA_DB.Exec(ddl).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { recommendMemCache.entries = nil })
+
+	download := func(user uint, app uint) {
+		global.GVA_DB.Create(&project.DownloadLog{UserID: user, AppID: app, Platform: constants.PlatformAndroid, Success: true, IP: "1.1.1.1", CreatedAt: time.Now()})
+	}
+	download(1, 1)
+	download(1, 2)
+	download(2, 1)
+	download(2, 2)
+	download(3, 3)
+	// 用户 3 购买了应用 1 的账号
+	global.GVA_DB.Exec("INSERT INTO app_accounts (id, app_id) VALUES (7, 'a1')")
+	global.GVA_DB.Exec("INSERT INTO orders (user_id, order_type, status, account_ids, paid_at) VALUES (3, 'account_product', 'paid', '[7]', ?)", time.Now())
+
+	var s RecommendService
+	if err := s.RebuildSimilarity(); err != nil {
+		t.Fatal(err)
+	}
+	appIDs := func(apps []project.Application) []uint64 {
+		ids := make([]uint64, len(apps))
+		for i, app := range apps {
+			ids[i] = app.ID
+		}
+		return ids
+	}
+
+	also, err := s.AlsoDownloaded(1, "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := appIDs(also); !equalUint64s(got, []uint64{2}) {
+		t.Errorf("also downloaded = %v, want [2]", got)
+	}
+
+	// 用户 4 只下载过应用 1，推荐应用 2；未登录时返回人工推荐
+	download(4, 1)
+	forYou, _ := s.ForYou(4, "", 5)
+	if got := appIDs(forYou); !equalUint64s(got, []uint64{2}) {
+		t.Errorf("for you = %v, want [2]", got)
+	}
+	anonymous, _ := s.ForYou(0, "", 5)
+	if got := appIDs(anonymous); !equalUint64s(got, []uint64{1}) {
+		t.Errorf("anonymous = %v, want curated [1]", got)
+	}
+
+	// 新的下载写入后清除缓存，已下载的应用不再推荐
+	if err := insertEvents([]DownloadEvent{{UserID: 4, AppID: 2, Platform: constants.PlatformAndroid, Success: true, IP: "1.1.1.1", CreatedAt: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+	forYou, _ = s.ForYou(4, "", 5)
+	if got := appIDs(forYou); len(got) != 0 {
+		t.Errorf("after download for you = %v, want empty", got)
+	}
+}
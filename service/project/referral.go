@@ -0,0 +1,299 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxReferralDepth 检查推荐关系是否成环时向上查找的最大层数
+	maxReferralDepth          = 100
+	defaultReferralCookieDays = 30
+)
+
+var errInviteCodeInvalid = errors.New("邀请码无效")
+
+// ReferralService 推荐关系：邀请码、推广链接点击归属和管理员修改推荐人
+type ReferralService struct{}
+
+var referralService = ReferralService{}
+
+// referralAttribution 注册时确定的推荐人
+type referralAttribution struct {
+	ReferrerID uint
+	Source     constants.ReferralBindSource
+	ClickID    string
+}
+
+// RecordClick 记录推广链接的点击，返回写入 cookie 的点击ID；channel 用来区分推广员在不同地方投放的链接
+func (s *ReferralService) RecordClick(code, channel, clientIP, userAgent, referer string) (*project.ReferralClick, error) {
+	referrer, err := findReferrerByCode(global.GVA_DB, code)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 16)
+	if _, err = rand.Read(b); err != nil {
+		return nil, err
+	}
+	click := project.ReferralClick{
+		ClickID:      hex.EncodeToString(b),
+		ReferrerID:   referrer.ID,
+		ReferralCode: *referrer.ReferralCode,
+		Channel:      normalizeReferralChannel(channel),
+		IP:           truncateRunes(clientIP, 45),
+		UserAgent:    truncateRunes(userAgent, 255),
+		Referer:      truncateRunes(referer, 255),
+	}
+	if err = global.GVA_DB.Create(&click).Error; err != nil {
+		return nil, err
+	}
+	return &click, nil
+}
+
+// ResolveAttribution 注册时确定推荐人：优先使用填写的邀请码，没有填写时使用有效期内的推广链接点击
+func (s *ReferralService) ResolveAttribution(inviteCode, clickID string) (referralAttribution, error) {
+	var attr referralAttribution
+	inviteCode = strings.TrimSpace(inviteCode)
+	if inviteCode != "" {
+		referrer, err := findReferrerByCode(global.GVA_DB, inviteCode)
+		if err != nil {
+			return attr, err
+		}
+		attr.ReferrerID, attr.Source = referrer.ID, constants.ReferralBindInviteCode
+	}
+	if clickID == "" {
+		return attr, nil
+	}
+	days := global.GVA_CONFIG.Referral.CookieDays
+	if days <= 0 {
+		days = defaultReferralCookieDays
+	}
+	var click project.ReferralClick
+	err := global.GVA_DB.Where("click_id = ? AND registered_user_id IS NULL AND created_at > ?", clickID, time.Now().AddDate(0, 0, -days)).
+		First(&click).Error
+	if err != nil {
+		return attr, nil
+	}
+	// 填写了其它人的邀请码时以邀请码为准，点击记录不归属
+	if attr.ReferrerID == 0 {
+		attr.ReferrerID, attr.Source = click.ReferrerID, constants.ReferralBindLink
+	}
+	if attr.ReferrerID == click.ReferrerID {
+		attr.ClickID = click.ClickID
+	}
+	return attr, nil
+}
+
+// bindOnRegister 在注册事务中记录推荐关系并更新推荐人的统计
+func (s *ReferralService) bindOnRegister(tx *gorm.DB, userID uint, attr referralAttribution) error {
+	if attr.ReferrerID == 0 {
+		return nil
+	}
+	if attr.ClickID != "" {
+		err := tx.Model(&project.ReferralClick{}).Where("click_id = ? AND registered_user_id IS NULL", attr.ClickID).
+			Updates(map[string]interface{}{"registered_user_id": userID, "registered_at": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+	}
+	err := tx.Create(&project.ReferralBindLog{
+		UserID:        userID,
+		NewReferrerID: attr.ReferrerID,
+		Source:        attr.Source,
+		ClickID:       attr.ClickID,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return teamStatService.OnMemberJoined(tx, attr.ReferrerID)
+}
+
+// RebindReferrer 管理员修改用户的推荐人，ReferrerID 为 0 表示解除；不能推荐自己，也不能形成环
+func (s *ReferralService) RebindReferrer(req request.RebindReferrerRequest, operatorID uint, operatorName string) error {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return errors.New("请填写修改原因")
+	}
+	if req.ReferrerID == req.UserID {
+		return errors.New("不能将用户设为自己的推荐人")
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		var user project.User
+		if err := tx.Select("id, referrer_id").Where("id = ?", req.UserID).First(&user).Error; err != nil {
+			return errors.New("用户不存在")
+		}
+		var oldReferrerID uint
+		if user.ReferrerID != nil {
+			oldReferrerID = *user.ReferrerID
+		}
+		if oldReferrerID == req.ReferrerID {
+			return errors.New("推荐人没有变化")
+		}
+		var newReferrer *uint
+		if req.ReferrerID > 0 {
+			var count int64
+			if err := tx.Model(&project.User{}).Where("id = ? AND deleted_at IS NULL", req.ReferrerID).Count(&count).Error; err != nil {
+				global.GVA_LOG.Error("查询推荐人失败!", zap.Error(err))
+				return err
+			}
+			if count == 0 {
+				return errors.New("推荐人不存在")
+			}
+			if err := checkReferralCycle(tx, req.UserID, req.ReferrerID); err != nil {
+				return err
+			}
+			newReferrer = &req.ReferrerID
+		}
+		if err := tx.Model(&project.User{}).Where("id = ?", req.UserID).Update("referrer_id", newReferrer).Error; err != nil {
+			return err
+		}
+		err := tx.Create(&project.ReferralBindLog{
+			UserID:        req.UserID,
+			OldReferrerID: oldReferrerID,
+			NewReferrerID: req.ReferrerID,
+			Source:        constants.ReferralBindAdmin,
+			OperatorID:    operatorID,
+			Operator:      operatorName,
+			Reason:        truncateRunes(reason, 200),
+		}).Error
+		if err != nil {
+			return err
+		}
+		global.GVA_LOG.Info("管理员修改推荐人", zap.Uint("userID", req.UserID), zap.Uint("from", oldReferrerID), zap.Uint("to", req.ReferrerID), zap.Uint("operatorID", operatorID))
+		return recomputeReferralStats(tx, oldReferrerID, req.ReferrerID)
+	})
+}
+
+// GetBindLogs 推荐关系变更记录
+func (s *ReferralService) GetBindLogs(req request.ReferralBindLogRequest) (list []project.ReferralBindLog, total int64, err error) {
+	db := global.GVA_DB.Model(&project.ReferralBindLog{})
+	if req.UserID > 0 {
+		db = db.Where("user_id = ? OR old_referrer_id = ? OR new_referrer_id = ?", req.UserID, req.UserID, req.UserID)
+	}
+	if req.Source != "" {
+		db = db.Where("source = ?", req.Source)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Scopes(req.Paginate()).Order("id desc").Find(&list).Error
+	return list, total, err
+}
+
+func findReferrerByCode(db *gorm.DB, code string) (project.User, error) {
+	var referrer project.User
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return referrer, errInviteCodeInvalid
+	}
+	err := db.Select("id, referral_code").Scopes(WithInviteCode(code)).Where("deleted_at IS NULL").First(&referrer).Error
+	if err != nil || referrer.ReferralCode == nil {
+		return referrer, errInviteCodeInvalid
+	}
+	return referrer, nil
+}
+
+// normalizeReferralChannel 渠道只保留小写字母、数字、下划线和短横线，最长32个字符
+func normalizeReferralChannel(channel string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(channel)) {
+		if b.Len() >= 32 {
+			break
+		}
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// checkReferralCycle 从新推荐人向上查找，遇到用户本人说明会形成环；链路断开时结束，查询出错时返回错误
+func checkReferralCycle(db *gorm.DB, userID, referrerID uint) error {
+	current := referrerID
+	for depth := 0; depth < maxReferralDepth && current > 0; depth++ {
+		if current == userID {
+			return errors.New("该推荐人是此用户的下级，不能形成循环推荐")
+		}
+		var u project.User
+		err := db.Select("id, referrer_id").Where("id = ?", current).First(&u).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			global.GVA_LOG.Error("查询推荐链失败!", zap.Error(err))
+			return err
+		}
+		if u.ReferrerID == nil {
+			return nil
+		}
+		current = *u.ReferrerID
+	}
+	if current > 0 {
+		return errors.New("推荐层级过深")
+	}
+	return nil
+}
+
+// recomputeReferralStats 推荐关系变化后重新统计推荐人的直属下级数据和团队消费，并重新评估等级
+func recomputeReferralStats(tx *gorm.DB, referrerIDs ...uint) error {
+	tiers, err := loadEnabledTiers(tx)
+	if err != nil {
+		return err
+	}
+	today := startOfDay(time.Now())
+	for _, id := range referrerIDs {
+		if id == 0 {
+			continue
+		}
+		var total, todayNew int64
+		var consumption float64
+		members := func() *gorm.DB {
+			return tx.Model(&project.User{}).Where("users.referrer_id = ? AND users.deleted_at IS NULL", id)
+		}
+		if err = members().Count(&total).Error; err != nil {
+			return err
+		}
+		if err = members().Where("users.created_at >= ?", today).Count(&todayNew).Error; err != nil {
+			return err
+		}
+		active, err := countActiveMembers(tx, id)
+		if err != nil {
+			return err
+		}
+		err = tx.Table("orders AS o").Joins("JOIN users u ON u.id = o.user_id").
+			Where("u.referrer_id = ? AND o.status = ?", id, project.OrderStatusPaid).
+			Select("COALESCE(SUM(o.final_amount), 0)").Scan(&consumption).Error
+		if err != nil {
+			return err
+		}
+		if err = ensureStatRows(tx, id); err != nil {
+			return err
+		}
+		if err = tx.Model(&project.UserStatistics{}).Where("user_id = ?", id).Update("successful_referrals", total).Error; err != nil {
+			return err
+		}
+		err = tx.Model(&project.TeamStatistics{}).Where("user_id = ?", id).Updates(map[string]interface{}{
+			"total_members":     total,
+			"today_new":         todayNew,
+			"active_members":    active,
+			"total_consumption": roundMoney(consumption),
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err = evaluateTier(tx, id, tiers, constants.TierChangeRebind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
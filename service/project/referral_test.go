@@ -0,0 +1,125 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	commonReq "ApkAdmin/model/common/request"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestReferralBinding(t *testing.T) {
+	setupTestDB(t, &project.User{}, &project.UserStatistics{}, &project.TeamStatistics{},
+		&project.UserCommissionAccount{}, &project.ReferralClick{}, &project.ReferralBindLog{},
+		&project.CommissionTier{}, &project.TeamTierLog{})
+	err := global.GVA_DB.Exec("CREATE TABLE orders (id integer PRIMARY KEY, user_id integer, status text, final_amount real, paid_at datetime)").Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := func(s string) *string { return &s }
+	global.GVA_DB.Create(&project.User{ID: 1, UUID: uuid.New(), Username: "a", Email: "a@example.com", ReferralCode: code("AAAA1111")})
+	global.GVA_DB.Create(&project.User{ID: 2, UUID: uuid.New(), Username: "b", Email: "b@example.com", ReferralCode: code("BBBB2222")})
+
+	var users UserService
+	var s ReferralService
+	register := func(phone, email, invite, clickID string) project.User {
+		t.Helper()
+		req := request.BaseRegisterRequest{Phone: phone, Email: email, Password: "Pass1234", InviteCode: invite}
+		if err := users.RegisterUser(req, "1.1.1.1", clickID); err != nil {
+			t.Fatalf("register %s: %v", email, err)
+		}
+		var u project.User
+		global.GVA_DB.Where("email = ?", email).First(&u)
+		return u
+	}
+
+	// 邀请码按 referral_code 查找推荐人，无效邀请码拒绝注册
+	if err = users.RegisterUser(request.BaseRegisterRequest{Phone: "13800000009", Email: "x@example.com", Password: "Pass1234", InviteCode: "NOPE"}, "1.1.1.1", ""); err != errInviteCodeInvalid {
+		t.Errorf("invalid invite code err = %v", err)
+	}
+	c := register("13800000001", "c@example.com", "BBBB2222", "")
+	if c.ReferrerID == nil || *c.ReferrerID != 2 {
+		t.Fatalf("referrer of c = %v", c.ReferrerID)
+	}
+	// 没有推荐人时 referrer_id 为空
+	d := register("13800000002", "d@example.com", "", "")
+	if d.ReferrerID != nil {
+		t.Errorf("referrer of d = %v", *d.ReferrerID)
+	}
+
+	// 推广链接：点击后注册归属到链接的推荐人，点击只能归属一次
+	click, err := s.RecordClick("AAAA1111", "forum", "2.2.2.2", "ua", "https://forum.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := register("13800000003", "e@example.com", "", click.ClickID)
+	if e.ReferrerID == nil || *e.ReferrerID != 1 {
+		t.Fatalf("referrer of e = %v", e.ReferrerID)
+	}
+	f := register("13800000004", "f@example.com", "", click.ClickID)
+	if f.ReferrerID != nil {
+		t.Errorf("used click should not attribute f to %d", *f.ReferrerID)
+	}
+	// 超过 cookie 有效期的点击不再归属
+	old, _ := s.RecordClick("AAAA1111", "forum", "2.2.2.2", "ua", "")
+	global.GVA_DB.Model(&project.ReferralClick{}).Where("id = ?", old.ID).Update("created_at", time.Now().AddDate(0, 0, -31))
+	if g := register("13800000005", "g@example.com", "", old.ClickID); g.ReferrerID != nil {
+		t.Errorf("expired click attributed g to %d", *g.ReferrerID)
+	}
+
+	statsOf := func(id uint) (team project.TeamStatistics, stats project.UserStatistics) {
+		global.GVA_DB.Where("user_id = ?", id).First(&team)
+		global.GVA_DB.Where("user_id = ?", id).First(&stats)
+		return
+	}
+	team, stats := statsOf(2)
+	if team.TotalMembers != 1 || team.TodayNew != 1 || stats.SuccessfulReferrals != 1 {
+		t.Errorf("stats of 2: team = %+v, referrals = %d", team, stats.SuccessfulReferrals)
+	}
+
+	// 修改推荐人：不能推荐自己，不能形成环
+	rebind := func(userID, referrerID uint) error {
+		return s.RebindReferrer(request.RebindReferrerRequest{UserID: userID, ReferrerID: referrerID, Reason: "客服核实"}, 9, "admin")
+	}
+	if err = rebind(2, 2); err == nil {
+		t.Error("self referral should be rejected")
+	}
+	if err = rebind(2, c.ID); err == nil {
+		t.Error("cycle should be rejected")
+	}
+	if err = s.RebindReferrer(request.RebindReferrerRequest{UserID: c.ID, ReferrerID: 1}, 9, "admin"); err == nil {
+		t.Error("reason is required")
+	}
+	if err = rebind(c.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	team, stats = statsOf(2)
+	if team.TotalMembers != 0 || stats.SuccessfulReferrals != 0 {
+		t.Errorf("old referrer stats = %+v, referrals = %d", team, stats.SuccessfulReferrals)
+	}
+	team, stats = statsOf(1)
+	if team.TotalMembers != 2 || stats.SuccessfulReferrals != 2 {
+		t.Errorf("new referrer total = %d", team.TotalMembers)
+	}
+
+	logs, total, err := s.GetBindLogs(request.ReferralBindLogRequest{PageInfo: commonReq.PageInfo{Page: 1, PageSize: 20}, UserID: c.ID})
+	if err != nil || total != 2 {
+		t.Fatalf("logs total = %d, err = %v", total, err)
+	}
+	if logs[0].Source != constants.ReferralBindAdmin || logs[0].OldReferrerID != 2 || logs[0].NewReferrerID != 1 || logs[0].OperatorID != 9 {
+		t.Errorf("latest log = %+v", logs[0])
+	}
+	if logs[1].Source != constants.ReferralBindInviteCode {
+		t.Errorf("register log = %+v", logs[1])
+	}
+
+	// 查询推荐链出错时不能当作没有环放行
+	global.GVA_DB.Exec("DROP TABLE users")
+	if err = checkReferralCycle(global.GVA_DB, c.ID, 1); err == nil {
+		t.Error("cycle check should fail when the query fails")
+	}
+}
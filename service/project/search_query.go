@@ -0,0 +1,459 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	projectModel "ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/project/response"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultTrendingHours = 24
+	maxTrendingLimit     = 50
+	trendingCacheTTL     = time.Minute
+	maxSearchQueryRunes  = 100
+	// 搜索词记录队列默认长度和写入协程数
+	defaultSearchQueueSize = 1000
+	defaultSearchWorkers   = 2
+	// searchTrendKeyPrefix 每小时一个有序集合，成员为搜索词，分值为搜索人次
+	searchTrendKeyPrefix = "search:trend:"
+)
+
+type SearchQueryService struct{}
+
+// SearchRecord 一次搜索的信息，在请求处理时生成
+type SearchRecord struct {
+	UserID      uint
+	IP          string
+	CountryCode string
+	Query       string
+	ResultCount int64
+	At          time.Time
+}
+
+// trendingCache 热搜计算结果，运营规则变化时清空
+var trendingCache struct {
+	sync.Mutex
+	expireAt time.Time
+	list     []response.TrendingSearch
+}
+
+// normalizeSearchQuery 搜索词归一化：去掉首尾空白、合并连续空白、英文转小写并截断长度
+func normalizeSearchQuery(q string) string {
+	q = strings.ToLower(strings.Join(strings.Fields(q), " "))
+	if utf8.RuneCountInString(q) > maxSearchQueryRunes {
+		q = string([]rune(q)[:maxSearchQueryRunes])
+	}
+	return q
+}
+
+func trendingHours() int {
+	return positiveOr(global.GVA_CONFIG.Search.TrendingHours, defaultTrendingHours)
+}
+
+func trendKey(hour time.Time) string {
+	return searchTrendKeyPrefix + hour.Format("2006010215")
+}
+
+// searchRecordPipeline 搜索词记录队列：有界队列 + 固定数量的写入协程，队列满时直接丢弃，不影响搜索请求
+type searchRecordPipeline struct {
+	queue   chan SearchRecord
+	dropped atomic.Int64
+
+	mu     sync.RWMutex // 保护 closed，关闭队列前需等待正在入队的请求
+	closed bool
+	wg     sync.WaitGroup
+}
+
+var searchPipeline atomic.Pointer[searchRecordPipeline]
+
+// StartRecorder 启动搜索词记录协程，重复调用无副作用
+func (s *SearchQueryService) StartRecorder() {
+	cfg := global.GVA_CONFIG.Search
+	p := &searchRecordPipeline{queue: make(chan SearchRecord, positiveOr(cfg.QueueSize, defaultSearchQueueSize))}
+	if !searchPipeline.CompareAndSwap(nil, p) {
+		return
+	}
+	workers := positiveOr(cfg.Workers, defaultSearchWorkers)
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer p.wg.Done()
+			for r := range p.queue {
+				s.Record(r)
+			}
+		}()
+	}
+}
+
+// StopRecorder 停止接收新记录并写完队列中的记录，超时后丢弃剩余记录
+func (s *SearchQueryService) StopRecorder(ctx context.Context) error {
+	p := searchPipeline.Load()
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.queue)
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if n := p.dropped.Load(); n > 0 {
+		global.GVA_LOG.Warn("搜索词记录队列已满，部分记录被丢弃", zap.Int64("count", n))
+	}
+	return nil
+}
+
+// Enqueue 投递搜索记录，不阻塞请求；记录协程未启动时直接写入
+func (s *SearchQueryService) Enqueue(r SearchRecord) {
+	p := searchPipeline.Load()
+	if p == nil {
+		s.Record(r)
+		return
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		p.dropped.Add(1)
+		return
+	}
+	select {
+	case p.queue <- r:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+// Record 记录搜索词，同一访客同一小时内相同的搜索词只计一次，有结果的搜索计入热搜
+func (s *SearchQueryService) Record(r SearchRecord) {
+	query := normalizeSearchQuery(r.Query)
+	if query == "" {
+		return
+	}
+	visitor := "ip:" + r.IP
+	if r.UserID > 0 {
+		visitor = "u:" + strconv.FormatUint(uint64(r.UserID), 10)
+	}
+	hour := r.At.Truncate(time.Hour)
+	log := projectModel.SearchQueryLog{
+		VisitorKey:  visitor,
+		HourBucket:  hour,
+		Query:       query,
+		UserID:      r.UserID,
+		CountryCode: r.CountryCode,
+		ResultCount: r.ResultCount,
+		CreatedAt:   r.At,
+	}
+	res := global.GVA_DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&log)
+	if res.Error != nil {
+		global.GVA_LOG.Error("记录搜索词失败!", zap.Error(res.Error))
+		return
+	}
+	if res.RowsAffected == 0 || r.ResultCount == 0 || global.GVA_REDIS == nil {
+		return
+	}
+	ctx := context.Background()
+	key := trendKey(hour)
+	pipe := global.GVA_REDIS.TxPipeline()
+	pipe.ZIncrBy(ctx, key, 1, query)
+	pipe.ExpireAt(ctx, key, hour.Add(time.Duration(trendingHours()+1)*time.Hour))
+	if _, err := pipe.Exec(ctx); err != nil {
+		global.GVA_LOG.Error("更新热搜计数失败!", zap.Error(err))
+	}
+}
+
+// Trending 热搜词：置顶词在前，其余按滑动窗口内的搜索人次排序，屏蔽词不出现
+func (s *SearchQueryService) Trending(limit int) ([]response.TrendingSearch, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	limit = min(limit, maxTrendingLimit)
+
+	trendingCache.Lock()
+	defer trendingCache.Unlock()
+	if time.Now().After(trendingCache.expireAt) {
+		list, err := s.computeTrending()
+		if err != nil {
+			return nil, err
+		}
+		trendingCache.list = list
+		trendingCache.expireAt = time.Now().Add(trendingCacheTTL)
+	}
+	list := trendingCache.list
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func invalidateTrending() {
+	trendingCache.Lock()
+	trendingCache.expireAt = time.Time{}
+	trendingCache.Unlock()
+}
+
+func (s *SearchQueryService) computeTrending() ([]response.TrendingSearch, error) {
+	var rules []projectModel.SearchTermRule
+	if err := global.GVA_DB.Order("sort_order desc, id asc").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	list := make([]response.TrendingSearch, 0, maxTrendingLimit)
+	pinned := make(map[string]bool)
+	var blocked []string
+	for _, rule := range rules {
+		switch rule.Action {
+		case constants.SearchTermPin:
+			if len(list) < maxTrendingLimit {
+				list = append(list, response.TrendingSearch{Term: rule.Term, Pinned: true})
+			}
+			pinned[rule.Term] = true
+		case constants.SearchTermBlock:
+			blocked = append(blocked, rule.Term)
+		}
+	}
+
+	// 多取一些，过滤屏蔽词和置顶词后仍能凑够数量
+	top, err := s.topQueries(maxTrendingLimit + len(rules))
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range top {
+		if len(list) >= maxTrendingLimit {
+			break
+		}
+		if !pinned[t.Term] && !containsAny(t.Term, blocked) {
+			list = append(list, t)
+		}
+	}
+	return list, nil
+}
+
+// containsAny 屏蔽词命中搜索词的任意部分即过滤
+func containsAny(term string, blocked []string) bool {
+	for _, b := range blocked {
+		if strings.Contains(term, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// topQueries 滑动窗口内搜索人次最多的词，优先读 Redis，不可用时从数据库统计
+func (s *SearchQueryService) topQueries(n int) ([]response.TrendingSearch, error) {
+	now := time.Now()
+	if global.GVA_REDIS != nil {
+		list, err := s.topQueriesFromRedis(now, n)
+		if err == nil {
+			return list, nil
+		}
+		global.GVA_LOG.Error("从Redis读取热搜失败，改为数据库统计!", zap.Error(err))
+	}
+	var list []response.TrendingSearch
+	err := global.GVA_DB.Model(&projectModel.SearchQueryLog{}).
+		Select("query AS term, COUNT(*) AS score").
+		Where("hour_bucket >= ? AND result_count > 0", now.Truncate(time.Hour).Add(-time.Duration(trendingHours()-1)*time.Hour)).
+		Group("query").
+		Order("score DESC").
+		Limit(n).
+		Scan(&list).Error
+	return list, err
+}
+
+func (s *SearchQueryService) topQueriesFromRedis(now time.Time, n int) ([]response.TrendingSearch, error) {
+	hour := now.Truncate(time.Hour)
+	keys := make([]string, trendingHours())
+	for i := range keys {
+		keys[i] = trendKey(hour.Add(-time.Duration(i) * time.Hour))
+	}
+	ctx := context.Background()
+	dest := searchTrendKeyPrefix + "window"
+	pipe := global.GVA_REDIS.TxPipeline()
+	pipe.ZUnionStore(ctx, dest, &redis.ZStore{Keys: keys})
+	pipe.Expire(ctx, dest, trendingCacheTTL)
+	rangeCmd := pipe.ZRevRangeWithScores(ctx, dest, 0, int64(n-1))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	zs := rangeCmd.Val()
+	list := make([]response.TrendingSearch, 0, len(zs))
+	for _, z := range zs {
+		term, _ := z.Member.(string)
+		list = append(list, response.TrendingSearch{Term: term, Score: int64(z.Score)})
+	}
+	return list, nil
+}
+
+// ==================== 运营管理 ====================
+
+// CreateTermRule 新增热搜词置顶或屏蔽规则
+func (s *SearchQueryService) CreateTermRule(req request.SearchTermRuleCreateRequest) error {
+	rule := projectModel.SearchTermRule{
+		Term:      normalizeSearchQuery(req.Term),
+		Action:    req.Action,
+		SortOrder: req.SortOrder,
+		Remark:    req.Remark,
+	}
+	if rule.Term == "" {
+		return errors.New("搜索词不能为空")
+	}
+	if err := s.checkTermUnique(rule.Term, 0); err != nil {
+		return err
+	}
+	if err := global.GVA_DB.Create(&rule).Error; err != nil {
+		return err
+	}
+	invalidateTrending()
+	return nil
+}
+
+// UpdateTermRule 编辑热搜词规则
+func (s *SearchQueryService) UpdateTermRule(req request.SearchTermRuleUpdateRequest) error {
+	term := normalizeSearchQuery(req.Term)
+	if term == "" {
+		return errors.New("搜索词不能为空")
+	}
+	if err := s.checkTermUnique(term, req.ID); err != nil {
+		return err
+	}
+	res := global.GVA_DB.Model(&projectModel.SearchTermRule{}).Where("id = ?", req.ID).Updates(map[string]interface{}{
+		"term":       term,
+		"action":     req.Action,
+		"sort_order": req.SortOrder,
+		"remark":     req.Remark,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("规则不存在")
+	}
+	invalidateTrending()
+	return nil
+}
+
+func (s *SearchQueryService) checkTermUnique(term string, excludeID uint) error {
+	var count int64
+	err := global.GVA_DB.Model(&projectModel.SearchTermRule{}).Where("term = ? AND id != ?", term, excludeID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("该搜索词已配置规则")
+	}
+	return nil
+}
+
+// DeleteTermRule 删除热搜词规则
+func (s *SearchQueryService) DeleteTermRule(id uint) error {
+	if err := global.GVA_DB.Delete(&projectModel.SearchTermRule{}, id).Error; err != nil {
+		return err
+	}
+	invalidateTrending()
+	return nil
+}
+
+// GetTermRuleList 热搜词规则列表
+func (s *SearchQueryService) GetTermRuleList(req request.SearchTermRuleListRequest) (list []projectModel.SearchTermRule, total int64, err error) {
+	db := global.GVA_DB.Model(&projectModel.SearchTermRule{})
+	if req.Action != "" {
+		db = db.Where("action = ?", req.Action)
+	}
+	if req.Keyword != "" {
+		db = db.Where("term LIKE ?", "%"+req.Keyword+"%")
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Scopes(req.Paginate()).Order("action asc, sort_order desc, id desc").Find(&list).Error
+	return list, total, err
+}
+
+// GetZeroResultQueries 统计没有搜索结果的词，按搜索人次排序，供运营补充应用
+func (s *SearchQueryService) GetZeroResultQueries(req request.ZeroResultQueryRequest) (list []response.ZeroResultQuery, total int64, err error) {
+	start, end, err := req.Range()
+	if err != nil {
+		return nil, 0, err
+	}
+	filtered := func() *gorm.DB {
+		db := global.GVA_DB.Model(&projectModel.SearchQueryLog{}).
+			Where("hour_bucket >= ? AND hour_bucket < ? AND result_count = 0", start, end)
+		if req.Keyword != "" {
+			db = db.Where("query LIKE ?", "%"+req.Keyword+"%")
+		}
+		return db
+	}
+	if err = filtered().Distinct("query").Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []struct {
+		Query          string
+		SearchCount    int64
+		Visitors       int64
+		LastSearchedAt string
+	}
+	err = filtered().
+		Select("query, COUNT(*) AS search_count, COUNT(DISTINCT visitor_key) AS visitors, MAX(created_at) AS last_searched_at").
+		Group("query").
+		Order("search_count DESC, query ASC").
+		Scopes(req.Paginate()).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	list = make([]response.ZeroResultQuery, 0, len(rows))
+	for _, r := range rows {
+		list = append(list, response.ZeroResultQuery{
+			Query:          r.Query,
+			SearchCount:    r.SearchCount,
+			Visitors:       r.Visitors,
+			LastSearchedAt: parseDBTime(r.LastSearchedAt),
+		})
+	}
+	return list, total, nil
+}
+
+// PruneLogs 清理超过保留天数的搜索词记录
+func (s *SearchQueryService) PruneLogs(days int) error {
+	if days <= 0 {
+		return nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+	return global.GVA_DB.Where("hour_bucket < ?", cutoff).Delete(&projectModel.SearchQueryLog{}).Error
+}
+
+// parseDBTime 解析聚合函数返回的时间，不同驱动下可能是字符串
+func parseDBTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,106 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/common/request"
+	projectModel "ApkAdmin/model/project"
+	request2 "ApkAdmin/model/project/request"
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSearchQueryTrending(t *testing.T) {
+	setupTestDB(t, &projectModel.SearchQueryLog{}, &projectModel.SearchTermRule{})
+	t.Cleanup(invalidateTrending)
+	var s SearchQueryService
+	now := time.Now()
+	record := func(user uint, ip, query string, results int64) {
+		s.Record(SearchRecord{UserID: user, IP: ip, Query: query, ResultCount: results, At: now})
+	}
+	// 同一访客同一小时重复搜索只计一次，大小写和空白归一化
+	record(1, "", "微信", 3)
+	record(1, "", " 微信 ", 3)
+	record(2, "", "微信", 3)
+	record(0, "1.1.1.1", "Tele Gram", 1)
+	record(0, "1.1.1.1", "tele   gram", 1)
+	record(0, "2.2.2.2", "赌博软件", 2)
+	record(0, "2.2.2.2", "抖音极速版", 0)
+	record(0, "3.3.3.3", "抖音极速版", 0)
+
+	var count int64
+	global.GVA_DB.Model(&projectModel.SearchQueryLog{}).Count(&count)
+	if count != 6 {
+		t.Fatalf("log rows = %d, want 6", count)
+	}
+
+	_ = s.CreateTermRule(request2.SearchTermRuleCreateRequest{Term: "赌博", Action: constants.SearchTermBlock})
+	_ = s.CreateTermRule(request2.SearchTermRuleCreateRequest{Term: "王者荣耀", Action: constants.SearchTermPin})
+	if err := s.CreateTermRule(request2.SearchTermRuleCreateRequest{Term: "王者荣耀", Action: constants.SearchTermBlock}); err == nil {
+		t.Error("duplicate term rule should fail")
+	}
+
+	list, err := s.Trending(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var terms []string
+	for _, item := range list {
+		terms = append(terms, item.Term)
+	}
+	want := []string{"王者荣耀", "微信", "tele gram"}
+	if len(terms) != len(want) || terms[0] != want[0] || terms[1] != want[1] || terms[2] != want[2] || !list[0].Pinned || list[1].Score != 2 {
+		t.Errorf("trending = %+v, want terms %v", list, want)
+	}
+
+	zero, total, err := s.GetZeroResultQueries(request2.ZeroResultQueryRequest{PageInfo: request.PageInfo{Page: 1, PageSize: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(zero) != 1 || zero[0].Query != "抖音极速版" || zero[0].Visitors != 2 || zero[0].LastSearchedAt.IsZero() {
+		t.Errorf("zero result queries = %+v, total = %d", zero, total)
+	}
+}
+
+func TestSearchRecordPipeline(t *testing.T) {
+	setupTestDB(t, &projectModel.SearchQueryLog{})
+	var s SearchQueryService
+	t.Cleanup(func() { searchPipeline.Store(nil) })
+	now := time.Now()
+	record := func(i int) SearchRecord {
+		return SearchRecord{IP: "1.1.1.1", Query: "q" + strconv.Itoa(i), At: now}
+	}
+
+	// 队列满时丢弃，不阻塞请求
+	p := &searchRecordPipeline{queue: make(chan SearchRecord, 1)}
+	searchPipeline.Store(p)
+	s.Enqueue(record(0))
+	s.Enqueue(record(1))
+	if len(p.queue) != 1 || p.dropped.Load() != 1 {
+		t.Fatalf("queue len = %d, dropped = %d", len(p.queue), p.dropped.Load())
+	}
+	searchPipeline.Store(nil)
+
+	saved := global.GVA_CONFIG.Search
+	t.Cleanup(func() { global.GVA_CONFIG.Search = saved })
+	global.GVA_CONFIG.Search.QueueSize = 100
+	global.GVA_CONFIG.Search.Workers = 2
+	s.StartRecorder()
+	for i := 0; i < 50; i++ {
+		s.Enqueue(record(i))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.StopRecorder(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// 关闭后写完队列中的记录，新的记录丢弃
+	s.Enqueue(record(99))
+	var count int64
+	global.GVA_DB.Model(&projectModel.SearchQueryLog{}).Count(&count)
+	if count != 50 {
+		t.Errorf("log rows after stop = %d, want 50", count)
+	}
+}
@@ -0,0 +1,461 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// teamStatBatchSize 全量重算和清零时每批处理的用户数，避免长时间锁表
+const teamStatBatchSize = 500
+
+// TeamStatService 推广统计：注册、支付、退款时增量更新计数，每晚按用户分批全量重算修正偏差
+type TeamStatService struct{}
+
+var teamStatService = TeamStatService{}
+
+// ==================== 事件 ====================
+
+// OnMemberJoined 新用户绑定推荐人后，推荐人的下级人数加一并重新评估等级，在注册事务中调用
+func (s *TeamStatService) OnMemberJoined(tx *gorm.DB, referrerID uint) error {
+	if err := ensureStatRows(tx, referrerID); err != nil {
+		return err
+	}
+	err := tx.Model(&project.UserStatistics{}).Where("user_id = ?", referrerID).
+		UpdateColumn("successful_referrals", gorm.Expr("successful_referrals + 1")).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Model(&project.TeamStatistics{}).Where("user_id = ?", referrerID).Updates(map[string]interface{}{
+		"total_members": gorm.Expr("total_members + 1"),
+		"today_new":     gorm.Expr("today_new + 1"),
+	}).Error
+	if err != nil {
+		return err
+	}
+	tiers, err := loadEnabledTiers(tx)
+	if err != nil {
+		return err
+	}
+	return evaluateTier(tx, referrerID, tiers, constants.TierChangeRegister)
+}
+
+// OnOrderPaid 订单变为已支付后更新买家的消费统计和推荐人的团队消费，在修改订单状态的事务中调用
+func (s *TeamStatService) OnOrderPaid(tx *gorm.DB, userID uint, amount float64, paidAt time.Time) error {
+	if err := ensureStatRows(tx, userID); err != nil {
+		return err
+	}
+	err := tx.Model(&project.UserStatistics{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+		"total_spent":  gorm.Expr("total_spent + ?", amount),
+		"total_orders": gorm.Expr("total_orders + 1"),
+	}).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Model(&project.UserStatistics{}).Where("user_id = ? AND (last_order_at IS NULL OR last_order_at < ?)", userID, paidAt).
+		Update("last_order_at", paidAt).Error
+	if err != nil {
+		return err
+	}
+	return s.updateReferrerConsumption(tx, userID, amount)
+}
+
+// OnOrderRefunded 订单退款后扣回买家的消费统计和推荐人的团队消费，需在订单状态改为已退款之后调用
+func (s *TeamStatService) OnOrderRefunded(tx *gorm.DB, userID uint, amount float64) error {
+	if err := ensureStatRows(tx, userID); err != nil {
+		return err
+	}
+	var last struct {
+		PaidAt *time.Time
+	}
+	err := tx.Model(&project.Order{}).Select("paid_at").
+		Where("user_id = ? AND status = ? AND paid_at IS NOT NULL", userID, project.OrderStatusPaid).
+		Order("paid_at DESC").Limit(1).Scan(&last).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Model(&project.UserStatistics{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+		"total_spent":   gorm.Expr("CASE WHEN total_spent > ? THEN total_spent - ? ELSE 0 END", amount, amount),
+		"total_orders":  gorm.Expr("CASE WHEN total_orders > 0 THEN total_orders - 1 ELSE 0 END"),
+		"last_order_at": last.PaidAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return s.updateReferrerConsumption(tx, userID, -amount)
+}
+
+// updateReferrerConsumption 调整买家推荐人的团队消费，并重新统计活跃下级
+func (s *TeamStatService) updateReferrerConsumption(tx *gorm.DB, userID uint, delta float64) error {
+	var user project.User
+	if err := tx.Select("id, referrer_id").Where("id = ?", userID).First(&user).Error; err != nil || user.ReferrerID == nil {
+		return nil
+	}
+	referrerID := *user.ReferrerID
+	if err := ensureStatRows(tx, referrerID); err != nil {
+		return err
+	}
+	err := tx.Model(&project.TeamStatistics{}).Where("user_id = ?", referrerID).
+		Update("total_consumption", gorm.Expr("CASE WHEN total_consumption + ? > 0 THEN total_consumption + ? ELSE 0 END", delta, delta)).Error
+	if err != nil {
+		return err
+	}
+	active, err := countActiveMembers(tx, referrerID)
+	if err != nil {
+		return err
+	}
+	return tx.Model(&project.TeamStatistics{}).Where("user_id = ?", referrerID).Update("active_members", active).Error
+}
+
+// ==================== 定时任务 ====================
+
+// ResetDailyCounters 零点清空今日新增，按主键分批更新
+func (s *TeamStatService) ResetDailyCounters() error {
+	for {
+		var ids []int64
+		err := global.GVA_DB.Model(&project.TeamStatistics{}).Where("today_new <> 0").
+			Limit(teamStatBatchSize).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		if err = global.GVA_DB.Model(&project.TeamStatistics{}).Where("id IN ?", ids).Update("today_new", 0).Error; err != nil {
+			return err
+		}
+	}
+}
+
+// RecomputeAll 按用户ID分批全量重算用户统计和团队统计，只更新有偏差的记录，并重新评估等级
+func (s *TeamStatService) RecomputeAll() error {
+	begin := time.Now()
+	tiers, err := loadEnabledTiers(global.GVA_DB)
+	if err != nil {
+		return err
+	}
+	// 活跃下级依赖下级自己的最后消费时间，先算完所有用户的消费统计
+	userFixed, err := eachUserBatch(s.recomputeUserBatch)
+	if err != nil {
+		return err
+	}
+	teamFixed, err := eachUserBatch(func(ids []uint) (int, error) {
+		return s.recomputeTeamBatch(ids, tiers)
+	})
+	if err != nil {
+		return err
+	}
+	global.GVA_LOG.Info("推广统计重算完成", zap.Int("userStatsFixed", userFixed), zap.Int("teamStatsFixed", teamFixed),
+		zap.Duration("cost", time.Since(begin)))
+	return nil
+}
+
+// eachUserBatch 按主键顺序分批取用户ID，返回各批修正的记录数之和
+func eachUserBatch(fn func(ids []uint) (int, error)) (int, error) {
+	var lastID uint
+	total := 0
+	for {
+		var ids []uint
+		err := global.GVA_DB.Model(&project.User{}).Where("id > ?", lastID).
+			Order("id").Limit(teamStatBatchSize).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return total, err
+		}
+		n, err := fn(ids)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		lastID = ids[len(ids)-1]
+	}
+}
+
+// recomputeUserBatch 重算一批用户的消费金额、订单数和最后消费时间
+func (s *TeamStatService) recomputeUserBatch(ids []uint) (int, error) {
+	var spends []struct {
+		UserID uint
+		Total  float64
+		Orders uint
+	}
+	err := global.GVA_DB.Model(&project.Order{}).
+		Select("user_id, SUM(final_amount) AS total, COUNT(*) AS orders").
+		Where("status = ? AND user_id IN ?", project.OrderStatusPaid, ids).
+		Group("user_id").Scan(&spends).Error
+	if err != nil {
+		return 0, err
+	}
+	// 取每个用户最近一笔已支付订单，不用 MAX 以便各驱动都能扫描为时间类型
+	var lasts []struct {
+		UserID uint
+		PaidAt *time.Time
+	}
+	err = global.GVA_DB.Table("orders AS o").Select("o.user_id, o.paid_at").
+		Where("o.status = ? AND o.user_id IN ? AND o.paid_at IS NOT NULL", project.OrderStatusPaid, ids).
+		Where("NOT EXISTS (SELECT 1 FROM orders o2 WHERE o2.user_id = o.user_id AND o2.status = ? AND o2.paid_at > o.paid_at)", project.OrderStatusPaid).
+		Scan(&lasts).Error
+	if err != nil {
+		return 0, err
+	}
+	var existing []project.UserStatistics
+	if err = global.GVA_DB.Where("user_id IN ?", ids).Find(&existing).Error; err != nil {
+		return 0, err
+	}
+
+	want := make(map[uint]*project.UserStatistics, len(ids))
+	for _, id := range ids {
+		want[id] = &project.UserStatistics{UserID: id}
+	}
+	for _, r := range spends {
+		want[r.UserID].TotalSpent, want[r.UserID].TotalOrders = roundMoney(r.Total), r.Orders
+	}
+	for _, r := range lasts {
+		want[r.UserID].LastOrderAt = r.PaidAt
+	}
+	current := make(map[uint]project.UserStatistics, len(existing))
+	for _, st := range existing {
+		current[st.UserID] = st
+	}
+
+	fixed := 0
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			w := want[id]
+			cur, ok := current[id]
+			if !ok {
+				fixed++
+				if err := tx.Create(w).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if roundMoney(cur.TotalSpent) == w.TotalSpent && cur.TotalOrders == w.TotalOrders && sameTime(cur.LastOrderAt, w.LastOrderAt) {
+				continue
+			}
+			fixed++
+			err := tx.Model(&project.UserStatistics{}).Where("user_id = ?", id).Updates(map[string]interface{}{
+				"total_spent":   w.TotalSpent,
+				"total_orders":  w.TotalOrders,
+				"last_order_at": w.LastOrderAt,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return fixed, err
+}
+
+// recomputeTeamBatch 重算一批推荐人的直属下级人数、今日新增、活跃人数、团队消费和佣金，并重新评估等级
+func (s *TeamStatService) recomputeTeamBatch(ids []uint, tiers []project.CommissionTier) (int, error) {
+	now := time.Now()
+	type memberRow struct {
+		UserID   uint
+		Total    int
+		TodayNew int
+	}
+	type amountRow struct {
+		UserID uint
+		Amount float64
+	}
+	var members []memberRow
+	err := global.GVA_DB.Model(&project.User{}).
+		Select("referrer_id AS user_id, COUNT(*) AS total, SUM(CASE WHEN created_at >= ? THEN 1 ELSE 0 END) AS today_new", startOfDay(now)).
+		Where("referrer_id IN ? AND deleted_at IS NULL", ids).
+		Group("referrer_id").Scan(&members).Error
+	if err != nil {
+		return 0, err
+	}
+	var actives []memberRow
+	err = global.GVA_DB.Table("users AS u").Joins("JOIN user_statistics us ON us.user_id = u.id").
+		Select("u.referrer_id AS user_id, COUNT(*) AS total").
+		Where("u.referrer_id IN ? AND u.deleted_at IS NULL AND us.last_order_at >= ?", ids, now.AddDate(0, 0, -activeMemberDays)).
+		Group("u.referrer_id").Scan(&actives).Error
+	if err != nil {
+		return 0, err
+	}
+	var consumptions []amountRow
+	err = global.GVA_DB.Table("orders AS o").Joins("JOIN users u ON u.id = o.user_id").
+		Select("u.referrer_id AS user_id, SUM(o.final_amount) AS amount").
+		Where("u.referrer_id IN ? AND o.status = ?", ids, project.OrderStatusPaid).
+		Group("u.referrer_id").Scan(&consumptions).Error
+	if err != nil {
+		return 0, err
+	}
+	var commissions []amountRow
+	err = global.GVA_DB.Model(&project.CommissionDetail{}).
+		Select("user_id, SUM(commission) AS amount").
+		Where("user_id IN ? AND status <> ?", ids, "frozen").
+		Group("user_id").Scan(&commissions).Error
+	if err != nil {
+		return 0, err
+	}
+	var teams []project.TeamStatistics
+	if err = global.GVA_DB.Where("user_id IN ?", ids).Find(&teams).Error; err != nil {
+		return 0, err
+	}
+	var userStats []project.UserStatistics
+	if err = global.GVA_DB.Select("user_id, successful_referrals").Where("user_id IN ?", ids).Find(&userStats).Error; err != nil {
+		return 0, err
+	}
+
+	want := make(map[uint]*project.TeamStatistics, len(ids))
+	for _, id := range ids {
+		want[id] = &project.TeamStatistics{UserID: int64(id)}
+	}
+	for _, r := range members {
+		want[r.UserID].TotalMembers, want[r.UserID].TodayNew = r.Total, r.TodayNew
+	}
+	for _, r := range actives {
+		want[r.UserID].ActiveMembers = r.Total
+	}
+	for _, r := range consumptions {
+		want[r.UserID].TotalConsumption = roundMoney(r.Amount)
+	}
+	for _, r := range commissions {
+		want[r.UserID].TotalCommission = roundMoney(r.Amount)
+	}
+	current := make(map[uint]project.TeamStatistics, len(teams))
+	for _, t := range teams {
+		current[uint(t.UserID)] = t
+	}
+	referrals := make(map[uint]uint, len(userStats))
+	for _, st := range userStats {
+		referrals[st.UserID] = st.SuccessfulReferrals
+	}
+
+	fixed := 0
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			w := want[id]
+			cur, ok := current[id]
+			if !ok {
+				fixed++
+				if err := tx.Create(w).Error; err != nil {
+					return err
+				}
+			} else if cur.TotalMembers != w.TotalMembers || cur.TodayNew != w.TodayNew || cur.ActiveMembers != w.ActiveMembers ||
+				roundMoney(cur.TotalConsumption) != w.TotalConsumption || roundMoney(cur.TotalCommission) != w.TotalCommission {
+				fixed++
+				err := tx.Model(&project.TeamStatistics{}).Where("user_id = ?", id).Updates(map[string]interface{}{
+					"total_members":     w.TotalMembers,
+					"today_new":         w.TodayNew,
+					"active_members":    w.ActiveMembers,
+					"total_consumption": w.TotalConsumption,
+					"total_commission":  w.TotalCommission,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			if n, ok := referrals[id]; ok && n != uint(w.TotalMembers) {
+				err := tx.Model(&project.UserStatistics{}).Where("user_id = ?", id).Update("successful_referrals", w.TotalMembers).Error
+				if err != nil {
+					return err
+				}
+			}
+			if err := applyTier(tx, id, cur.CurrentTierID, w.TotalMembers, tiers, constants.TierChangeRecompute); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return fixed, err
+}
+
+// ==================== 等级 ====================
+
+// loadEnabledTiers 启用的等级，按直属下级人数要求从低到高
+func loadEnabledTiers(db *gorm.DB) ([]project.CommissionTier, error) {
+	var tiers []project.CommissionTier
+	err := db.Where("status = ?", 1).Order("min_subordinates ASC").Find(&tiers).Error
+	return tiers, err
+}
+
+// matchTier 直属下级人数达到要求的最高等级，一个都没达到时为空
+func matchTier(tiers []project.CommissionTier, members int) *project.CommissionTier {
+	var matched *project.CommissionTier
+	for i := range tiers {
+		if tiers[i].MinSubordinates <= members {
+			matched = &tiers[i]
+		}
+	}
+	return matched
+}
+
+// evaluateTier 读取当前团队统计后重新评估等级
+func evaluateTier(tx *gorm.DB, userID uint, tiers []project.CommissionTier, source constants.TierChangeSource) error {
+	var team project.TeamStatistics
+	if err := tx.Where("user_id = ?", userID).First(&team).Error; err != nil {
+		return err
+	}
+	return applyTier(tx, userID, team.CurrentTierID, team.TotalMembers, tiers, source)
+}
+
+// applyTier 等级变化时更新团队统计的当前等级并记录升降级
+func applyTier(tx *gorm.DB, userID uint, currentTierID *int, members int, tiers []project.CommissionTier, source constants.TierChangeSource) error {
+	target := matchTier(tiers, members)
+	var targetID *int
+	if target != nil {
+		targetID = &target.ID
+	}
+	if currentTierID == nil && targetID == nil || currentTierID != nil && targetID != nil && *currentTierID == *targetID {
+		return nil
+	}
+	if err := tx.Model(&project.TeamStatistics{}).Where("user_id = ?", userID).Update("current_tier_id", targetID).Error; err != nil {
+		return err
+	}
+	log := project.TeamTierLog{
+		UserID:       userID,
+		FromTierID:   currentTierID,
+		ToTierID:     targetID,
+		TotalMembers: members,
+		Source:       source,
+	}
+	// 原等级可能已被停用，不在启用列表里时单独查询
+	fromMin := -1
+	if currentTierID != nil {
+		var from project.CommissionTier
+		if err := tx.Select("id, name, min_subordinates").Where("id = ?", *currentTierID).First(&from).Error; err == nil {
+			log.FromTierName, fromMin = from.Name, from.MinSubordinates
+		}
+	}
+	if target != nil {
+		log.ToTierName = target.Name
+		log.Upgrade = target.MinSubordinates > fromMin
+	}
+	return tx.Create(&log).Error
+}
+
+// ==================== 工具 ====================
+
+// ensureStatRows 确保用户统计和团队统计记录存在
+func ensureStatRows(tx *gorm.DB, userID uint) error {
+	if err := tx.FirstOrCreate(&project.UserStatistics{UserID: userID}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).FirstOrCreate(&project.TeamStatistics{UserID: int64(userID)}).Error
+}
+
+// countActiveMembers 近 activeMemberDays 天有消费的直属下级人数
+func countActiveMembers(tx *gorm.DB, referrerID uint) (int64, error) {
+	var active int64
+	err := tx.Model(&project.User{}).Joins("JOIN user_statistics us ON us.user_id = users.id").
+		Where("users.referrer_id = ? AND users.deleted_at IS NULL AND us.last_order_at >= ?", referrerID, time.Now().AddDate(0, 0, -activeMemberDays)).
+		Count(&active).Error
+	return active, err
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// sameTime 按秒比较，数据库存储的精度可能低于内存中的时间
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Truncate(time.Second).Equal(b.Truncate(time.Second))
+}
@@ -0,0 +1,130 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTeamStatistics(t *testing.T) {
+	setupTestDB(t, &project.User{}, &project.UserStatistics{}, &project.TeamStatistics{},
+		&project.CommissionTier{}, &project.CommissionDetail{}, &project.TeamTierLog{})
+	err := global.GVA_DB.Exec("CREATE TABLE orders (id integer PRIMARY KEY, user_id integer, status text, final_amount real, paid_at datetime)").Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := 1
+	global.GVA_DB.Create(&[]project.CommissionTier{
+		{ID: 1, Name: "青铜", MinSubordinates: 0, Rate: 5, Status: &status},
+		{ID: 2, Name: "白银", MinSubordinates: 2, Rate: 8, Status: &status},
+	})
+	promoter := uint(1)
+	global.GVA_DB.Create(&project.User{ID: 1, UUID: uuid.New(), Username: "boss", Email: "boss@example.com"})
+	global.GVA_DB.Create(&project.User{ID: 2, UUID: uuid.New(), Username: "alice", Email: "alice@example.com", ReferrerID: &promoter})
+	global.GVA_DB.Create(&project.User{ID: 3, UUID: uuid.New(), Username: "bob", Email: "bob@example.com", ReferrerID: &promoter})
+	team := func() (team project.TeamStatistics) {
+		global.GVA_DB.Where("user_id = ?", 1).First(&team)
+		return
+	}
+	userStat := func(id uint) (st project.UserStatistics) {
+		global.GVA_DB.Where("user_id = ?", id).First(&st)
+		return
+	}
+
+	var s TeamStatService
+	for i := 0; i < 2; i++ {
+		if err = s.OnMemberJoined(global.GVA_DB, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ts := team(); ts.TotalMembers != 2 || ts.TodayNew != 2 || ts.CurrentTierID == nil || *ts.CurrentTierID != 2 {
+		t.Fatalf("after joins team = %+v", ts)
+	}
+	if n := userStat(1).SuccessfulReferrals; n != 2 {
+		t.Errorf("successful referrals = %d", n)
+	}
+
+	// 支付和退款
+	paidAt := time.Now().Add(-time.Hour)
+	global.GVA_DB.Exec("INSERT INTO orders (id, user_id, status, final_amount, paid_at) VALUES (1, 2, 'paid', 10, ?)", paidAt)
+	if err = s.OnOrderPaid(global.GVA_DB, 2, 10, paidAt); err != nil {
+		t.Fatal(err)
+	}
+	if st := userStat(2); st.TotalSpent != 10 || st.TotalOrders != 1 || st.LastOrderAt == nil {
+		t.Errorf("buyer after paid = %+v", st)
+	}
+	if ts := team(); ts.TotalConsumption != 10 || ts.ActiveMembers != 1 {
+		t.Errorf("team after paid = %+v", ts)
+	}
+	global.GVA_DB.Exec("UPDATE orders SET status = 'refunded' WHERE id = 1")
+	if err = s.OnOrderRefunded(global.GVA_DB, 2, 10); err != nil {
+		t.Fatal(err)
+	}
+	if st := userStat(2); st.TotalSpent != 0 || st.TotalOrders != 0 || st.LastOrderAt != nil {
+		t.Errorf("buyer after refund = %+v", st)
+	}
+	if ts := team(); ts.TotalConsumption != 0 || ts.ActiveMembers != 0 {
+		t.Errorf("team after refund = %+v", ts)
+	}
+
+	// 制造偏差：计数被改坏、订单没有经过事件、等级要求提高
+	global.GVA_DB.Model(&project.TeamStatistics{}).Where("user_id = ?", 1).Updates(map[string]interface{}{"total_members": 99, "today_new": 5})
+	global.GVA_DB.Model(&project.UserStatistics{}).Where("user_id = ?", 2).Update("total_spent", 500)
+	global.GVA_DB.Exec("INSERT INTO orders (id, user_id, status, final_amount, paid_at) VALUES (2, 3, 'paid', 25.5, ?)", paidAt)
+	global.GVA_DB.Create(&project.CommissionDetail{UserId: 1, OrderId: 2, OrderNo: "o2", OrderUserId: 3, OrderAmount: 25.5, CommissionRate: 0.1, Commission: 2.55, Status: "pending"})
+	global.GVA_DB.Model(&project.CommissionTier{}).Where("id = ?", 2).Update("min_subordinates", 3)
+
+	if err = s.RecomputeAll(); err != nil {
+		t.Fatal(err)
+	}
+	ts := team()
+	if ts.TotalMembers != 2 || ts.TodayNew != 2 || ts.ActiveMembers != 1 || ts.TotalConsumption != 25.5 || ts.TotalCommission != 2.55 {
+		t.Errorf("team after recompute = %+v", ts)
+	}
+	if ts.CurrentTierID == nil || *ts.CurrentTierID != 1 {
+		t.Errorf("tier after recompute = %v", ts.CurrentTierID)
+	}
+	if st := userStat(2); st.TotalSpent != 0 {
+		t.Errorf("buyer 2 after recompute = %+v", st)
+	}
+	if st := userStat(3); st.TotalSpent != 25.5 || st.TotalOrders != 1 || st.LastOrderAt == nil {
+		t.Errorf("buyer 3 after recompute = %+v", st)
+	}
+
+	var logs []project.TeamTierLog
+	global.GVA_DB.Where("user_id = ?", 1).Order("id").Find(&logs)
+	if len(logs) != 3 {
+		t.Fatalf("tier logs = %+v", logs)
+	}
+	if !logs[0].Upgrade || logs[0].FromTierID != nil || logs[0].ToTierName != "青铜" || logs[0].Source != constants.TierChangeRegister {
+		t.Errorf("first log = %+v", logs[0])
+	}
+	if !logs[1].Upgrade || logs[1].ToTierName != "白银" {
+		t.Errorf("second log = %+v", logs[1])
+	}
+	if l := logs[2]; l.Upgrade || l.FromTierName != "白银" || l.ToTierName != "青铜" || l.Source != constants.TierChangeRecompute {
+		t.Errorf("downgrade log = %+v", l)
+	}
+
+	// 再次重算没有偏差，不重复记录等级变化
+	var before, after int64
+	global.GVA_DB.Model(&project.TeamTierLog{}).Count(&before)
+	if err = s.RecomputeAll(); err != nil {
+		t.Fatal(err)
+	}
+	global.GVA_DB.Model(&project.TeamTierLog{}).Count(&after)
+	if after != before {
+		t.Errorf("tier logs after second recompute = %d, want %d", after, before)
+	}
+
+	if err = s.ResetDailyCounters(); err != nil {
+		t.Fatal(err)
+	}
+	if ts = team(); ts.TodayNew != 0 || ts.TotalMembers != 2 {
+		t.Errorf("team after reset = %+v", ts)
+	}
+}
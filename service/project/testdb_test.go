@@ -0,0 +1,103 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	// sqlite 没有 JSON_CONTAINS，注册一个支持数组包含判断的实现，让 MySQL 的查询语句在测试中可用
+	gosqlite.MustRegisterDeterministicScalarFunction("JSON_CONTAINS", 2, func(_ *gosqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		var target, candidate interface{}
+		for i, dst := range []*interface{}{&target, &candidate} {
+			var raw []byte
+			switch v := args[i].(type) {
+			case nil:
+				return nil, nil
+			case []byte:
+				raw = v
+			default:
+				raw = []byte(fmt.Sprint(v))
+			}
+			if err := json.Unmarshal(raw, dst); err != nil {
+				return nil, err
+			}
+		}
+		return jsonContains(target, candidate), nil
+	})
+}
+
+// jsonContains 按 MySQL JSON_CONTAINS 的规则判断数组或标量是否包含候选值
+func jsonContains(target, candidate interface{}) bool {
+	arr, ok := target.([]interface{})
+	if !ok {
+		return reflect.DeepEqual(target, candidate)
+	}
+	if want, ok := candidate.([]interface{}); ok {
+		for _, c := range want {
+			if !jsonContains(arr, c) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, v := range arr {
+		if reflect.DeepEqual(v, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// setupTestDB 为单个测试创建独立的 sqlite 数据库，只迁移测试用到的表，返回测试临时目录
+func setupTestDB(t *testing.T, models ...interface{}) string {
+	t.Helper()
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "test.db") + "?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+		// 唯一索引冲突转换为 gorm.ErrDuplicatedKey，与业务代码的判断一致
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只建传入的表，不自动迁移关联模型；applications、app_packages 等使用 enum 类型的表由测试手动建表
+	if len(models) > 0 {
+		if err = db.Migrator().CreateTable(models...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	global.GVA_DB = db
+	global.GVA_LOG = zap.NewNop()
+	return dir
+}
+
+// createApplicationTables sqlite 不支持 enum 类型，applications 和 app_packages 手动建表，数据由各测试自己写入
+func createApplicationTables(t *testing.T) {
+	t.Helper()
+	for _, ddl := range []string{
+		`CREATE TABLE applications (id integer PRIMARY KEY, app_id text, app_name text, country_code text, category_id integer,
+			subcategory_id integer, app_icon text, description text, is_hot integer, is_recommend integer, is_free numeric,
+			rating real, download_count integer, sales_count integer, apk_sales_count integer, account_sales_count integer,
+			sort_order integer, account_price text, warranty_days integer NOT NULL DEFAULT 0, status text, created_at datetime, updated_at datetime, created_by integer)`,
+		`CREATE TABLE app_packages (id integer PRIMARY KEY, app_id text, platform text, status text, version_name text, version_code integer,
+			package_size integer, published_at datetime, rating_average real, rating_count integer)`,
+	} {
+		if err := global.GVA_DB.Exec(ddl).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,509 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/project/response"
+	"ApkAdmin/utils"
+	"ApkAdmin/utils/upload"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	uploadSessionDir = "./breakpointDir/sessions/" // 分片临时目录
+	uploadSessionTTL = 24 * time.Hour              // 会话闲置多久后被回收
+)
+
+type UploadSessionService struct{}
+
+// InitSession 初始化分片上传会话
+func (s *UploadSessionService) InitSession(uid uint, req request.UploadSessionInitRequest) (*response.UploadSessionResp, error) {
+	chunkTotal := int((req.FileSize + req.ChunkSize - 1) / req.ChunkSize)
+	// 对象存储分片上传要求除最后一片外每片不小于 5MB
+	if upload.NewMultipartOss() != nil && chunkTotal > 1 && req.ChunkSize < upload.MultipartMinPartSize {
+		return nil, fmt.Errorf("分片大小不能小于%dMB", upload.MultipartMinPartSize/1024/1024)
+	}
+	if req.PackageID != nil {
+		if err := s.checkPackage(*req.PackageID); err != nil {
+			return nil, err
+		}
+	}
+	session := project.UploadSession{
+		SessionID:  uuid.New().String(),
+		FileName:   req.FileName,
+		FileSize:   req.FileSize,
+		FileSha256: req.FileSha256,
+		ChunkSize:  req.ChunkSize,
+		ChunkTotal: chunkTotal,
+		Status:     constants.UploadSessionUploading,
+		PackageID:  req.PackageID,
+		ExpiresAt:  time.Now().Add(uploadSessionTTL),
+		CreatedBy:  uid,
+	}
+	if err := global.GVA_DB.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return s.buildSessionResp(&session, nil), nil
+}
+
+// UploadChunk 上传分片，同一分片重复上传是幂等的
+func (s *UploadSessionService) UploadChunk(req request.UploadSessionChunkRequest, content []byte) error {
+	session, err := s.getSession(req.SessionID)
+	if err != nil {
+		return err
+	}
+	if session.Status != constants.UploadSessionUploading {
+		return errors.New("会话当前状态不允许上传分片")
+	}
+	if session.IsExpired() {
+		return errors.New("上传会话已过期")
+	}
+	chunkNumber := *req.ChunkNumber
+	if chunkNumber >= session.ChunkTotal {
+		return fmt.Errorf("分片序号超出范围，总分片数为%d", session.ChunkTotal)
+	}
+	if int64(len(content)) != session.ExpectedChunkSize(chunkNumber) {
+		return fmt.Errorf("分片大小不正确，期望%d字节", session.ExpectedChunkSize(chunkNumber))
+	}
+	chunkSha256 := utils.SHA256V(content)
+	if chunkSha256 != req.ChunkSha256 {
+		return errors.New("分片校验失败，请重新上传")
+	}
+
+	// 已上传且内容一致，直接返回成功
+	var existing project.UploadSessionChunk
+	err = global.GVA_DB.Where("session_id = ? AND chunk_number = ?", session.SessionID, chunkNumber).First(&existing).Error
+	if err == nil && existing.ChunkSha256 == chunkSha256 {
+		if _, statErr := os.Stat(s.chunkPath(session.SessionID, chunkNumber)); statErr == nil {
+			return nil
+		}
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if err = s.writeChunkFile(session.SessionID, chunkNumber, content); err != nil {
+		global.GVA_LOG.Error("写入分片失败", zap.Error(err), zap.String("sessionId", session.SessionID))
+		return errors.New("写入分片失败")
+	}
+	chunk := project.UploadSessionChunk{
+		SessionID:   session.SessionID,
+		ChunkNumber: chunkNumber,
+		ChunkSize:   int64(len(content)),
+		ChunkSha256: chunkSha256,
+	}
+	err = global.GVA_DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}, {Name: "chunk_number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"chunk_size", "chunk_sha256", "updated_at"}),
+	}).Create(&chunk).Error
+	if err != nil {
+		return err
+	}
+	// 有分片上传就顺延过期时间，避免大文件慢速上传被回收
+	return global.GVA_DB.Model(&project.UploadSession{}).
+		Where("id = ?", session.ID).
+		Update("expires_at", time.Now().Add(uploadSessionTTL)).Error
+}
+
+// GetSession 查询会话进度及缺失分片
+func (s *UploadSessionService) GetSession(sessionID string) (*response.UploadSessionResp, error) {
+	session, err := s.getSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	var uploaded []int
+	if err = global.GVA_DB.Model(&project.UploadSessionChunk{}).
+		Where("session_id = ?", sessionID).
+		Pluck("chunk_number", &uploaded).Error; err != nil {
+		return nil, err
+	}
+	return s.buildSessionResp(session, uploaded), nil
+}
+
+// CompleteSession 校验整个文件并写入对象存储，成功后挂载到安装包
+func (s *UploadSessionService) CompleteSession(uid uint, req request.UploadSessionCompleteRequest) (*response.UploadSessionResp, error) {
+	session, err := s.getSession(req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == constants.UploadSessionCompleted {
+		return s.buildSessionResp(session, nil), nil
+	}
+	if session.IsExpired() {
+		return nil, errors.New("上传会话已过期")
+	}
+	packageID := session.PackageID
+	if req.PackageID != nil {
+		packageID = req.PackageID
+	}
+	if packageID != nil {
+		if err = s.checkPackage(*packageID); err != nil {
+			return nil, err
+		}
+	}
+
+	var chunks []project.UploadSessionChunk
+	if err = global.GVA_DB.Where("session_id = ?", session.SessionID).
+		Order("chunk_number ASC").
+		Find(&chunks).Error; err != nil {
+		return nil, err
+	}
+	if len(chunks) != session.ChunkTotal {
+		return nil, fmt.Errorf("还有%d个分片未上传", session.ChunkTotal-len(chunks))
+	}
+
+	// 抢占合并权，防止重复提交并发合并；同时顺延过期时间，避免大文件合并期间被回收任务清理
+	result := global.GVA_DB.Model(&project.UploadSession{}).
+		Where("id = ? AND status IN ?", session.ID, []constants.UploadSessionStatus{constants.UploadSessionUploading, constants.UploadSessionFailed}).
+		Updates(map[string]interface{}{
+			"status":     constants.UploadSessionCompleting,
+			"expires_at": time.Now().Add(uploadSessionTTL),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("会话正在合并中，请勿重复提交")
+	}
+
+	fileMd5, err := s.verifyFileHash(session, chunks)
+	if err != nil {
+		s.markFailed(session, err.Error())
+		return nil, err
+	}
+
+	objectName, fileURL, err := s.storeFile(session, chunks)
+	if err != nil {
+		global.GVA_LOG.Error("合并上传文件失败", zap.Error(err), zap.String("sessionId", session.SessionID))
+		s.markFailed(session, "写入存储失败")
+		return nil, errors.New("写入存储失败")
+	}
+
+	now := time.Now()
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&project.UploadSession{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+			"status":       constants.UploadSessionCompleted,
+			"object_name":  objectName,
+			"file_url":     fileURL,
+			"package_id":   packageID,
+			"fail_reason":  nil,
+			"completed_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		if packageID != nil {
+			// 上传时已校验过 SHA-256，扫描时再与存储中的文件比对
+			updates := packageFileChangedUpdates(&session.FileSha256, &fileMd5)
+			updates["object_name"] = objectName
+			updates["file_url"] = fileURL
+			updates["file_name"] = session.FileName
+			updates["package_size"] = session.FileSize
+			updates["uploaded_at"] = now
+			updates["updated_at"] = now
+			updates["updated_by"] = uid
+			if err := tx.Model(&project.AppPackage{}).Where("id = ?", *packageID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("session_id = ?", session.SessionID).Delete(&project.UploadSessionChunk{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.removeChunkDir(session.SessionID)
+
+	session.Status = constants.UploadSessionCompleted
+	session.ObjectName = &objectName
+	session.FileURL = &fileURL
+	session.PackageID = packageID
+	return s.buildSessionResp(session, nil), nil
+}
+
+// AbortSession 取消上传并清理分片
+func (s *UploadSessionService) AbortSession(sessionID string) error {
+	session, err := s.getSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if session.Status == constants.UploadSessionCompleted || session.Status == constants.UploadSessionCompleting {
+		return errors.New("会话已完成或正在合并，无法取消")
+	}
+	return s.releaseSession(session)
+}
+
+// CleanExpiredSessions 回收过期未完成的上传会话（定时任务）
+// 合并中的会话在抢占时已顺延过期时间，仍然过期说明合并进程已中断
+func (s *UploadSessionService) CleanExpiredSessions() error {
+	var sessions []project.UploadSession
+	err := global.GVA_DB.
+		Where("status IN ?", []constants.UploadSessionStatus{
+			constants.UploadSessionUploading,
+			constants.UploadSessionFailed,
+			constants.UploadSessionCompleting,
+		}).
+		Where("expires_at < ?", time.Now()).
+		Limit(500).
+		Find(&sessions).Error
+	if err != nil {
+		global.GVA_LOG.Error("查询过期上传会话失败", zap.Error(err))
+		return err
+	}
+	released := 0
+	for i := range sessions {
+		// 查询之后可能又有分片上传或开始合并，按原状态和过期时间抢占，抢不到说明会话仍在使用
+		result := global.GVA_DB.Model(&project.UploadSession{}).
+			Where("id = ? AND status = ? AND expires_at < ?", sessions[i].ID, sessions[i].Status, time.Now()).
+			Update("status", constants.UploadSessionAborted)
+		if result.Error != nil {
+			global.GVA_LOG.Error("回收上传会话失败", zap.Error(result.Error), zap.String("sessionId", sessions[i].SessionID))
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		if err := s.releaseSession(&sessions[i]); err != nil {
+			global.GVA_LOG.Error("回收上传会话失败", zap.Error(err), zap.String("sessionId", sessions[i].SessionID))
+			continue
+		}
+		released++
+	}
+	if released > 0 {
+		global.GVA_LOG.Info("回收过期上传会话", zap.Int("count", released))
+	}
+	return nil
+}
+
+// ==================== 辅助方法 ====================
+
+func (s *UploadSessionService) getSession(sessionID string) (*project.UploadSession, error) {
+	var session project.UploadSession
+	err := global.GVA_DB.Where("session_id = ?", sessionID).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("上传会话不存在")
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *UploadSessionService) checkPackage(packageID uint64) error {
+	var count int64
+	if err := global.GVA_DB.Model(&project.AppPackage{}).Where("id = ?", packageID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("安装包不存在")
+	}
+	return nil
+}
+
+func (s *UploadSessionService) buildSessionResp(session *project.UploadSession, uploaded []int) *response.UploadSessionResp {
+	resp := &response.UploadSessionResp{
+		SessionID:  session.SessionID,
+		FileName:   session.FileName,
+		FileSize:   session.FileSize,
+		ChunkSize:  session.ChunkSize,
+		ChunkTotal: session.ChunkTotal,
+		Status:     session.Status,
+		PackageID:  session.PackageID,
+		ObjectName: session.ObjectName,
+		FileURL:    session.FileURL,
+		ExpiresAt:  session.ExpiresAt,
+	}
+	if session.Status == constants.UploadSessionCompleted {
+		resp.UploadedChunks = session.ChunkTotal
+		resp.MissingChunks = []int{}
+		return resp
+	}
+	done := make(map[int]bool, len(uploaded))
+	for _, n := range uploaded {
+		done[n] = true
+	}
+	resp.UploadedChunks = len(done)
+	resp.MissingChunks = make([]int, 0, session.ChunkTotal-len(done))
+	for i := 0; i < session.ChunkTotal; i++ {
+		if !done[i] {
+			resp.MissingChunks = append(resp.MissingChunks, i)
+		}
+	}
+	return resp
+}
+
+func (s *UploadSessionService) chunkPath(sessionID string, chunkNumber int) string {
+	return filepath.Join(uploadSessionDir, sessionID, strconv.Itoa(chunkNumber))
+}
+
+// writeChunkFile 先写临时文件再重命名，保证分片文件要么完整要么不存在
+func (s *UploadSessionService) writeChunkFile(sessionID string, chunkNumber int, content []byte) error {
+	dir := filepath.Join(uploadSessionDir, sessionID)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, strconv.Itoa(chunkNumber)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.chunkPath(sessionID, chunkNumber))
+}
+
+func (s *UploadSessionService) removeChunkDir(sessionID string) {
+	if err := os.RemoveAll(filepath.Join(uploadSessionDir, sessionID)); err != nil {
+		global.GVA_LOG.Warn("清理分片目录失败", zap.Error(err), zap.String("sessionId", sessionID))
+	}
+}
+
+// verifyFileHash 按顺序读取所有分片校验整个文件的 SHA-256，同时返回文件 MD5
+func (s *UploadSessionService) verifyFileHash(session *project.UploadSession, chunks []project.UploadSessionChunk) (string, error) {
+	sha := sha256.New()
+	md := md5.New()
+	h := io.MultiWriter(sha, md)
+	var total int64
+	for _, chunk := range chunks {
+		f, err := os.Open(s.chunkPath(session.SessionID, chunk.ChunkNumber))
+		if err != nil {
+			return "", fmt.Errorf("分片%d丢失，请重新上传", chunk.ChunkNumber)
+		}
+		n, err := io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("读取分片%d失败", chunk.ChunkNumber)
+		}
+		total += n
+	}
+	if total != session.FileSize {
+		return "", errors.New("文件大小与初始化时不一致")
+	}
+	if hex.EncodeToString(sha.Sum(nil)) != session.FileSha256 {
+		return "", errors.New("文件SHA-256校验失败")
+	}
+	return hex.EncodeToString(md.Sum(nil)), nil
+}
+
+// storeFile 支持分片上传的对象存储直接逐片推送，否则合并到本地存储目录
+func (s *UploadSessionService) storeFile(session *project.UploadSession, chunks []project.UploadSessionChunk) (objectName string, fileURL string, err error) {
+	mp := upload.NewMultipartOss()
+	if mp == nil {
+		return s.assembleLocal(session, chunks)
+	}
+
+	objectName = fmt.Sprintf("private/package/%s/%s/%s", time.Now().Format("2006-01-02"), session.SessionID, session.FileName)
+	uploadID, err := mp.InitMultipart(objectName)
+	if err != nil {
+		return "", "", err
+	}
+	// 记录 uploadID 和 objectName，进程中断后由回收任务中止分片上传
+	err = global.GVA_DB.Model(&project.UploadSession{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+		"upload_id":   uploadID,
+		"object_name": objectName,
+	}).Error
+	if err != nil {
+		_ = mp.AbortMultipart(objectName, uploadID)
+		return "", "", err
+	}
+	session.UploadID = &uploadID
+	session.ObjectName = &objectName
+
+	parts := make([]upload.MultipartPart, 0, len(chunks))
+	for _, chunk := range chunks {
+		etag, err := s.uploadPart(mp, objectName, uploadID, chunk)
+		if err != nil {
+			_ = mp.AbortMultipart(objectName, uploadID)
+			return "", "", err
+		}
+		parts = append(parts, upload.MultipartPart{PartNumber: chunk.ChunkNumber + 1, ETag: etag})
+	}
+	fileURL, err = mp.CompleteMultipart(objectName, uploadID, parts)
+	if err != nil {
+		_ = mp.AbortMultipart(objectName, uploadID)
+		return "", "", err
+	}
+	return objectName, fileURL, nil
+}
+
+func (s *UploadSessionService) uploadPart(mp upload.MultipartOSS, objectName, uploadID string, chunk project.UploadSessionChunk) (string, error) {
+	f, err := os.Open(s.chunkPath(chunk.SessionID, chunk.ChunkNumber))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return mp.UploadPart(objectName, uploadID, chunk.ChunkNumber+1, f, chunk.ChunkSize)
+}
+
+func (s *UploadSessionService) assembleLocal(session *project.UploadSession, chunks []project.UploadSessionChunk) (string, string, error) {
+	objectName := fmt.Sprintf("package/%s/%s", session.SessionID, session.FileName)
+	dst := filepath.Join(global.GVA_CONFIG.Local.StorePath, "package", session.SessionID)
+	if err := os.MkdirAll(dst, os.ModePerm); err != nil {
+		return "", "", err
+	}
+	out, err := os.Create(filepath.Join(dst, session.FileName))
+	if err != nil {
+		return "", "", err
+	}
+	defer out.Close()
+	for _, chunk := range chunks {
+		f, err := os.Open(s.chunkPath(session.SessionID, chunk.ChunkNumber))
+		if err != nil {
+			return "", "", err
+		}
+		_, err = io.Copy(out, f)
+		f.Close()
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return objectName, global.GVA_CONFIG.Local.Path + "/" + objectName, nil
+}
+
+func (s *UploadSessionService) markFailed(session *project.UploadSession, reason string) {
+	err := global.GVA_DB.Model(&project.UploadSession{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+		"status":      constants.UploadSessionFailed,
+		"fail_reason": reason,
+	}).Error
+	if err != nil {
+		global.GVA_LOG.Error("更新上传会话状态失败", zap.Error(err), zap.String("sessionId", session.SessionID))
+	}
+}
+
+// releaseSession 中止对象存储分片上传、删除本地分片并标记会话为已取消
+func (s *UploadSessionService) releaseSession(session *project.UploadSession) error {
+	if session.UploadID != nil && session.ObjectName != nil {
+		if mp := upload.NewMultipartOss(); mp != nil {
+			if err := mp.AbortMultipart(*session.ObjectName, *session.UploadID); err != nil {
+				global.GVA_LOG.Warn("中止分片上传失败", zap.Error(err), zap.String("sessionId", session.SessionID))
+			}
+		}
+	}
+	s.removeChunkDir(session.SessionID)
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", session.SessionID).Delete(&project.UploadSessionChunk{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&project.UploadSession{}).Where("id = ?", session.ID).
+			Update("status", constants.UploadSessionAborted).Error
+	})
+}
@@ -0,0 +1,154 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setupUploadSessionTest(t *testing.T) string {
+	t.Helper()
+	dir := setupTestDB(t, &project.UploadSession{}, &project.UploadSessionChunk{})
+	// 分片目录是相对路径，切到临时目录避免写入源码目录
+	t.Chdir(dir)
+	saved := global.GVA_CONFIG
+	global.GVA_CONFIG.System.OssType = "local"
+	global.GVA_CONFIG.Local.StorePath = filepath.Join(dir, "store")
+	global.GVA_CONFIG.Local.Path = "uploads/file"
+	t.Cleanup(func() { global.GVA_CONFIG = saved })
+	return dir
+}
+
+func uploadTestChunk(s *UploadSessionService, sessionID string, n int, content []byte) error {
+	return s.UploadChunk(request.UploadSessionChunkRequest{SessionID: sessionID, ChunkNumber: &n, ChunkSha256: utils.SHA256V(content)}, content)
+}
+
+func TestUploadSession(t *testing.T) {
+	setupUploadSessionTest(t)
+	content := bytes.Repeat([]byte("apk-"), 6) // 24 字节，按 10 字节分成 3 片
+	chunks := [][]byte{content[:10], content[10:20], content[20:]}
+
+	var s UploadSessionService
+	resp, err := s.InitSession(1, request.UploadSessionInitRequest{FileName: "app.apk", FileSize: int64(len(content)), FileSha256: utils.SHA256V(content), ChunkSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ChunkTotal != 3 || len(resp.MissingChunks) != 3 {
+		t.Fatalf("init = %+v", resp)
+	}
+	id := resp.SessionID
+
+	// 分片大小或校验值不对时拒绝
+	if err = uploadTestChunk(&s, id, 0, chunks[0][:5]); err == nil {
+		t.Error("short chunk should be rejected")
+	}
+	n := 1
+	if err = s.UploadChunk(request.UploadSessionChunkRequest{SessionID: id, ChunkNumber: &n, ChunkSha256: utils.SHA256V(chunks[0])}, chunks[1]); err == nil {
+		t.Error("chunk with wrong sha256 should be rejected")
+	}
+
+	// 断点续传：重复上传同一分片是幂等的，查询返回缺失的分片
+	for _, i := range []int{0, 2, 2} {
+		if err = uploadTestChunk(&s, id, i, chunks[i]); err != nil {
+			t.Fatalf("chunk %d: %v", i, err)
+		}
+	}
+	resp, err = s.GetSession(id)
+	if err != nil || resp.UploadedChunks != 2 || len(resp.MissingChunks) != 1 || resp.MissingChunks[0] != 1 {
+		t.Fatalf("progress = %+v, err = %v", resp, err)
+	}
+	if _, err = s.CompleteSession(1, request.UploadSessionCompleteRequest{SessionID: id}); err == nil {
+		t.Error("complete with missing chunks should fail")
+	}
+
+	if err = uploadTestChunk(&s, id, 1, chunks[1]); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = s.CompleteSession(1, request.UploadSessionCompleteRequest{SessionID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != constants.UploadSessionCompleted || resp.ObjectName == nil {
+		t.Fatalf("complete = %+v", resp)
+	}
+	got, err := os.ReadFile(filepath.Join(global.GVA_CONFIG.Local.StorePath, *resp.ObjectName))
+	if err != nil || !bytes.Equal(got, content) {
+		t.Errorf("assembled file = %q, err = %v", got, err)
+	}
+	if _, err = os.Stat(filepath.Join(uploadSessionDir, id)); !os.IsNotExist(err) {
+		t.Errorf("chunk dir should be removed, stat err = %v", err)
+	}
+	var count int64
+	global.GVA_DB.Model(&project.UploadSessionChunk{}).Where("session_id = ?", id).Count(&count)
+	if count != 0 {
+		t.Errorf("chunk rows = %d after complete", count)
+	}
+	// 重复完成直接返回结果
+	if resp, err = s.CompleteSession(1, request.UploadSessionCompleteRequest{SessionID: id}); err != nil || resp.Status != constants.UploadSessionCompleted {
+		t.Errorf("repeat complete = %+v, err = %v", resp, err)
+	}
+}
+
+func TestCleanExpiredUploadSessions(t *testing.T) {
+	setupUploadSessionTest(t)
+	var s UploadSessionService
+	newSession := func(name string) string {
+		t.Helper()
+		content := []byte(name)
+		resp, err := s.InitSession(1, request.UploadSessionInitRequest{FileName: name, FileSize: int64(len(content)), FileSha256: utils.SHA256V(content), ChunkSize: 64})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = uploadTestChunk(&s, resp.SessionID, 0, content); err != nil {
+			t.Fatal(err)
+		}
+		return resp.SessionID
+	}
+	expired, active, completing := newSession("expired.apk"), newSession("active.apk"), newSession("completing.apk")
+	past := time.Now().Add(-time.Hour)
+	global.GVA_DB.Model(&project.UploadSession{}).Where("session_id = ?", expired).Update("expires_at", past)
+	// 合并中的会话抢占时已顺延过期时间，不会被回收
+	global.GVA_DB.Model(&project.UploadSession{}).Where("session_id = ?", completing).Updates(map[string]interface{}{
+		"status": constants.UploadSessionCompleting, "expires_at": time.Now().Add(uploadSessionTTL),
+	})
+
+	if err := s.CleanExpiredSessions(); err != nil {
+		t.Fatal(err)
+	}
+	status := func(id string) constants.UploadSessionStatus {
+		var session project.UploadSession
+		global.GVA_DB.Where("session_id = ?", id).First(&session)
+		return session.Status
+	}
+	if st := status(expired); st != constants.UploadSessionAborted {
+		t.Errorf("expired session status = %s", st)
+	}
+	if _, err := os.Stat(filepath.Join(uploadSessionDir, expired)); !os.IsNotExist(err) {
+		t.Errorf("expired chunk dir should be removed, stat err = %v", err)
+	}
+	if st := status(active); st != constants.UploadSessionUploading {
+		t.Errorf("active session status = %s", st)
+	}
+	if st := status(completing); st != constants.UploadSessionCompleting {
+		t.Errorf("completing session status = %s", st)
+	}
+	if _, err := os.Stat(filepath.Join(uploadSessionDir, completing)); err != nil {
+		t.Errorf("completing chunk dir removed: %v", err)
+	}
+
+	// 合并进程中断后超过有效期的会话会被回收
+	global.GVA_DB.Model(&project.UploadSession{}).Where("session_id = ?", completing).Update("expires_at", past)
+	if err := s.CleanExpiredSessions(); err != nil {
+		t.Fatal(err)
+	}
+	if st := status(completing); st != constants.UploadSessionAborted {
+		t.Errorf("stale completing session status = %s", st)
+	}
+}
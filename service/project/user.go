@@ -167,17 +167,16 @@ func (u *UserService) GetSimpleUser(conditions ...func(*gorm.DB) *gorm.DB) (user
 	return user, err
 }
 
-// RegisterUser 用户注册
-func (u *UserService) RegisterUser(req request.BaseRegisterRequest, clientIP string) error {
-	// 邀请码
-	var referrerID uint
-	referrerID = 0
-	if req.InviteCode != "" {
-		var referrerUser project.User
-		err := global.GVA_DB.Model(&project.User{}).First(&referrerUser).Error
-		if err == nil && referrerUser.ID > 0 {
-			referrerID = referrerUser.ID
-		}
+// RegisterUser 用户注册，clickID 为推广链接点击写入的 cookie
+func (u *UserService) RegisterUser(req request.BaseRegisterRequest, clientIP, clickID string) error {
+	// 推荐人：优先使用填写的邀请码，其次使用推广链接的点击记录
+	attr, err := referralService.ResolveAttribution(req.InviteCode, clickID)
+	if err != nil {
+		return err
+	}
+	var referrerID *uint
+	if attr.ReferrerID > 0 {
+		referrerID = &attr.ReferrerID
 	}
 	passwordHash := utils.BcryptHash(req.Password)
 	// 处理日期
@@ -198,7 +197,7 @@ func (u *UserService) RegisterUser(req request.BaseRegisterRequest, clientIP str
 		AccountStatus: constants.AccountStatusNormal,
 		EmailVerified: false,
 		PhoneVerified: false,
-		ReferrerID:    &referrerID,
+		ReferrerID:    referrerID,
 		ReferralCode:  &ReferralCode,
 		RegisterIP:    &clientIP,
 	}
@@ -232,44 +231,8 @@ func (u *UserService) RegisterUser(req request.BaseRegisterRequest, clientIP str
 		if err := tx.Create(&commissionAccount).Error; err != nil {
 			return err
 		}
-		// 5. 如果有推荐人，更新推荐人的统计
-		if referrerID > 0 {
-			// 确保推荐人的统计记录存在
-			var count int64
-
-			// 检查 user_statistics
-			tx.Model(&project.UserStatistics{}).Where("user_id = ?", referrerID).Count(&count)
-			if count == 0 {
-				refStats := project.UserStatistics{UserID: referrerID}
-				tx.Create(&refStats)
-			}
-
-			// 检查 team_statistics
-			tx.Model(&project.TeamStatistics{}).Where("user_id = ?", referrerID).Count(&count)
-			if count == 0 {
-				refTeamStats := project.TeamStatistics{UserID: int64(referrerID)}
-				tx.Create(&refTeamStats)
-			}
-
-			// ✅ 更新 user_statistics
-			if err := tx.Model(&project.UserStatistics{}).
-				Where("user_id = ?", referrerID).
-				UpdateColumn("successful_referrals", gorm.Expr("successful_referrals + 1")).
-				Error; err != nil {
-				return err
-			}
-
-			// ✅ 更新 team_statistics
-			if err := tx.Model(&project.TeamStatistics{}).
-				Where("user_id = ?", referrerID).
-				Updates(map[string]interface{}{
-					"total_members": gorm.Expr("total_members + 1"),
-					"today_new":     gorm.Expr("today_new + 1"),
-				}).Error; err != nil {
-				return err
-			}
-		}
-		return nil
+		// 5. 如果有推荐人，记录推荐关系并重新统计推荐人的数据
+		return referralService.bindOnRegister(tx, user.ID, attr)
 	})
 }
 
@@ -280,7 +243,7 @@ func (u *UserService) BatchUpdateUserStatus(ids []uint, status string) error {
 		Update("account_status", status).Error
 }
 
-// ResetUserPassword 重置用户密码
+// ResetUserPassword 重置用户密码，已登录的设备需要重新登录
 func (u *UserService) ResetUserPassword(id uint) (string, error) {
 	// 生成新密码
 	newPassword := u.generateRandomPassword()
@@ -291,11 +254,14 @@ func (u *UserService) ResetUserPassword(id uint) (string, error) {
 		Updates(map[string]interface{}{
 			"password_hash": passwordHash,
 		}).Error
-	return newPassword, err
+	if err != nil {
+		return "", err
+	}
+	return newPassword, u.RevokeSessions(id)
 }
 
 // ChangeUserPassword 用户修改密码
-func (u *UserService) ChangeUserPassword(id uint, req request.ChangeUserPasswordRequest) error {
+func (u *UserService) ChangeUserPassword(id uint, req request.ChangeUserPasswordRequest, clientIP string) error {
 	var user project.User
 	err := global.GVA_DB.Model(&project.User{}).Where("id = ? ", id).First(&user).Error
 	if err != nil {
@@ -305,6 +271,10 @@ func (u *UserService) ChangeUserPassword(id uint, req request.ChangeUserPassword
 	if !utils.BcryptCheck(req.OldPassword, user.PasswordHash) {
 		return errors.New("旧密码不正确")
 	}
+	// 开启双因子认证的用户需要验证第二因子
+	if err = twoFactorService.Verify(id, req.TwoFactorCode, clientIP); err != nil {
+		return err
+	}
 	// 生成新密码
 	passwordHash := utils.BcryptHash(req.NewPassword)
 	// 更新密码
@@ -318,7 +288,7 @@ func (u *UserService) ChangeUserPassword(id uint, req request.ChangeUserPassword
 
 // ApplyWithdraw 用户提现申请
 // ApplyWithdraw 申请提现
-func (u *UserService) ApplyWithdraw(userID uint, req request.UserWithdrawRequest) error {
+func (u *UserService) ApplyWithdraw(userID uint, req request.UserWithdrawRequest, clientIP string) error {
 	// 1. 验证请求参数
 	if err := req.Validate(); err != nil {
 		return err
@@ -349,6 +319,11 @@ func (u *UserService) ApplyWithdraw(userID uint, req request.UserWithdrawRequest
 		return errors.New("不支持该提现方式")
 	}
 
+	// 开启双因子认证的用户需要验证第二因子
+	if err = twoFactorService.Verify(userID, req.TwoFactorCode, clientIP); err != nil {
+		return err
+	}
+
 	// 5. 使用事务处理提现流程
 	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
 		// 5.1 检查今日提现次数
@@ -608,6 +583,11 @@ func (u *UserService) Login(us *project.User, clientIP, userAgent string) (userI
 		return nil, u.handleLoginFailure(&user, clientIP)
 	}
 
+	// 开启双因子认证时由 UserTwoFactorService.CompleteLogin 记录登录
+	if user.TwoFactorEnabled {
+		return &user, nil
+	}
+
 	// ==================== 第三步：登录成功 ====================
 	u.handleLoginSuccess(&user, clientIP, userAgent)
 	return &user, err
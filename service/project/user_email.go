@@ -0,0 +1,276 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/system"
+	emailUtils "ApkAdmin/plugin/email/utils"
+	systemService "ApkAdmin/service/system"
+	"ApkAdmin/utils"
+	"ApkAdmin/utils/signedtoken"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// sendMail 测试时替换
+var sendMail = emailUtils.Email
+
+var (
+	errTokenUsed      = errors.New("链接已使用或已失效")
+	errSiteURLMissing = errors.New("未配置前台站点地址，暂时无法发送邮件")
+)
+
+// UserEmailService 前台用户的邮箱验证和找回密码
+type UserEmailService struct{}
+
+// SendVerifyEmail 给用户当前邮箱发送验证链接，已验证的邮箱不再发送。site 为空时拒绝发送，链接不能取自请求头
+func (s *UserEmailService) SendVerifyEmail(userID uint, clientIP, site string) error {
+	if site == "" {
+		return errSiteURLMissing
+	}
+	var user project.User
+	if err := global.GVA_DB.Select("id, email, email_verified").Where("id = ?", userID).First(&user).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	if user.EmailVerified {
+		return errors.New("邮箱已验证")
+	}
+	if user.Email == "" {
+		return errors.New("未设置邮箱")
+	}
+	if err := checkMailThrottle(constants.UserTokenEmailVerify, user.Email, clientIP); err != nil {
+		return err
+	}
+	cfg := global.GVA_CONFIG.AccountEmail
+	link, err := issueEmailToken(user, constants.UserTokenEmailVerify, clientIP, site, cfg.VerifyPath, "/verify-email?token=%s", cfg.VerifyTTL, 1440)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf(`<p>您好，请点击下面的链接完成邮箱验证：</p><p><a href="%s">%s</a></p><p>链接%d分钟内有效，只能使用一次。如非本人操作请忽略本邮件。</p>`,
+		html.EscapeString(link), html.EscapeString(link), positiveOr(cfg.VerifyTTL, 1440))
+	return sendMail(user.Email, "邮箱验证", body)
+}
+
+// VerifyEmail 使用验证链接中的令牌；用户在发出链接后修改了邮箱时链接失效
+func (s *UserEmailService) VerifyEmail(token string) error {
+	record, err := useEmailToken(token, constants.UserTokenEmailVerify, func(tx *gorm.DB, record project.UserEmailToken) error {
+		res := tx.Model(&project.User{}).Where("id = ? AND email = ?", record.UserID, record.Email).Update("email_verified", true)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errTokenUsed
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	global.GVA_LOG.Info("用户邮箱验证成功", zap.Uint("userID", record.UserID))
+	return nil
+}
+
+// SendPasswordReset 发送重置密码链接。邮箱未注册时同样返回成功，避免被用来探测邮箱
+func (s *UserEmailService) SendPasswordReset(email, clientIP, site string) error {
+	if site == "" {
+		return errSiteURLMissing
+	}
+	email = strings.TrimSpace(email)
+	if err := utils.ValidateEmail(email); err != nil {
+		return err
+	}
+	if err := checkMailThrottle(constants.UserTokenPasswordReset, email, clientIP); err != nil {
+		return err
+	}
+	var user project.User
+	err := global.GVA_DB.Select("id, email").Where("email = ? AND deleted_at IS NULL", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cfg := global.GVA_CONFIG.AccountEmail
+	link, err := issueEmailToken(user, constants.UserTokenPasswordReset, clientIP, site, cfg.ResetPath, "/reset-password?token=%s", cfg.ResetTTL, 30)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf(`<p>您好，我们收到了重置密码的申请，请点击下面的链接设置新密码：</p><p><a href="%s">%s</a></p><p>链接%d分钟内有效，只能使用一次。重置后所有设备需要重新登录。如非本人操作请忽略本邮件。</p>`,
+		html.EscapeString(link), html.EscapeString(link), positiveOr(cfg.ResetTTL, 30))
+	return sendMail(user.Email, "重置密码", body)
+}
+
+// ResetPassword 使用重置链接设置新密码，同时作废其它未使用的重置链接并让已登录的设备下线
+func (s *UserEmailService) ResetPassword(token, password string) error {
+	if err := utils.ValidatePassword(password); err != nil {
+		return err
+	}
+	record, err := useEmailToken(token, constants.UserTokenPasswordReset, func(tx *gorm.DB, record project.UserEmailToken) error {
+		err := tx.Model(&project.User{}).Where("id = ?", record.UserID).Updates(map[string]interface{}{
+			"password_hash":         utils.BcryptHash(password),
+			"failed_login_attempts": 0,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&project.UserEmailToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", record.UserID, constants.UserTokenPasswordReset).
+			Update("used_at", time.Now()).Error
+	})
+	if err != nil {
+		return err
+	}
+	var users UserService
+	return users.RevokeSessions(record.UserID)
+}
+
+// ChangeEmail 修改邮箱，需要登录密码和第二因子；新邮箱需要重新验证，旧邮箱未使用的验证链接失效
+func (u *UserService) ChangeEmail(userID uint, req request.ChangeEmailRequest, clientIP string) error {
+	email := strings.TrimSpace(req.Email)
+	if err := utils.ValidateEmail(email); err != nil {
+		return err
+	}
+	var user project.User
+	if err := global.GVA_DB.Select("id, email, password_hash").Where("id = ?", userID).First(&user).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	if !utils.BcryptCheck(req.Password, user.PasswordHash) {
+		return errors.New("登录密码不正确")
+	}
+	if strings.EqualFold(email, user.Email) {
+		return errors.New("新邮箱与当前邮箱相同")
+	}
+	if count, _ := u.CountUserBy(WithEmail(email)); count > 0 {
+		return errors.New("该邮箱已被注册")
+	}
+	if err := twoFactorService.Verify(userID, req.TwoFactorCode, clientIP); err != nil {
+		return err
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&project.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"email": email, "email_verified": false}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&project.UserEmailToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, constants.UserTokenEmailVerify).
+			Update("used_at", time.Now()).Error
+	})
+}
+
+// RecordSession 记录签发的登录令牌，顺便清理该用户已过期的记录
+func (u *UserService) RecordSession(userID uint, token string, expiresAt time.Time, clientIP, userAgent string) error {
+	global.GVA_DB.Where("user_id = ? AND expires_at < ?", userID, time.Now()).Delete(&project.UserSession{})
+	return global.GVA_DB.Create(&project.UserSession{
+		UserID:    userID,
+		Token:     token,
+		IP:        truncateRunes(clientIP, 45),
+		UserAgent: truncateRunes(userAgent, 255),
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// RevokeSessions 把用户未过期的登录令牌加入 JWT 黑名单
+func (u *UserService) RevokeSessions(userID uint) error {
+	var sessions []project.UserSession
+	if err := global.GVA_DB.Where("user_id = ? AND expires_at > ?", userID, time.Now()).Find(&sessions).Error; err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := systemService.JwtServiceApp.JsonInBlacklist(system.JwtBlacklist{Jwt: session.Token}); err != nil {
+			return err
+		}
+	}
+	return global.GVA_DB.Where("user_id = ?", userID).Delete(&project.UserSession{}).Error
+}
+
+// issueEmailToken 生成令牌并保存 Nonce 摘要，返回邮件中的链接
+func issueEmailToken(user project.User, purpose constants.UserTokenPurpose, clientIP, site, path, defaultPath string, ttl, defaultTTL int) (string, error) {
+	token, payload, err := signedtoken.New(emailTokenKey(), string(purpose), user.ID, time.Duration(positiveOr(ttl, defaultTTL))*time.Minute)
+	if err != nil {
+		return "", err
+	}
+	err = global.GVA_DB.Create(&project.UserEmailToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		NonceHash: signedtoken.HashNonce(payload.Nonce),
+		RequestIP: truncateRunes(clientIP, 45),
+		ExpiresAt: payload.ExpiresAt,
+	}).Error
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		path = defaultPath
+	}
+	return strings.TrimRight(site, "/") + fmt.Sprintf(path, token), nil
+}
+
+// useEmailToken 校验令牌并在事务中标记为已使用，apply 返回错误时整体回滚
+func useEmailToken(token string, purpose constants.UserTokenPurpose, apply func(tx *gorm.DB, record project.UserEmailToken) error) (project.UserEmailToken, error) {
+	var record project.UserEmailToken
+	payload, err := signedtoken.Parse(emailTokenKey(), strings.TrimSpace(token), string(purpose))
+	if err != nil {
+		return record, err
+	}
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("nonce_hash = ? AND purpose = ? AND user_id = ?", signedtoken.HashNonce(payload.Nonce), purpose, payload.UserID).First(&record).Error
+		if err != nil {
+			return errTokenUsed
+		}
+		res := tx.Model(&project.UserEmailToken{}).Where("id = ? AND used_at IS NULL", record.ID).Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errTokenUsed
+		}
+		return apply(tx, record)
+	})
+	return record, err
+}
+
+// checkMailThrottle 按邮箱限制发送间隔和每小时次数，按IP限制每小时次数
+func checkMailThrottle(purpose constants.UserTokenPurpose, email, clientIP string) error {
+	cfg := global.GVA_CONFIG.AccountEmail
+	email = strings.ToLower(email)
+	lastKey := fmt.Sprintf("mail:%s:last:%s", purpose, email)
+	if v, ok := global.BlackCache.Get(lastKey); ok {
+		if last, ok := v.(time.Time); ok {
+			interval := time.Duration(positiveOr(cfg.ResendInterval, 60)) * time.Second
+			if wait := interval - time.Since(last); wait > 0 {
+				return fmt.Errorf("发送过于频繁，请%d秒后再试", int(wait.Seconds())+1)
+			}
+		}
+	}
+	emailKey := fmt.Sprintf("mail:%s:email:%s", purpose, email)
+	ipKey := fmt.Sprintf("mail:%s:ip:%s", purpose, clientIP)
+	emailCount, _ := global.BlackCache.Get(emailKey)
+	ipCount, _ := global.BlackCache.Get(ipKey)
+	if n, _ := emailCount.(int); n >= positiveOr(cfg.EmailHourlyLimit, 5) {
+		return errors.New("该邮箱发送次数过多，请稍后再试")
+	}
+	if n, _ := ipCount.(int); n >= positiveOr(cfg.IPHourlyLimit, 20) {
+		return errors.New("发送次数过多，请稍后再试")
+	}
+	n, _ := emailCount.(int)
+	global.BlackCache.Set(emailKey, n+1, time.Hour)
+	n, _ = ipCount.(int)
+	global.BlackCache.Set(ipKey, n+1, time.Hour)
+	global.BlackCache.Set(lastKey, time.Now(), time.Hour)
+	return nil
+}
+
+// emailTokenKey 邮件令牌的签名密钥，与 JWT 共用
+func emailTokenKey() []byte {
+	return []byte("user-email:" + global.GVA_CONFIG.JWT.SigningKey)
+}
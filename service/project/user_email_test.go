@@ -0,0 +1,120 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/system"
+	"ApkAdmin/utils"
+	"ApkAdmin/utils/signedtoken"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/songzhibin97/gkit/cache/local_cache"
+)
+
+func TestUserEmailFlows(t *testing.T) {
+	setupTestDB(t, &project.User{}, &project.UserEmailToken{}, &project.UserSession{}, &system.JwtBlacklist{})
+	global.BlackCache = local_cache.NewCache()
+	global.GVA_CONFIG.JWT.SigningKey = "test-signing-key"
+	saved := global.GVA_CONFIG.AccountEmail
+	originalSend := sendMail
+	t.Cleanup(func() { global.GVA_CONFIG.AccountEmail = saved; sendMail = originalSend })
+	global.GVA_CONFIG.AccountEmail.ResendInterval = 1
+	global.GVA_CONFIG.AccountEmail.EmailHourlyLimit = 1
+
+	var sent []string
+	sendMail = func(to, subject, body string) error {
+		sent = append(sent, to+"|"+body)
+		return nil
+	}
+	tokenIn := func(body string) string {
+		m := regexp.MustCompile(`token=([A-Za-z0-9_\-.]+)`).FindStringSubmatch(body)
+		if m == nil {
+			t.Fatalf("no token in %s", body)
+		}
+		return m[1]
+	}
+	global.GVA_DB.Create(&project.User{ID: 1, Username: "u1", Email: "u1@example.com", PasswordHash: utils.BcryptHash("Old12345")})
+
+	var s UserEmailService
+	const site = "https://shop.example.com/"
+	// 未配置站点地址时不发送，也不占用发送次数
+	err := s.SendVerifyEmail(1, "1.1.1.1", "")
+	if err != errSiteURLMissing {
+		t.Errorf("send without site err = %v", err)
+	}
+	if err = s.SendVerifyEmail(1, "1.1.1.1", site); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || !strings.Contains(sent[0], "https://shop.example.com/verify-email?token=") {
+		t.Fatalf("sent = %v", sent)
+	}
+	if err = s.SendVerifyEmail(1, "1.1.1.1", site); err == nil || !strings.Contains(err.Error(), "频繁") {
+		t.Errorf("resend within interval err = %v", err)
+	}
+	verifyToken := tokenIn(sent[0])
+	if err = s.VerifyEmail(verifyToken + "x"); err != signedtoken.ErrInvalid {
+		t.Errorf("tampered token err = %v", err)
+	}
+	if err = s.VerifyEmail(verifyToken); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.VerifyEmail(verifyToken); err != errTokenUsed {
+		t.Errorf("reused token err = %v", err)
+	}
+	var user project.User
+	global.GVA_DB.First(&user, 1)
+	if !user.EmailVerified {
+		t.Error("email should be verified")
+	}
+
+	// 未注册邮箱不发送也不报错
+	if err = s.SendPasswordReset("nobody@example.com", "2.2.2.2", site); err != nil || len(sent) != 1 {
+		t.Errorf("unknown email err = %v, sent = %d", err, len(sent))
+	}
+	if err = s.SendPasswordReset("u1@example.com", "2.2.2.2", site); err != nil {
+		t.Fatal(err)
+	}
+	resetToken := tokenIn(sent[1])
+	if err = s.VerifyEmail(resetToken); err != signedtoken.ErrInvalid {
+		t.Errorf("reset token used for verify err = %v", err)
+	}
+
+	var users UserService
+	if err = users.RecordSession(1, "jwt-a", time.Now().Add(time.Hour), "1.1.1.1", "ua"); err != nil {
+		t.Fatal(err)
+	}
+	users.RecordSession(1, "jwt-old", time.Now().Add(-time.Hour), "1.1.1.1", "ua")
+	if err = s.ResetPassword(resetToken, "short"); err == nil {
+		t.Error("weak password should be rejected")
+	}
+	if err = s.ResetPassword(resetToken, "New12345"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.ResetPassword(resetToken, "New12345"); err != errTokenUsed {
+		t.Errorf("reused reset token err = %v", err)
+	}
+	global.GVA_DB.First(&user, 1)
+	if !utils.BcryptCheck("New12345", user.PasswordHash) {
+		t.Error("password not updated")
+	}
+	if _, ok := global.BlackCache.Get("jwt-a"); !ok {
+		t.Error("live session should be blacklisted")
+	}
+	if _, ok := global.BlackCache.Get("jwt-old"); ok {
+		t.Error("expired session should not be blacklisted")
+	}
+	var sessions int64
+	global.GVA_DB.Model(&project.UserSession{}).Count(&sessions)
+	if sessions != 0 {
+		t.Errorf("sessions left = %d", sessions)
+	}
+
+	// 每小时次数：超过间隔后同一邮箱仍受每小时次数限制，邮箱不区分大小写
+	time.Sleep(1100 * time.Millisecond)
+	if err = s.SendPasswordReset("U1@example.com", "3.3.3.3", site); err == nil || !strings.Contains(err.Error(), "次数过多") {
+		t.Errorf("hourly limit err = %v", err)
+	}
+}
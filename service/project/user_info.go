@@ -57,7 +57,7 @@ func (u *UserService) GetUserDetail(conditions ...func(*gorm.DB) *gorm.DB) (*res
 		}(),
 
 		// 账户状态
-		AccountStatus:     string(user.AccountStatus),
+		AccountStatus:     string(rune(user.AccountStatus)),
 		AccountStatusText: user.GetAccountStatusText(),
 		EmailVerified:     user.EmailVerified,
 		PhoneVerified:     user.PhoneVerified,
@@ -0,0 +1,47 @@
+package project
+
+import (
+	"ApkAdmin/global"
+	projectModel "ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"time"
+)
+
+type UserNotificationService struct{}
+
+// GetNotificationList 站内通知列表，未读在前
+func (s *UserNotificationService) GetNotificationList(userID uint, req request.NotificationListRequest) (list []projectModel.UserNotification, total int64, err error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 50 {
+		req.PageSize = 20
+	}
+	db := global.GVA_DB.Model(&projectModel.UserNotification{}).Where("user_id = ?", userID)
+	if req.UnreadOnly {
+		db = db.Where("is_read = ?", false)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Order("is_read asc, id desc").
+		Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+// UnreadCount 未读通知数量
+func (s *UserNotificationService) UnreadCount(userID uint) (count int64, err error) {
+	err = global.GVA_DB.Model(&projectModel.UserNotification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).Count(&count).Error
+	return count, err
+}
+
+// MarkRead 标记通知已读，ids 为空时全部标记为已读
+func (s *UserNotificationService) MarkRead(userID uint, ids []uint64) error {
+	db := global.GVA_DB.Model(&projectModel.UserNotification{}).Where("user_id = ? AND is_read = ?", userID, false)
+	if len(ids) > 0 {
+		db = db.Where("id IN ?", ids)
+	}
+	return db.Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()}).Error
+}
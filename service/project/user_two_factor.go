@@ -0,0 +1,365 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/model/project/response"
+	"ApkAdmin/utils/signedtoken"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount = 10
+	// loginChallengeTTL 密码验证通过后完成双因子验证的时限
+	loginChallengeTTL = 5 * time.Minute
+	// loginChallengeAttempts 每个登录挑战最多尝试的验证码次数
+	loginChallengeAttempts = 5
+	// twoFactorVerifyAttempts 同一用户连续验证失败的次数上限，达到后锁定 twoFactorLockDuration
+	twoFactorVerifyAttempts = 5
+	twoFactorLockDuration   = 15 * time.Minute
+	recoveryCodeAlphabet    = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+var (
+	errTwoFactorRequired = errors.New("请输入双因子验证码")
+	errTwoFactorInvalid  = errors.New("双因子验证码错误")
+	errTwoFactorLocked   = errors.New("双因子验证失败次数过多，请15分钟后再试")
+)
+
+// twoFactorAttemptMu 保证读取和增加失败次数是原子的，并发请求不能绕过次数限制
+var twoFactorAttemptMu sync.Mutex
+
+// UserTwoFactorService 前台用户的双因子认证（TOTP 和恢复码）
+type UserTwoFactorService struct{}
+
+var twoFactorService = UserTwoFactorService{}
+
+// Setup 生成待确认的密钥，确认前不生效，重复调用会覆盖上一次未确认的密钥
+func (s *UserTwoFactorService) Setup(userID uint) (*response.TwoFactorSetupResponse, error) {
+	var user project.User
+	if err := global.GVA_DB.Select("id, username, email, two_factor_enabled").Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if user.TwoFactorEnabled {
+		return nil, errors.New("已开启双因子认证")
+	}
+	accountName := user.Email
+	if accountName == "" {
+		accountName = user.Username
+	}
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      constants.SystemName,
+		AccountName: accountName,
+	})
+	if err != nil {
+		return nil, errors.New("生成验证器密钥失败")
+	}
+	img, err := key.Image(200, 200)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	err = global.GVA_DB.Model(&project.User{}).Where("id = ? AND two_factor_enabled = ?", userID, false).
+		Update("two_factor_secret", key.Secret()).Error
+	if err != nil {
+		return nil, err
+	}
+	return &response.TwoFactorSetupResponse{
+		Secret: key.Secret(),
+		URL:    key.URL(),
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// Enable 用验证器中的验证码确认密钥并开启，返回只显示一次的恢复码
+func (s *UserTwoFactorService) Enable(userID uint, code, clientIP string) ([]string, error) {
+	var user project.User
+	if err := global.GVA_DB.Select("id, two_factor_enabled, two_factor_secret").Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if user.TwoFactorEnabled {
+		return nil, errors.New("已开启双因子认证")
+	}
+	if user.TwoFactorSecret == "" {
+		return nil, errors.New("请先获取验证器密钥")
+	}
+	if !totp.Validate(strings.TrimSpace(code), user.TwoFactorSecret) {
+		return nil, errTwoFactorInvalid
+	}
+	var codes []string
+	err := global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&project.User{}).Where("id = ? AND two_factor_enabled = ?", userID, false).
+			Updates(map[string]interface{}{"two_factor_enabled": true, "two_factor_at": time.Now()})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("已开启双因子认证")
+		}
+		var err error
+		if codes, err = replaceRecoveryCodes(tx, userID); err != nil {
+			return err
+		}
+		return writeTwoFactorLog(tx, project.UserTwoFactorLog{UserID: userID, Action: constants.TwoFactorActionEnable, IP: clientIP})
+	})
+	return codes, err
+}
+
+// Disable 用户关闭双因子认证，需要验证码或恢复码
+func (s *UserTwoFactorService) Disable(userID uint, code, clientIP string) error {
+	if err := s.Verify(userID, code, clientIP); err != nil {
+		return err
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := clearTwoFactor(tx, userID); err != nil {
+			return err
+		}
+		return writeTwoFactorLog(tx, project.UserTwoFactorLog{UserID: userID, Action: constants.TwoFactorActionDisable, IP: clientIP})
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部失效
+func (s *UserTwoFactorService) RegenerateRecoveryCodes(userID uint, code, clientIP string) ([]string, error) {
+	enabled, err := twoFactorEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, errors.New("未开启双因子认证")
+	}
+	if err = s.Verify(userID, code, clientIP); err != nil {
+		return nil, err
+	}
+	var codes []string
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if codes, err = replaceRecoveryCodes(tx, userID); err != nil {
+			return err
+		}
+		return writeTwoFactorLog(tx, project.UserTwoFactorLog{UserID: userID, Action: constants.TwoFactorActionRegenerate, IP: clientIP})
+	})
+	return codes, err
+}
+
+// Verify 校验第二因子，用于登录、提现、修改密码和邮箱等操作；未开启双因子认证时直接通过。
+// 同一个 TOTP 验证码在有效期内只能使用一次，恢复码使用后失效；同一用户连续失败达到上限后暂时锁定
+func (s *UserTwoFactorService) Verify(userID uint, code, clientIP string) error {
+	var user project.User
+	if err := global.GVA_DB.Select("id, two_factor_enabled, two_factor_secret").Where("id = ?", userID).First(&user).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	if !user.TwoFactorEnabled {
+		return nil
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return errTwoFactorRequired
+	}
+	// 校验前先占用一次尝试次数，通过后清零
+	failKey := fmt.Sprintf("2fa:fail:%d", userID)
+	twoFactorAttemptMu.Lock()
+	failures, _ := global.BlackCache.Get(failKey)
+	n, _ := failures.(int)
+	if n >= twoFactorVerifyAttempts {
+		twoFactorAttemptMu.Unlock()
+		return errTwoFactorLocked
+	}
+	global.BlackCache.Set(failKey, n+1, twoFactorLockDuration)
+	twoFactorAttemptMu.Unlock()
+
+	err := s.verifyCode(userID, user.TwoFactorSecret, code, clientIP)
+	if err == nil {
+		global.BlackCache.Delete(failKey)
+	} else if n+1 == twoFactorVerifyAttempts {
+		global.GVA_LOG.Warn("双因子验证连续失败，暂时锁定", zap.Uint("userID", userID), zap.String("ip", clientIP))
+	}
+	return err
+}
+
+// verifyCode 校验 TOTP 验证码或恢复码
+func (s *UserTwoFactorService) verifyCode(userID uint, secret, code, clientIP string) error {
+	if len(code) == 6 && strings.Trim(code, "0123456789") == "" {
+		usedKey := fmt.Sprintf("2fa:used:%d:%s", userID, code)
+		if _, used := global.BlackCache.Get(usedKey); used || !totp.Validate(code, secret) {
+			return errTwoFactorInvalid
+		}
+		global.BlackCache.Set(usedKey, struct{}{}, 2*time.Minute)
+		return nil
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&project.UserRecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+			Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errTwoFactorInvalid
+		}
+		var left int64
+		tx.Model(&project.UserRecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&left)
+		return writeTwoFactorLog(tx, project.UserTwoFactorLog{
+			UserID: userID,
+			Action: constants.TwoFactorActionRecoveryUsed,
+			IP:     clientIP,
+			Note:   fmt.Sprintf("剩余恢复码 %d 个", left),
+		})
+	})
+}
+
+// IssueLoginChallenge 密码验证通过后签发登录挑战令牌，不能用于访问其它接口
+func (s *UserTwoFactorService) IssueLoginChallenge(userID uint) (string, time.Time, error) {
+	token, payload, err := signedtoken.New(emailTokenKey(), string(constants.UserTokenLogin2FA), userID, loginChallengeTTL)
+	return token, payload.ExpiresAt, err
+}
+
+// CompleteLogin 校验登录挑战和第二因子，成功后记录登录；验证码错误计入登录失败次数
+func (s *UserTwoFactorService) CompleteLogin(challenge, code, clientIP, userAgent string) (*project.User, error) {
+	payload, err := signedtoken.Parse(emailTokenKey(), strings.TrimSpace(challenge), string(constants.UserTokenLogin2FA))
+	if err != nil {
+		return nil, errors.New("登录已超时，请重新登录")
+	}
+	nonceKey := "2fa:challenge:" + payload.Nonce
+	attempts, _ := global.BlackCache.Get(nonceKey)
+	n, _ := attempts.(int)
+	if n >= loginChallengeAttempts {
+		return nil, errors.New("登录已超时，请重新登录")
+	}
+	global.BlackCache.Set(nonceKey, n+1, loginChallengeTTL)
+
+	var users UserService
+	var user project.User
+	if err = global.GVA_DB.Where("id = ?", payload.UserID).First(&user).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if err = users.checkAccountStatus(&user); err != nil {
+		return nil, err
+	}
+	if err = s.Verify(user.ID, code, clientIP); err != nil {
+		if errors.Is(err, errTwoFactorInvalid) {
+			lockErr := users.handleLoginFailure(&user, clientIP)
+			if user.AccountStatus == constants.AccountStatusLocked {
+				return nil, lockErr
+			}
+		}
+		return nil, err
+	}
+	// 挑战令牌只能成功使用一次
+	global.BlackCache.Set(nonceKey, loginChallengeAttempts, loginChallengeTTL)
+	users.handleLoginSuccess(&user, clientIP, userAgent)
+	return &user, nil
+}
+
+// AdminReset 管理员重置用户的双因子认证（例如用户丢失设备且没有恢复码），用户需要重新开启
+func (s *UserTwoFactorService) AdminReset(req request.AdminResetTwoFactorRequest, operatorID uint, operatorName, clientIP string) error {
+	enabled, err := twoFactorEnabled(req.ID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return errors.New("该用户未开启双因子认证")
+	}
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := clearTwoFactor(tx, req.ID); err != nil {
+			return err
+		}
+		return writeTwoFactorLog(tx, project.UserTwoFactorLog{
+			UserID:     req.ID,
+			Action:     constants.TwoFactorActionAdminReset,
+			OperatorID: operatorID,
+			Operator:   operatorName,
+			IP:         clientIP,
+			Note:       truncateRunes(strings.TrimSpace(req.Reason), 200),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	global.BlackCache.Delete(fmt.Sprintf("2fa:fail:%d", req.ID))
+	global.GVA_LOG.Info("管理员重置用户双因子认证", zap.Uint("userID", req.ID), zap.Uint("operatorID", operatorID))
+	return nil
+}
+
+// GetLogs 双因子认证记录
+func (s *UserTwoFactorService) GetLogs(req request.TwoFactorLogRequest) (list []project.UserTwoFactorLog, total int64, err error) {
+	db := global.GVA_DB.Model(&project.UserTwoFactorLog{})
+	if req.UserID > 0 {
+		db = db.Where("user_id = ?", req.UserID)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Scopes(req.Paginate()).Order("id desc").Find(&list).Error
+	return list, total, err
+}
+
+func twoFactorEnabled(userID uint) (bool, error) {
+	var user project.User
+	if err := global.GVA_DB.Select("id, two_factor_enabled").Where("id = ?", userID).First(&user).Error; err != nil {
+		return false, errors.New("用户不存在")
+	}
+	return user.TwoFactorEnabled, nil
+}
+
+func clearTwoFactor(tx *gorm.DB, userID uint) error {
+	err := tx.Model(&project.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"two_factor_enabled": false,
+		"two_factor_secret":  "",
+		"two_factor_at":      nil,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&project.UserRecoveryCode{}).Error
+}
+
+// replaceRecoveryCodes 删除旧恢复码并生成新的一组
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&project.UserRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCodeCount)
+	rows := make([]project.UserRecoveryCode, recoveryCodeCount)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		for j, b := range buf {
+			buf[j] = recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)]
+		}
+		codes[i] = string(buf[:5]) + "-" + string(buf[5:])
+		rows[i] = project.UserRecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(codes[i])}
+	}
+	return codes, tx.Create(&rows).Error
+}
+
+// hashRecoveryCode 忽略大小写和分隔符
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeTwoFactorLog(tx *gorm.DB, entry project.UserTwoFactorLog) error {
+	entry.IP = truncateRunes(entry.IP, 45)
+	return tx.Create(&entry).Error
+}
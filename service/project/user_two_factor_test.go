@@ -0,0 +1,137 @@
+package project
+
+import (
+	"ApkAdmin/constants"
+	"ApkAdmin/global"
+	commonReq "ApkAdmin/model/common/request"
+	"ApkAdmin/model/project"
+	"ApkAdmin/model/project/request"
+	"ApkAdmin/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/songzhibin97/gkit/cache/local_cache"
+)
+
+func TestUserTwoFactor(t *testing.T) {
+	setupTestDB(t, &project.User{}, &project.UserRecoveryCode{}, &project.UserTwoFactorLog{})
+	global.BlackCache = local_cache.NewCache()
+	global.GVA_CONFIG.JWT.SigningKey = "test-signing-key"
+	phone := "13800000000"
+	global.GVA_DB.Create(&project.User{ID: 1, Username: "u1", Email: "u1@example.com", Phone: &phone, PasswordHash: utils.BcryptHash("Old12345")})
+
+	var s UserTwoFactorService
+	setup, err := s.Setup(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(setup.QRCode, "data:image/png;base64,") || !strings.HasPrefix(setup.URL, "otpauth://totp/") {
+		t.Errorf("setup = %+v", setup)
+	}
+	// 未开启前不需要第二因子
+	if err = s.Verify(1, "", "1.1.1.1"); err != nil {
+		t.Errorf("verify before enable err = %v", err)
+	}
+	if _, err = s.Enable(1, "000000", "1.1.1.1"); err != errTwoFactorInvalid {
+		t.Errorf("enable with wrong code err = %v", err)
+	}
+	code, _ := totp.GenerateCode(setup.Secret, time.Now())
+	codes, err := s.Enable(1, code, "1.1.1.1")
+	if err != nil || len(codes) != recoveryCodeCount {
+		t.Fatalf("enable = %v, %v", codes, err)
+	}
+	if _, err = s.Setup(1); err == nil {
+		t.Error("setup after enable should be rejected")
+	}
+
+	// 修改密码需要第二因子，同一个验证码不能重复使用
+	var users UserService
+	change := request.ChangeUserPasswordRequest{OldPassword: "Old12345", NewPassword: "New12345"}
+	if err = users.ChangeUserPassword(1, change, "1.1.1.1"); err != errTwoFactorRequired {
+		t.Errorf("change password without code err = %v", err)
+	}
+	change.TwoFactorCode = code
+	if err = users.ChangeUserPassword(1, change, "1.1.1.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Verify(1, code, "1.1.1.1"); err != errTwoFactorInvalid {
+		t.Errorf("replayed code err = %v", err)
+	}
+
+	// 恢复码不区分大小写，只能用一次
+	if err = s.Verify(1, strings.ToUpper(codes[0]), "1.1.1.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Verify(1, codes[0], "1.1.1.1"); err != errTwoFactorInvalid {
+		t.Errorf("reused recovery code err = %v", err)
+	}
+
+	// 登录：密码通过后不记录登录，挑战令牌验证通过才算登录成功
+	user, err := users.Login(&project.User{Phone: &phone, PasswordHash: "New12345"}, "1.1.1.1", "ua")
+	if err != nil || !user.TwoFactorEnabled {
+		t.Fatalf("login = %+v, %v", user, err)
+	}
+	challenge, _, err := s.IssueLoginChallenge(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.CompleteLogin(challenge, "000000", "1.1.1.1", "ua"); err != errTwoFactorInvalid {
+		t.Errorf("wrong code err = %v", err)
+	}
+	global.GVA_DB.First(user, 1)
+	if user.FailedLoginAttempts != 1 || user.LoginCount != 0 {
+		t.Errorf("after wrong code: failed = %d, logins = %d", user.FailedLoginAttempts, user.LoginCount)
+	}
+	if user, err = s.CompleteLogin(challenge, codes[1], "1.1.1.1", "ua"); err != nil {
+		t.Fatal(err)
+	}
+	if user.LoginCount != 1 || user.FailedLoginAttempts != 0 {
+		t.Errorf("after login: failed = %d, logins = %d", user.FailedLoginAttempts, user.LoginCount)
+	}
+	if _, err = s.CompleteLogin(challenge, codes[2], "1.1.1.1", "ua"); err == nil {
+		t.Error("challenge should be single use")
+	}
+
+	regenerated, err := s.RegenerateRecoveryCodes(1, codes[3], "1.1.1.1")
+	if err != nil || len(regenerated) != recoveryCodeCount {
+		t.Fatalf("regenerate = %v, %v", regenerated, err)
+	}
+	if err = s.Verify(1, codes[4], "1.1.1.1"); err != errTwoFactorInvalid {
+		t.Errorf("old recovery code after regenerate err = %v", err)
+	}
+
+	// 连续失败达到上限后锁定，正确的恢复码和其它需要第二因子的操作也被拒绝
+	for i := 1; i < twoFactorVerifyAttempts; i++ {
+		if err = s.Verify(1, "aaaaa-bbbbb", "1.1.1.1"); err != errTwoFactorInvalid {
+			t.Fatalf("failure %d err = %v", i+1, err)
+		}
+	}
+	if err = s.Verify(1, regenerated[0], "1.1.1.1"); err != errTwoFactorLocked {
+		t.Errorf("verify while locked err = %v", err)
+	}
+	change = request.ChangeUserPasswordRequest{OldPassword: "New12345", NewPassword: "Other123", TwoFactorCode: regenerated[0]}
+	if err = users.ChangeUserPassword(1, change, "1.1.1.1"); err != errTwoFactorLocked {
+		t.Errorf("change password while locked err = %v", err)
+	}
+
+	if err = s.AdminReset(request.AdminResetTwoFactorRequest{ID: 1, Reason: "用户丢失手机"}, 9, "admin", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Verify(1, "", "1.1.1.1"); err != nil {
+		t.Errorf("verify after reset err = %v", err)
+	}
+	var left int64
+	global.GVA_DB.Model(&project.UserRecoveryCode{}).Count(&left)
+	if left != 0 {
+		t.Errorf("recovery codes left = %d", left)
+	}
+	logs, total, err := s.GetLogs(request.TwoFactorLogRequest{PageInfo: commonReq.PageInfo{Page: 1, PageSize: 20}, UserID: 1})
+	if err != nil || total != 6 {
+		t.Fatalf("logs total = %d, err = %v", total, err)
+	}
+	if logs[0].Action != constants.TwoFactorActionAdminReset || logs[0].OperatorID != 9 || logs[0].Note != "用户丢失手机" {
+		t.Errorf("latest log = %+v", logs[0])
+	}
+}
@@ -2,7 +2,6 @@ package task
 
 import (
 	"ApkAdmin/model/common"
-	"ApkAdmin/model/project"
 	"errors"
 	"fmt"
 	"time"
@@ -49,26 +48,3 @@ func ClearTable(db *gorm.DB) error {
 	}
 	return nil
 }
-
-// 每天或每小时执行
-func UpdateActiveMembers(db *gorm.DB) {
-	// 统计每个用户的活跃下级数（近30天有订单）
-	sql := `
-        UPDATE team_statistics ts
-        SET active_members = (
-            SELECT COUNT(DISTINCT u.id)
-            FROM users u
-            INNER JOIN user_statistics us ON u.id = us.user_id
-            WHERE u.referrer_id = ts.user_id
-            AND us.last_order_at >= DATE_SUB(NOW(), INTERVAL 30 DAY)
-        )
-    `
-	db.Exec(sql)
-}
-
-// 每天0点执行
-func ResetTodayNew(db *gorm.DB) {
-	db.Model(&project.TeamStatistics{}).
-		Where("today_new > 0").
-		Update("today_new", 0)
-}
@@ -0,0 +1,97 @@
+// Package accountcheck 应用账号健康检查，每个应用可以配置一种检查器
+package accountcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Verdict 检查结论
+type Verdict string
+
+const (
+	VerdictOK      Verdict = "ok"      // 账号可用
+	VerdictExpired Verdict = "expired" // 账号已过期
+	VerdictRisk    Verdict = "risk"    // 账号异常，可能被封或密码被改
+	VerdictUnknown Verdict = "unknown" // 无法判断，例如没有填写到期时间
+)
+
+// Account 待检查的账号，Detail 为解密后的明文
+type Account struct {
+	ID        uint
+	Detail    string
+	ExtraInfo string
+}
+
+// Result 检查结果，Reason 会写入状态变更记录
+type Result struct {
+	Verdict Verdict
+	Reason  string
+}
+
+// Checker 账号检查器。返回 error 表示检查本身失败（网络错误等），不应据此修改账号状态
+type Checker interface {
+	Check(ctx context.Context, account Account) (Result, error)
+}
+
+// Factory 根据应用的检查器配置创建检查器，配置非法时返回错误
+type Factory func(config json.RawMessage) (Checker, error)
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]Factory{}
+)
+
+// now 便于测试替换
+var now = time.Now
+
+// Register 注册检查器类型，重复注册会覆盖
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	factories[name] = factory
+}
+
+// New 按类型和配置创建检查器
+func New(name string, config json.RawMessage) (Checker, error) {
+	mu.RLock()
+	factory, ok := factories[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("不支持的检查器类型: %s", name)
+	}
+	if len(config) == 0 {
+		config = json.RawMessage("{}")
+	}
+	return factory(config)
+}
+
+// Types 已注册的检查器类型
+func Types() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(TypeExtraExpiry, newExtraExpiry)
+	Register(TypeHTTPProbe, newHTTPProbe)
+}
+
+// extraInfoMap 额外信息为 JSON 对象时解析为 map，否则返回 nil
+func extraInfoMap(extra string) map[string]interface{} {
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(extra), &m); err != nil {
+		return nil
+	}
+	return m
+}
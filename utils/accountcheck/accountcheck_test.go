@@ -0,0 +1,115 @@
+package accountcheck
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExtraExpiry(t *testing.T) {
+	fixed := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return fixed }
+	t.Cleanup(func() { now = time.Now })
+
+	checker, err := New(TypeExtraExpiry, json.RawMessage(`{"risk_days":3,"location":"UTC"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		extra string
+		want  Verdict
+	}{
+		{`{"expire_at":"2026-05-09"}`, VerdictExpired},
+		{`{"expire_at":"2026-05-10"}`, VerdictRisk}, // 当天结束才到期
+		{`{"到期时间":"2026-05-12 08:00"}`, VerdictRisk},
+		{`{"expiry":"2026-06-01T00:00:00Z"}`, VerdictOK},
+		{`{"expires_at":1778300000}`, VerdictExpired},
+		{`{"expire_at":"下个月"}`, VerdictUnknown},
+		{`{"other":"x"}`, VerdictUnknown},
+		{`纯文本`, VerdictUnknown},
+	}
+	for _, c := range cases {
+		got, err := checker.Check(context.Background(), Account{ExtraInfo: c.extra})
+		if err != nil {
+			t.Fatalf("%s: %v", c.extra, err)
+		}
+		if got.Verdict != c.want {
+			t.Errorf("%s: verdict = %s (%s), want %s", c.extra, got.Verdict, got.Reason, c.want)
+		}
+	}
+
+	custom, _ := New(TypeExtraExpiry, json.RawMessage(`{"field":"vip_end","location":"UTC"}`))
+	got, _ := custom.Check(context.Background(), Account{ExtraInfo: `{"expire_at":"2020-01-01","vip_end":"2027-01-01"}`})
+	if got.Verdict != VerdictOK {
+		t.Errorf("custom field verdict = %s", got.Verdict)
+	}
+}
+
+func TestHTTPProbe(t *testing.T) {
+	var gotBody, gotToken string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody, gotToken = string(b), r.Header.Get("X-Region")
+		switch r.URL.Query().Get("u") {
+		case "alice":
+			w.Write([]byte(`{"token":"abc"}`))
+		case "bob":
+			w.Write([]byte(`{"error":"subscription expired"}`))
+		case "carol":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "dave":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`{"error":"account locked"}`))
+		}
+	}))
+	defer srv.Close()
+
+	cfg, _ := json.Marshal(HTTPProbeConfig{
+		URL:             srv.URL + "/login?u={{index .Parts 0 | urlquery}}",
+		Headers:         map[string]string{"X-Region": "{{.Extra.region}}"},
+		Body:            `{"user":{{json (index .Parts 0)}},"password":{{json (index .Parts 1)}}}`,
+		OKContains:      "token",
+		ExpiredContains: []string{"expired"},
+		RiskContains:    []string{"locked"},
+	})
+	checker, err := New(TypeHTTPProbe, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		user string
+		want Verdict
+	}{
+		{"alice", VerdictOK},
+		{"bob", VerdictExpired},
+		{"carol", VerdictRisk},
+		{"eve", VerdictRisk},
+	}
+	for _, c := range cases {
+		got, err := checker.Check(context.Background(), Account{Detail: c.user + "----p\"w", ExtraInfo: `{"region":"cn"}`})
+		if err != nil {
+			t.Fatalf("%s: %v", c.user, err)
+		}
+		if got.Verdict != c.want {
+			t.Errorf("%s: verdict = %s (%s), want %s", c.user, got.Verdict, got.Reason, c.want)
+		}
+	}
+	if gotBody != `{"user":"eve","password":"p\"w"}` || gotToken != "cn" {
+		t.Errorf("request body = %s, header = %s", gotBody, gotToken)
+	}
+	if _, err = checker.Check(context.Background(), Account{Detail: "dave----x"}); err == nil {
+		t.Error("5xx should be reported as check error")
+	}
+
+	if _, err = New(TypeHTTPProbe, json.RawMessage(`{}`)); err == nil {
+		t.Error("empty url should be rejected")
+	}
+	if _, err = New("unknown", nil); err == nil {
+		t.Error("unknown type should be rejected")
+	}
+}
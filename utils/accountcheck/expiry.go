@@ -0,0 +1,119 @@
+package accountcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TypeExtraExpiry 从额外信息中读取到期时间
+const TypeExtraExpiry = "extra_expiry"
+
+// defaultExpiryFields 未配置字段名时依次尝试
+var defaultExpiryFields = []string{"expire_at", "expires_at", "expired_at", "expiry", "到期时间", "过期时间"}
+
+var expiryLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+// ExtraExpiryConfig 到期时间检查配置
+type ExtraExpiryConfig struct {
+	Field    string `json:"field"`     // 额外信息中的字段名，为空时尝试常见字段名
+	Location string `json:"location"`  // 不带时区的时间按此时区解析，默认 Asia/Shanghai
+	RiskDays int    `json:"risk_days"` // 距到期不足该天数时标记为风险，0 表示不提前标记
+}
+
+type extraExpiry struct {
+	fields   []string
+	location *time.Location
+	riskDays int
+}
+
+func newExtraExpiry(raw json.RawMessage) (Checker, error) {
+	var cfg ExtraExpiryConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("到期时间检查配置格式错误: %w", err)
+	}
+	if cfg.RiskDays < 0 {
+		return nil, fmt.Errorf("risk_days 不能小于0")
+	}
+	c := &extraExpiry{fields: defaultExpiryFields, riskDays: cfg.RiskDays}
+	if cfg.Field != "" {
+		c.fields = []string{cfg.Field}
+	}
+	if cfg.Location == "" {
+		cfg.Location = "Asia/Shanghai"
+	}
+	loc, err := time.LoadLocation(cfg.Location)
+	if err != nil {
+		return nil, fmt.Errorf("时区 %s 无效: %w", cfg.Location, err)
+	}
+	c.location = loc
+	return c, nil
+}
+
+func (c *extraExpiry) Check(_ context.Context, account Account) (Result, error) {
+	extra := extraInfoMap(account.ExtraInfo)
+	var value interface{}
+	var field string
+	for _, f := range c.fields {
+		if v, ok := extra[f]; ok && v != nil && v != "" {
+			value, field = v, f
+			break
+		}
+	}
+	if value == nil {
+		return Result{Verdict: VerdictUnknown, Reason: "额外信息中没有到期时间"}, nil
+	}
+	expireAt, ok := c.parse(value)
+	if !ok {
+		return Result{Verdict: VerdictUnknown, Reason: fmt.Sprintf("无法解析到期时间 %s=%v", field, value)}, nil
+	}
+	current := now()
+	text := expireAt.In(c.location).Format("2006-01-02 15:04")
+	if !expireAt.After(current) {
+		return Result{Verdict: VerdictExpired, Reason: "已于 " + text + " 到期"}, nil
+	}
+	if c.riskDays > 0 && expireAt.Sub(current) < time.Duration(c.riskDays)*24*time.Hour {
+		return Result{Verdict: VerdictRisk, Reason: "将于 " + text + " 到期"}, nil
+	}
+	return Result{Verdict: VerdictOK}, nil
+}
+
+// parse 支持常见日期格式字符串和秒/毫秒时间戳
+func (c *extraExpiry) parse(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case float64:
+		return unixTime(int64(v)), true
+	case string:
+		v = strings.TrimSpace(v)
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return unixTime(n), true
+		}
+		for _, layout := range expiryLayouts {
+			if t, err := time.ParseInLocation(layout, v, c.location); err == nil {
+				if layout == "2006-01-02" || layout == "2006/01/02" {
+					// 只有日期时视为当天结束时到期
+					t = t.Add(24*time.Hour - time.Second)
+				}
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func unixTime(n int64) time.Time {
+	if n > 1e12 {
+		return time.UnixMilli(n)
+	}
+	return time.Unix(n, 0)
+}
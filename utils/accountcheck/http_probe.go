@@ -0,0 +1,171 @@
+package accountcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TypeHTTPProbe 按模板构造登录请求，根据响应判断账号状态
+const TypeHTTPProbe = "http_probe"
+
+const maxProbeBody = 64 << 10
+
+// HTTPProbeConfig 登录探测配置。URL、请求头和请求体是 text/template 模板，可用变量：
+//
+//	.Detail 账号详情原文
+//	.Parts  账号详情按 separator 拆分后的各段，如 {{index .Parts 0}}
+//	.Fields 账号详情为 JSON 对象时的字段
+//	.Extra  额外信息为 JSON 对象时的字段
+//
+// 模板函数 json 把值编码为 JSON，urlquery 做 URL 编码。
+type HTTPProbeConfig struct {
+	Method          string            `json:"method"`           // 默认 POST
+	URL             string            `json:"url"`              // 请求地址模板
+	Headers         map[string]string `json:"headers"`          // 请求头模板
+	Body            string            `json:"body"`             // 请求体模板
+	Separator       string            `json:"separator"`        // 账号详情分隔符，默认 ----
+	TimeoutSeconds  int               `json:"timeout_seconds"`  // 默认 10 秒
+	OKStatus        []int             `json:"ok_status"`        // 视为登录成功的状态码，默认 2xx
+	OKContains      string            `json:"ok_contains"`      // 登录成功的响应必须包含的内容
+	ExpiredContains []string          `json:"expired_contains"` // 响应包含任意一项时判定为过期
+	RiskContains    []string          `json:"risk_contains"`    // 响应包含任意一项时判定为风险
+}
+
+type httpProbe struct {
+	cfg     HTTPProbeConfig
+	url     *template.Template
+	body    *template.Template
+	headers map[string]*template.Template
+	client  *http.Client
+}
+
+var probeFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+func newHTTPProbe(raw json.RawMessage) (Checker, error) {
+	var cfg HTTPProbeConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("登录探测配置格式错误: %w", err)
+	}
+	if cfg.URL == "" {
+		return nil, errors.New("登录探测地址不能为空")
+	}
+	if cfg.Method == "" {
+		cfg.Method = http.MethodPost
+	}
+	if cfg.Separator == "" {
+		cfg.Separator = "----"
+	}
+	if cfg.TimeoutSeconds <= 0 {
+		cfg.TimeoutSeconds = 10
+	}
+	p := &httpProbe{
+		cfg:     cfg,
+		headers: make(map[string]*template.Template, len(cfg.Headers)),
+		client:  &http.Client{Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second},
+	}
+	var err error
+	if p.url, err = template.New("url").Funcs(probeFuncs).Option("missingkey=zero").Parse(cfg.URL); err != nil {
+		return nil, fmt.Errorf("url 模板错误: %w", err)
+	}
+	if p.body, err = template.New("body").Funcs(probeFuncs).Option("missingkey=zero").Parse(cfg.Body); err != nil {
+		return nil, fmt.Errorf("body 模板错误: %w", err)
+	}
+	for k, v := range cfg.Headers {
+		if p.headers[k], err = template.New(k).Funcs(probeFuncs).Option("missingkey=zero").Parse(v); err != nil {
+			return nil, fmt.Errorf("请求头 %s 模板错误: %w", k, err)
+		}
+	}
+	return p, nil
+}
+
+func (p *httpProbe) Check(ctx context.Context, account Account) (Result, error) {
+	data := map[string]interface{}{
+		"Detail": account.Detail,
+		"Parts":  strings.Split(account.Detail, p.cfg.Separator),
+		"Fields": extraInfoMap(account.Detail),
+		"Extra":  extraInfoMap(account.ExtraInfo),
+	}
+	url, err := render(p.url, data)
+	if err != nil {
+		return Result{}, err
+	}
+	body, err := render(p.body, data)
+	if err != nil {
+		return Result{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, p.cfg.Method, url, strings.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	for k, tpl := range p.headers {
+		v, err := render(tpl, data)
+		if err != nil {
+			return Result{}, err
+		}
+		req.Header.Set(k, v)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	if err != nil {
+		return Result{}, err
+	}
+	if resp.StatusCode >= 500 {
+		// 对方服务异常，不代表账号有问题
+		return Result{}, fmt.Errorf("登录探测返回 HTTP %d", resp.StatusCode)
+	}
+
+	for _, s := range p.cfg.ExpiredContains {
+		if s != "" && bytes.Contains(respBody, []byte(s)) {
+			return Result{Verdict: VerdictExpired, Reason: "登录响应包含 " + s}, nil
+		}
+	}
+	for _, s := range p.cfg.RiskContains {
+		if s != "" && bytes.Contains(respBody, []byte(s)) {
+			return Result{Verdict: VerdictRisk, Reason: "登录响应包含 " + s}, nil
+		}
+	}
+	if !p.statusOK(resp.StatusCode) {
+		return Result{Verdict: VerdictRisk, Reason: fmt.Sprintf("登录失败，HTTP %d", resp.StatusCode)}, nil
+	}
+	if p.cfg.OKContains != "" && !bytes.Contains(respBody, []byte(p.cfg.OKContains)) {
+		return Result{Verdict: VerdictRisk, Reason: "登录响应缺少 " + p.cfg.OKContains}, nil
+	}
+	return Result{Verdict: VerdictOK}, nil
+}
+
+func (p *httpProbe) statusOK(code int) bool {
+	if len(p.cfg.OKStatus) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, c := range p.cfg.OKStatus {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func render(tpl *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染模板 %s 失败: %w", tpl.Name(), err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,401 @@
+// Package bsdiff 实现 Colin Percival 的 bsdiff 二进制差分算法
+//
+// 补丁结构与 BSDIFF40 一致（控制块 / 差异块 / 额外块），由于标准库没有 bzip2 压缩，
+// 三个数据块改用 gzip 压缩，文件头魔数为 "BSDIFFGZ"，客户端需使用对应的 bspatch 实现。
+package bsdiff
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const magic = "BSDIFFGZ"
+
+const headerSize = 32
+
+// ErrCorruptPatch 补丁格式错误
+var ErrCorruptPatch = errors.New("bsdiff: corrupt patch")
+
+// Diff 计算从 oldData 到 newData 的补丁
+func Diff(oldData, newData []byte) ([]byte, error) {
+	I := qsufsort(oldData)
+
+	db := make([]byte, len(newData))
+	eb := make([]byte, len(newData))
+	var dblen, eblen int
+	var ctrl bytes.Buffer
+
+	var scan, pos, length int
+	var lastscan, lastpos, lastoffset int
+	for scan < len(newData) {
+		var oldscore int
+		scan += length
+		for scsc := scan; scan < len(newData); scan++ {
+			pos, length = search(I, oldData, newData[scan:], 0, len(oldData))
+			for ; scsc < scan+length; scsc++ {
+				if scsc+lastoffset < len(oldData) && oldData[scsc+lastoffset] == newData[scsc] {
+					oldscore++
+				}
+			}
+			if (length == oldscore && length != 0) || length > oldscore+8 {
+				break
+			}
+			if scan+lastoffset < len(oldData) && oldData[scan+lastoffset] == newData[scan] {
+				oldscore--
+			}
+		}
+
+		if length == oldscore && scan != len(newData) {
+			continue
+		}
+
+		// 向前扩展
+		var lenf int
+		{
+			var s, sf int
+			for i := 0; lastscan+i < scan && lastpos+i < len(oldData); {
+				if oldData[lastpos+i] == newData[lastscan+i] {
+					s++
+				}
+				i++
+				if s*2-i > sf*2-lenf {
+					sf = s
+					lenf = i
+				}
+			}
+		}
+
+		// 向后扩展
+		var lenb int
+		if scan < len(newData) {
+			var s, sb int
+			for i := 1; scan >= lastscan+i && pos >= i; i++ {
+				if oldData[pos-i] == newData[scan-i] {
+					s++
+				}
+				if s*2-i > sb*2-lenb {
+					sb = s
+					lenb = i
+				}
+			}
+		}
+
+		// 处理前后扩展的重叠部分
+		if lastscan+lenf > scan-lenb {
+			overlap := (lastscan + lenf) - (scan - lenb)
+			var s, ss, lens int
+			for i := 0; i < overlap; i++ {
+				if newData[lastscan+lenf-overlap+i] == oldData[lastpos+lenf-overlap+i] {
+					s++
+				}
+				if newData[scan-lenb+i] == oldData[pos-lenb+i] {
+					s--
+				}
+				if s > ss {
+					ss = s
+					lens = i + 1
+				}
+			}
+			lenf += lens - overlap
+			lenb -= lens
+		}
+
+		for i := 0; i < lenf; i++ {
+			db[dblen+i] = newData[lastscan+i] - oldData[lastpos+i]
+		}
+		extraLen := (scan - lenb) - (lastscan + lenf)
+		copy(eb[eblen:], newData[lastscan+lenf:lastscan+lenf+extraLen])
+		dblen += lenf
+		eblen += extraLen
+
+		writeOff(&ctrl, lenf)
+		writeOff(&ctrl, extraLen)
+		writeOff(&ctrl, (pos-lenb)-(lastpos+lenf))
+
+		lastscan = scan - lenb
+		lastpos = pos - lenb
+		lastoffset = pos - scan
+	}
+
+	ctrlBlock, err := compress(ctrl.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	diffBlock, err := compress(db[:dblen])
+	if err != nil {
+		return nil, err
+	}
+	extraBlock, err := compress(eb[:eblen])
+	if err != nil {
+		return nil, err
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, headerSize+len(ctrlBlock)+len(diffBlock)+len(extraBlock)))
+	out.WriteString(magic)
+	writeOff(out, len(ctrlBlock))
+	writeOff(out, len(diffBlock))
+	writeOff(out, len(newData))
+	out.Write(ctrlBlock)
+	out.Write(diffBlock)
+	out.Write(extraBlock)
+	return out.Bytes(), nil
+}
+
+// Patch 将补丁应用到 oldData 上得到新文件
+func Patch(oldData, patch []byte) ([]byte, error) {
+	if len(patch) < headerSize || string(patch[:8]) != magic {
+		return nil, ErrCorruptPatch
+	}
+	ctrlLen := readOff(patch[8:16])
+	diffLen := readOff(patch[16:24])
+	newSize := readOff(patch[24:32])
+	if ctrlLen < 0 || diffLen < 0 || newSize < 0 || headerSize+ctrlLen+diffLen > len(patch) {
+		return nil, ErrCorruptPatch
+	}
+	body := patch[headerSize:]
+	ctrlReader, err := gzip.NewReader(bytes.NewReader(body[:ctrlLen]))
+	if err != nil {
+		return nil, ErrCorruptPatch
+	}
+	diffReader, err := gzip.NewReader(bytes.NewReader(body[ctrlLen : ctrlLen+diffLen]))
+	if err != nil {
+		return nil, ErrCorruptPatch
+	}
+	extraReader, err := gzip.NewReader(bytes.NewReader(body[ctrlLen+diffLen:]))
+	if err != nil {
+		return nil, ErrCorruptPatch
+	}
+
+	newData := make([]byte, newSize)
+	var oldpos, newpos int
+	var buf [8]byte
+	for newpos < newSize {
+		var ctrl [3]int
+		for i := range ctrl {
+			if _, err := io.ReadFull(ctrlReader, buf[:]); err != nil {
+				return nil, ErrCorruptPatch
+			}
+			ctrl[i] = readOff(buf[:])
+		}
+		if ctrl[0] < 0 || ctrl[1] < 0 || newpos+ctrl[0] > newSize {
+			return nil, ErrCorruptPatch
+		}
+		if _, err := io.ReadFull(diffReader, newData[newpos:newpos+ctrl[0]]); err != nil {
+			return nil, ErrCorruptPatch
+		}
+		for i := 0; i < ctrl[0]; i++ {
+			if oldpos+i >= 0 && oldpos+i < len(oldData) {
+				newData[newpos+i] += oldData[oldpos+i]
+			}
+		}
+		newpos += ctrl[0]
+		oldpos += ctrl[0]
+
+		if newpos+ctrl[1] > newSize {
+			return nil, ErrCorruptPatch
+		}
+		if _, err := io.ReadFull(extraReader, newData[newpos:newpos+ctrl[1]]); err != nil {
+			return nil, ErrCorruptPatch
+		}
+		newpos += ctrl[1]
+		oldpos += ctrl[2]
+	}
+	return newData, nil
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeOff 按 bsdiff 的符号-数值格式写入 8 字节小端整数
+func writeOff(w io.Writer, x int) {
+	var buf [8]byte
+	y := x
+	if y < 0 {
+		y = -y
+	}
+	binary.LittleEndian.PutUint64(buf[:], uint64(y))
+	if x < 0 {
+		buf[7] |= 0x80
+	}
+	_, _ = w.Write(buf[:])
+}
+
+func readOff(buf []byte) int {
+	y := int(binary.LittleEndian.Uint64(buf) &^ (1 << 63))
+	if buf[7]&0x80 != 0 {
+		y = -y
+	}
+	return y
+}
+
+func matchlen(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+func search(I []int, oldData, newData []byte, st, en int) (pos, n int) {
+	for en-st >= 2 {
+		x := st + (en-st)/2
+		if bytes.Compare(oldData[I[x]:], newData) < 0 {
+			st = x
+		} else {
+			en = x
+		}
+	}
+	x := matchlen(oldData[I[st]:], newData)
+	y := matchlen(oldData[I[en]:], newData)
+	if x > y {
+		return I[st], x
+	}
+	return I[en], y
+}
+
+// qsufsort Larsson-Sadakane 后缀数组排序
+func qsufsort(buf []byte) []int {
+	var buckets [256]int
+	I := make([]int, len(buf)+1)
+	V := make([]int, len(buf)+1)
+
+	for _, c := range buf {
+		buckets[c]++
+	}
+	for i := 1; i < 256; i++ {
+		buckets[i] += buckets[i-1]
+	}
+	copy(buckets[1:], buckets[:])
+	buckets[0] = 0
+
+	for i, c := range buf {
+		buckets[c]++
+		I[buckets[c]] = i
+	}
+	I[0] = len(buf)
+	for i, c := range buf {
+		V[i] = buckets[c]
+	}
+	V[len(buf)] = 0
+	for i := 1; i < 256; i++ {
+		if buckets[i] == buckets[i-1]+1 {
+			I[buckets[i]] = -1
+		}
+	}
+	I[0] = -1
+
+	for h := 1; I[0] != -(len(buf) + 1); h += h {
+		var n, i int
+		for i < len(buf)+1 {
+			if I[i] < 0 {
+				n -= I[i]
+				i -= I[i]
+			} else {
+				if n != 0 {
+					I[i-n] = -n
+				}
+				n = V[I[i]] + 1 - i
+				split(I, V, i, n, h)
+				i += n
+				n = 0
+			}
+		}
+		if n != 0 {
+			I[i-n] = -n
+		}
+	}
+
+	for i := 0; i < len(buf)+1; i++ {
+		I[V[i]] = i
+	}
+	return I
+}
+
+func split(I, V []int, start, length, h int) {
+	if length < 16 {
+		for k := start; k < start+length; {
+			j := 1
+			x := V[I[k]+h]
+			for i := 1; k+i < start+length; i++ {
+				if V[I[k+i]+h] < x {
+					x = V[I[k+i]+h]
+					j = 0
+				}
+				if V[I[k+i]+h] == x {
+					I[k+i], I[k+j] = I[k+j], I[k+i]
+					j++
+				}
+			}
+			for i := 0; i < j; i++ {
+				V[I[k+i]] = k + j - 1
+			}
+			if j == 1 {
+				I[k] = -1
+			}
+			k += j
+		}
+		return
+	}
+
+	x := V[I[start+length/2]+h]
+	var jj, kk int
+	for i := start; i < start+length; i++ {
+		if V[I[i]+h] < x {
+			jj++
+		}
+		if V[I[i]+h] == x {
+			kk++
+		}
+	}
+	jj += start
+	kk += jj
+
+	i, j, k := start, 0, 0
+	for i < jj {
+		if V[I[i]+h] < x {
+			i++
+		} else if V[I[i]+h] == x {
+			I[i], I[jj+j] = I[jj+j], I[i]
+			j++
+		} else {
+			I[i], I[kk+k] = I[kk+k], I[i]
+			k++
+		}
+	}
+	for jj+j < kk {
+		if V[I[jj+j]+h] == x {
+			j++
+		} else {
+			I[jj+j], I[kk+k] = I[kk+k], I[jj+j]
+			k++
+		}
+	}
+
+	if jj > start {
+		split(I, V, start, jj-start, h)
+	}
+	for i := 0; i < kk-jj; i++ {
+		V[I[jj+i]] = kk - 1
+	}
+	if jj == kk-1 {
+		I[jj] = -1
+	}
+	if start+length > kk {
+		split(I, V, kk, start+length-kk, h)
+	}
+}
@@ -0,0 +1,57 @@
+package bsdiff
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestDiffPatch(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	base := make([]byte, 64*1024)
+	r.Read(base)
+
+	modified := append([]byte(nil), base...)
+	for i := 0; i < 200; i++ {
+		modified[r.Intn(len(modified))] = byte(r.Intn(256))
+	}
+	modified = append(modified[:1000], append([]byte("inserted block"), modified[1000:]...)...)
+	modified = append(modified[:30000], modified[32000:]...)
+
+	cases := []struct {
+		name     string
+		old, new []byte
+	}{
+		{"empty", nil, nil},
+		{"from empty", nil, []byte("hello world")},
+		{"to empty", []byte("hello world"), nil},
+		{"identical", base, base},
+		{"modified", base, modified},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			patch, err := Diff(c.old, c.new)
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+			got, err := Patch(c.old, patch)
+			if err != nil {
+				t.Fatalf("Patch() error = %v", err)
+			}
+			if !bytes.Equal(got, c.new) {
+				t.Fatalf("Patch() result mismatch, got %d bytes, want %d bytes", len(got), len(c.new))
+			}
+		})
+	}
+
+	patch, _ := Diff(base, modified)
+	if len(patch) >= len(modified)/4 {
+		t.Errorf("patch too large: %d bytes for %d bytes input", len(patch), len(modified))
+	}
+}
+
+func TestPatchCorrupt(t *testing.T) {
+	if _, err := Patch([]byte("old"), []byte("not a patch")); err != ErrCorruptPatch {
+		t.Errorf("Patch() error = %v, want ErrCorruptPatch", err)
+	}
+}
@@ -4,11 +4,30 @@ import (
 	"ApkAdmin/global"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
+)
+
+// 密文格式：
+//
+//	旧格式（无前缀）       base64(nonce|密文)，使用 system.encryption-key
+//	v1:<密钥ID>:<数据>      base64(nonce|密文)，由主密钥直接加密
+//	v2:<密钥ID>:<数据密钥>:<数据>  信封加密，数据密钥随机生成并由主密钥加密
+//
+// base64 标准字母表不含冒号，可以据此区分旧格式
+const (
+	formatDirect   = "v1"
+	formatEnvelope = "v2"
+
+	// SystemKeyID system.encryption-key 对应的密钥ID，未配置密钥环时使用
+	SystemKeyID = "system"
 )
 
 // 从配置密钥派生出固定长度的加密密钥
@@ -17,67 +36,197 @@ func deriveKey(key string) []byte {
 	return hash[:] // 返回 32 字节
 }
 
-// EncryptAccountDetail 加密账号详情
+// keyring 当前配置中的全部密钥，system.encryption-key 始终可用于解密
+func keyring() map[string][]byte {
+	cfg := global.GVA_CONFIG.Encryption
+	keys := make(map[string][]byte, len(cfg.Keys)+1)
+	keys[SystemKeyID] = deriveKey(global.GVA_CONFIG.System.EncryptionKey)
+	for _, k := range cfg.Keys {
+		if k.ID != "" && k.ID != SystemKeyID {
+			keys[k.ID] = deriveKey(k.Secret)
+		}
+	}
+	return keys
+}
+
+// ActiveKeyID 当前加密使用的密钥ID
+func ActiveKeyID() string {
+	if id := global.GVA_CONFIG.Encryption.Active; id != "" {
+		return id
+	}
+	return SystemKeyID
+}
+
+// ValidateKeyring 检查密钥环配置：密钥ID不能重复或包含冒号，active 必须存在
+func ValidateKeyring() error {
+	cfg := global.GVA_CONFIG.Encryption
+	seen := map[string]bool{}
+	for _, k := range cfg.Keys {
+		switch {
+		case k.ID == "" || k.Secret == "":
+			return errors.New("密钥ID和密钥内容不能为空")
+		case k.ID == SystemKeyID:
+			return fmt.Errorf("密钥ID %s 为保留字", SystemKeyID)
+		case strings.Contains(k.ID, ":"):
+			return fmt.Errorf("密钥ID %s 不能包含冒号", k.ID)
+		case seen[k.ID]:
+			return fmt.Errorf("密钥ID %s 重复", k.ID)
+		}
+		seen[k.ID] = true
+	}
+	if cfg.Active != "" && !seen[cfg.Active] {
+		return fmt.Errorf("active 密钥 %s 不在密钥列表中", cfg.Active)
+	}
+	return nil
+}
+
+// IsVersioned 是否为带版本前缀的密文
+func IsVersioned(encrypted string) bool {
+	parts := strings.SplitN(encrypted, ":", 3)
+	return len(parts) == 3 && (parts[0] == formatDirect || parts[0] == formatEnvelope)
+}
+
+// CiphertextKeyID 密文使用的密钥ID和是否为信封加密，旧格式返回 SystemKeyID
+func CiphertextKeyID(encrypted string) (keyID string, envelope bool) {
+	if !IsVersioned(encrypted) {
+		return SystemKeyID, false
+	}
+	parts := strings.SplitN(encrypted, ":", 3)
+	return parts[1], parts[0] == formatEnvelope
+}
+
+// NeedsReencrypt 密文是否需要用当前密钥和加密方式重新加密
+func NeedsReencrypt(encrypted string) bool {
+	if !IsVersioned(encrypted) {
+		return true
+	}
+	keyID, envelope := CiphertextKeyID(encrypted)
+	return keyID != ActiveKeyID() || envelope != global.GVA_CONFIG.Encryption.Envelope
+}
+
+// EncryptAccountDetail 使用当前密钥加密账号详情
 func EncryptAccountDetail(plaintext string) (string, error) {
 	if plaintext == "" {
 		return "", errors.New("plaintext cannot be empty")
 	}
+	keyID := ActiveKeyID()
+	key, ok := keyring()[keyID]
+	if !ok {
+		return "", fmt.Errorf("加密密钥 %s 未配置", keyID)
+	}
+	if !global.GVA_CONFIG.Encryption.Envelope {
+		data, err := seal(key, []byte(plaintext), []byte(formatDirect+":"+keyID))
+		if err != nil {
+			return "", err
+		}
+		return formatDirect + ":" + keyID + ":" + base64.StdEncoding.EncodeToString(data), nil
+	}
 
-	key := deriveKey(global.GVA_CONFIG.System.EncryptionKey)
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
 		return "", err
 	}
-
-	gcm, err := cipher.NewGCM(block)
+	wrapped, err := seal(key, dataKey, []byte(formatEnvelope+":"+keyID))
 	if err != nil {
 		return "", err
 	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+	data, err := seal(dataKey, []byte(plaintext), nil)
+	if err != nil {
 		return "", err
 	}
-
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	return formatEnvelope + ":" + keyID + ":" + base64.StdEncoding.EncodeToString(wrapped) + ":" + base64.StdEncoding.EncodeToString(data), nil
 }
 
-// DecryptAccountDetail 解密账号详情
+// DecryptAccountDetail 按密文前缀选择密钥解密，兼容没有前缀的旧密文
 func DecryptAccountDetail(encrypted string) (string, error) {
 	if encrypted == "" {
 		return "", errors.New("encrypted text cannot be empty")
 	}
-
-	ciphertext, err := base64.StdEncoding.DecodeString(encrypted)
-	if err != nil {
-		return "", err
+	keys := keyring()
+	if !IsVersioned(encrypted) {
+		data, err := base64.StdEncoding.DecodeString(encrypted)
+		if err != nil {
+			return "", err
+		}
+		plaintext, err := open(keys[SystemKeyID], data, nil)
+		return string(plaintext), err
 	}
 
-	key := deriveKey(global.GVA_CONFIG.System.EncryptionKey)
+	parts := strings.Split(encrypted, ":")
+	format, keyID := parts[0], parts[1]
+	key, ok := keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("解密密钥 %s 未配置", keyID)
+	}
+	switch {
+	case format == formatDirect && len(parts) == 3:
+		data, err := base64.StdEncoding.DecodeString(parts[2])
+		if err != nil {
+			return "", err
+		}
+		plaintext, err := open(key, data, []byte(formatDirect+":"+keyID))
+		return string(plaintext), err
+	case format == formatEnvelope && len(parts) == 4:
+		wrapped, err := base64.StdEncoding.DecodeString(parts[2])
+		if err != nil {
+			return "", err
+		}
+		data, err := base64.StdEncoding.DecodeString(parts[3])
+		if err != nil {
+			return "", err
+		}
+		dataKey, err := open(key, wrapped, []byte(formatEnvelope+":"+keyID))
+		if err != nil {
+			return "", err
+		}
+		plaintext, err := open(dataKey, data, nil)
+		return string(plaintext), err
+	}
+	return "", fmt.Errorf("不支持的密文格式 %s", format)
+}
 
-	block, err := aes.NewCipher(key)
+// seal AES-GCM 加密，返回 nonce|密文
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
 
-	gcm, err := cipher.NewGCM(block)
+func open(key, data, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
 	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return "", errors.New("ciphertext too short")
+	if len(data) < nonceSize {
+		return nil, errors.New("ciphertext too short")
 	}
+	return gcm.Open(nil, data[:nonceSize], data[nonceSize:], aad)
+}
 
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return cipher.NewGCM(block)
+}
 
-	return string(plaintext), nil
+// AccountDetailHash 账号详情的带密钥哈希，用于查重。GCM 加密结果每次不同，无法直接比较密文；
+// 使用与加密不同的派生密钥，哈希泄露时无法离线撞库
+func AccountDetailHash(plaintext string) string {
+	secret := global.GVA_CONFIG.Encryption.HashKey
+	if secret == "" {
+		secret = global.GVA_CONFIG.System.EncryptionKey
+	}
+	key := deriveKey("account-detail-hash:" + secret)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.TrimSpace(plaintext)))
+	return hex.EncodeToString(mac.Sum(nil))
 }
@@ -0,0 +1,86 @@
+package crypto
+
+import (
+	"ApkAdmin/config"
+	"ApkAdmin/global"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func withConfig(t *testing.T, system string, enc config.Encryption) {
+	t.Helper()
+	saved := global.GVA_CONFIG
+	global.GVA_CONFIG.System.EncryptionKey = system
+	global.GVA_CONFIG.Encryption = enc
+	t.Cleanup(func() { global.GVA_CONFIG = saved })
+}
+
+// legacyEncrypt 轮换前的密文格式
+func legacyEncrypt(t *testing.T, secret, plaintext string) string {
+	block, _ := aes.NewCipher(deriveKey(secret))
+	gcm, _ := cipher.NewGCM(block)
+	nonce := make([]byte, gcm.NonceSize())
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func TestKeyRotation(t *testing.T) {
+	withConfig(t, "old-secret", config.Encryption{})
+	legacy := legacyEncrypt(t, "old-secret", "user:pass")
+	v1, err := EncryptAccountDetail("user:pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(v1, "v1:system:") || NeedsReencrypt(v1) || !NeedsReencrypt(legacy) {
+		t.Errorf("v1 = %s", v1)
+	}
+
+	// 新增密钥并设为 active，旧密文仍可解密
+	withConfig(t, "old-secret", config.Encryption{Active: "k2", Keys: []config.EncryptionKey{{ID: "k2", Secret: "new-secret"}}, Envelope: true})
+	if err := ValidateKeyring(); err != nil {
+		t.Fatal(err)
+	}
+	v2, err := EncryptAccountDetail("user:pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, envelope := CiphertextKeyID(v2); id != "k2" || !envelope || strings.Count(v2, ":") != 3 {
+		t.Errorf("v2 = %s", v2)
+	}
+	for _, ct := range []string{legacy, v1, v2} {
+		if got, err := DecryptAccountDetail(ct); err != nil || got != "user:pass" {
+			t.Errorf("decrypt %s = %q, %v", ct, got, err)
+		}
+	}
+	if !NeedsReencrypt(v1) || NeedsReencrypt(v2) {
+		t.Error("only ciphertexts not using the active key and mode need re-encryption")
+	}
+
+	// 篡改密钥ID会导致认证失败
+	tampered := strings.Replace(v1, "v1:system:", "v1:k2:", 1)
+	if _, err := DecryptAccountDetail(tampered); err == nil {
+		t.Error("ciphertext bound to another key id should not decrypt")
+	}
+	// 移除旧密钥后无法解密
+	withConfig(t, "rotated", config.Encryption{Active: "k2", Keys: []config.EncryptionKey{{ID: "k2", Secret: "new-secret"}}})
+	if _, err := DecryptAccountDetail(v1); err == nil {
+		t.Error("ciphertext of removed key should not decrypt")
+	}
+	if _, err := DecryptAccountDetail(v2); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIsVersioned(t *testing.T) {
+	for s, want := range map[string]bool{"user:pass:x": false, "v1:k:abc": true, "v2:k:a:b": true, "abc==": false} {
+		if IsVersioned(s) != want {
+			t.Errorf("IsVersioned(%q) = %v", s, !want)
+		}
+	}
+	withConfig(t, "", config.Encryption{Active: "missing"})
+	if ValidateKeyring() == nil {
+		t.Error("active key not in keyring should be invalid")
+	}
+}
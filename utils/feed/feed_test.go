@@ -0,0 +1,82 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFeed() Feed {
+	at := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	return Feed{
+		Title:   "最新版本",
+		Link:    "https://example.com",
+		FeedURL: "https://example.com/feed.xml",
+		Updated: at,
+		Items: []Item{
+			{ID: "package-1", Title: "微信 8.0 <Android>", Link: "https://example.com/app/1", Content: "修复问题 & 优化", Category: "android", Published: at},
+		},
+	}
+}
+
+func TestRSS(t *testing.T) {
+	out, err := testFeed().RSS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Channel struct {
+			Items []struct {
+				Title   string `xml:"title"`
+				GUID    string `xml:"guid"`
+				PubDate string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err = xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("invalid xml: %v\n%s", err, out)
+	}
+	if len(doc.Channel.Items) != 1 || doc.Channel.Items[0].Title != "微信 8.0 <Android>" || doc.Channel.Items[0].PubDate != "Fri, 01 May 2026 08:00:00 +0000" {
+		t.Errorf("items = %+v", doc.Channel.Items)
+	}
+	if !strings.Contains(string(out), `rel="self"`) {
+		t.Error("missing self link")
+	}
+}
+
+func TestAtom(t *testing.T) {
+	out, err := testFeed().Atom()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		XMLName xml.Name
+		Entries []struct {
+			ID      string `xml:"id"`
+			Content string `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err = xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("invalid xml: %v\n%s", err, out)
+	}
+	if doc.XMLName.Space != "http://www.w3.org/2005/Atom" || len(doc.Entries) != 1 || doc.Entries[0].Content != "修复问题 & 优化" {
+		t.Errorf("doc = %+v", doc)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	out, err := testFeed().JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err = json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	items := doc["items"].([]interface{})
+	if doc["version"] != "https://jsonfeed.org/version/1.1" || len(items) != 1 || items[0].(map[string]interface{})["date_published"] != "2026-05-01T08:00:00Z" {
+		t.Errorf("doc = %s", out)
+	}
+}
@@ -168,3 +168,11 @@ func BuildPublicUrl(objectName string) string {
 		objectName,
 	)
 }
+
+// StringValue 返回字符串指针的值，nil 时返回空字符串
+func StringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
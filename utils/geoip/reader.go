@@ -0,0 +1,360 @@
+// Package geoip 读取 MaxMind DB (mmdb) 格式的 IP 库并解析访问者所在国家
+//
+// 只实现了查询所需的部分：二叉搜索树 + 数据段解码，不依赖官方 SDK，
+// GeoLite2-Country / GeoLite2-City 以及兼容格式的第三方库都可以直接使用。
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/big"
+	"net"
+	"os"
+)
+
+var metadataStart = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// ErrInvalidDatabase 数据库文件格式错误
+var ErrInvalidDatabase = errors.New("geoip: invalid database")
+
+// maxDecodeDepth 数据段嵌套层数上限，防止损坏的文件导致无限递归
+const maxDecodeDepth = 32
+
+// Metadata 数据库元信息
+type Metadata struct {
+	DatabaseType string
+	NodeCount    uint
+	RecordSize   uint
+	IPVersion    uint
+}
+
+// Reader mmdb 文件读取器，可以并发使用
+type Reader struct {
+	Metadata  Metadata
+	tree      []byte
+	data      decoder
+	nodeSize  uint
+	ipv4Start uint
+}
+
+// Open 读取整个数据库文件
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromBytes(buf)
+}
+
+// FromBytes 从内存中的数据库内容创建读取器
+func FromBytes(buf []byte) (*Reader, error) {
+	i := bytes.LastIndex(buf, metadataStart)
+	if i < 0 {
+		return nil, ErrInvalidDatabase
+	}
+	v, _, err := decoder{buf: buf[i+len(metadataStart):]}.decode(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, ErrInvalidDatabase
+	}
+
+	r := &Reader{}
+	r.Metadata.DatabaseType, _ = m["database_type"].(string)
+	r.Metadata.NodeCount = toUint(m["node_count"])
+	r.Metadata.RecordSize = toUint(m["record_size"])
+	r.Metadata.IPVersion = toUint(m["ip_version"])
+	switch r.Metadata.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, ErrInvalidDatabase
+	}
+	if r.Metadata.IPVersion != 4 && r.Metadata.IPVersion != 6 {
+		return nil, ErrInvalidDatabase
+	}
+
+	r.nodeSize = r.Metadata.RecordSize / 4
+	treeSize := r.Metadata.NodeCount * r.nodeSize
+	// 搜索树和数据段之间有 16 字节的 0 作为分隔
+	if treeSize+16 > uint(i) {
+		return nil, ErrInvalidDatabase
+	}
+	r.tree = buf[:treeSize]
+	r.data = decoder{buf: buf[treeSize+16 : i]}
+
+	// IPv6 库中 IPv4 地址位于 ::/96 子树下
+	if r.Metadata.IPVersion == 6 {
+		node := uint(0)
+		for j := 0; j < 96 && node < r.Metadata.NodeCount; j++ {
+			node = r.readNode(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// Lookup 查询 IP 对应的数据记录，未收录时返回 nil
+func (r *Reader) Lookup(ip net.IP) (interface{}, error) {
+	var addr []byte
+	node := uint(0)
+	if ip4 := ip.To4(); ip4 != nil {
+		addr = ip4
+		node = r.ipv4Start
+	} else if ip16 := ip.To16(); ip16 != nil && r.Metadata.IPVersion == 6 {
+		addr = ip16
+	} else {
+		return nil, nil
+	}
+
+	nodeCount := r.Metadata.NodeCount
+	for i := 0; i < len(addr)*8 && node < nodeCount; i++ {
+		bit := (addr[i>>3] >> (7 - uint(i&7))) & 1
+		node = r.readNode(node, bit)
+	}
+	switch {
+	case node == nodeCount:
+		return nil, nil
+	case node < nodeCount:
+		return nil, ErrInvalidDatabase
+	}
+	offset := node - nodeCount - 16
+	v, _, err := r.data.decode(offset, 0)
+	return v, err
+}
+
+// Country 查询 IP 所在国家的 ISO 3166-1 代码，未收录时返回空字符串
+func (r *Reader) Country(ip net.IP) (string, error) {
+	v, err := r.Lookup(ip)
+	if err != nil || v == nil {
+		return "", err
+	}
+	record, _ := v.(map[string]interface{})
+	for _, key := range []string{"country", "registered_country"} {
+		country, _ := record[key].(map[string]interface{})
+		if code, _ := country["iso_code"].(string); code != "" {
+			return code, nil
+		}
+	}
+	return "", nil
+}
+
+func (r *Reader) readNode(node uint, bit byte) uint {
+	b := r.tree[node*r.nodeSize : (node+1)*r.nodeSize]
+	switch r.Metadata.RecordSize {
+	case 24:
+		if bit == 0 {
+			return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3])<<16 | uint(b[4])<<8 | uint(b[5])
+	case 28:
+		// 中间字节的高 4 位属于左记录，低 4 位属于右记录
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		if bit == 0 {
+			return uint(binary.BigEndian.Uint32(b[:4]))
+		}
+		return uint(binary.BigEndian.Uint32(b[4:]))
+	}
+}
+
+// 数据段字段类型
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+type decoder struct {
+	buf []byte
+}
+
+// decode 解码 offset 处的字段，返回值和下一个字段的偏移
+func (d decoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	if depth > maxDecodeDepth || offset >= uint(len(d.buf)) {
+		return nil, 0, ErrInvalidDatabase
+	}
+	ctrl := d.buf[offset]
+	offset++
+	typ := uint(ctrl >> 5)
+
+	if typ == typePointer {
+		ptr, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		v, _, err := d.decode(ptr, depth+1)
+		return v, next, err
+	}
+	if typ == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return nil, 0, ErrInvalidDatabase
+		}
+		typ = 7 + uint(d.buf[offset])
+		offset++
+	}
+	size, offset, err := d.size(ctrl, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return d.value(typ, size, offset, depth)
+}
+
+func (d decoder) pointer(ctrl byte, offset uint) (uint, uint, error) {
+	ss := uint(ctrl>>3) & 0x3
+	b, err := d.bytes(offset, ss+1)
+	if err != nil {
+		return 0, 0, err
+	}
+	v := uint(ctrl & 0x7)
+	var ptr uint
+	switch ss {
+	case 0:
+		ptr = v<<8 | uint(b[0])
+	case 1:
+		ptr = (v<<16 | uint(b[0])<<8 | uint(b[1])) + 2048
+	case 2:
+		ptr = (v<<24 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])) + 526336
+	default:
+		ptr = uint(binary.BigEndian.Uint32(b))
+	}
+	return ptr, offset + ss + 1, nil
+}
+
+func (d decoder) size(ctrl byte, offset uint) (uint, uint, error) {
+	size := uint(ctrl & 0x1F)
+	if size < 29 {
+		return size, offset, nil
+	}
+	n := size - 28
+	b, err := d.bytes(offset, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch size {
+	case 29:
+		size = 29 + uint(b[0])
+	case 30:
+		size = 285 + (uint(b[0])<<8 | uint(b[1]))
+	default:
+		size = 65821 + (uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]))
+	}
+	return size, offset + n, nil
+}
+
+func (d decoder) value(typ, size, offset uint, depth int) (interface{}, uint, error) {
+	switch typ {
+	case typeMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			k, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, 0, ErrInvalidDatabase
+			}
+			if m[key], offset, err = d.decode(next, depth+1); err != nil {
+				return nil, 0, err
+			}
+		}
+		return m, offset, nil
+	case typeArray:
+		a := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			v, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, v)
+			offset = next
+		}
+		return a, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	case typeContainer, typeEndMarker:
+		return nil, offset, nil
+	}
+
+	b, err := d.bytes(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	next := offset + size
+	switch typ {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes:
+		return append([]byte(nil), b...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, ErrInvalidDatabase
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, ErrInvalidDatabase
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, ErrInvalidDatabase
+		}
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return v, next, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, ErrInvalidDatabase
+		}
+		var v uint32
+		for _, c := range b {
+			v = v<<8 | uint32(c)
+		}
+		return int32(v), next, nil
+	case typeUint128:
+		return new(big.Int).SetBytes(b), next, nil
+	default:
+		return nil, 0, ErrInvalidDatabase
+	}
+}
+
+func (d decoder) bytes(offset, n uint) ([]byte, error) {
+	if offset+n > uint(len(d.buf)) {
+		return nil, ErrInvalidDatabase
+	}
+	return d.buf[offset : offset+n], nil
+}
+
+func toUint(v interface{}) uint {
+	switch n := v.(type) {
+	case uint64:
+		return uint(n)
+	case int32:
+		return uint(n)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,173 @@
+package geoip
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+// 测试用的 mmdb 编码，只覆盖读取器需要的类型
+
+func encCtrl(typ, size int) []byte {
+	if typ <= 7 {
+		return []byte{byte(typ<<5 | size)}
+	}
+	return []byte{byte(size), byte(typ - 7)}
+}
+
+func encString(s string) []byte {
+	return append(encCtrl(typeString, len(s)), s...)
+}
+
+func encUint(typ int, v uint64, size int) []byte {
+	b := encCtrl(typ, size)
+	for i := size - 1; i >= 0; i-- {
+		b = append(b, byte(v>>(8*uint(i))))
+	}
+	return b
+}
+
+func encMap(kv ...[]byte) []byte {
+	b := encCtrl(typeMap, len(kv)/2)
+	for _, x := range kv {
+		b = append(b, x...)
+	}
+	return b
+}
+
+func encPointer(p int) []byte {
+	return []byte{byte(typePointer<<5 | (p>>8)&0x7), byte(p)}
+}
+
+type testNetwork struct {
+	ip     net.IP
+	prefix int
+	data   int // 数据段中的偏移
+}
+
+// buildDB 构造 24 位记录的搜索树
+func buildDB(t *testing.T, ipVersion int, networks []testNetwork, data []byte) []byte {
+	t.Helper()
+	type record struct {
+		node int // >0 表示子节点
+		data int // >=0 表示数据偏移
+	}
+	nodes := [][2]record{{{data: -1}, {data: -1}}}
+	for _, n := range networks {
+		addr := n.ip.To16()
+		prefix := n.prefix
+		if ipVersion == 4 {
+			addr = n.ip.To4()
+		} else if ip4 := n.ip.To4(); ip4 != nil {
+			// IPv6 库中 IPv4 位于 ::/96 下，而不是 ::ffff:0:0/96
+			addr = append(make([]byte, 12), ip4...)
+			prefix += 96
+		}
+		cur := 0
+		for i := 0; i < prefix; i++ {
+			bit := (addr[i>>3] >> (7 - uint(i&7))) & 1
+			if i == prefix-1 {
+				nodes[cur][bit] = record{data: n.data}
+				break
+			}
+			if nodes[cur][bit].node == 0 {
+				nodes = append(nodes, [2]record{{data: -1}, {data: -1}})
+				nodes[cur][bit] = record{node: len(nodes) - 1, data: -1}
+			}
+			cur = nodes[cur][bit].node
+		}
+	}
+
+	nodeCount := len(nodes)
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		for _, r := range n {
+			v := nodeCount
+			switch {
+			case r.node > 0:
+				v = r.node
+			case r.data >= 0:
+				v = nodeCount + 16 + r.data
+			}
+			buf.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+		}
+	}
+	buf.Write(make([]byte, 16))
+	buf.Write(data)
+	buf.Write(metadataStart)
+	buf.Write(encMap(
+		encString("node_count"), encUint(typeUint32, uint64(nodeCount), 4),
+		encString("record_size"), encUint(typeUint16, 24, 2),
+		encString("ip_version"), encUint(typeUint16, uint64(ipVersion), 2),
+		encString("database_type"), encString("Test-Country"),
+	))
+	return buf.Bytes()
+}
+
+func testData() (data []byte, cn, us int) {
+	cnCountry := encMap(encString("iso_code"), encString("CN"))
+	cnRecord := encMap(
+		encString("country"), cnCountry,
+		encString("geoname_id"), encUint(typeUint64, 1814991, 4),
+	)
+	cn = 0
+	data = append(data, cnRecord...)
+	// US 记录只有 registered_country，并通过指针复用键名
+	us = len(data)
+	data = append(data, encMap(
+		encString("registered_country"), encMap(encPointer(len(encCtrl(typeMap, 2))+len(encString("country"))+len(encCtrl(typeMap, 1))), encString("US")),
+	)...)
+	return data, cn, us
+}
+
+func TestReaderCountry(t *testing.T) {
+	data, cn, us := testData()
+	for _, ipVersion := range []int{4, 6} {
+		db := buildDB(t, ipVersion, []testNetwork{
+			{net.ParseIP("1.2.0.0"), 16, cn},
+			{net.ParseIP("8.8.8.0"), 24, us},
+		}, data)
+		r, err := FromBytes(db)
+		if err != nil {
+			t.Fatalf("ipv%d: FromBytes() error = %v", ipVersion, err)
+		}
+		if r.Metadata.DatabaseType != "Test-Country" {
+			t.Errorf("ipv%d: DatabaseType = %q", ipVersion, r.Metadata.DatabaseType)
+		}
+		cases := map[string]string{
+			"1.2.3.4":    "CN",
+			"1.2.255.1":  "CN",
+			"1.3.0.1":    "",
+			"8.8.8.8":    "US",
+			"8.8.9.8":    "",
+			"2001:db8::": "",
+		}
+		for ip, want := range cases {
+			got, err := r.Country(net.ParseIP(ip))
+			if err != nil {
+				t.Fatalf("ipv%d: Country(%s) error = %v", ipVersion, ip, err)
+			}
+			if got != want {
+				t.Errorf("ipv%d: Country(%s) = %q, want %q", ipVersion, ip, got, want)
+			}
+		}
+	}
+}
+
+func TestReaderInvalid(t *testing.T) {
+	if _, err := FromBytes([]byte("not a database")); err != ErrInvalidDatabase {
+		t.Errorf("FromBytes() error = %v, want ErrInvalidDatabase", err)
+	}
+	data, cn, _ := testData()
+	db := buildDB(t, 4, []testNetwork{{net.ParseIP("1.2.0.0"), 16, cn}}, data)
+	// 截断数据段
+	i := bytes.LastIndex(db, metadataStart)
+	broken := append(append([]byte(nil), db[:i-len(data)+3]...), db[i:]...)
+	r, err := FromBytes(broken)
+	if err != nil {
+		t.Fatalf("FromBytes() error = %v", err)
+	}
+	if _, err = r.Country(net.ParseIP("1.2.3.4")); err != ErrInvalidDatabase {
+		t.Errorf("Country() error = %v, want ErrInvalidDatabase", err)
+	}
+}
@@ -0,0 +1,112 @@
+package geoip
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+
+	"ApkAdmin/global"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 同一请求内缓存识别结果
+const (
+	contextKey   = "geoip_country"
+	ipContextKey = "geoip_ip_country"
+)
+
+var countryCodeRegexp = regexp.MustCompile(`^[A-Z]{2,3}$`)
+
+var (
+	mu         sync.Mutex
+	reader     *Reader
+	readerPath string
+)
+
+// CountryFromRequest 识别访问者国家，用于应用列表、详情等展示：允许覆盖时优先使用请求头或查询参数，否则按客户端IP查询，无法识别时返回空字符串。
+// 覆盖值由客户端决定，不能用于下载限制等访问控制，访问控制使用 CountryFromIP
+func CountryFromRequest(c *gin.Context) string {
+	if v, ok := c.Get(contextKey); ok {
+		return v.(string)
+	}
+	country := override(c)
+	if country == "" {
+		country = CountryFromIP(c)
+	}
+	c.Set(contextKey, country)
+	return country
+}
+
+// CountryFromIP 只按客户端IP识别国家，不受请求头和查询参数影响，无法识别时返回空字符串
+func CountryFromIP(c *gin.Context) string {
+	if v, ok := c.Get(ipContextKey); ok {
+		return v.(string)
+	}
+	country := lookup(c)
+	c.Set(ipContextKey, country)
+	return country
+}
+
+func override(c *gin.Context) string {
+	cfg := global.GVA_CONFIG.GeoIP
+	if !cfg.AllowOverride {
+		return ""
+	}
+	if code := normalize(c.Query(cfg.OverrideQuery), cfg.OverrideQuery != ""); code != "" {
+		return code
+	}
+	return normalize(c.GetHeader(cfg.OverrideHeader), cfg.OverrideHeader != "")
+}
+
+func lookup(c *gin.Context) string {
+	r := currentReader()
+	if r == nil {
+		return ""
+	}
+	ip := net.ParseIP(c.ClientIP())
+	if ip == nil {
+		return ""
+	}
+	code, err := r.Country(ip)
+	if err != nil {
+		global.GVA_LOG.Warn("查询IP所属国家失败", zap.Error(err), zap.String("ip", c.ClientIP()))
+		return ""
+	}
+	return strings.ToUpper(code)
+}
+
+func normalize(code string, enabled bool) string {
+	if !enabled {
+		return ""
+	}
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !countryCodeRegexp.MatchString(code) {
+		return ""
+	}
+	return code
+}
+
+// currentReader 按配置路径懒加载数据库，路径变化（如系统重载）后重新打开
+func currentReader() *Reader {
+	path := global.GVA_CONFIG.GeoIP.DBPath
+	mu.Lock()
+	defer mu.Unlock()
+	if path == readerPath {
+		return reader
+	}
+	readerPath = path
+	reader = nil
+	if path == "" {
+		return nil
+	}
+	r, err := Open(path)
+	if err != nil {
+		global.GVA_LOG.Error("加载GeoIP数据库失败，将不按IP识别国家!", zap.Error(err), zap.String("path", path))
+		return nil
+	}
+	reader = r
+	return reader
+}
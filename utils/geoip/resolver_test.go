@@ -0,0 +1,51 @@
+package geoip
+
+import (
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ApkAdmin/global"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestCountryOverrideOnlyForDisplay(t *testing.T) {
+	data, cn, _ := testData()
+	path := filepath.Join(t.TempDir(), "country.mmdb")
+	if err := os.WriteFile(path, buildDB(t, 4, []testNetwork{{net.ParseIP("1.2.0.0"), 16, cn}}, data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	saved := global.GVA_CONFIG.GeoIP
+	t.Cleanup(func() { global.GVA_CONFIG.GeoIP = saved })
+	global.GVA_LOG = zap.NewNop()
+	global.GVA_CONFIG.GeoIP.DBPath = path
+	global.GVA_CONFIG.GeoIP.OverrideHeader = "X-Country-Code"
+	global.GVA_CONFIG.GeoIP.OverrideQuery = "country"
+
+	request := func() *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/?country=jp", nil)
+		c.Request.RemoteAddr = "1.2.3.4:5678"
+		c.Request.Header.Set("X-Country-Code", "US")
+		return c
+	}
+
+	c := request()
+	if got := CountryFromRequest(c); got != "CN" {
+		t.Errorf("override disabled: CountryFromRequest = %q, want CN", got)
+	}
+
+	// 允许覆盖时只影响展示，按IP识别的国家不变
+	global.GVA_CONFIG.GeoIP.AllowOverride = true
+	c = request()
+	if got := CountryFromRequest(c); got != "JP" {
+		t.Errorf("CountryFromRequest = %q, want JP", got)
+	}
+	if got := CountryFromIP(c); got != "CN" {
+		t.Errorf("CountryFromIP = %q, want CN", got)
+	}
+}
@@ -0,0 +1,104 @@
+// Package locale 解析 Accept-Language 并从可用语言中选择最合适的一个
+package locale
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Normalize 统一语言代码格式：下划线换成连字符，语言部分小写，地区部分大写，如 zh_cn -> zh-CN
+func Normalize(code string) string {
+	code = strings.TrimSpace(strings.ReplaceAll(code, "_", "-"))
+	if code == "" {
+		return ""
+	}
+	parts := strings.Split(code, "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch len(parts[i]) {
+		case 2:
+			parts[i] = strings.ToUpper(parts[i])
+		case 4:
+			// 书写系统，如 Hans、Hant
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		default:
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// base 语言主标签，如 zh-CN -> zh
+func base(code string) string {
+	if i := strings.IndexByte(code, '-'); i > 0 {
+		return code[:i]
+	}
+	return code
+}
+
+// ParseAcceptLanguage 按权重从高到低返回 Accept-Language 中的语言，忽略 q=0 和 *
+func ParseAcceptLanguage(header string) []string {
+	type tag struct {
+		code string
+		q    float64
+		pos  int
+	}
+	var tags []tag
+	for i, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		code := Normalize(fields[0])
+		if code == "" || code == "*" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, tag{code: code, q: q, pos: i})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	codes := make([]string, len(tags))
+	for i, t := range tags {
+		codes[i] = t.code
+	}
+	return codes
+}
+
+// Match 按偏好顺序选择可用语言：先完全匹配，再匹配主语言（zh-TW 可以用 zh，zh 可以用 zh-CN），都没有时返回 false
+func Match(preferred, available []string) (string, bool) {
+	if len(available) == 0 {
+		return "", false
+	}
+	normalized := make([]string, len(available))
+	for i, a := range available {
+		normalized[i] = Normalize(a)
+	}
+	for _, p := range preferred {
+		p = Normalize(p)
+		for i, a := range normalized {
+			if a == p {
+				return available[i], true
+			}
+		}
+		for i, a := range normalized {
+			if a == base(p) {
+				return available[i], true
+			}
+		}
+		for i, a := range normalized {
+			if base(a) == base(p) {
+				return available[i], true
+			}
+		}
+	}
+	return "", false
+}
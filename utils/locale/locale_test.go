@@ -0,0 +1,40 @@
+package locale
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	got := ParseAcceptLanguage("en-US;q=0.8, zh_cn, ja;q=0, *;q=0.1, zh-hant-tw;q=0.9")
+	want := []string{"zh-CN", "zh-Hant-TW", "en-US"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseAcceptLanguage() = %v, want %v", got, want)
+	}
+	if got := ParseAcceptLanguage(""); len(got) != 0 {
+		t.Errorf("empty header = %v", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	available := []string{"en", "zh-CN", "zh-TW"}
+	cases := []struct {
+		preferred []string
+		want      string
+		ok        bool
+	}{
+		{[]string{"zh-TW"}, "zh-TW", true},
+		{[]string{"zh-tw"}, "zh-TW", true},
+		{[]string{"zh-HK"}, "zh-CN", true},
+		{[]string{"en-GB"}, "en", true},
+		{[]string{"fr", "en-US"}, "en", true},
+		{[]string{"fr"}, "", false},
+		{nil, "", false},
+	}
+	for _, c := range cases {
+		got, ok := Match(c.preferred, available)
+		if got != c.want || ok != c.ok {
+			t.Errorf("Match(%v) = %q, %v, want %q, %v", c.preferred, got, ok, c.want, c.ok)
+		}
+	}
+}
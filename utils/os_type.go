@@ -54,3 +54,22 @@ func IsMobile(c *gin.Context) bool {
 	osType := GetOSType(c)
 	return osType == OSTypeIOS || osType == OSTypeAndroid
 }
+
+// ParseDeviceType 解析 User-Agent 字符串获取设备类型：mobile、tablet 或 desktop
+func ParseDeviceType(userAgent string) string {
+	userAgent = strings.ToLower(userAgent)
+
+	switch {
+	case strings.Contains(userAgent, "ipad") || strings.Contains(userAgent, "tablet"):
+		return "tablet"
+	// 安卓平板的 UA 中不包含 mobile
+	case strings.Contains(userAgent, "android") && !strings.Contains(userAgent, "mobile"):
+		return "tablet"
+	case strings.Contains(userAgent, "iphone") ||
+		strings.Contains(userAgent, "ipod") ||
+		strings.Contains(userAgent, "mobile"):
+		return "mobile"
+	default:
+		return "desktop"
+	}
+}
@@ -0,0 +1,118 @@
+// Package pinyin 汉字转拼音首字母，用于搜索时按首字母缩写匹配（如 wx -> 微信）
+//
+// initials.txt 由 Unicode CLDR 的拼音排序规则（Perl Unicode::Collate::CJK::Pinyin）
+// 按字母分段导出，每行为「大写字母 空格 该字母开头的全部汉字」。多音字只取排序规则中的读音。
+package pinyin
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+//go:embed initials.txt
+var initialsData string
+
+// polyphones 应用名称中常见多音字的其它读音首字母
+var polyphones = map[rune]string{
+	'乐': "y", // 音乐
+	'行': "h", // 银行
+	'长': "c", // 长城
+	'重': "c", // 重庆
+	'调': "t", // 调整
+	'传': "z", // 传记
+	'会': "k", // 会计
+	'校': "j", // 校对
+	'藏': "z", // 西藏
+	'省': "x", // 反省
+	'单': "s", // 单县
+	'折': "s", // 折本
+	'曾': "z", // 曾经
+}
+
+// maxVariants 多音字组合数量上限，避免长名称组合爆炸
+const maxVariants = 8
+
+var (
+	initialsOnce sync.Once
+	initials     map[rune]byte
+)
+
+func loadInitials() {
+	initials = make(map[rune]byte, 21000)
+	for _, line := range strings.Split(initialsData, "\n") {
+		if len(line) < 3 || line[1] != ' ' {
+			continue
+		}
+		letter := line[0] + ('a' - 'A')
+		for _, r := range line[2:] {
+			if r != utf8.RuneError {
+				initials[r] = letter
+			}
+		}
+	}
+}
+
+// Initial 返回汉字拼音首字母（小写），非汉字或未收录时返回 false
+func Initial(r rune) (byte, bool) {
+	initialsOnce.Do(loadInitials)
+	b, ok := initials[r]
+	return b, ok
+}
+
+// Initials 返回字符串中汉字的拼音首字母，字母和数字原样保留（转小写），其它字符忽略
+func Initials(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			b.WriteRune(r + ('a' - 'A'))
+		default:
+			if c, ok := Initial(r); ok {
+				b.WriteByte(c)
+			}
+		}
+	}
+	return b.String()
+}
+
+// Variants 返回考虑常见多音字后的全部首字母组合，第一个与 Initials 相同，最多 maxVariants 个
+func Variants(s string) []string {
+	variants := []string{""}
+	for _, r := range s {
+		var letters string
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			letters = string(r)
+		case r >= 'A' && r <= 'Z':
+			letters = string(r + ('a' - 'A'))
+		default:
+			c, ok := Initial(r)
+			if !ok {
+				continue
+			}
+			letters = string(c)
+			for _, alt := range polyphones[r] {
+				if byte(alt) != c {
+					letters += string(alt)
+				}
+			}
+		}
+		next := make([]string, 0, len(variants)*len(letters))
+		for i := 0; i < len(letters); i++ {
+			for _, v := range variants {
+				if len(next) < maxVariants {
+					next = append(next, v+letters[i:i+1])
+				}
+			}
+		}
+		variants = next
+	}
+	if len(variants) == 1 && variants[0] == "" {
+		return nil
+	}
+	return variants
+}
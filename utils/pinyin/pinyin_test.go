@@ -0,0 +1,39 @@
+package pinyin
+
+import "testing"
+
+func TestInitials(t *testing.T) {
+	cases := map[string]string{
+		"微信":       "wx",
+		"支付宝":      "zfb",
+		"抖音 极速版":   "dyjsb",
+		"QQ音乐":     "qqyl",
+		"王者荣耀2024": "wzry2024",
+		"":         "",
+		"😀!":       "",
+	}
+	for in, want := range cases {
+		if got := Initials(in); got != want {
+			t.Errorf("Initials(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if _, ok := Initial('a'); ok {
+		t.Error("Initial('a') ok = true, want false")
+	}
+}
+
+func TestVariants(t *testing.T) {
+	got := Variants("QQ音乐")
+	if len(got) != 2 || got[0] != "qqyl" || got[1] != "qqyy" {
+		t.Errorf("Variants(QQ音乐) = %v, want [qqyl qqyy]", got)
+	}
+	if got := Variants("微信"); len(got) != 1 || got[0] != "wx" {
+		t.Errorf("Variants(微信) = %v, want [wx]", got)
+	}
+	if got := Variants("行行行行行行行行行行"); len(got) != maxVariants {
+		t.Errorf("len(Variants) = %d, want %d", len(got), maxVariants)
+	}
+	if got := Variants("!"); got != nil {
+		t.Errorf("Variants(!) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"hash/fnv"
+	"strconv"
+)
+
+// RolloutBucket 根据用户ID计算灰度分桶 [0, 100)
+// seed 一般传应用ID，使同一用户在不同应用上落入不同的桶，同一应用上结果稳定
+func RolloutBucket(seed string, userID uint) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(seed))
+	_, _ = h.Write([]byte{':'})
+	_, _ = h.Write([]byte(strconv.FormatUint(uint64(userID), 10)))
+	return int(h.Sum32() % 100)
+}
@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamavChunkSize INSTREAM 每次发送的数据块大小，需小于 clamd 的 StreamMaxLength
+const clamavChunkSize = 64 * 1024
+
+// ClamAV 通过 clamd 的 INSTREAM 命令扫描文件
+type ClamAV struct {
+	Network string        // unix 或 tcp
+	Address string        // socket 路径或 host:port
+	Timeout time.Duration // 单次扫描超时
+}
+
+func (c *ClamAV) Name() string { return "clamav" }
+
+func (c *ClamAV) Scan(r io.Reader) (Result, error) {
+	conn, err := net.DialTimeout(c.Network, c.Address, 10*time.Second)
+	if err != nil {
+		return Result{}, fmt.Errorf("连接clamd失败: %w", err)
+	}
+	defer conn.Close()
+	if c.Timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(c.Timeout))
+	}
+
+	if _, err = conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Result{}, err
+	}
+	buf := make([]byte, clamavChunkSize)
+	var size [4]byte
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, err = conn.Write(size[:]); err != nil {
+				return Result{}, err
+			}
+			if _, err = conn.Write(buf[:n]); err != nil {
+				return Result{}, err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return Result{}, readErr
+		}
+	}
+	// 长度为 0 的数据块表示结束
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err = conn.Write(size[:]); err != nil {
+		return Result{}, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Result{}, err
+	}
+	return parseClamdReply(reply)
+}
+
+// parseClamdReply 解析 "stream: OK" / "stream: Xxx FOUND" / "... ERROR"
+func parseClamdReply(reply string) (Result, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return Result{Clean: true}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return Result{Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	default:
+		return Result{}, fmt.Errorf("clamd返回异常: %s", reply)
+	}
+}
@@ -0,0 +1,89 @@
+// Package scanner 安装包安全扫描
+package scanner
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"ApkAdmin/config"
+)
+
+// Result 扫描结果
+type Result struct {
+	Clean     bool   // 是否未发现威胁
+	Signature string // 命中的病毒特征名称
+}
+
+// Scanner 安全扫描接口，实现方需要完整读取 r
+type Scanner interface {
+	Name() string
+	Scan(r io.Reader) (Result, error)
+}
+
+// New 根据配置创建扫描器，未配置时返回不做扫描的 Noop
+func New(cfg config.Scanner) Scanner {
+	switch cfg.Type {
+	case "clamav":
+		timeout := time.Duration(cfg.Timeout) * time.Second
+		if timeout <= 0 {
+			timeout = 5 * time.Minute
+		}
+		network := cfg.Network
+		if network == "" {
+			network = "unix"
+		}
+		return &ClamAV{Network: network, Address: cfg.Address, Timeout: timeout}
+	default:
+		return Noop{}
+	}
+}
+
+// Noop 不做扫描，所有文件视为安全
+type Noop struct{}
+
+func (Noop) Name() string { return "noop" }
+
+func (Noop) Scan(r io.Reader) (Result, error) {
+	_, err := io.Copy(io.Discard, r)
+	return Result{Clean: err == nil}, err
+}
+
+// Inspection 一次读取文件得到的哈希与扫描结果
+type Inspection struct {
+	Sha256 string
+	Md5    string
+	Size   int64
+	Result Result
+}
+
+// Inspect 扫描文件并同时计算 SHA-256 和 MD5，扫描器提前返回时会读完剩余内容以保证哈希完整
+func Inspect(r io.Reader, s Scanner) (*Inspection, error) {
+	sha := sha256.New()
+	md := md5.New()
+	counter := &countWriter{}
+	tee := io.TeeReader(r, io.MultiWriter(sha, md, counter))
+
+	result, err := s.Scan(tee)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(io.Discard, tee); err != nil {
+		return nil, err
+	}
+	return &Inspection{
+		Sha256: hex.EncodeToString(sha.Sum(nil)),
+		Md5:    hex.EncodeToString(md.Sum(nil)),
+		Size:   counter.n,
+		Result: result,
+	}, nil
+}
+
+type countWriter struct{ n int64 }
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+var eicar = []byte(`X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+
+func TestInspect(t *testing.T) {
+	stub := &Stub{Signatures: map[string][]byte{"Eicar-Test-Signature": eicar}}
+	clean := bytes.Repeat([]byte("apk"), 100000)
+	infected := append(append([]byte(nil), clean...), eicar...)
+
+	cases := []struct {
+		name      string
+		data      []byte
+		clean     bool
+		signature string
+	}{
+		{"clean", clean, true, ""},
+		{"infected", infected, false, "Eicar-Test-Signature"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Inspect(bytes.NewReader(c.data), stub)
+			if err != nil {
+				t.Fatalf("Inspect() error = %v", err)
+			}
+			sha := sha256.Sum256(c.data)
+			md := md5.Sum(c.data)
+			if got.Sha256 != hex.EncodeToString(sha[:]) || got.Md5 != hex.EncodeToString(md[:]) {
+				t.Errorf("Inspect() hash mismatch")
+			}
+			if got.Size != int64(len(c.data)) {
+				t.Errorf("Inspect() size = %d, want %d", got.Size, len(c.data))
+			}
+			if got.Result.Clean != c.clean || got.Result.Signature != c.signature {
+				t.Errorf("Inspect() result = %+v", got.Result)
+			}
+		})
+	}
+
+	stub.Err = errors.New("engine down")
+	if _, err := Inspect(bytes.NewReader(clean), stub); err == nil {
+		t.Error("Inspect() expected scanner error")
+	}
+}
+
+// fakeClamd 模拟 clamd INSTREAM 协议，内容包含 EICAR 时返回 FOUND
+func fakeClamd(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				cmd, err := r.ReadString(0)
+				if err != nil || cmd != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+				var data bytes.Buffer
+				for {
+					var size uint32
+					if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+						return
+					}
+				}
+				if bytes.Contains(data.Bytes(), eicar) {
+					conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}(conn)
+		}
+	}()
+	return ln
+}
+
+func TestClamAV(t *testing.T) {
+	ln := fakeClamd(t)
+	defer ln.Close()
+	c := &ClamAV{Network: "tcp", Address: ln.Addr().String(), Timeout: 5 * time.Second}
+
+	res, err := c.Scan(bytes.NewReader(bytes.Repeat([]byte("a"), 3*clamavChunkSize+7)))
+	if err != nil || !res.Clean {
+		t.Fatalf("Scan() clean file = %+v, %v", res, err)
+	}
+	res, err = c.Scan(strings.NewReader("header" + string(eicar)))
+	if err != nil || res.Clean || res.Signature != "Eicar-Test-Signature" {
+		t.Fatalf("Scan() infected file = %+v, %v", res, err)
+	}
+}
+
+func TestParseClamdReply(t *testing.T) {
+	if _, err := parseClamdReply("INSTREAM size limit exceeded. ERROR\x00"); err == nil {
+		t.Error("parseClamdReply() expected error")
+	}
+}
@@ -0,0 +1,30 @@
+package scanner
+
+import (
+	"bytes"
+	"io"
+)
+
+// Stub 按内容特征匹配的扫描器，用于测试或未部署杀毒引擎的环境
+type Stub struct {
+	Signatures map[string][]byte // 特征名称 -> 特征内容
+	Err        error             // 不为空时模拟扫描失败
+}
+
+func (s *Stub) Name() string { return "stub" }
+
+func (s *Stub) Scan(r io.Reader) (Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Result{}, err
+	}
+	if s.Err != nil {
+		return Result{}, s.Err
+	}
+	for name, sig := range s.Signatures {
+		if bytes.Contains(data, sig) {
+			return Result{Signature: name}, nil
+		}
+	}
+	return Result{Clean: true}, nil
+}
@@ -0,0 +1,111 @@
+package search
+
+import (
+	"unicode"
+)
+
+// segment 连续的汉字或连续的字母数字
+type segment struct {
+	han   bool
+	runes []rune
+}
+
+// segments 切分文本，字母统一转小写，其它字符作为分隔符
+func segments(text string) []segment {
+	var list []segment
+	var cur *segment
+	for _, r := range text {
+		var han bool
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han = true
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			r = unicode.ToLower(r)
+		default:
+			cur = nil
+			continue
+		}
+		if cur == nil || cur.han != han {
+			list = append(list, segment{han: han})
+			cur = &list[len(list)-1]
+		}
+		cur.runes = append(cur.runes, r)
+	}
+	return list
+}
+
+// grams 汉字段的二元组，只有一个字时返回单字
+func (s segment) grams() []string {
+	if len(s.runes) == 1 {
+		return []string{string(s.runes)}
+	}
+	grams := make([]string, 0, len(s.runes)-1)
+	for i := 0; i+1 < len(s.runes); i++ {
+		grams = append(grams, string(s.runes[i:i+2]))
+	}
+	return grams
+}
+
+// indexTerms 文本的索引词：汉字的单字和二元组，字母数字的整词
+func indexTerms(text string) []string {
+	var terms []string
+	for _, s := range segments(text) {
+		if !s.han {
+			terms = append(terms, string(s.runes))
+			continue
+		}
+		for _, r := range s.runes {
+			terms = append(terms, string(r))
+		}
+		if len(s.runes) > 1 {
+			terms = append(terms, s.grams()...)
+		}
+	}
+	return terms
+}
+
+// maxEdits 单词允许的编辑距离，短词不纠错以免误匹配
+func maxEdits(word string) int {
+	n := len([]rune(word))
+	switch {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// withinEdits 判断 a、b 的 Damerau-Levenshtein 距离（相邻交换算一次）是否不超过 k
+func withinEdits(a, b []rune, k int) bool {
+	if d := len(a) - len(b); d > k || -d > k {
+		return false
+	}
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > k {
+			return false
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)] <= k
+}
@@ -0,0 +1,341 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"ApkAdmin/utils/pinyin"
+)
+
+// 候选词的打分系数：完全匹配 > 前缀匹配 > 纠错匹配
+const (
+	factorExact  = 1.0
+	factorPrefix = 0.8
+	factorFuzzy  = 0.6
+)
+
+type memDoc struct {
+	doc   Document
+	terms map[string]float64 // 索引词 -> 按字段加权后的词频
+}
+
+// Memory 进程内倒排索引，可以并发使用
+type Memory struct {
+	mu       sync.RWMutex
+	docs     map[uint64]*memDoc
+	postings map[string]map[uint64]float64
+	words    []string // 排好序的字母数字词，用于前缀和纠错匹配
+}
+
+// NewMemory 创建空的进程内索引
+func NewMemory() *Memory {
+	return &Memory{
+		docs:     make(map[uint64]*memDoc),
+		postings: make(map[string]map[uint64]float64),
+	}
+}
+
+func (m *Memory) Name() string { return "memory" }
+
+func (m *Memory) Index(docs ...Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range docs {
+		m.remove(d.ID)
+		m.add(d)
+	}
+	m.rebuildWords()
+	return nil
+}
+
+func (m *Memory) Delete(ids ...uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		m.remove(id)
+	}
+	m.rebuildWords()
+	return nil
+}
+
+func (m *Memory) Replace(docs []Document) error {
+	fresh := NewMemory()
+	for _, d := range docs {
+		fresh.add(d)
+	}
+	fresh.rebuildWords()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.docs, m.postings, m.words = fresh.docs, fresh.postings, fresh.words
+	return nil
+}
+
+func (m *Memory) add(d Document) {
+	terms := analyze(d)
+	m.docs[d.ID] = &memDoc{doc: d, terms: terms}
+	for t, w := range terms {
+		p := m.postings[t]
+		if p == nil {
+			p = make(map[uint64]float64)
+			m.postings[t] = p
+		}
+		p[d.ID] = w
+	}
+}
+
+func (m *Memory) remove(id uint64) {
+	old, ok := m.docs[id]
+	if !ok {
+		return
+	}
+	for t := range old.terms {
+		delete(m.postings[t], id)
+		if len(m.postings[t]) == 0 {
+			delete(m.postings, t)
+		}
+	}
+	delete(m.docs, id)
+}
+
+func (m *Memory) rebuildWords() {
+	words := m.words[:0]
+	for t := range m.postings {
+		if r := []rune(t); len(r) > 0 && !isHan(r[0]) {
+			words = append(words, t)
+		}
+	}
+	sort.Strings(words)
+	m.words = words
+}
+
+// analyze 计算文档各索引词的加权词频
+func analyze(d Document) map[string]float64 {
+	terms := make(map[string]float64)
+	add := func(text string, weight float64) {
+		for _, t := range indexTerms(text) {
+			terms[t] += weight
+		}
+	}
+	add(d.Title, weightTitle)
+	for _, v := range pinyin.Variants(d.Title) {
+		terms[v] = max(terms[v], weightPinyin)
+	}
+	for _, k := range d.Keywords {
+		add(k, weightKeyword)
+	}
+	add(d.Body, weightBody)
+	return terms
+}
+
+func (m *Memory) Search(q Query) (Result, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	scores := m.match(q.Text)
+	result := Result{Facets: make(map[string][]FacetCount, len(q.Facets))}
+	for _, field := range q.Facets {
+		counts := make(map[string]int64)
+		for id := range scores {
+			doc := m.docs[id].doc
+			if !passFilters(doc, q.Filters, field) {
+				continue
+			}
+			seen := make(map[string]bool)
+			for _, v := range doc.Facets[field] {
+				if !seen[v] {
+					seen[v] = true
+					counts[v]++
+				}
+			}
+		}
+		result.Facets[field] = sortFacets(counts)
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		if passFilters(m.docs[id].doc, q.Filters, "") {
+			hits = append(hits, Hit{ID: id, Score: score})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if q.SortBy != "" {
+			a, b := m.docs[hits[i].ID].doc.Sorts[q.SortBy], m.docs[hits[j].ID].doc.Sorts[q.SortBy]
+			if a != b {
+				return a > b
+			}
+		}
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	result.Total = int64(len(hits))
+	result.Hits = paginate(hits, q.Offset, q.Limit)
+	return result, nil
+}
+
+// queryGroup 查询中的一段：汉字段要求命中一半以上的二元组，单词要求任一候选词命中
+type queryGroup struct {
+	terms    map[string]float64 // 候选词 -> 系数
+	need     int
+	additive bool // 汉字段累加各二元组得分，单词取最高分的候选词
+}
+
+// match 返回命中的文档及得分，查询为空时返回全部文档
+func (m *Memory) match(text string) map[uint64]float64 {
+	groups := m.queryGroups(text)
+	scores := make(map[uint64]float64)
+	if len(groups) == 0 {
+		for id, d := range m.docs {
+			scores[id] = boostOf(d.doc)
+		}
+		return scores
+	}
+
+	matched := make(map[uint64]int)
+	for _, g := range groups {
+		groupScores := make(map[uint64]float64)
+		hits := make(map[uint64]int)
+		for term, factor := range g.terms {
+			idf := m.idf(term)
+			for id, w := range m.postings[term] {
+				s := idf * saturate(w) * factor
+				if g.additive {
+					groupScores[id] += s
+					hits[id]++
+				} else if s > groupScores[id] {
+					groupScores[id] = s
+					hits[id] = 1
+				}
+			}
+		}
+		for id, s := range groupScores {
+			if hits[id] >= g.need {
+				scores[id] += s
+				matched[id]++
+			}
+		}
+	}
+
+	// 优先要求命中所有分段，没有结果时放宽为命中任一分段并按命中比例降权
+	all := make(map[uint64]float64)
+	for id, s := range scores {
+		if matched[id] == len(groups) {
+			all[id] = s * boostOf(m.docs[id].doc)
+		}
+	}
+	if len(all) > 0 || len(groups) == 1 {
+		return all
+	}
+	for id, s := range scores {
+		all[id] = s * float64(matched[id]) / float64(len(groups)) * boostOf(m.docs[id].doc)
+	}
+	return all
+}
+
+func (m *Memory) queryGroups(text string) []queryGroup {
+	var groups []queryGroup
+	for _, s := range segments(text) {
+		if s.han {
+			grams := s.grams()
+			g := queryGroup{terms: make(map[string]float64, len(grams)), need: (len(grams) + 1) / 2, additive: true}
+			for _, gram := range grams {
+				g.terms[gram] = factorExact
+			}
+			groups = append(groups, g)
+			continue
+		}
+		groups = append(groups, queryGroup{terms: m.expandWord(string(s.runes)), need: 1})
+	}
+	return groups
+}
+
+// expandWord 单词的候选索引词：原词、以其为前缀的词、编辑距离内的词
+func (m *Memory) expandWord(word string) map[string]float64 {
+	terms := map[string]float64{word: factorExact}
+	if len(word) >= 2 {
+		i := sort.SearchStrings(m.words, word)
+		for ; i < len(m.words) && strings.HasPrefix(m.words[i], word); i++ {
+			if m.words[i] != word {
+				terms[m.words[i]] = factorPrefix
+			}
+		}
+	}
+	if k := maxEdits(word); k > 0 {
+		w := []rune(word)
+		for _, candidate := range m.words {
+			if _, ok := terms[candidate]; !ok && withinEdits(w, []rune(candidate), k) {
+				terms[candidate] = factorFuzzy
+			}
+		}
+	}
+	return terms
+}
+
+func (m *Memory) idf(term string) float64 {
+	df := float64(len(m.postings[term]))
+	n := float64(len(m.docs))
+	return math.Log(1 + (n-df+0.5)/(df+0.5))
+}
+
+// saturate 词频饱和，避免描述中重复堆砌关键词拉高得分
+func saturate(w float64) float64 {
+	return w * 2.2 / (w + 1.2)
+}
+
+// passFilters 判断文档是否满足过滤条件，skip 指定的属性不参与判断
+func passFilters(d Document, filters map[string][]string, skip string) bool {
+	for field, values := range filters {
+		if field == skip || len(values) == 0 {
+			continue
+		}
+		ok := false
+		for _, v := range d.Facets[field] {
+			for _, want := range values {
+				if v == want {
+					ok = true
+				}
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func sortFacets(counts map[string]int64) []FacetCount {
+	list := make([]FacetCount, 0, len(counts))
+	for v, n := range counts {
+		list = append(list, FacetCount{Value: v, Count: n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Value < list[j].Value
+	})
+	return list
+}
+
+func paginate(hits []Hit, offset, limit int) []Hit {
+	if offset >= len(hits) {
+		return []Hit{}
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	end := len(hits)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return hits[offset:end]
+}
+
+func isHan(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
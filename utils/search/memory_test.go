@@ -0,0 +1,130 @@
+package search
+
+import (
+	"testing"
+)
+
+func testDocs() []Document {
+	return []Document{
+		{ID: 1, Title: "微信", Body: "聊天、朋友圈和支付", Keywords: []string{"社交"},
+			Facets: map[string][]string{"category": {"1"}, "platform": {"android", "ios"}}, Sorts: map[string]float64{"downloads": 100}, Boost: 2},
+		{ID: 2, Title: "微信读书", Body: "海量正版电子书", Keywords: []string{"阅读"},
+			Facets: map[string][]string{"category": {"2"}, "platform": {"android"}}, Sorts: map[string]float64{"downloads": 500}},
+		{ID: 3, Title: "Telegram", Body: "Fast and secure messaging", Keywords: []string{"社交"},
+			Facets: map[string][]string{"category": {"1"}, "platform": {"android", "ios"}}, Sorts: map[string]float64{"downloads": 50}},
+		{ID: 4, Title: "QQ音乐", Body: "听歌识曲", Keywords: []string{"音乐"},
+			Facets: map[string][]string{"category": {"3"}, "platform": {"ios"}}},
+	}
+}
+
+func ids(hits []Hit) []uint64 {
+	list := make([]uint64, len(hits))
+	for i, h := range hits {
+		list[i] = h.ID
+	}
+	return list
+}
+
+func equalIDs(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMemorySearch(t *testing.T) {
+	m := NewMemory()
+	if err := m.Replace(testDocs()); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		q    Query
+		want []uint64
+	}{
+		{"中文标题", Query{Text: "微信"}, []uint64{1, 2}},
+		{"正文", Query{Text: "电子书"}, []uint64{2}},
+		{"分类名称", Query{Text: "社交"}, []uint64{1, 3}},
+		{"拼音首字母", Query{Text: "wxds"}, []uint64{2}},
+		{"多音字首字母", Query{Text: "qqyy"}, []uint64{4}},
+		{"英文前缀", Query{Text: "tele"}, []uint64{3}},
+		{"拼写错误", Query{Text: "telegarm"}, []uint64{3}},
+		{"大小写", Query{Text: "SECURE"}, []uint64{3}},
+		{"多词放宽", Query{Text: "微信 不存在的词"}, []uint64{1, 2}},
+		{"过滤", Query{Text: "微信", Filters: map[string][]string{"platform": {"ios"}}}, []uint64{1}},
+		{"排序", Query{Text: "微信", SortBy: "downloads"}, []uint64{2, 1}},
+		{"分页", Query{Text: "微信", Offset: 1, Limit: 1}, []uint64{2}},
+		{"无结果", Query{Text: "抖音"}, []uint64{}},
+	}
+	for _, c := range cases {
+		res, err := m.Search(c.q)
+		if err != nil {
+			t.Fatalf("%s: Search() error = %v", c.name, err)
+		}
+		if got := ids(res.Hits); !equalIDs(got, c.want) {
+			t.Errorf("%s: hits = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestMemoryFacets(t *testing.T) {
+	m := NewMemory()
+	_ = m.Index(testDocs()...)
+	res, _ := m.Search(Query{
+		Text:    "社交",
+		Filters: map[string][]string{"platform": {"android"}, "category": {"1"}},
+		Facets:  []string{"platform", "category"},
+	})
+	if res.Total != 2 {
+		t.Fatalf("Total = %d, want 2", res.Total)
+	}
+	// 统计平台时忽略平台过滤条件
+	platform := res.Facets["platform"]
+	if len(platform) != 2 || platform[0] != (FacetCount{"android", 2}) || platform[1] != (FacetCount{"ios", 2}) {
+		t.Errorf("platform facets = %v", platform)
+	}
+	if category := res.Facets["category"]; len(category) != 1 || category[0] != (FacetCount{"1", 2}) {
+		t.Errorf("category facets = %v", category)
+	}
+}
+
+func TestMemoryUpdate(t *testing.T) {
+	m := NewMemory()
+	_ = m.Index(testDocs()...)
+	_ = m.Index(Document{ID: 3, Title: "Signal"})
+	_ = m.Delete(1, 99)
+	if res, _ := m.Search(Query{Text: "telegram"}); res.Total != 0 {
+		t.Errorf("old title still indexed: %v", ids(res.Hits))
+	}
+	if res, _ := m.Search(Query{Text: "signal"}); !equalIDs(ids(res.Hits), []uint64{3}) {
+		t.Errorf("signal hits = %v, want [3]", ids(res.Hits))
+	}
+	if res, _ := m.Search(Query{Text: "微信"}); !equalIDs(ids(res.Hits), []uint64{2}) {
+		t.Errorf("deleted doc still indexed: %v", ids(res.Hits))
+	}
+}
+
+func TestWithinEdits(t *testing.T) {
+	cases := []struct {
+		a, b string
+		k    int
+		want bool
+	}{
+		{"wechat", "wechat", 0, true},
+		{"wechta", "wechat", 1, true},
+		{"wchat", "wechat", 1, true},
+		{"wexhat", "wechat", 1, true},
+		{"weixin", "wechat", 2, false},
+		{"tiktok", "tik", 2, false},
+	}
+	for _, c := range cases {
+		if got := withinEdits([]rune(c.a), []rune(c.b), c.k); got != c.want {
+			t.Errorf("withinEdits(%q, %q, %d) = %v, want %v", c.a, c.b, c.k, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package search
+
+import (
+	"errors"
+	"strings"
+
+	"ApkAdmin/utils/pinyin"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// mysqlDoc 索引主表，两个全文索引分别用于标题加权和全字段匹配
+type mysqlDoc struct {
+	ID       uint64  `gorm:"primaryKey;autoIncrement:false"`
+	Title    string  `gorm:"type:varchar(255);not null;index:ft_title,class:FULLTEXT,option:WITH PARSER ngram;index:ft_all,class:FULLTEXT,option:WITH PARSER ngram"`
+	Pinyin   string  `gorm:"type:varchar(255);not null;index:ft_title,class:FULLTEXT,option:WITH PARSER ngram;index:ft_all,class:FULLTEXT,option:WITH PARSER ngram;comment:拼音首字母"`
+	Keywords string  `gorm:"type:varchar(1000);not null;index:ft_all,class:FULLTEXT,option:WITH PARSER ngram"`
+	Body     string  `gorm:"type:text;index:ft_all,class:FULLTEXT,option:WITH PARSER ngram"`
+	Boost    float64 `gorm:"not null;default:1"`
+}
+
+// mysqlFacet 文档属性，每个属性值一行
+type mysqlFacet struct {
+	DocID uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Field string `gorm:"primaryKey;type:varchar(50);index:idx_field_value,priority:1"`
+	Value string `gorm:"primaryKey;type:varchar(100);index:idx_field_value,priority:2"`
+}
+
+// mysqlSort 文档排序字段
+type mysqlSort struct {
+	DocID uint64  `gorm:"primaryKey;autoIncrement:false"`
+	Field string  `gorm:"primaryKey;type:varchar(50)"`
+	Value float64 `gorm:"not null"`
+}
+
+// MySQL 基于 InnoDB FULLTEXT ngram 的索引，需要 MySQL 5.7.6 及以上版本。
+// ngram 按二元组匹配，对中文和拼写错误的英文都能部分命中，相关度由 MySQL 计算后再乘以文档权重。
+type MySQL struct {
+	db     *gorm.DB
+	docs   string
+	facets string
+	sorts  string
+}
+
+// NewMySQL 创建索引并自动建表，表名为 prefix_docs、prefix_facets、prefix_sorts
+func NewMySQL(db *gorm.DB, prefix string) (*MySQL, error) {
+	if db == nil {
+		return nil, errors.New("search: 数据库未初始化")
+	}
+	e := &MySQL{db: db, docs: prefix + "_docs", facets: prefix + "_facets", sorts: prefix + "_sorts"}
+	for table, model := range map[string]interface{}{e.docs: &mysqlDoc{}, e.facets: &mysqlFacet{}, e.sorts: &mysqlSort{}} {
+		if err := db.Table(table).AutoMigrate(model); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func (e *MySQL) Name() string { return "mysql" }
+
+func (e *MySQL) Index(docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	return e.db.Transaction(func(tx *gorm.DB) error {
+		ids := make([]uint64, len(docs))
+		for i, d := range docs {
+			ids[i] = d.ID
+		}
+		if err := e.deleteAttrs(tx, ids); err != nil {
+			return err
+		}
+		return e.insert(tx, docs, true)
+	})
+}
+
+func (e *MySQL) Delete(ids ...uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return e.db.Transaction(func(tx *gorm.DB) error {
+		if err := e.deleteAttrs(tx, ids); err != nil {
+			return err
+		}
+		return tx.Table(e.docs).Where("id IN ?", ids).Delete(&mysqlDoc{}).Error
+	})
+}
+
+func (e *MySQL) Replace(docs []Document) error {
+	return e.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{e.facets, e.sorts, e.docs} {
+			if err := tx.Exec("DELETE FROM " + quote(table)).Error; err != nil {
+				return err
+			}
+		}
+		return e.insert(tx, docs, false)
+	})
+}
+
+func (e *MySQL) deleteAttrs(tx *gorm.DB, ids []uint64) error {
+	if err := tx.Table(e.facets).Where("doc_id IN ?", ids).Delete(&mysqlFacet{}).Error; err != nil {
+		return err
+	}
+	return tx.Table(e.sorts).Where("doc_id IN ?", ids).Delete(&mysqlSort{}).Error
+}
+
+func (e *MySQL) insert(tx *gorm.DB, docs []Document, upsert bool) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	rows := make([]mysqlDoc, 0, len(docs))
+	var facets []mysqlFacet
+	var sorts []mysqlSort
+	for _, d := range docs {
+		rows = append(rows, mysqlDoc{
+			ID:       d.ID,
+			Title:    d.Title,
+			Pinyin:   strings.Join(pinyin.Variants(d.Title), " "),
+			Keywords: strings.Join(d.Keywords, " "),
+			Body:     d.Body,
+			Boost:    boostOf(d),
+		})
+		for field, values := range d.Facets {
+			seen := make(map[string]bool)
+			for _, v := range values {
+				if !seen[v] {
+					seen[v] = true
+					facets = append(facets, mysqlFacet{DocID: d.ID, Field: field, Value: v})
+				}
+			}
+		}
+		for field, v := range d.Sorts {
+			sorts = append(sorts, mysqlSort{DocID: d.ID, Field: field, Value: v})
+		}
+	}
+	db := tx.Table(e.docs)
+	if upsert {
+		db = db.Clauses(clause.OnConflict{UpdateAll: true})
+	}
+	if err := db.CreateInBatches(rows, 200).Error; err != nil {
+		return err
+	}
+	if len(facets) > 0 {
+		if err := tx.Table(e.facets).CreateInBatches(facets, 500).Error; err != nil {
+			return err
+		}
+	}
+	if len(sorts) > 0 {
+		if err := tx.Table(e.sorts).CreateInBatches(sorts, 500).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *MySQL) Search(q Query) (Result, error) {
+	text := strings.TrimSpace(q.Text)
+	result := Result{Facets: make(map[string][]FacetCount, len(q.Facets))}
+	if err := e.filtered(q, text, "").Count(&result.Total).Error; err != nil {
+		return result, err
+	}
+
+	db := e.filtered(q, text, "")
+	if text != "" {
+		db = db.Select("d.id AS id, (MATCH(d.title, d.pinyin) AGAINST (?) * ? + MATCH(d.title, d.pinyin, d.keywords, d.body) AGAINST (?)) * d.boost AS score",
+			text, weightTitle, text)
+	} else {
+		db = db.Select("d.id AS id, d.boost AS score")
+	}
+	if q.SortBy != "" {
+		db = db.Joins("LEFT JOIN "+quote(e.sorts)+" s ON s.doc_id = d.id AND s.field = ?", q.SortBy).Order("s.value DESC")
+	}
+	db = db.Order("score DESC").Order("d.id ASC").Offset(max(q.Offset, 0))
+	if q.Limit > 0 {
+		db = db.Limit(q.Limit)
+	}
+	if err := db.Scan(&result.Hits).Error; err != nil {
+		return result, err
+	}
+
+	for _, field := range q.Facets {
+		var counts []FacetCount
+		err := e.filtered(q, text, field).
+			Joins("JOIN "+quote(e.facets)+" ff ON ff.doc_id = d.id AND ff.field = ?", field).
+			Select("ff.value AS value, COUNT(*) AS count").
+			Group("ff.value").
+			Order("count DESC").Order("ff.value ASC").
+			Scan(&counts).Error
+		if err != nil {
+			return result, err
+		}
+		result.Facets[field] = counts
+	}
+	return result, nil
+}
+
+// filtered 构建匹配和过滤条件，skip 指定的属性不参与过滤
+func (e *MySQL) filtered(q Query, text, skip string) *gorm.DB {
+	db := e.db.Table(quote(e.docs) + " AS d")
+	if text != "" {
+		db = db.Where("MATCH(d.title, d.pinyin, d.keywords, d.body) AGAINST (?)", text)
+	}
+	for field, values := range q.Filters {
+		if field == skip || len(values) == 0 {
+			continue
+		}
+		db = db.Where("EXISTS (SELECT 1 FROM "+quote(e.facets)+" f WHERE f.doc_id = d.id AND f.field = ? AND f.value IN ?)", field, values)
+	}
+	return db
+}
+
+func quote(table string) string {
+	return "`" + strings.ReplaceAll(table, "`", "") + "`"
+}
@@ -0,0 +1,92 @@
+// Package search 全文搜索索引
+//
+// 提供两种实现：Memory 为进程内倒排索引，中文按单字和二元组切分，英文按单词切分，
+// 支持前缀、拼音首字母和编辑距离纠错；MySQL 使用 FULLTEXT ngram 索引，适合多实例共享同一份索引。
+package search
+
+import (
+	"ApkAdmin/config"
+
+	"gorm.io/gorm"
+)
+
+// Document 待索引的文档
+type Document struct {
+	ID       uint64
+	Title    string              // 标题，权重最高，同时索引拼音首字母
+	Body     string              // 正文，如应用描述
+	Keywords []string            // 关键词，如分类名称
+	Facets   map[string][]string // 可过滤和统计的属性，如 category、platform
+	Sorts    map[string]float64  // 可排序的数值，如 downloads、rating
+	Boost    float64             // 文档先验权重，与相关度相乘，<=0 视为 1
+}
+
+// Query 搜索条件
+type Query struct {
+	Text    string
+	Filters map[string][]string // 属性值命中任意一个即可，多个属性之间为且
+	Facets  []string            // 需要统计数量的属性，统计时忽略该属性自身的过滤条件
+	SortBy  string              // 按 Sorts 中的字段降序排列，为空按相关度
+	Offset  int
+	Limit   int
+}
+
+// Hit 命中的文档
+type Hit struct {
+	ID    uint64
+	Score float64
+}
+
+// FacetCount 属性值及命中数量
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// Result 搜索结果
+type Result struct {
+	Total  int64
+	Hits   []Hit
+	Facets map[string][]FacetCount
+}
+
+// Engine 搜索索引接口
+type Engine interface {
+	Name() string
+	// Index 新增或覆盖文档
+	Index(docs ...Document) error
+	// Delete 删除文档，不存在的ID忽略
+	Delete(ids ...uint64) error
+	// Replace 用给定文档全量替换索引内容
+	Replace(docs []Document) error
+	Search(q Query) (Result, error)
+}
+
+// New 根据配置创建索引，未配置时使用进程内索引
+func New(cfg config.Search, db *gorm.DB) (Engine, error) {
+	switch cfg.Type {
+	case "mysql":
+		prefix := cfg.TablePrefix
+		if prefix == "" {
+			prefix = "app_search"
+		}
+		return NewMySQL(db, prefix)
+	default:
+		return NewMemory(), nil
+	}
+}
+
+// fieldWeights 各字段的权重
+const (
+	weightTitle   = 3.0
+	weightPinyin  = 2.5
+	weightKeyword = 2.0
+	weightBody    = 1.0
+)
+
+func boostOf(d Document) float64 {
+	if d.Boost > 0 {
+		return d.Boost
+	}
+	return 1
+}
@@ -0,0 +1,100 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func buildXLSX(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadXLSX(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+			xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="账号" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId3" Target="worksheets/data.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+			<si><t>app_id</t></si><si><t>account_detail</t></si><si><r><t>user</t></r><r><t>@mail.com</t></r></si></sst>`,
+		"xl/worksheets/data.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+			<row r="2"><c r="A2" t="inlineStr"><is><t>a1</t></is></c><c r="C2"><v>12</v></c><c r="D2" t="s"><v>2</v></c></row>
+			<row r="3"><c r="A3" t="str"><v>  </v></c></row>
+		</sheetData></worksheet>`,
+	})
+	rows, err := Read("accounts.XLSX", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"app_id", "account_detail"}, {"a1", "", "12", "user@mail.com"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	rows, err := Read("a.csv", []byte("\ufeffapp_id,account_detail\na1,\"x,y\"\na2\n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"app_id", "account_detail"}, {"a1", "x,y"}, {"a2"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+	if _, err = Read("a.xls", nil); err != ErrUnsupported {
+		t.Errorf("xls err = %v", err)
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	for ref, want := range map[string]int{"A1": 0, "Z9": 25, "AA1": 26, "AB12": 27, "XFD1": 16383} {
+		if got, _ := columnIndex(ref); got != want {
+			t.Errorf("columnIndex(%s) = %d, want %d", ref, got, want)
+		}
+	}
+	for _, ref := range []string{"1", "XFE1", "ZZZZZZZZZZZZ1", "ZZZZZZZZZZZZZZZZZZZZZZZZ1"} {
+		if _, err := columnIndex(ref); err == nil {
+			t.Errorf("columnIndex(%s) should fail", ref)
+		}
+	}
+}
+
+func TestReadXLSXMalformed(t *testing.T) {
+	sheet := func(cells string) map[string]string {
+		return map[string]string{
+			"xl/workbook.xml": `<workbook/>`,
+			"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+				<row r="1">` + cells + `</row></sheetData></worksheet>`,
+		}
+	}
+	// 超大的列号不能导致按列号补齐空单元格
+	if _, err := Read("a.xlsx", buildXLSX(t, sheet(`<c r="ZZZZZZZZZZZZ1"><v>1</v></c>`))); err == nil || !strings.Contains(err.Error(), "XFD") {
+		t.Errorf("huge column err = %v", err)
+	}
+
+	saved := MaxXMLSize
+	t.Cleanup(func() { MaxXMLSize = saved })
+	MaxXMLSize = 1 << 20
+	big := sheet(strings.Repeat(`<c r="A1"><v>1</v></c>`, 100000))
+	if _, err := Read("a.xlsx", buildXLSX(t, big)); err == nil || !strings.Contains(err.Error(), "解压后超过") {
+		t.Errorf("oversized sheet err = %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package signedtoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignAndParse(t *testing.T) {
+	key := []byte("k1")
+	token, p, err := New(key, "email_verify", 42, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(key, token, "email_verify")
+	if err != nil || got.UserID != 42 || got.Nonce != p.Nonce || !got.ExpiresAt.Equal(p.ExpiresAt) {
+		t.Fatalf("parse = %+v, %v", got, err)
+	}
+	if _, err = Parse(key, token, "password_reset"); err != ErrInvalid {
+		t.Errorf("other purpose err = %v", err)
+	}
+	if _, err = Parse([]byte("k2"), token, "email_verify"); err != ErrInvalid {
+		t.Errorf("other key err = %v", err)
+	}
+	forged := Sign([]byte("k2"), Payload{Purpose: "email_verify", UserID: 1, Nonce: "x", ExpiresAt: p.ExpiresAt})
+	if _, err = Parse(key, forged, "email_verify"); err != ErrInvalid {
+		t.Errorf("forged err = %v", err)
+	}
+	for _, bad := range []string{"", "abc", token + "x", "." + token} {
+		if _, err = Parse(key, bad, "email_verify"); err != ErrInvalid {
+			t.Errorf("%q err = %v", bad, err)
+		}
+	}
+
+	later := time.Now().Add(2 * time.Hour)
+	now = func() time.Time { return later }
+	t.Cleanup(func() { now = time.Now })
+	if _, err = Parse(key, token, "email_verify"); err != ErrExpired {
+		t.Errorf("expired err = %v", err)
+	}
+}
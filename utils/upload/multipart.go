@@ -0,0 +1,168 @@
+package upload
+
+import (
+	"errors"
+	"io"
+
+	"ApkAdmin/global"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"go.uber.org/zap"
+)
+
+// MultipartMinPartSize 对象存储分片上传除最后一片外的最小分片大小
+const MultipartMinPartSize int64 = 5 * 1024 * 1024
+
+// MultipartPart 已上传的分片
+type MultipartPart struct {
+	PartNumber int    // 分片序号，从1开始
+	ETag       string // 对象存储返回的ETag
+}
+
+// MultipartOSS 支持分片上传的对象存储接口
+type MultipartOSS interface {
+	InitMultipart(objectName string) (uploadID string, err error)
+	UploadPart(objectName, uploadID string, partNumber int, body io.ReadSeeker, size int64) (etag string, err error)
+	CompleteMultipart(objectName, uploadID string, parts []MultipartPart) (url string, err error)
+	AbortMultipart(objectName, uploadID string) error
+}
+
+// NewMultipartOss 根据配置返回支持分片上传的对象存储，不支持时返回 nil
+func NewMultipartOss() MultipartOSS {
+	switch global.GVA_CONFIG.System.OssType {
+	case "aliyun-oss":
+		return &AliyunOSS{}
+	case "aws-s3":
+		return &AwsS3{}
+	default:
+		return nil
+	}
+}
+
+func aliyunMultipartResult(bucket *oss.Bucket, objectName, uploadID string) oss.InitiateMultipartUploadResult {
+	return oss.InitiateMultipartUploadResult{
+		Bucket:   bucket.BucketName,
+		Key:      objectName,
+		UploadID: uploadID,
+	}
+}
+
+func (*AliyunOSS) InitMultipart(objectName string) (string, error) {
+	bucket, err := NewBucket()
+	if err != nil {
+		return "", errors.New("function AliyunOSS.NewBucket() Failed, err:" + err.Error())
+	}
+	imur, err := bucket.InitiateMultipartUpload(objectName)
+	if err != nil {
+		global.GVA_LOG.Error("function bucket.InitiateMultipartUpload() failed", zap.Error(err))
+		return "", err
+	}
+	return imur.UploadID, nil
+}
+
+func (*AliyunOSS) UploadPart(objectName, uploadID string, partNumber int, body io.ReadSeeker, size int64) (string, error) {
+	bucket, err := NewBucket()
+	if err != nil {
+		return "", errors.New("function AliyunOSS.NewBucket() Failed, err:" + err.Error())
+	}
+	part, err := bucket.UploadPart(aliyunMultipartResult(bucket, objectName, uploadID), body, size, partNumber)
+	if err != nil {
+		global.GVA_LOG.Error("function bucket.UploadPart() failed", zap.Error(err), zap.Int("part", partNumber))
+		return "", err
+	}
+	return part.ETag, nil
+}
+
+func (*AliyunOSS) CompleteMultipart(objectName, uploadID string, parts []MultipartPart) (string, error) {
+	bucket, err := NewBucket()
+	if err != nil {
+		return "", errors.New("function AliyunOSS.NewBucket() Failed, err:" + err.Error())
+	}
+	ossParts := make([]oss.UploadPart, 0, len(parts))
+	for _, p := range parts {
+		ossParts = append(ossParts, oss.UploadPart{PartNumber: p.PartNumber, ETag: p.ETag})
+	}
+	if _, err = bucket.CompleteMultipartUpload(aliyunMultipartResult(bucket, objectName, uploadID), ossParts); err != nil {
+		global.GVA_LOG.Error("function bucket.CompleteMultipartUpload() failed", zap.Error(err))
+		return "", err
+	}
+	return global.GVA_CONFIG.AliyunOSS.BucketUrl + "/" + objectName, nil
+}
+
+func (*AliyunOSS) AbortMultipart(objectName, uploadID string) error {
+	bucket, err := NewBucket()
+	if err != nil {
+		return errors.New("function AliyunOSS.NewBucket() Failed, err:" + err.Error())
+	}
+	return bucket.AbortMultipartUpload(aliyunMultipartResult(bucket, objectName, uploadID))
+}
+
+// awsObjectKey 与 UploadFile 保持一致，所有对象都放在 PathPrefix 下
+func awsObjectKey(objectName string) string {
+	return global.GVA_CONFIG.AwsS3.PathPrefix + "/" + objectName
+}
+
+func (*AwsS3) InitMultipart(objectName string) (string, error) {
+	svc := s3.New(newSession())
+	out, err := svc.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(global.GVA_CONFIG.AwsS3.Bucket),
+		Key:    aws.String(awsObjectKey(objectName)),
+	})
+	if err != nil {
+		global.GVA_LOG.Error("function svc.CreateMultipartUpload() failed", zap.Error(err))
+		return "", err
+	}
+	return aws.StringValue(out.UploadId), nil
+}
+
+func (*AwsS3) UploadPart(objectName, uploadID string, partNumber int, body io.ReadSeeker, size int64) (string, error) {
+	svc := s3.New(newSession())
+	out, err := svc.UploadPart(&s3.UploadPartInput{
+		Bucket:        aws.String(global.GVA_CONFIG.AwsS3.Bucket),
+		Key:           aws.String(awsObjectKey(objectName)),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    aws.Int64(int64(partNumber)),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+	})
+	if err != nil {
+		global.GVA_LOG.Error("function svc.UploadPart() failed", zap.Error(err), zap.Int("part", partNumber))
+		return "", err
+	}
+	return aws.StringValue(out.ETag), nil
+}
+
+func (*AwsS3) CompleteMultipart(objectName, uploadID string, parts []MultipartPart) (string, error) {
+	svc := s3.New(newSession())
+	completed := make([]*s3.CompletedPart, 0, len(parts))
+	for _, p := range parts {
+		completed = append(completed, &s3.CompletedPart{
+			ETag:       aws.String(p.ETag),
+			PartNumber: aws.Int64(int64(p.PartNumber)),
+		})
+	}
+	key := awsObjectKey(objectName)
+	_, err := svc.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(global.GVA_CONFIG.AwsS3.Bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		global.GVA_LOG.Error("function svc.CompleteMultipartUpload() failed", zap.Error(err))
+		return "", err
+	}
+	return global.GVA_CONFIG.AwsS3.BaseURL + "/" + key, nil
+}
+
+func (*AwsS3) AbortMultipart(objectName, uploadID string) error {
+	svc := s3.New(newSession())
+	_, err := svc.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(global.GVA_CONFIG.AwsS3.Bucket),
+		Key:      aws.String(awsObjectKey(objectName)),
+		UploadId: aws.String(uploadID),
+	})
+	return err
+}
@@ -0,0 +1,117 @@
+package upload
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ApkAdmin/global"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"go.uber.org/zap"
+)
+
+// ObjectStorage 按对象路径读写文件的存储接口，用于服务端生成的文件（如差分补丁）
+type ObjectStorage interface {
+	GetObject(objectName string) (io.ReadCloser, error)
+	PutObject(objectName string, body io.Reader) (url string, err error)
+}
+
+// NewObjectStorage 根据配置返回支持按路径读写的存储，不支持时返回 nil
+func NewObjectStorage() ObjectStorage {
+	switch global.GVA_CONFIG.System.OssType {
+	case "aliyun-oss":
+		return &AliyunOSS{}
+	case "aws-s3":
+		return &AwsS3{}
+	case "local":
+		return &Local{}
+	default:
+		return nil
+	}
+}
+
+func (*AliyunOSS) GetObject(objectName string) (io.ReadCloser, error) {
+	bucket, err := NewBucket()
+	if err != nil {
+		return nil, errors.New("function AliyunOSS.NewBucket() Failed, err:" + err.Error())
+	}
+	return bucket.GetObject(objectName)
+}
+
+func (*AliyunOSS) PutObject(objectName string, body io.Reader) (string, error) {
+	bucket, err := NewBucket()
+	if err != nil {
+		return "", errors.New("function AliyunOSS.NewBucket() Failed, err:" + err.Error())
+	}
+	if err = bucket.PutObject(objectName, body); err != nil {
+		global.GVA_LOG.Error("function bucket.PutObject() failed", zap.Error(err))
+		return "", err
+	}
+	return global.GVA_CONFIG.AliyunOSS.BucketUrl + "/" + objectName, nil
+}
+
+func (*AwsS3) GetObject(objectName string) (io.ReadCloser, error) {
+	svc := s3.New(newSession())
+	out, err := svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(global.GVA_CONFIG.AwsS3.Bucket),
+		Key:    aws.String(awsObjectKey(objectName)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (*AwsS3) PutObject(objectName string, body io.Reader) (string, error) {
+	key := awsObjectKey(objectName)
+	_, err := s3manager.NewUploader(newSession()).Upload(&s3manager.UploadInput{
+		Bucket: aws.String(global.GVA_CONFIG.AwsS3.Bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	})
+	if err != nil {
+		global.GVA_LOG.Error("function uploader.Upload() failed", zap.Error(err))
+		return "", err
+	}
+	return global.GVA_CONFIG.AwsS3.BaseURL + "/" + key, nil
+}
+
+// localObjectPath 对象路径必须位于 StorePath 之内
+func localObjectPath(objectName string) (string, error) {
+	if objectName == "" || strings.Contains(objectName, "..") {
+		return "", errors.New("非法的对象路径")
+	}
+	return filepath.Join(global.GVA_CONFIG.Local.StorePath, filepath.FromSlash(objectName)), nil
+}
+
+func (*Local) GetObject(objectName string) (io.ReadCloser, error) {
+	p, err := localObjectPath(objectName)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+func (*Local) PutObject(objectName string, body io.Reader) (string, error) {
+	p, err := localObjectPath(objectName)
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return "", errors.New("function os.MkdirAll() failed, err:" + err.Error())
+	}
+	out, err := os.Create(p)
+	if err != nil {
+		return "", errors.New("function os.Create() failed, err:" + err.Error())
+	}
+	defer out.Close()
+	if _, err = io.Copy(out, body); err != nil {
+		return "", errors.New("function io.Copy() failed, err:" + err.Error())
+	}
+	return global.GVA_CONFIG.Local.Path + "/" + objectName, nil
+}